@@ -0,0 +1,137 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary compat_checker compares the Go and Protobuf3 code that is generated
+// for two versions of a YANG schema, and reports the differences between
+// them. It exits with a non-zero status if any of the differences are not
+// backwards compatible.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygen"
+)
+
+var (
+	oldModules       = flag.String("old", "", "Comma separated list of the YANG files containing the modules of the previous version of the schema.")
+	newModules       = flag.String("new", "", "Comma separated list of the YANG files containing the modules of the new version of the schema.")
+	oldPaths         = flag.String("old_path", "", "Comma separated list of paths to be recursively searched for modules or submodules included by the previous version of the schema.")
+	newPaths         = flag.String("new_path", "", "Comma separated list of paths to be recursively searched for modules or submodules included by the new version of the schema.")
+	compressPaths    = flag.Bool("compress_paths", false, "If set to true, the schema's paths are compressed, according to OpenConfig YANG module conventions.")
+	configState      = flag.String("config_state", "config", "Specifies how leaves that are duplicated within the config and state containers are mapped when compress_paths is set: config maps the config leaf, state maps the state leaf, and both maps each leaf to a distinct field, prefixing the name of the state leaf with state.")
+	excludeModules   = flag.String("exclude_modules", "", "Comma separated set of module names that should be excluded from code generation. This can be used to ensure overlapping namespaces can be ignored.")
+	packageName      = flag.String("package_name", "openconfig", "The name of the Proto package that generated messages should belong to as their parent.")
+	enumPackageName  = flag.String("enum_package_name", "enums", "The name of the package within the generated package that should contain global enum definitions.")
+	ignoreCircDeps   = flag.Bool("ignore_circdeps", false, "If set to true, circular dependencies between submodules are ignored.")
+	generateFakeRoot = flag.Bool("generate_fakeroot", false, "If set to true, a fake element at the root of the data tree is generated.")
+	fakeRootName     = flag.String("fakeroot_name", "", "The name of the fake root entity.")
+	generateService  = flag.Bool("generate_service", false, "If set to true, the gRPC services generated for the schema are compared.")
+	fieldLockFile    = flag.String("field_lock_file", "", "The path to a JSON file recording the field numbers assigned to generated protobuf fields, which is used when generating both versions of the schema.")
+	jsonOutput       = flag.Bool("json", false, "If set to true, the differences are written as JSON rather than as text.")
+)
+
+// splitList splits the comma-separated list s into its elements, returning an
+// empty slice if s is empty.
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// includePaths returns the set of paths that should be searched for included
+// modules from the comma-separated list of paths s. For each path "..." is
+// appended to ensure that the directory is recursively searched.
+func includePaths(s string) []string {
+	paths := []string{}
+	for _, p := range splitList(s) {
+		paths = append(paths, filepath.Join(p, "..."))
+	}
+	return paths
+}
+
+// main parses command-line flags to determine the two sets of YANG modules
+// that are to be compared, and calls the ygen library to compare the code
+// generated for them. The differences are written to stdout.
+func main() {
+	flag.Parse()
+
+	oldFiles, newFiles := splitList(*oldModules), splitList(*newModules)
+	if len(oldFiles) == 0 || len(newFiles) == 0 {
+		log.Exitln("Error: the old and new modules must be specified")
+	}
+
+	csPref, perr := ygen.ParseConfigStatePreference(*configState)
+	if perr != nil {
+		log.Exitf("%v", perr)
+	}
+
+	var fieldLock *ygen.ProtoFieldLock
+	if *fieldLockFile != "" {
+		b, err := ioutil.ReadFile(*fieldLockFile)
+		if err != nil {
+			log.Exitf("could not read field lock file %v, got error: %v", *fieldLockFile, err)
+		}
+		if fieldLock, err = ygen.UnmarshalProtoFieldLock(b); err != nil {
+			log.Exitf("could not parse field lock file %v, got error: %v", *fieldLockFile, err)
+		}
+	}
+
+	cg := ygen.NewYANGCodeGenerator(&ygen.GeneratorConfig{
+		CompressOCPaths:       *compressPaths,
+		ConfigStatePreference: csPref,
+		ExcludeModules:        splitList(*excludeModules),
+		GenerateFakeRoot:      *generateFakeRoot,
+		FakeRootName:          *fakeRootName,
+		YANGParseOptions: yang.Options{
+			IgnoreSubmoduleCircularDependencies: *ignoreCircDeps,
+		},
+		ProtoOptions: ygen.ProtoOpts{
+			BasePackageName: *packageName,
+			EnumPackageName: *enumPackageName,
+			FieldLock:       fieldLock,
+			GenerateService: *generateService,
+		},
+	})
+
+	report, err := cg.CheckCompatibility(oldFiles, includePaths(*oldPaths), newFiles, includePaths(*newPaths))
+	if err != nil {
+		log.Exitf("%v\n", err)
+	}
+
+	if *jsonOutput {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Exitf("could not marshal report, got error: %v", err)
+		}
+		fmt.Println(string(b))
+	} else {
+		for _, c := range report.Changes {
+			fmt.Println(c)
+		}
+	}
+
+	if report.HasBreakingChanges() {
+		os.Exit(1)
+	}
+}
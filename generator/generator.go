@@ -21,6 +21,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -33,6 +34,7 @@ import (
 var (
 	yangPaths        = flag.String("path", "", "Comma separated list of paths to be recursively searched for included modules or submodules within the defined YANG modules.")
 	compressPaths    = flag.Bool("compress_paths", false, "If set to true, the schema's paths are compressed, according to OpenConfig YANG module conventions.")
+	configState      = flag.String("config_state", "config", "Specifies how leaves that are duplicated within the config and state containers are mapped when compress_paths is set: config maps the config leaf, state maps the state leaf, and both maps each leaf to a distinct field, prefixing the name of the state leaf with state.")
 	excludeModules   = flag.String("exclude_modules", "", "Comma separated set of module names that should be excluded from code generation this can be used to ensure overlapping namespaces can be ignored.")
 	packageName      = flag.String("package_name", "ocstructs", "The name of the Go package that should be generated.")
 	outputFile       = flag.String("output_file", "", "The file that the generated Go code should be written to.")
@@ -43,6 +45,8 @@ var (
 	ygotImportPath   = flag.String("ygot_path", ygen.DefaultYgotImportPath, "The import path to use for ygot.")
 	ytypesImportPath = flag.String("ytypes_path", ygen.DefaultYtypesImportPath, "The import path to use for ytypes.")
 	goyangImportPath = flag.String("goyang_path", ygen.DefaultGoyangImportPath, "The import path to use for goyang's yang package.")
+	applyDeviations  = flag.Bool("apply_deviations", false, "If set to true, the deviation statements within the YANG modules are applied to the schema prior to code generation, and are included in the report written to report_file.")
+	reportFile       = flag.String("report_file", "", "The path to which a JSON report of the schema nodes that are modified by augment and deviation statements, and the Go types that they are mapped to, should be written.")
 )
 
 // writeGoCode takes a ygen.GeneratedGoCode struct and writes the Go code
@@ -111,6 +115,11 @@ func main() {
 		}
 	}
 
+	csPref, perr := ygen.ParseConfigStatePreference(*configState)
+	if perr != nil {
+		log.Exitf("Error: %v\n", perr)
+	}
+
 	// If no output file is specified, we output to os.Stdout, otherwise
 	// we write to the specified file.
 	var outfh *os.File
@@ -139,12 +148,14 @@ func main() {
 
 	// Perform the code generation.
 	cg := ygen.NewYANGCodeGenerator(&ygen.GeneratorConfig{
-		CompressOCPaths:    *compressPaths,
-		ExcludeModules:     modsExcluded,
-		PackageName:        *packageName,
-		GenerateFakeRoot:   *generateFakeRoot,
-		FakeRootName:       *fakeRootName,
-		GenerateJSONSchema: *generateSchema,
+		CompressOCPaths:       *compressPaths,
+		ConfigStatePreference: csPref,
+		ExcludeModules:        modsExcluded,
+		PackageName:           *packageName,
+		GenerateFakeRoot:      *generateFakeRoot,
+		FakeRootName:          *fakeRootName,
+		ApplyDeviations:       *applyDeviations,
+		GenerateJSONSchema:    *generateSchema,
 		YANGParseOptions: yang.Options{
 			IgnoreSubmoduleCircularDependencies: *ignoreCircDeps,
 		},
@@ -162,4 +173,18 @@ func main() {
 
 	// Write out the Go code to the specified file handle.
 	writeGoCode(outfh, generatedGoCode)
+
+	if *reportFile != "" {
+		report, err := cg.GenerateReport(generateModules, includePaths)
+		if err != nil {
+			log.Exitf("ERROR Generating Report: %s\n", err)
+		}
+		b, merr := report.Marshal()
+		if merr != nil {
+			log.Exitf("ERROR Marshalling Report: %v\n", merr)
+		}
+		if err := ioutil.WriteFile(*reportFile, b, 0644); err != nil {
+			log.Exitf("Error: could not write report file: %v\n", err)
+		}
+	}
 }
@@ -20,6 +20,7 @@ package main
 
 import (
 	"flag"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -32,6 +33,7 @@ import (
 var (
 	yangPaths           = flag.String("path", "", "Comma separated list of paths to be recursively searched for included modules or submodules within the defined YANG modules.")
 	compressPaths       = flag.Bool("compress_paths", false, "If set to true, the schema's paths are compressed, according to OpenConfig YANG module conventions.")
+	configState         = flag.String("config_state", "config", "Specifies how leaves that are duplicated within the config and state containers are mapped when compress_paths is set: config maps the config leaf, state maps the state leaf, and both maps each leaf to a distinct field, prefixing the name of the state leaf with state.")
 	excludeModules      = flag.String("exclude_modules", "", "Comma separated set of module names that should be excluded from code generation. This can be used to ensure overlapping namespaces can be ignored.")
 	packageName         = flag.String("package_name", "openconfig", "The name of the Proto package that generated messages should belong to as their parent.")
 	enumPackageName     = flag.String("enum_package_name", "enums", "The name of the package within the generated package that should contain global enum definitions.")
@@ -44,6 +46,11 @@ var (
 	fakeRootName        = flag.String("fakeroot_name", "Device", "The name of the fake root entity.")
 	annotateSchemaPaths = flag.Bool("add_schemapaths", true, "If set to true, the schema path of each YANG entity is added as a protobuf field option")
 	annotateEnumNames   = flag.Bool("add_enumnames", true, "If set to true, each value within output enums will be annotated with the label in the original YANG schema.")
+	generateService     = flag.Bool("generate_service", false, "If set to true, a gRPC service is generated with methods to get, set and subscribe to the root entities of the schema, and a method for each YANG rpc and action.")
+	serviceName         = flag.String("service_name", ygen.DefaultServiceName, "The name of the generated gRPC service.")
+	fieldLockFile       = flag.String("field_lock_file", "", "The path to a JSON file recording the field numbers assigned to generated fields. If the file exists, previously assigned field numbers are reused, and those of removed fields are reserved. The file is updated after code generation.")
+	applyDeviations     = flag.Bool("apply_deviations", false, "If set to true, the deviation statements within the YANG modules are applied to the schema prior to code generation, and are included in the report written to report_file.")
+	reportFile          = flag.String("report_file", "", "The path to which a JSON report of the schema nodes that are modified by augment and deviation statements, and the protobuf types that they are mapped to, should be written.")
 )
 
 // main parses command-line flags to determine the set of YANG modules for
@@ -86,13 +93,35 @@ func main() {
 		}
 	}
 
+	csPref, perr := ygen.ParseConfigStatePreference(*configState)
+	if perr != nil {
+		log.Exitf("%v", perr)
+	}
+
+	// Read the field lock from a previous run, if one exists.
+	var fieldLock *ygen.ProtoFieldLock
+	if *fieldLockFile != "" {
+		b, err := ioutil.ReadFile(*fieldLockFile)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			log.Exitf("could not read field lock file %v, got error: %v", *fieldLockFile, err)
+		default:
+			if fieldLock, err = ygen.UnmarshalProtoFieldLock(b); err != nil {
+				log.Exitf("could not parse field lock file %v, got error: %v", *fieldLockFile, err)
+			}
+		}
+	}
+
 	// Perform the code generation.
 	cg := ygen.NewYANGCodeGenerator(&ygen.GeneratorConfig{
-		CompressOCPaths:  *compressPaths,
-		ExcludeModules:   modsExcluded,
-		PackageName:      *packageName,
-		GenerateFakeRoot: *generateFakeRoot,
-		FakeRootName:     *fakeRootName,
+		CompressOCPaths:       *compressPaths,
+		ConfigStatePreference: csPref,
+		ExcludeModules:        modsExcluded,
+		PackageName:           *packageName,
+		GenerateFakeRoot:      *generateFakeRoot,
+		FakeRootName:          *fakeRootName,
+		ApplyDeviations:       *applyDeviations,
 		YANGParseOptions: yang.Options{
 			IgnoreSubmoduleCircularDependencies: *ignoreCircDeps,
 		},
@@ -102,6 +131,9 @@ func main() {
 			YextPath:            *yextPath,
 			AnnotateSchemaPaths: *annotateSchemaPaths,
 			AnnotateEnumNames:   *annotateEnumNames,
+			FieldLock:           fieldLock,
+			GenerateService:     *generateService,
+			ServiceName:         *serviceName,
 		},
 	})
 
@@ -129,6 +161,33 @@ func main() {
 		for _, e := range p.Enums {
 			f.WriteString(e)
 		}
+		for _, s := range p.Services {
+			f.WriteString(s)
+		}
 		f.Sync()
 	}
+
+	if *fieldLockFile != "" {
+		b, err := generatedProtoCode.FieldLock.Marshal()
+		if err != nil {
+			log.Exitf("could not marshal field lock, got error: %v", err)
+		}
+		if err := ioutil.WriteFile(*fieldLockFile, b, 0644); err != nil {
+			log.Exitf("could not write field lock file %v, got error: %v", *fieldLockFile, err)
+		}
+	}
+
+	if *reportFile != "" {
+		report, err := cg.GenerateReport(generateModules, includePaths)
+		if err != nil {
+			log.Exitf("could not generate report, got error: %v", err)
+		}
+		b, merr := report.Marshal()
+		if merr != nil {
+			log.Exitf("could not marshal report, got error: %v", merr)
+		}
+		if err := ioutil.WriteFile(*reportFile, b, 0644); err != nil {
+			log.Exitf("could not write report file %v, got error: %v", *reportFile, err)
+		}
+	}
 }
@@ -71,6 +71,11 @@ func Dedent() {
 	globalIndent = strings.TrimPrefix(globalIndent, ". ")
 }
 
+// ResetIndent sets the DbgPrint Indent level to zero.
+func ResetIndent() {
+	globalIndent = ""
+}
+
 // ValueStr returns a string representation of value which may be a value, ptr,
 // or struct type.
 func ValueStr(value interface{}) string {
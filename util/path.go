@@ -0,0 +1,148 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RelativeSchemaPath returns a path to the schema for the struct field f.
+// Paths are embedded in the "path" struct tag and can be either simple:
+//
+//	e.g. "path:a"
+//
+// or composite e.g.
+//
+//	e.g. "path:config/a|a"
+//
+// which is found in OpenConfig leaf-ref cases where the key of a list is a
+// leafref. In the latter case, this function returns {"config", "a"}, and the
+// schema *yang.Entry for the field is given by schema.Dir["config"].Dir["a"].
+func RelativeSchemaPath(f reflect.StructField) ([]string, error) {
+	pathAnnotation, ok := f.Tag.Lookup("path")
+	if !ok {
+		return nil, fmt.Errorf("field %s did not specify a path", f.Name)
+	}
+
+	paths := strings.Split(pathAnnotation, "|")
+	if len(paths) == 1 {
+		pathAnnotation = strings.TrimPrefix(pathAnnotation, "/")
+		return strings.Split(pathAnnotation, "/"), nil
+	}
+	for _, pv := range paths {
+		pv = strings.TrimPrefix(pv, "/")
+		pe := strings.Split(pv, "/")
+		if len(pe) > 1 {
+			return pe, nil
+		}
+	}
+
+	return nil, fmt.Errorf("field %s had path tag %s with |, but no elements of form a/b", f.Name, pathAnnotation)
+}
+
+// SchemaPaths returns all the paths in the path tag, plus the path value of
+// the rootname tag, if one is present.
+func SchemaPaths(f reflect.StructField) ([][]string, error) {
+	var out [][]string
+	rootTag, ok := f.Tag.Lookup("rootname")
+	if ok {
+		out = append(out, strings.Split(rootTag, "/"))
+	}
+	pathTag, ok := f.Tag.Lookup("path")
+	if (!ok || pathTag == "") && rootTag == "" {
+		return nil, fmt.Errorf("field %s did not specify a path", f.Name)
+	}
+	if pathTag == "" {
+		return out, nil
+	}
+
+	ps := strings.Split(pathTag, "|")
+	for _, p := range ps {
+		sp := removeRootPrefix(strings.Split(p, "/"))
+		out = append(out, StripModulePrefixes(sp))
+	}
+	return out, nil
+}
+
+// IsYgotAnnotation reports whether the struct field f stores the metadata
+// annotations of the nodes of a GoStruct, rather than a node of the data tree.
+// Such fields are identified by the ygotAnnotation struct tag, and do not have
+// a path.
+func IsYgotAnnotation(f reflect.StructField) bool {
+	_, ok := f.Tag.Lookup("ygotAnnotation")
+	return ok
+}
+
+// removeRootPrefix removes the root prefix from root schema entities e.g.
+// Bgp_Global has path "/bgp/global" == {"", "bgp", "global"}
+//
+//	-> {"global"}
+func removeRootPrefix(path []string) []string {
+	if len(path) < 2 || path[0] != "" {
+		// not a root path
+		return path
+	}
+	return path[2:]
+}
+
+// StripModulePrefixes returns "in" with each element with the format "A:B" changed
+// to "B".
+func StripModulePrefixes(in []string) []string {
+	var out []string
+	for _, v := range in {
+		out = append(out, StripModulePrefix(v))
+	}
+	return out
+}
+
+// StripModulePrefix returns s with any prefix up to and including the last ':'
+// character removed.
+func StripModulePrefix(s string) string {
+	sv := strings.Split(s, ":")
+	return sv[len(sv)-1]
+}
+
+// RemoveXPATHPredicates removes predicates from an XPath string. e.g.,
+// RemoveXPATHPredicates(/foo/bar[name="foo"]/config/baz -> /foo/bar/config/baz.
+func RemoveXPATHPredicates(s string) (string, error) {
+	var b bytes.Buffer
+	for i := 0; i < len(s); {
+		ss := s[i:]
+		si, ei := strings.Index(ss, "["), strings.Index(ss, "]")
+		switch {
+		case si == -1 && ei == -1:
+			// This substring didn't contain a [] pair, therefore write it
+			// to the buffer.
+			b.WriteString(ss)
+			// Move to the last character of the substring.
+			i += len(ss)
+		case si == -1 || ei == -1:
+			// This substring contained a mismatched pair of []s.
+			return "", fmt.Errorf("Mismatched brackets within substring %s of %s, [ pos: %d, ] pos: %d", ss, s, si, ei)
+		case si > ei:
+			// This substring contained a ] before a [.
+			return "", fmt.Errorf("Incorrect ordering of [] within substring %s of %s, [ pos: %d, ] pos: %d", ss, s, si, ei)
+		default:
+			// This substring contained a matched set of []s.
+			b.WriteString(ss[0:si])
+			i += ei + 1
+		}
+	}
+
+	return b.String(), nil
+}
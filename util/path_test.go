@@ -0,0 +1,79 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+)
+
+func TestRemoveXPATHPredicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{{
+		name: "simple predicate",
+		in:   `/foo/bar[name="eth0"]`,
+		want: "/foo/bar",
+	}, {
+		name: "predicate with path",
+		in:   `/foo/bar[name="/foo/bar/baz"]/config/hat`,
+		want: "/foo/bar/config/hat",
+	}, {
+		name: "predicate with function",
+		in:   `/foo/bar[name="current()/../interface"]/config/baz`,
+		want: "/foo/bar/config/baz",
+	}, {
+		name: "multiple predicates",
+		in:   `/foo/bar[name="current()/../interface"]/container/list[key="42"]/config/foo`,
+		want: "/foo/bar/container/list/config/foo",
+	}, {
+		name:    "] without [",
+		in:      `/foo/bar]`,
+		wantErr: true,
+	}, {
+		name:    "[ without closure",
+		in:      `/foo/bar[`,
+		wantErr: true,
+	}, {
+		name: "multiple predicates, end of string",
+		in:   `/foo/bar/name[e="1"]/bar[j="2"]`,
+		want: "/foo/bar/name/bar",
+	}, {
+		name:    "][ in incorrect order",
+		in:      `/foo/bar][`,
+		wantErr: true,
+	}, {
+		name: "empty string",
+		in:   ``,
+		want: ``,
+	}, {
+		name: "predicate directly",
+		in:   `foo[bar="test"]`,
+		want: `foo`,
+	}}
+
+	for _, tt := range tests {
+		got, err := RemoveXPATHPredicates(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: RemoveXPATHPredicates(%s): got unexpected error, got: %v", tt.name, tt.in, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: removePredicate(%v): did not get expected value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
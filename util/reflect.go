@@ -21,12 +21,36 @@ import (
 	"github.com/kylelemons/godebug/pretty"
 )
 
-// IsTypeStructPtr reports whether v is a struct ptr type.
+// leafValueStruct is implemented by struct types that represent the value of
+// a single YANG leaf, such as ygot.Decimal64, rather than a YANG container or
+// list member. The struct reflection helpers in this package treat such types
+// as scalar values rather than structs.
+type leafValueStruct interface {
+	IsYANGLeafValue()
+}
+
+// leafValueStructType is the reflect.Type of the leafValueStruct interface.
+var leafValueStructType = reflect.TypeOf((*leafValueStruct)(nil)).Elem()
+
+// IsTypeLeafValueStruct reports whether t is a struct type, or struct ptr type,
+// which represents the value of a single YANG leaf, such as ygot.Decimal64.
+func IsTypeLeafValueStruct(t reflect.Type) bool {
+	if t == reflect.TypeOf(nil) {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t.Implements(leafValueStructType)
+}
+
+// IsTypeStructPtr reports whether v is a struct ptr type. Structs that
+// represent the value of a YANG leaf are not considered to be structs.
 func IsTypeStructPtr(t reflect.Type) bool {
 	if t == reflect.TypeOf(nil) {
 		return false
 	}
-	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && !IsTypeLeafValueStruct(t)
 }
 
 // IsTypeSlicePtr reports whether v is a slice ptr type.
@@ -89,9 +113,10 @@ func IsValueInterface(v reflect.Value) bool {
 	return v.Kind() == reflect.Interface
 }
 
-// IsValueStruct reports whether v is a struct type.
+// IsValueStruct reports whether v is a struct type. Structs that represent
+// the value of a YANG leaf are not considered to be structs.
 func IsValueStruct(v reflect.Value) bool {
-	return v.Kind() == reflect.Struct
+	return v.Kind() == reflect.Struct && !IsTypeLeafValueStruct(v.Type())
 }
 
 // IsValueStructPtr reports whether v is a struct ptr type.
@@ -397,6 +422,9 @@ func forEachFieldInternal(ni *NodeInfo, in, out interface{}, iterFunction FieldI
 	case IsValueStruct(ni.FieldValue) || IsValueStructPtr(ni.FieldValue):
 		structElems := derefIfStructPtr(ni.FieldValue)
 		for i := 0; i < structElems.NumField(); i++ {
+			if IsYgotAnnotation(structElems.Type().Field(i)) {
+				continue
+			}
 			nn := *ni
 			nn.ParentStruct = ni.FieldValue.Interface()
 			nn.FieldType = structElems.Type().Field(i)
@@ -432,3 +460,35 @@ func derefIfStructPtr(value reflect.Value) reflect.Value {
 	}
 	return value
 }
+
+// GetKeyValue returns the value from the structVal field whose last path
+// element is key. The value is dereferenced if it is a ptr type. This function
+// is used to create a key value for a keyed list.
+// GetKeyValue returns an error if no path in any of the fields of structVal has
+// key as the last path element.
+func GetKeyValue(structVal reflect.Value, key string) (interface{}, error) {
+	for i := 0; i < structVal.NumField(); i++ {
+		f := structVal.Type().Field(i)
+		if IsYgotAnnotation(f) {
+			continue
+		}
+		p, err := RelativeSchemaPath(f)
+		if err != nil {
+			return nil, err
+		}
+		if p[len(p)-1] == key {
+			fv := structVal.Field(i)
+			if fv.Type().Kind() == reflect.Ptr {
+				// The type for the key is the dereferenced type, if the type
+				// is a ptr.
+				if !fv.Elem().IsValid() {
+					return nil, fmt.Errorf("key field %s (%s) has nil value %v", key, fv.Type(), fv)
+				}
+				return fv.Elem().Interface(), nil
+			}
+			return fv.Interface(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not find key field %s in struct type %s", key, structVal.Type())
+}
@@ -117,15 +117,23 @@ func TestIsValueNil(t *testing.T) {
 	}
 }
 
+// leafValue is a struct which represents the value of a YANG leaf.
+type leafValue struct {
+	V int
+}
+
+func (leafValue) IsYANGLeafValue() {}
+
 func TestIsValueFuncs(t *testing.T) {
 	testInt := int(42)
 	testStruct := struct{}{}
+	testLeafValue := leafValue{V: 42}
 	testSlice := []bool{}
 	testMap := map[bool]bool{}
 	var testNilSlice []bool
 	var testNilMap map[bool]bool
 
-	allValues := []interface{}{nil, testInt, &testInt, testStruct, &testStruct, testNilSlice, testSlice, &testSlice, testNilMap, testMap, &testMap}
+	allValues := []interface{}{nil, testInt, &testInt, testStruct, &testStruct, testLeafValue, &testLeafValue, testNilSlice, testSlice, &testSlice, testNilMap, testMap, &testMap}
 
 	tests := []struct {
 		desc     string
@@ -135,7 +143,7 @@ func TestIsValueFuncs(t *testing.T) {
 		{
 			desc:     "IsValuePtr",
 			function: IsValuePtr,
-			okValues: []interface{}{&testInt, &testStruct, &testSlice, &testMap},
+			okValues: []interface{}{&testInt, &testStruct, &testLeafValue, &testSlice, &testMap},
 		},
 		{
 			desc:     "IsValueStruct",
@@ -165,7 +173,7 @@ func TestIsValueFuncs(t *testing.T) {
 		{
 			desc:     "IsValueScalar",
 			function: IsValueScalar,
-			okValues: []interface{}{testInt, &testInt},
+			okValues: []interface{}{testInt, &testInt, testLeafValue, &testLeafValue},
 		},
 	}
 
@@ -181,14 +189,15 @@ func TestIsValueFuncs(t *testing.T) {
 func TestIsTypeFuncs(t *testing.T) {
 	testInt := int(42)
 	testStruct := struct{}{}
+	testLeafValue := leafValue{V: 42}
 	testSlice := []bool{}
 	testSliceOfInterface := []interface{}{}
 	testMap := map[bool]bool{}
 	var testNilSlice []bool
 	var testNilMap map[bool]bool
 
-	allTypes := []interface{}{nil, testInt, &testInt, testStruct, &testStruct, testNilSlice,
-		testSlice, &testSlice, testSliceOfInterface, testNilMap, testMap, &testMap}
+	allTypes := []interface{}{nil, testInt, &testInt, testStruct, &testStruct, testLeafValue, &testLeafValue,
+		testNilSlice, testSlice, &testSlice, testSliceOfInterface, testNilMap, testMap, &testMap}
 
 	tests := []struct {
 		desc     string
@@ -200,6 +209,11 @@ func TestIsTypeFuncs(t *testing.T) {
 			function: IsTypeStructPtr,
 			okTypes:  []interface{}{&testStruct},
 		},
+		{
+			desc:     "IsTypeLeafValueStruct",
+			function: IsTypeLeafValueStruct,
+			okTypes:  []interface{}{testLeafValue, &testLeafValue},
+		},
 		{
 			desc:     "IsTypeSlicePtr",
 			function: IsTypeSlicePtr,
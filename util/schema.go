@@ -0,0 +1,361 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// IsChoiceOrCase returns true if the entry is either a 'case' or a 'choice'
+// node within the schema. These are schema nodes only, and the code generation
+// operates on data tree paths.
+func IsChoiceOrCase(e *yang.Entry) bool {
+	return e.IsChoice() || e.IsCase()
+}
+
+// IsFakeRoot reports whether the supplied yang.Entry represents the synthesised
+// root entity in the generated code.
+func IsFakeRoot(e *yang.Entry) bool {
+	if _, ok := e.Annotation["isFakeRoot"]; ok {
+		return true
+	}
+	return false
+}
+
+// IsContainerLike reports whether the supplied yang.Entry is a container, or
+// is an entity that is represented in the same manner as a container in the
+// generated code - that is, the input or output of an rpc or action, or a
+// notification.
+func IsContainerLike(e *yang.Entry) bool {
+	switch e.Kind {
+	case yang.InputEntry, yang.OutputEntry, yang.NotificationEntry:
+		return true
+	}
+	return e.IsContainer()
+}
+
+// ChildSchema returns the schema for the struct field f, if f contains a valid
+// path tag and the schema path is found in the schema tree. It returns an error
+// if the struct tag is invalid, or nil if tag is valid but the schema is not
+// found in the tree at the specified path.
+func ChildSchema(schema *yang.Entry, f reflect.StructField) (*yang.Entry, error) {
+	pathTag, _ := f.Tag.Lookup("path")
+	DbgSchema("childSchema for schema %s, field %s, tag %s\n", schema.Name, f.Name, pathTag)
+	if rootName, ok := f.Tag.Lookup("rootname"); ok {
+		return schema.Dir[rootName], nil
+	}
+	p, err := RelativeSchemaPath(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers have the container schema name as the first element in the
+	// path tag for each field e.g. System { Dns ... path: "system/dns"
+	// Strip this off since the supplied schema already refers to the struct
+	// schema element.
+	if IsContainerLike(schema) && len(p) > 1 && p[0] == schema.Name {
+		p = p[1:]
+	}
+	DbgSchema("RelativeSchemaPath yields %v\n", p)
+	// For empty path, return the parent schema.
+	childSchema := schema
+	foundSchema := true
+	// Traverse the returned schema path to get the child schema.
+	DbgSchema("traversing schema Dirs...")
+	for ; len(p) > 0; p = p[1:] {
+		DbgSchema("/%s", p[0])
+		ns, ok := childSchema.Dir[StripModulePrefix(p[0])]
+		if !ok {
+			foundSchema = false
+			break
+		}
+		childSchema = ns
+	}
+	if foundSchema {
+		DbgSchema(" - found\n")
+		return childSchema, nil
+	}
+	DbgSchema(" - not found\n")
+
+	// Path is not null and was not found in the schema. It could be inside a
+	// choice/case schema element which is not represented in the path tags.
+	// e.g. choice1/case1/leaf1 could have abbreviated tag `path: "leaf1"`.
+	// In this case, try to match against any named elements within any choice/
+	// case subtrees. These are guaranteed to be unique within the current
+	// level namespace so a path tag name match will be unique if one is found.
+	if len(p) != 1 {
+		// Nodes within choice/case have a path tag with only the last schema
+		// path element i.e. choice1/case1/leaf1 path in the schema will have
+		// struct tag `path:"leaf1"`. This implies that only paths with length
+		// 1 are eligible for this matching.
+		return nil, nil
+	}
+	entries := make(map[string]*yang.Entry)
+	for _, ch := range childSchema.Dir {
+		if IsChoiceOrCase(ch) {
+			FindFirstNonChoiceOrCase(ch, entries)
+		}
+	}
+
+	DbgSchema("checking for %s against non choice/case entries: %v\n", p[0], stringMapKeys(entries))
+	for name, entry := range entries {
+		DbgSchema("%s ? ", name)
+
+		if StripModulePrefix(name) == p[0] {
+			DbgSchema(" - match\n")
+			return entry, nil
+		}
+	}
+
+	DbgSchema(" - no matches\n")
+	return nil, nil
+}
+
+// SchemaTreeRoot returns the root of the schema tree, given any node in that
+// tree. It returns nil if schema is nil.
+func SchemaTreeRoot(schema *yang.Entry) *yang.Entry {
+	if schema == nil {
+		return nil
+	}
+
+	root := schema
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	return root
+}
+
+// SchemaDataPath returns the path of the node described by schema in the data
+// tree, which excludes the names of any choice and case statements that the
+// node is within. The path is relative to the root of the schema tree where
+// it is the fake root of generated code, or a module.
+func SchemaDataPath(schema *yang.Entry) []string {
+	return schemaPath(schema, true)
+}
+
+// SchemaNodePath returns the schema path of the node described by schema,
+// which includes the names of any choice and case statements that the node is
+// within, relative to the root of the schema tree as for SchemaDataPath.
+func SchemaNodePath(schema *yang.Entry) []string {
+	return schemaPath(schema, false)
+}
+
+// schemaPath returns the elements of the path of e, excluding choice and case
+// statements if data is set. Since the schema tree of generated code may omit
+// the containers that surround lists at the root, the schema path annotation
+// of the nearest enclosing generated struct is used where present.
+func schemaPath(e *yang.Entry, data bool) []string {
+	var path []string
+	for n := e; n != nil && !IsFakeRoot(n) && !IsModuleEntry(n); n = n.Parent {
+		if sp, ok := n.Annotation["schemapath"].(string); ok {
+			// The schema path is of the form /module/a/b, and includes the
+			// names of choice and case statements, which are removed using
+			// the ancestors of n that remain within the tree.
+			parts := strings.Split(strings.TrimPrefix(sp, "/"), "/")[1:]
+			if data {
+				i := len(parts) - 1
+				for a := n.Parent; a != nil && !IsFakeRoot(a) && i > 0; a = a.Parent {
+					i--
+					if IsChoiceOrCase(a) {
+						parts = append(parts[:i], parts[i+1:]...)
+					}
+				}
+			}
+			return append(parts, path...)
+		}
+		if !data || !IsChoiceOrCase(n) {
+			path = append([]string{n.Name}, path...)
+		}
+	}
+	return path
+}
+
+// IsModuleEntry determines whether e describes a YANG module, which is the
+// root of a schema tree that is parsed at runtime.
+func IsModuleEntry(e *yang.Entry) bool {
+	_, ok := e.Node.(*yang.Module)
+	return ok
+}
+
+// DataPathChild returns the child of schema whose path within the data tree
+// matches the start of the path elements elems, and the number of elements
+// of its path, which may be greater than len(elems). Children within choice and case
+// statements are matched directly. The children of the fake root of generated
+// code are matched using their data path, which may consist of more than one
+// element where path compression has removed the containers that surround
+// them. Module prefixes are ignored. A nil entry is returned if no child
+// matches.
+func DataPathChild(schema *yang.Entry, elems []string) (*yang.Entry, int) {
+	children := map[string]*yang.Entry{}
+	for _, ch := range schema.Dir {
+		FindFirstNonChoiceOrCase(ch, children)
+	}
+	names := stringMapKeys(children)
+	sort.Strings(names)
+	for _, k := range names {
+		ch := children[k]
+		path := []string{ch.Name}
+		if IsFakeRoot(schema) {
+			path = SchemaDataPath(ch)
+		}
+		if len(path) == 0 {
+			continue
+		}
+		match := true
+		for i, n := range elems {
+			if i == len(path) {
+				break
+			}
+			if StripModulePrefix(n) != StripModulePrefix(path[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return ch, len(path)
+		}
+	}
+	return nil, 0
+}
+
+// FindFirstNonChoiceOrCase recursively traverses the schema tree and populates
+// m with the set of the first nodes in every path that neither case nor choice
+// nodes. The keys in the map are the schema element names of the matching
+// elements.
+func FindFirstNonChoiceOrCase(e *yang.Entry, m map[string]*yang.Entry) {
+	switch {
+	case !IsChoiceOrCase(e):
+		m[e.Name] = e
+	case e.IsDir():
+		for _, ch := range e.Dir {
+			FindFirstNonChoiceOrCase(ch, m)
+		}
+	}
+}
+
+// ResolveIfLeafRef returns a ptr to the schema pointed to by the provided leaf-ref
+// schema. It returns schema itself if schema is not a leaf-ref.
+func ResolveIfLeafRef(schema *yang.Entry) (*yang.Entry, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	// TODO(mostrowski): this should only be possible in fakeroot. Add an
+	// explicit check for that once data is available in the schema.
+	if schema.Type == nil {
+		return schema, nil
+	}
+
+	orig := schema
+	s := schema
+	for ykind := s.Type.Kind; ykind == yang.Yleafref; {
+		ns, err := FindLeafRefSchema(s, s.Type.Path)
+		if err != nil {
+			return schema, err
+		}
+		s = ns
+		ykind = s.Type.Kind
+	}
+
+	if s != orig {
+		DbgPrint("follow schema leaf-ref from %s to %s, type %v", orig.Name, s.Name, s.Type.Kind)
+	}
+	return s, nil
+}
+
+// FindLeafRefSchema returns a schema Entry at the path pathStr relative to
+// schema if it exists, or an error otherwise.
+// pathStr has either:
+//   - the relative form "../a/b/../b/c", where ".." indicates the parent of the
+//     node, or
+//   - the absolute form "/a/b/c", which indicates the absolute path from the
+//     root of the schema tree.
+func FindLeafRefSchema(schema *yang.Entry, pathStr string) (*yang.Entry, error) {
+	if pathStr == "" {
+		return nil, fmt.Errorf("leafref schema %s has empty path", schema.Name)
+	}
+
+	refSchema := schema
+	pathStr, err := RemoveXPATHPredicates(pathStr)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Split(pathStr, "/")
+
+	// For absolute path, reset to root of the schema tree.
+	if pathStr[0] == '/' {
+		refSchema = SchemaTreeRoot(schema)
+		path = path[1:]
+	}
+
+	for i := 0; i < len(path); i++ {
+		pe, err := stripPrefix(path[i])
+		if err != nil {
+			return nil, fmt.Errorf("leafref schema %s path %s: %v", schema.Name, pathStr, err)
+		}
+
+		if pe == ".." {
+			if refSchema.Parent == nil {
+				return nil, fmt.Errorf("parent of %s is nil for leafref schema %s with path %s", refSchema.Name, schema.Name, pathStr)
+			}
+			refSchema = refSchema.Parent
+			continue
+		}
+		if refSchema.Dir[pe] == nil {
+			if IsFakeRoot(refSchema) {
+				// Special handling is required for the fake root, since it
+				// contains only entries for which code is generated. These
+				// would not normally be removed in the schema, but the
+				// schema fakeroot is a special case which is constructed to
+				// contain the generated root elements.
+				// Therefore, need to check the path element also against the
+				// child of the entry.
+				pech, err := stripPrefix(path[i+1])
+				if err != nil {
+					return nil, err
+				}
+				if refSchema.Dir[pech] != nil {
+					refSchema = refSchema.Dir[pech]
+					// Skip this element.
+					i++
+					continue
+				}
+			}
+			return nil, fmt.Errorf("schema node %s is nil for leafref schema %s with path %s", pe, schema.Name, pathStr)
+		}
+		refSchema = refSchema.Dir[pe]
+	}
+
+	return refSchema, nil
+}
+
+// stripPrefix removes the prefix from a YANG path element. For example, removing
+// foo from "foo:bar". Such qualified paths are used in YANG modules where remote
+// paths are referenced.
+func stripPrefix(name string) (string, error) {
+	ps := strings.Split(name, ":")
+	switch len(ps) {
+	case 1:
+		return name, nil
+	case 2:
+		return ps[1], nil
+	}
+	return "", fmt.Errorf("path element did not form a valid name (name, prefix:name): %v", name)
+}
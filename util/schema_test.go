@@ -0,0 +1,228 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+func TestIsFakeRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *yang.Entry
+		want bool
+	}{
+		{
+			name: "explicitly true",
+			in: &yang.Entry{
+				Name: "entry",
+				Annotation: map[string]interface{}{
+					"isFakeRoot": true,
+				},
+			},
+			want: true,
+		},
+		{
+			name: "unspecified",
+			in: &yang.Entry{
+				Name: "entry",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := IsFakeRoot(tt.in); got != tt.want {
+			t.Errorf("%v: IsFakeRoot(%v): did not get expected return value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsContainerLike(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *yang.Entry
+		want bool
+	}{
+		{
+			name: "container",
+			in:   &yang.Entry{Name: "container", Kind: yang.DirectoryEntry},
+			want: true,
+		},
+		{
+			name: "list",
+			in:   &yang.Entry{Name: "list", Kind: yang.DirectoryEntry, ListAttr: &yang.ListAttr{}},
+		},
+		{
+			name: "rpc input",
+			in:   &yang.Entry{Name: "input", Kind: yang.InputEntry},
+			want: true,
+		},
+		{
+			name: "rpc output",
+			in:   &yang.Entry{Name: "output", Kind: yang.OutputEntry},
+			want: true,
+		},
+		{
+			name: "notification",
+			in:   &yang.Entry{Name: "notification", Kind: yang.NotificationEntry},
+			want: true,
+		},
+		{
+			name: "leaf",
+			in:   &yang.Entry{Name: "leaf", Kind: yang.LeafEntry},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := IsContainerLike(tt.in); got != tt.want {
+			t.Errorf("%v: IsContainerLike(%v): did not get expected return value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// dataPathSchema returns a schema tree rooted at a fake root, with a list
+// whose surrounding container has been removed by path compression, and a
+// container within a choice and case statement.
+func dataPathSchema() map[string]*yang.Entry {
+	mtu := &yang.Entry{Name: "mtu", Kind: yang.LeafEntry}
+	intf := &yang.Entry{
+		Name:       "interface",
+		Kind:       yang.DirectoryEntry,
+		ListAttr:   &yang.ListAttr{},
+		Dir:        map[string]*yang.Entry{"mtu": mtu},
+		Annotation: map[string]interface{}{"schemapath": "/mod/interfaces/interface"},
+	}
+	name := &yang.Entry{Name: "name", Kind: yang.LeafEntry}
+	protocol := &yang.Entry{
+		Name:       "protocol",
+		Kind:       yang.DirectoryEntry,
+		Dir:        map[string]*yang.Entry{"name": name},
+		Annotation: map[string]interface{}{"schemapath": "/mod/top/c1/k1/protocol"},
+	}
+	k1 := &yang.Entry{Name: "k1", Kind: yang.CaseEntry, Dir: map[string]*yang.Entry{"protocol": protocol}}
+	c1 := &yang.Entry{Name: "c1", Kind: yang.ChoiceEntry, Dir: map[string]*yang.Entry{"k1": k1}}
+	top := &yang.Entry{
+		Name:       "top",
+		Kind:       yang.DirectoryEntry,
+		Dir:        map[string]*yang.Entry{"c1": c1},
+		Annotation: map[string]interface{}{"schemapath": "/mod/top"},
+	}
+	root := &yang.Entry{
+		Name:       "device",
+		Kind:       yang.DirectoryEntry,
+		Dir:        map[string]*yang.Entry{"interface": intf, "top": top},
+		Annotation: map[string]interface{}{"isFakeRoot": true},
+	}
+	mtu.Parent, intf.Parent, top.Parent = intf, root, root
+	name.Parent, protocol.Parent, k1.Parent, c1.Parent = protocol, k1, c1, top
+	return map[string]*yang.Entry{
+		"root": root, "interface": intf, "mtu": mtu, "top": top, "protocol": protocol, "name": name,
+	}
+}
+
+func TestSchemaDataPath(t *testing.T) {
+	s := dataPathSchema()
+	tests := []struct {
+		name     string
+		in       *yang.Entry
+		wantData []string
+		wantNode []string
+	}{{
+		name: "fake root",
+		in:   s["root"],
+	}, {
+		name:     "list with compressed parent",
+		in:       s["interface"],
+		wantData: []string{"interfaces", "interface"},
+		wantNode: []string{"interfaces", "interface"},
+	}, {
+		name:     "leaf within list",
+		in:       s["mtu"],
+		wantData: []string{"interfaces", "interface", "mtu"},
+		wantNode: []string{"interfaces", "interface", "mtu"},
+	}, {
+		name:     "annotated container within choice and case",
+		in:       s["protocol"],
+		wantData: []string{"top", "protocol"},
+		wantNode: []string{"top", "c1", "k1", "protocol"},
+	}, {
+		name:     "leaf within choice and case",
+		in:       s["name"],
+		wantData: []string{"top", "protocol", "name"},
+		wantNode: []string{"top", "c1", "k1", "protocol", "name"},
+	}}
+
+	for _, tt := range tests {
+		if got := SchemaDataPath(tt.in); !reflect.DeepEqual(got, tt.wantData) {
+			t.Errorf("%s: SchemaDataPath(%s): got: %v, want: %v", tt.name, tt.in.Name, got, tt.wantData)
+		}
+		if got := SchemaNodePath(tt.in); !reflect.DeepEqual(got, tt.wantNode) {
+			t.Errorf("%s: SchemaNodePath(%s): got: %v, want: %v", tt.name, tt.in.Name, got, tt.wantNode)
+		}
+	}
+}
+
+func TestDataPathChild(t *testing.T) {
+	s := dataPathSchema()
+	tests := []struct {
+		name     string
+		inSchema *yang.Entry
+		inElems  []string
+		want     *yang.Entry
+		wantLen  int
+	}{{
+		name:     "full data path of fake root child",
+		inSchema: s["root"],
+		inElems:  []string{"interfaces", "interface", "mtu"},
+		want:     s["interface"],
+		wantLen:  2,
+	}, {
+		name:     "path ending within data path of fake root child",
+		inSchema: s["root"],
+		inElems:  []string{"interfaces"},
+		want:     s["interface"],
+		wantLen:  2,
+	}, {
+		name:     "module prefixes",
+		inSchema: s["root"],
+		inElems:  []string{"mod:top"},
+		want:     s["top"],
+		wantLen:  1,
+	}, {
+		name:     "child within choice and case",
+		inSchema: s["top"],
+		inElems:  []string{"protocol", "name"},
+		want:     s["protocol"],
+		wantLen:  1,
+	}, {
+		name:     "path that omits the container removed by compression",
+		inSchema: s["root"],
+		inElems:  []string{"interface"},
+	}, {
+		name:     "unknown child",
+		inSchema: s["top"],
+		inElems:  []string{"c1"},
+	}}
+
+	for _, tt := range tests {
+		got, n := DataPathChild(tt.inSchema, tt.inElems)
+		if got != tt.want || n != tt.wantLen {
+			t.Errorf("%s: DataPathChild(%s, %v): got: %v, %d, want: %v, %d", tt.name, tt.inSchema.Name, tt.inElems, got, n, tt.want, tt.wantLen)
+		}
+	}
+}
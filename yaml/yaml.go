@@ -0,0 +1,936 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yaml encodes and decodes the subset of YAML that is required to
+// represent a JSON data tree, such as that produced by the ygot JSON renderers
+// or consumed by ytypes.Unmarshal. Block and flow mappings and sequences,
+// plain, single-quoted and double-quoted scalars, literal and folded block
+// scalars, and comments are supported. Anchors, aliases, tags, complex mapping
+// keys and multiple documents are not.
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// yamlPlainRE matches the strings that are output as plain scalars.
+	// Other strings are double-quoted, such that they cannot be confused
+	// with scalars of other types, or YAML indicators.
+	yamlPlainRE = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./:-]*$`)
+	// yamlIntRE and yamlFloatRE match the integer and floating point plain
+	// scalars of the YAML 1.2 core schema.
+	yamlIntRE   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloatRE = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	// yamlReserved stores the plain scalars that are not resolved to
+	// strings by YAML 1.1 or YAML 1.2 parsers, in lower case.
+	yamlReserved = map[string]bool{
+		"null": true, "true": true, "false": true, "yes": true, "no": true,
+		"on": true, "off": true, "y": true, "n": true,
+	}
+)
+
+// Marshal returns the YAML encoding of the JSON data tree v. Each value
+// within v is encoded such that it is decoded to the same JSON type, i.e.,
+// strings that would otherwise be read as numbers, booleans or null are
+// quoted. The keys of mappings are sorted, and the order of sequences is
+// retained. indent is the string that nested collections are indented by,
+// which must consist of spaces.
+func Marshal(v interface{}, indent string) ([]byte, error) {
+	if indent == "" || strings.Trim(indent, " ") != "" {
+		return nil, fmt.Errorf("invalid YAML indentation %q, must consist of spaces", indent)
+	}
+
+	// The data tree is normalised by encoding it as JSON, such that any
+	// type that can be marshalled to JSON can be encoded.
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(js))
+	d.UseNumber()
+	var tree interface{}
+	if err := d.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	e := &yamlEncoder{indent: indent}
+	switch t := tree.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			return []byte("{}\n"), nil
+		}
+		e.writeMap(t, "", "")
+	case []interface{}:
+		if flow, ok := yamlFlowSeq(t); ok {
+			return []byte(flow + "\n"), nil
+		}
+		e.writeSeq(t, "", "")
+	default:
+		e.b.WriteString(yamlScalar(t) + "\n")
+	}
+	return e.b.Bytes(), nil
+}
+
+// yamlEncoder stores the state of the YAML encoding of a data tree.
+type yamlEncoder struct {
+	b      bytes.Buffer
+	indent string
+}
+
+// writeMap writes the block mapping m. Its first key is written following
+// the prefix first, and all other keys following the prefix rest, which is
+// of the same length as first.
+func (e *yamlEncoder) writeMap(m map[string]interface{}, first, rest string) {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		p := rest
+		if i == 0 {
+			p = first
+		}
+		e.b.WriteString(p + yamlString(k) + ":")
+		e.writeValue(m[k], rest+e.indent)
+	}
+}
+
+// writeSeq writes the block sequence s. Its first entry is written following
+// the prefix first, and all other entries following the prefix rest, which is
+// of the same length as first.
+func (e *yamlEncoder) writeSeq(s []interface{}, first, rest string) {
+	for i, v := range s {
+		p := rest
+		if i == 0 {
+			p = first
+		}
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if len(t) != 0 {
+				e.writeMap(t, p+"- ", rest+"  ")
+				continue
+			}
+		case []interface{}:
+			if _, ok := yamlFlowSeq(t); !ok {
+				e.writeSeq(t, p+"- ", rest+"  ")
+				continue
+			}
+		}
+		e.b.WriteString(p + "-")
+		e.writeValue(v, rest+"  ")
+	}
+}
+
+// writeValue writes the value v of a mapping key, or sequence entry, which
+// has been written to the current line. Nested collections are written on
+// the following lines, following the prefix.
+func (e *yamlEncoder) writeValue(v interface{}, prefix string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			e.b.WriteString(" {}\n")
+			return
+		}
+		e.b.WriteString("\n")
+		e.writeMap(t, prefix, prefix)
+	case []interface{}:
+		if flow, ok := yamlFlowSeq(t); ok {
+			e.b.WriteString(" " + flow + "\n")
+			return
+		}
+		e.b.WriteString("\n")
+		e.writeSeq(t, prefix, prefix)
+	default:
+		e.b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+// yamlFlowSeq returns the flow style encoding of the sequence s, and true, if
+// it is written in flow style. Empty sequences, and those that contain only
+// null values, such as the RFC7951 encoding of a YANG empty leaf, are written
+// in flow style.
+func yamlFlowSeq(s []interface{}) (string, bool) {
+	for _, v := range s {
+		if v != nil {
+			return "", false
+		}
+	}
+	return "[" + strings.TrimSuffix(strings.Repeat("null, ", len(s)), ", ") + "]", true
+}
+
+// yamlScalar returns the YAML encoding of the scalar JSON value v.
+func yamlScalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(t)
+	case json.Number:
+		return t.String()
+	case string:
+		return yamlString(t)
+	}
+	return yamlString(fmt.Sprintf("%v", v))
+}
+
+// yamlString returns the YAML encoding of the string s, which is a plain
+// scalar where it cannot be resolved to any other type, and a double-quoted
+// scalar otherwise.
+func yamlString(s string) string {
+	if yamlPlainRE.MatchString(s) && !strings.HasSuffix(s, ":") && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlParser stores the state of the parsing of a YAML document.
+type yamlParser struct {
+	lines []string
+	// ln is the index of the line currently being parsed.
+	ln int
+}
+
+// Parse parses the YAML document data, and returns the JSON data tree
+// that it describes, in the form returned by encoding/json when unmarshalling
+// into an interface{}: mappings are returned as map[string]interface{},
+// sequences as []interface{}, and numbers as float64. The errors returned
+// specify the line of the document at which they occurred.
+func Parse(data []byte) (interface{}, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("yaml: invalid UTF-8 in document")
+	}
+	p := &yamlParser{lines: strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")}
+
+	if err := p.skipBlank(); err != nil {
+		return nil, err
+	}
+	if p.more() && p.isMarker("---") {
+		p.ln++
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.more() || p.isMarker("...") {
+		return nil, nil
+	}
+
+	n, err := p.indentOf(p.ln)
+	if err != nil {
+		return nil, err
+	}
+	if n != 0 {
+		return nil, p.errorf(p.ln, "unexpected indentation of top-level node")
+	}
+	v, err := p.parseNode(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.skipBlank(); err != nil {
+		return nil, err
+	}
+	switch {
+	case !p.more():
+	case p.isMarker("..."):
+	case p.isMarker("---"):
+		return nil, p.errorf(p.ln, "multiple documents are not supported")
+	default:
+		return nil, p.errorf(p.ln, "unexpected content after top-level node")
+	}
+	return v, nil
+}
+
+// errorf returns an error for the line with index ln.
+func (p *yamlParser) errorf(ln int, format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", ln+1, fmt.Sprintf(format, args...))
+}
+
+// more determines whether there are lines remaining to be parsed.
+func (p *yamlParser) more() bool {
+	return p.ln < len(p.lines)
+}
+
+// isMarker determines whether the current line is the document marker m.
+func (p *yamlParser) isMarker(m string) bool {
+	l := p.lines[p.ln]
+	return strings.HasPrefix(l, m) && isYAMLBlank(l[len(m):])
+}
+
+// skipBlank advances the parser past the lines that are empty, or contain
+// only a comment.
+func (p *yamlParser) skipBlank() error {
+	for ; p.more(); p.ln++ {
+		if !isYAMLBlank(p.lines[p.ln]) {
+			_, err := p.indentOf(p.ln)
+			return err
+		}
+	}
+	return nil
+}
+
+// isYAMLBlank determines whether s contains only whitespace or a comment.
+func isYAMLBlank(s string) bool {
+	t := strings.TrimLeft(s, " \t")
+	return t == "" || t[0] == '#'
+}
+
+// indentOf returns the indentation of the line with index ln. An error is
+// returned if the line is indented using tabs.
+func (p *yamlParser) indentOf(ln int) (int, error) {
+	l := p.lines[ln]
+	n := len(l) - len(strings.TrimLeft(l, " "))
+	if n < len(l) && l[n] == '\t' {
+		return 0, p.errorf(ln, "found a tab character used for indentation")
+	}
+	return n, nil
+}
+
+// nextIndent returns the indentation of the next line that is not blank,
+// or -1 if there is no such line. The parser is advanced to that line.
+func (p *yamlParser) nextIndent() (int, error) {
+	if err := p.skipBlank(); err != nil {
+		return 0, err
+	}
+	if !p.more() || p.isMarker("---") || p.isMarker("...") {
+		return -1, nil
+	}
+	return p.indentOf(p.ln)
+}
+
+// parseNode parses the block node that starts at column col of the current
+// line. All subsequent lines of a block collection must be indented by col.
+func (p *yamlParser) parseNode(col int) (interface{}, error) {
+	c := p.lines[p.ln][col:]
+	if isYAMLSeqEntry(c) {
+		return p.parseSeq(col)
+	}
+	if _, _, ok, err := p.splitMapEntry(p.ln, col); err != nil {
+		return nil, err
+	} else if ok {
+		return p.parseMap(col)
+	}
+	return p.parseInline(col, col-1)
+}
+
+// isYAMLSeqEntry determines whether the content c starts a block sequence
+// entry.
+func isYAMLSeqEntry(c string) bool {
+	return c == "-" || strings.HasPrefix(c, "- ") || strings.HasPrefix(c, "-\t")
+}
+
+// parseSeq parses the block sequence whose entries start at column col.
+func (p *yamlParser) parseSeq(col int) ([]interface{}, error) {
+	s := []interface{}{}
+	for {
+		c := p.lines[p.ln][col:]
+		if !isYAMLSeqEntry(c) {
+			return nil, p.errorf(p.ln, "expected a sequence entry")
+		}
+		vcol := col + 1 + len(c[1:]) - len(strings.TrimLeft(c[1:], " "))
+		var v interface{}
+		if isYAMLBlank(c[1:]) {
+			var err error
+			if v, err = p.parseNested(col, false); err != nil {
+				return nil, err
+			}
+		} else {
+			if strings.HasPrefix(strings.TrimLeft(c[1:], " "), "\t") {
+				return nil, p.errorf(p.ln, "found a tab character used for indentation")
+			}
+			var err error
+			if v, err = p.parseNode(vcol); err != nil {
+				return nil, err
+			}
+		}
+		s = append(s, v)
+
+		n, err := p.nextIndent()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n < col:
+			return s, nil
+		case n > col:
+			return nil, p.errorf(p.ln, "unexpected indentation")
+		case !isYAMLSeqEntry(p.lines[p.ln][col:]):
+			if _, _, ok, _ := p.splitMapEntry(p.ln, col); ok {
+				// The sequence is the value of a mapping key at the same
+				// indentation, which is followed by the next key.
+				return s, nil
+			}
+			return nil, p.errorf(p.ln, "expected a sequence entry")
+		}
+	}
+}
+
+// parseMap parses the block mapping whose keys start at column col.
+func (p *yamlParser) parseMap(col int) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	for {
+		kln := p.ln
+		k, vcol, ok, err := p.splitMapEntry(p.ln, col)
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return nil, p.errorf(p.ln, "expected a mapping key")
+		}
+		if _, dup := m[k]; dup {
+			return nil, p.errorf(kln, "duplicate mapping key %q", k)
+		}
+
+		var v interface{}
+		if isYAMLBlank(p.lines[p.ln][vcol:]) {
+			if v, err = p.parseNested(col, true); err != nil {
+				return nil, err
+			}
+		} else if v, err = p.parseInline(vcol, col); err != nil {
+			return nil, err
+		}
+		m[k] = v
+
+		n, err := p.nextIndent()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n < col:
+			return m, nil
+		case n > col:
+			return nil, p.errorf(p.ln, "unexpected indentation")
+		}
+	}
+}
+
+// parseNested parses the block node that follows a sequence entry indicator,
+// or mapping key, at column col that has no value on the same line. Where
+// there is no such node, the value is null. The entries of a sequence that is
+// the value of a mapping key may have the same indentation as the key.
+func (p *yamlParser) parseNested(col int, isMapValue bool) (interface{}, error) {
+	ln := p.ln
+	p.ln++
+	n, err := p.nextIndent()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case n > col:
+		return p.parseNode(n)
+	case n == col && isMapValue && isYAMLSeqEntry(p.lines[p.ln][col:]):
+		return p.parseSeq(col)
+	}
+	// Leave the parser at the line following the entry, such that the
+	// caller determines whether the collection continues.
+	p.ln = ln + 1
+	return nil, nil
+}
+
+// splitMapEntry determines whether the line with index ln contains a mapping
+// entry at column col. If so, it returns the key of the entry, the column at
+// which its value starts, and true.
+func (p *yamlParser) splitMapEntry(ln, col int) (string, int, bool, error) {
+	l := p.lines[ln]
+	c := l[col:]
+	var key string
+	i := 0
+	switch {
+	case c == "":
+		return "", 0, false, nil
+	case c[0] == '"' || c[0] == '\'':
+		s, end, err := p.parseQuoted(ln, col)
+		if err != nil {
+			return "", 0, false, err
+		}
+		key, i = s, end-col
+		for i < len(c) && c[i] == ' ' {
+			i++
+		}
+		if i == len(c) || c[i] != ':' || (i+1 < len(c) && c[i+1] != ' ' && c[i+1] != '\t') {
+			return "", 0, false, nil
+		}
+	case c[0] == '?':
+		if len(c) == 1 || c[1] == ' ' {
+			return "", 0, false, p.errorf(ln, "complex mapping keys are not supported")
+		}
+		fallthrough
+	default:
+		if strings.ContainsRune("[{#&*!|>%@`", rune(c[0])) {
+			return "", 0, false, nil
+		}
+		i = yamlValueIndicator(c)
+		if i == -1 {
+			return "", 0, false, nil
+		}
+		key = strings.TrimRight(c[:i], " \t")
+	}
+	// Skip the ':' value indicator, and the whitespace following it.
+	v := i + 1
+	for v < len(c) && (c[v] == ' ' || c[v] == '\t') {
+		v++
+	}
+	return key, col + v, true, nil
+}
+
+// yamlValueIndicator returns the index of the ':' that separates the plain
+// key at the start of c from its value, or -1 if there is none.
+func yamlValueIndicator(c string) int {
+	for i := 0; i < len(c); i++ {
+		switch {
+		case c[i] == '#' && i > 0 && (c[i-1] == ' ' || c[i-1] == '\t'):
+			return -1
+		case c[i] == ':' && (i+1 == len(c) || c[i+1] == ' ' || c[i+1] == '\t'):
+			return i
+		}
+	}
+	return -1
+}
+
+// parseInline parses the value that starts at column col of the current
+// line, which is not a block collection. parent is the column of the mapping
+// key or sequence entry that the value belongs to, or -1 for a top-level
+// value. The parser is advanced to the line following the value.
+func (p *yamlParser) parseInline(col, parent int) (interface{}, error) {
+	l := p.lines[p.ln]
+	c := l[col:]
+	switch c[0] {
+	case '[', '{':
+		fp := &yamlFlowParser{p: p, ln: p.ln, col: col}
+		v, err := fp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if !isYAMLBlank(p.lines[fp.ln][fp.col:]) {
+			return nil, p.errorf(fp.ln, "unexpected content after flow collection")
+		}
+		p.ln = fp.ln + 1
+		return v, nil
+	case '"', '\'':
+		s, end, err := p.parseQuoted(p.ln, col)
+		if err != nil {
+			return nil, err
+		}
+		if !isYAMLBlank(l[end:]) {
+			return nil, p.errorf(p.ln, "unexpected content after quoted scalar")
+		}
+		p.ln++
+		return s, nil
+	case '|', '>':
+		return p.parseBlockScalar(col, parent)
+	case '&', '*', '!':
+		return nil, p.errorf(p.ln, "anchors, aliases and tags are not supported")
+	case '%', '@', '`':
+		return nil, p.errorf(p.ln, "found reserved character %q that cannot start a plain scalar", c[0])
+	}
+
+	if isYAMLSeqEntry(c) {
+		return nil, p.errorf(p.ln, "sequence entries are not allowed in this context")
+	}
+	s := yamlStripComment(c)
+	if yamlValueIndicator(s) != -1 {
+		return nil, p.errorf(p.ln, "mapping values are not allowed in this context")
+	}
+	// Plain scalars continue onto the following lines that are indented
+	// further than the node that they belong to, which are folded into a
+	// single line.
+	p.ln++
+	for p.more() && !isYAMLBlank(p.lines[p.ln]) {
+		n, err := p.indentOf(p.ln)
+		if err != nil {
+			return nil, err
+		}
+		if n <= parent {
+			break
+		}
+		cont := yamlStripComment(p.lines[p.ln][n:])
+		if yamlValueIndicator(cont) != -1 || strings.HasPrefix(strings.TrimSpace(p.lines[p.ln]), "#") {
+			return nil, p.errorf(p.ln, "mapping values are not allowed in this context")
+		}
+		s += " " + cont
+		p.ln++
+	}
+	return resolveYAMLPlain(s), nil
+}
+
+// yamlStripComment returns the content of the plain scalar c, without any
+// trailing comment or whitespace.
+func yamlStripComment(c string) string {
+	for i := 1; i < len(c); i++ {
+		if c[i] == '#' && (c[i-1] == ' ' || c[i-1] == '\t') {
+			c = c[:i]
+			break
+		}
+	}
+	return strings.TrimRight(c, " \t")
+}
+
+// resolveYAMLPlain returns the value of the plain scalar s, according to
+// the YAML 1.2 core schema. Numbers are returned as float64 values.
+func resolveYAMLPlain(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1)
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1)
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN()
+	}
+	switch {
+	case yamlIntRE.MatchString(s), yamlFloatRE.MatchString(s):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0o"):
+		base := 16
+		if s[1] == 'o' {
+			base = 8
+		}
+		if i, err := strconv.ParseUint(s[2:], base, 64); err == nil {
+			return float64(i)
+		}
+	}
+	return s
+}
+
+// parseQuoted parses the single or double-quoted scalar that starts at
+// column col of the line with index ln. It returns the value of the scalar,
+// and the column following it. Quoted scalars must be contained within a
+// single line.
+func (p *yamlParser) parseQuoted(ln, col int) (string, int, error) {
+	l := p.lines[ln]
+	q := l[col]
+	var b bytes.Buffer
+	for i := col + 1; i < len(l); i++ {
+		ch := l[i]
+		switch {
+		case ch == q && q == '\'' && i+1 < len(l) && l[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case ch == q:
+			return b.String(), i + 1, nil
+		case ch == '\\' && q == '"':
+			r, n, err := unescapeYAML(l[i:])
+			if err != nil {
+				return "", 0, p.errorf(ln, "%v", err)
+			}
+			b.WriteString(r)
+			i += n - 1
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", 0, p.errorf(ln, "unterminated quoted scalar, quoted scalars must be contained within one line")
+}
+
+// yamlEscapes maps the single character escape sequences of double-quoted
+// scalars to the characters that they represent.
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+	'/': "/", '\\': "\\", 'N': "\u0085", '_': " ", 'L': " ",
+	'P': " ",
+}
+
+// unescapeYAML returns the value of the escape sequence at the start of s,
+// and its length.
+func unescapeYAML(s string) (string, int, error) {
+	if len(s) < 2 {
+		return "", 0, fmt.Errorf("invalid escape sequence at end of line")
+	}
+	if r, ok := yamlEscapes[s[1]]; ok {
+		return r, 2, nil
+	}
+	n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[1]]
+	if n == 0 || len(s) < 2+n {
+		return "", 0, fmt.Errorf("invalid escape sequence %q", s[:2])
+	}
+	cp, err := strconv.ParseUint(s[2:2+n], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(cp)) {
+		return "", 0, fmt.Errorf("invalid escape sequence %q", s[:2+n])
+	}
+	return string(rune(cp)), 2 + n, nil
+}
+
+// parseBlockScalar parses the literal or folded block scalar whose header
+// starts at column col of the current line. parent is the column of the
+// mapping key or sequence entry that the scalar belongs to, whose content
+// must be indented further.
+func (p *yamlParser) parseBlockScalar(col, parent int) (string, error) {
+	hln := p.ln
+	h := yamlStripComment(p.lines[p.ln][col:])
+	literal := h[0] == '|'
+	chomp, indent := byte(0), 0
+	for _, ch := range []byte(h[1:]) {
+		switch {
+		case (ch == '-' || ch == '+') && chomp == 0:
+			chomp = ch
+		case ch >= '1' && ch <= '9' && indent == 0:
+			indent = parent + 1 + int(ch-'1')
+			if parent < 0 {
+				indent = int(ch - '0')
+			}
+		default:
+			return "", p.errorf(hln, "invalid block scalar header %q", h)
+		}
+	}
+
+	// Collect the lines of the scalar, which are those that are empty, or
+	// are indented by at least its indentation.
+	var lines []string
+	p.ln++
+	for ; p.more(); p.ln++ {
+		l := p.lines[p.ln]
+		if strings.TrimLeft(l, " ") == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n, err := p.indentOf(p.ln)
+		if err != nil {
+			return "", err
+		}
+		if indent == 0 {
+			if n <= parent {
+				break
+			}
+			indent = n
+		}
+		if n < indent {
+			break
+		}
+		lines = append(lines, l[indent:])
+	}
+
+	// Trailing empty lines are handled according to the chomping indicator,
+	// and are not part of the next node.
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	p.ln -= trailing
+	if len(lines) == 0 {
+		if chomp == '+' {
+			return strings.Repeat("\n", trailing), nil
+		}
+		return "", nil
+	}
+
+	var b bytes.Buffer
+	for i, l := range lines {
+		if i > 0 {
+			// Folded scalars join consecutive lines that are not more
+			// indented with a space. The line break preceding a run of
+			// empty lines is discarded, such that each empty line is a
+			// single line break.
+			prev := lines[i-1]
+			switch {
+			case literal:
+				b.WriteString("\n")
+			case l == "" && prev != "" && prev[0] != ' ':
+			case l == "", prev == "", l[0] == ' ', prev[0] == ' ':
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(l)
+	}
+
+	switch chomp {
+	case '-':
+	case '+':
+		b.WriteString(strings.Repeat("\n", trailing+1))
+	default:
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// yamlFlowParser parses a flow collection, which may span multiple lines.
+type yamlFlowParser struct {
+	p *yamlParser
+	// ln and col are the index of the line, and the column within it, of
+	// the next character to be parsed.
+	ln, col int
+}
+
+// skipSpace advances the parser past whitespace, line breaks and comments.
+// It returns an error if the end of the document is reached.
+func (f *yamlFlowParser) skipSpace() error {
+	for f.ln < len(f.p.lines) {
+		l := f.p.lines[f.ln]
+		for f.col < len(l) && (l[f.col] == ' ' || l[f.col] == '\t') {
+			f.col++
+		}
+		if f.col < len(l) && l[f.col] != '#' {
+			return nil
+		}
+		f.ln++
+		f.col = 0
+	}
+	return f.p.errorf(len(f.p.lines)-1, "unterminated flow collection")
+}
+
+// peek returns the next character to be parsed.
+func (f *yamlFlowParser) peek() byte {
+	return f.p.lines[f.ln][f.col]
+}
+
+// parseValue parses the flow node that starts at the current position.
+func (f *yamlFlowParser) parseValue() (interface{}, error) {
+	if err := f.skipSpace(); err != nil {
+		return nil, err
+	}
+	switch f.peek() {
+	case '[':
+		return f.parseSeq()
+	case '{':
+		return f.parseMap()
+	case '"', '\'':
+		s, end, err := f.p.parseQuoted(f.ln, f.col)
+		if err != nil {
+			return nil, err
+		}
+		f.col = end
+		return s, nil
+	case '&', '*', '!':
+		return nil, f.p.errorf(f.ln, "anchors, aliases and tags are not supported")
+	case ']', '}', ',':
+		return nil, f.p.errorf(f.ln, "unexpected %q in flow collection", f.peek())
+	}
+	return resolveYAMLPlain(f.plain()), nil
+}
+
+// plain returns the plain scalar that starts at the current position, which
+// ends at a flow indicator, value indicator, comment or line end.
+func (f *yamlFlowParser) plain() string {
+	l := f.p.lines[f.ln]
+	start := f.col
+	for ; f.col < len(l); f.col++ {
+		ch := l[f.col]
+		if ch == ',' || ch == ']' || ch == '}' || ch == '[' || ch == '{' {
+			break
+		}
+		if ch == ':' && (f.col+1 == len(l) || strings.ContainsRune(" \t,]}", rune(l[f.col+1]))) {
+			break
+		}
+		if ch == '#' && f.col > start && (l[f.col-1] == ' ' || l[f.col-1] == '\t') {
+			break
+		}
+	}
+	return strings.TrimRight(l[start:f.col], " \t")
+}
+
+// parseSeq parses the flow sequence that starts at the current position.
+func (f *yamlFlowParser) parseSeq() ([]interface{}, error) {
+	f.col++
+	s := []interface{}{}
+	for {
+		if err := f.skipSpace(); err != nil {
+			return nil, err
+		}
+		if f.peek() == ']' {
+			f.col++
+			return s, nil
+		}
+		v, err := f.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+		if err := f.next(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseMap parses the flow mapping that starts at the current position.
+func (f *yamlFlowParser) parseMap() (map[string]interface{}, error) {
+	f.col++
+	m := map[string]interface{}{}
+	for {
+		if err := f.skipSpace(); err != nil {
+			return nil, err
+		}
+		if f.peek() == '}' {
+			f.col++
+			return m, nil
+		}
+
+		kln := f.ln
+		var k string
+		switch f.peek() {
+		case '"', '\'':
+			s, end, err := f.p.parseQuoted(f.ln, f.col)
+			if err != nil {
+				return nil, err
+			}
+			k, f.col = s, end
+		case '[', '{':
+			return nil, f.p.errorf(f.ln, "complex mapping keys are not supported")
+		default:
+			k = f.plain()
+		}
+		if _, dup := m[k]; dup {
+			return nil, f.p.errorf(kln, "duplicate mapping key %q", k)
+		}
+		if err := f.skipSpace(); err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if f.peek() == ':' {
+			f.col++
+			var err error
+			if v, err = f.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		m[k] = v
+		if err := f.next('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next advances the parser past the ',' separating the entries of a flow
+// collection, or to the character end that terminates the collection.
+func (f *yamlFlowParser) next(end byte) error {
+	if err := f.skipSpace(); err != nil {
+		return err
+	}
+	switch f.peek() {
+	case ',':
+		f.col++
+		return nil
+	case end:
+		return nil
+	}
+	return f.p.errorf(f.ln, "expected ',' or %q in flow collection, got %q", end, f.peek())
+}
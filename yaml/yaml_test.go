@@ -0,0 +1,334 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yaml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       interface{}
+		inIndent string
+		want     string
+		wantErr  bool
+	}{{
+		name: "scalars",
+		in: map[string]interface{}{
+			"string":   "value",
+			"int":      42,
+			"uint64":   uint64(18446744073709551615),
+			"float":    4.2,
+			"bool":     true,
+			"null":     nil,
+			"identity": "openconfig-if:ETHERNET",
+		},
+		inIndent: "  ",
+		want: `bool: true
+float: 4.2
+identity: openconfig-if:ETHERNET
+int: 42
+"null": null
+string: value
+uint64: 18446744073709551615
+`,
+	}, {
+		name: "strings that are quoted",
+		in: []interface{}{
+			"42", "-1.5", "true", "No", "null", "~", "", "a b", "a: b", "@",
+			"- a", "a:", "#", "line\nbreak", "'", `"`,
+		},
+		inIndent: "  ",
+		want: `- "42"
+- "-1.5"
+- "true"
+- "No"
+- "null"
+- "~"
+- ""
+- "a b"
+- "a: b"
+- "@"
+- "- a"
+- "a:"
+- "#"
+- "line\nbreak"
+- "'"
+- "\""
+`,
+	}, {
+		name: "nested collections",
+		in: map[string]interface{}{
+			"container": map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"name": "b", "config": map[string]interface{}{"name": "b"}},
+					map[string]interface{}{"name": "a"},
+				},
+				"leaf-list": []interface{}{"z", "a"},
+				"nested":    []interface{}{[]interface{}{"a", "b"}, []interface{}{}},
+				"empty":     []interface{}{nil},
+				"@empty":    map[string]interface{}{},
+			},
+		},
+		inIndent: "  ",
+		want: `container:
+  "@empty": {}
+  empty: [null]
+  leaf-list:
+    - z
+    - a
+  list:
+    - config:
+        name: b
+      name: b
+    - name: a
+  nested:
+    - - a
+      - b
+    - []
+`,
+	}, {
+		name:     "wide indentation",
+		in:       map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{map[string]interface{}{"c": "d", "e": "f"}}}},
+		inIndent: "    ",
+		want: `a:
+    b:
+        - c: d
+          e: f
+`,
+	}, {
+		name:     "empty map",
+		in:       map[string]interface{}{},
+		inIndent: "  ",
+		want:     "{}\n",
+	}, {
+		name:     "invalid indentation",
+		in:       map[string]interface{}{},
+		inIndent: "\t",
+		wantErr:  true,
+	}}
+
+	for _, tt := range tests {
+		got, err := Marshal(tt.in, tt.inIndent)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Marshal(%v): got unexpected error: %v", tt.name, tt.in, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: Marshal(%v): did not get expected output, got:\n%s\nwant:\n%s", tt.name, tt.in, got, tt.want)
+		}
+
+		// The output must be parsed to the same data tree as the input
+		// when it is encoded as JSON.
+		js, err := json.Marshal(tt.in)
+		if err != nil {
+			t.Fatalf("%s: json.Marshal(%v): got unexpected error: %v", tt.name, tt.in, err)
+		}
+		var want interface{}
+		if err := json.Unmarshal(js, &want); err != nil {
+			t.Fatalf("%s: json.Unmarshal(%s): got unexpected error: %v", tt.name, js, err)
+		}
+		parsed, err := Parse(got)
+		if err != nil {
+			t.Errorf("%s: Parse(%s): got unexpected error: %v", tt.name, got, err)
+			continue
+		}
+		if diff := pretty.Compare(parsed, want); diff != "" {
+			t.Errorf("%s: Parse(%s): did not get expected value, diff(-got,+want):\n%s", tt.name, got, diff)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    interface{}
+		wantErr string
+	}{{
+		name: "scalars",
+		in: `# comment
+---
+plain: a value with spaces # comment
+colon: mod:name
+single: 'it''s'
+double: "tab\tand \u00e9"
+int: 42
+negative: -7
+float: 1.5e3
+hex: 0x1f
+bool: True
+null: ~
+empty:
+quoted-key: "42"
+"@quoted": x
+...
+`,
+		want: map[string]interface{}{
+			"plain":      "a value with spaces",
+			"colon":      "mod:name",
+			"single":     "it's",
+			"double":     "tab\tand \u00e9",
+			"int":        float64(42),
+			"negative":   float64(-7),
+			"float":      float64(1500),
+			"hex":        float64(31),
+			"bool":       true,
+			"null":       nil,
+			"empty":      nil,
+			"quoted-key": "42",
+			"@quoted":    "x",
+		},
+	}, {
+		name: "block collections",
+		in: `list:
+- name: a
+  config:
+    name: a
+
+- name: b
+nested:
+  - - x
+    - y
+  -
+    - z
+map:
+    deep:
+        value: 1
+`,
+		want: map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "a", "config": map[string]interface{}{"name": "a"}},
+				map[string]interface{}{"name": "b"},
+			},
+			"nested": []interface{}{[]interface{}{"x", "y"}, []interface{}{"z"}},
+			"map":    map[string]interface{}{"deep": map[string]interface{}{"value": float64(1)}},
+		},
+	}, {
+		name: "flow collections",
+		in: `empty-leaf: [null]
+list: [a, "b", 1, {k: v, "q": [x]}]
+map: {
+  a: 1, # comment
+  b: [],
+}
+`,
+		want: map[string]interface{}{
+			"empty-leaf": []interface{}{nil},
+			"list":       []interface{}{"a", "b", float64(1), map[string]interface{}{"k": "v", "q": []interface{}{"x"}}},
+			"map":        map[string]interface{}{"a": float64(1), "b": []interface{}{}},
+		},
+	}, {
+		name: "block scalars",
+		in: `literal: |
+  line one
+    indented
+
+  line three
+folded: >-
+  folded
+  text
+
+  paragraph
+keep: |+
+  text
+
+strip: |-
+  text
+multi: plain text
+  continued
+`,
+		want: map[string]interface{}{
+			"literal": "line one\n  indented\n\nline three\n",
+			"folded":  "folded text\nparagraph",
+			"keep":    "text\n\n",
+			"strip":   "text",
+			"multi":   "plain text continued",
+		},
+	}, {
+		name: "empty document",
+		in:   "# only a comment\n",
+		want: nil,
+	}, {
+		name:    "tab indentation",
+		in:      "a:\n\tb: c\n",
+		wantErr: "yaml: line 2: found a tab character used for indentation",
+	}, {
+		name:    "duplicate key",
+		in:      "a: 1\nb: 2\na: 3\n",
+		wantErr: `yaml: line 3: duplicate mapping key "a"`,
+	}, {
+		name:    "unexpected indentation",
+		in:      "a:\n  b:\n    c: 1\n   d: 2\n",
+		wantErr: "yaml: line 4: unexpected indentation",
+	}, {
+		name:    "mapping within continued plain scalar",
+		in:      "a:\n  b: 1\n    c: 2\n",
+		wantErr: "yaml: line 3: mapping values are not allowed in this context",
+	}, {
+		name:    "mapping value in plain scalar",
+		in:      "a: b: c\n",
+		wantErr: "yaml: line 1: mapping values are not allowed in this context",
+	}, {
+		name:    "unterminated quoted scalar",
+		in:      "a: 1\nb: \"value\n",
+		wantErr: "yaml: line 2: unterminated quoted scalar",
+	}, {
+		name:    "unterminated flow collection",
+		in:      "a: [1,\n  2\n",
+		wantErr: "yaml: line 3: unterminated flow collection",
+	}, {
+		name:    "invalid flow collection",
+		in:      "a: [1,\n  \"2\" 3]\n",
+		wantErr: `yaml: line 2: expected ',' or ']' in flow collection`,
+	}, {
+		name:    "sequence within mapping",
+		in:      "a: 1\n- b\n",
+		wantErr: "yaml: line 2: expected a mapping key",
+	}, {
+		name:    "alias",
+		in:      "a: *b\n",
+		wantErr: "yaml: line 1: anchors, aliases and tags are not supported",
+	}, {
+		name:    "multiple documents",
+		in:      "a: 1\n---\nb: 2\n",
+		wantErr: "yaml: line 2: multiple documents are not supported",
+	}}
+
+	for _, tt := range tests {
+		got, err := Parse([]byte(tt.in))
+		if err != nil {
+			if tt.wantErr == "" || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Parse(%s): got unexpected error: %v, want: %s", tt.name, tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if tt.wantErr != "" {
+			t.Errorf("%s: Parse(%s): did not get expected error, got: %v, want: %s", tt.name, tt.in, got, tt.wantErr)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: Parse(%s): did not get expected value, diff(-got,+want):\n%s", tt.name, tt.in, diff)
+		}
+	}
+}
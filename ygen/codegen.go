@@ -47,6 +47,10 @@ type GeneratorConfig struct {
 	// CompressOCPaths indicates whether paths should be compressed in the output
 	// of an OpenConfig schema.
 	CompressOCPaths bool
+	// ConfigStatePreference specifies how the leaves within the config and
+	// state containers of an OpenConfig schema are mapped to fields when
+	// CompressOCPaths is set to true.
+	ConfigStatePreference ConfigStatePreference
 	// ExcludeModules specifies any modules that are included within the set of
 	// modules that should have code generated for them that should be ignored during
 	// code generation. This is due to the fact that some schemas (e.g., OpenConfig
@@ -77,6 +81,12 @@ type GeneratorConfig struct {
 	// generation function, such that it can be handled by an external
 	// library.
 	StoreRawSchema bool
+	// ApplyDeviations specifies whether the deviation statements within the
+	// input YANG modules are applied to the schema prior to code being
+	// generated, and whether the nodes that they modify are included in the
+	// report returned by GenerateReport. Deviations whose target cannot be
+	// found are not applied, and are listed within the report.
+	ApplyDeviations bool
 	// GoOptions stores a struct which stores Go code generation specific
 	// options for the code generaton.
 	GoOptions GoOpts
@@ -84,6 +94,45 @@ type GeneratorConfig struct {
 	ProtoOptions ProtoOpts
 }
 
+// ConfigStatePreference specifies how the leaves within the config and state
+// containers of an OpenConfig schema are mapped to the fields of a compressed
+// struct or message, since the leaves within the config container are
+// duplicated within the state container.
+type ConfigStatePreference int64
+
+const (
+	// PreferConfig maps leaves that are within both the config and state
+	// containers to a single field, whose path is that of the leaf within
+	// the config container. It is the default.
+	PreferConfig ConfigStatePreference = iota
+	// PreferState maps leaves that are within both the config and state
+	// containers to a single field, whose path is that of the leaf within
+	// the state container. It is intended for consumers of operational state,
+	// such as telemetry collectors.
+	PreferState
+	// ConfigAndState maps the leaves within the config and state containers
+	// to distinct fields. The fields for leaves of the state container that
+	// are duplicated within the config container are named with the prefix
+	// "state-" prepended to the name of the leaf (e.g., StateMtu for the Go
+	// field, and state_mtu for the protobuf field).
+	ConfigAndState
+)
+
+// ParseConfigStatePreference returns the ConfigStatePreference named by s,
+// which is one of "config", "state" or "both". An empty string is parsed as
+// PreferConfig.
+func ParseConfigStatePreference(s string) (ConfigStatePreference, error) {
+	switch s {
+	case "", "config":
+		return PreferConfig, nil
+	case "state":
+		return PreferState, nil
+	case "both":
+		return ConfigAndState, nil
+	}
+	return PreferConfig, fmt.Errorf("invalid config/state preference %q, must be one of config, state or both", s)
+}
+
 // GoOpts stores Go specific options for the code generation library.
 type GoOpts struct {
 	// SchemaVarName is the name for the variable which stores the compressed
@@ -134,6 +183,27 @@ type ProtoOpts struct {
 	// original YANG names in the output protobuf file.
 	// See https://github.com/openconfig/ygot/blob/master/docs/yang-to-protobuf-transformations-spec.md#annotation-of-enums
 	AnnotateEnumNames bool
+	// FieldLock specifies the field numbers that were assigned to the
+	// fields of messages during a previous code generation run. Fields
+	// that are within the lock retain their field numbers, and the field
+	// numbers of fields that have been removed from the schema are
+	// reserved. If FieldLock is nil, field numbers are derived solely
+	// from the schema paths of the fields. The lock is not modified, the
+	// updated lock is returned in GeneratedProto3.
+	FieldLock *ProtoFieldLock
+	// GenerateService specifies whether a gRPC service should be generated
+	// for the schema. The service contains Get, Set and Subscribe methods
+	// for the fake root (if it is generated) and each top-level container
+	// or list within the schema, along with a method for each YANG rpc or
+	// action statement, whose input and output are generated messages.
+	GenerateService bool
+	// ServiceName specifies the name of the generated gRPC service. If it
+	// is unset, DefaultServiceName is used.
+	ServiceName string
+	// ServicePackageName specifies the name of the package, within the base
+	// package, that the generated gRPC service is defined in. If it is
+	// unset, DefaultServicePackageName is used.
+	ServicePackageName string
 }
 
 // NewYANGCodeGenerator returns a new instance of the YANGCodeGenerator
@@ -228,6 +298,11 @@ type GeneratedProto3 struct {
 	// messages defined within the package. The calling application can write out the defined packages to the
 	// files expected by the protoc tool.
 	Packages map[string]Proto3Package
+	// FieldLock stores the field numbers that were assigned to the fields of the
+	// generated messages, along with those that are reserved. It should be
+	// stored by the calling application and supplied in ProtoOpts.FieldLock when
+	// code is next generated for the schema.
+	FieldLock *ProtoFieldLock
 }
 
 // Proto3Package stores the code for a generated protobuf3 package.
@@ -236,6 +311,7 @@ type Proto3Package struct {
 	Header   string   // Header is the header text to be used in the package.
 	Messages []string // Messages is a slice of strings containing the set of messages that are within the generated package.
 	Enums    []string // Enums is a slice of string containing the generated set of enumerations within the package.
+	Services []string // Services is a slice of strings containing the generated set of gRPC services within the package.
 }
 
 // YANGCodeGeneratorError is a type implementing error that is returned to the
@@ -311,6 +387,8 @@ func (cg *YANGCodeGenerator) GenerateGoCode(yangFiles, includePaths []string) (*
 
 	// Store the returned schematree within the state for this code generation.
 	cg.state.schematree = mdef.schemaTree
+	cg.state.configStatePreference = cg.Config.ConfigStatePreference
+	cg.state.annotations = mdef.annotations
 
 	goStructs, errs := cg.state.buildDirectoryDefinitions(mdef.directoryEntries, cg.Config.CompressOCPaths, cg.Config.GenerateFakeRoot, golang)
 	if errs != nil {
@@ -453,6 +531,7 @@ func (cg *YANGCodeGenerator) GenerateProto3(yangFiles, includePaths []string) (*
 	}
 
 	cg.state.schematree = mdef.schemaTree
+	cg.state.configStatePreference = cg.Config.ConfigStatePreference
 
 	penums, errs := cg.state.findEnumSet(mdef.enumEntries, cg.Config.CompressOCPaths, true)
 	if errs != nil {
@@ -470,7 +549,8 @@ func (cg *YANGCodeGenerator) GenerateProto3(yangFiles, includePaths []string) (*
 	}
 
 	genProto := &GeneratedProto3{
-		Packages: map[string]Proto3Package{},
+		Packages:  map[string]Proto3Package{},
+		FieldLock: cg.Config.ProtoOptions.FieldLock.copy(),
 	}
 	ye := NewYANGCodeGeneratorError()
 
@@ -529,6 +609,7 @@ func (cg *YANGCodeGenerator) GenerateProto3(yangFiles, includePaths []string) (*
 			baseImportPath:      cg.Config.ProtoOptions.BaseImportPath,
 			annotateSchemaPaths: cg.Config.ProtoOptions.AnnotateSchemaPaths,
 			annotateEnumNames:   cg.Config.ProtoOptions.AnnotateEnumNames,
+			fieldLock:           genProto.FieldLock,
 		})
 
 		if errs != nil {
@@ -561,6 +642,35 @@ func (cg *YANGCodeGenerator) GenerateProto3(yangFiles, includePaths []string) (*
 		genProto.Packages[genMsg.packageName] = tp
 	}
 
+	if cg.Config.ProtoOptions.GenerateService {
+		serviceName := cg.Config.ProtoOptions.ServiceName
+		if serviceName == "" {
+			serviceName = DefaultServiceName
+		}
+		servicePackageName := cg.Config.ProtoOptions.ServicePackageName
+		if servicePackageName == "" {
+			servicePackageName = DefaultServicePackageName
+		}
+
+		svc, err := writeProto3Service(findServiceRootEntries(mdef.directoryEntries, cg.Config.CompressOCPaths), mdef.rpcEntries, cg.state, protoServiceConfig{
+			name:            serviceName,
+			compressPaths:   cg.Config.CompressOCPaths,
+			basePackageName: basePackageName,
+			baseImportPath:  cg.Config.ProtoOptions.BaseImportPath,
+		})
+		if err != nil {
+			ye.Errors = append(ye.Errors, err)
+		} else {
+			pkgName := fmt.Sprintf("%s.%s", basePackageName, servicePackageName)
+			pkgImports[pkgName] = map[string]interface{}{}
+			addNewKeys(pkgImports[pkgName], svc.requiredImports)
+			genProto.Packages[pkgName] = Proto3Package{
+				FilePath: protoPackageToFilePath(pkgName),
+				Services: []string{svc.serviceCode},
+			}
+		}
+	}
+
 	for n, pkg := range genProto.Packages {
 		h, err := writeProto3Header(proto3Header{
 			PackageName:            n,
@@ -609,7 +719,7 @@ func processModules(yangFiles, includePaths []string, options yang.Options) ([]*
 
 	var processErr []error
 	for _, name := range yangFiles {
-		if err := moduleSet.Read(name); err != nil {
+		if err := readModule(moduleSet, name); err != nil {
 			processErr = append(processErr, err)
 		}
 	}
@@ -636,7 +746,9 @@ func processModules(yangFiles, includePaths []string, options yang.Options) ([]*
 	// routines.
 	entries := []*yang.Entry{}
 	for _, modName := range modNames {
-		entries = append(entries, yang.ToEntry(mods[modName]))
+		e := yang.ToEntry(mods[modName])
+		annotateInvertedPatterns(e)
+		entries = append(entries, e)
 	}
 	return entries, nil
 }
@@ -656,6 +768,24 @@ type mappedYANGDefinitions struct {
 	// schemaTree is a ctree.Tree that stores a copy of the YANG schema tree, containing
 	// only leaf entries, such that schema paths can be referenced.
 	schemaTree *ctree.Tree
+	// rpcEntries is the set of entities that correspond to YANG rpc or action
+	// statements in the input YANG. The map is keyed by the string path to the
+	// entry in the YANG schema.
+	rpcEntries map[string]*yang.Entry
+	// notificationEntries is the set of entities that correspond to YANG
+	// notification statements in the input YANG. The map is keyed by the
+	// string path to the entry in the YANG schema.
+	notificationEntries map[string]*yang.Entry
+	// modifications is the set of changes that are made to the YANG schema
+	// by augment and deviation statements within the input YANG.
+	modifications []*schemaModification
+	// unresolvedDeviations is the set of deviation statements within the
+	// input YANG whose target could not be found. It is populated only when
+	// deviations are applied.
+	unresolvedDeviations []*UnresolvedDeviation
+	// annotations is the set of RFC7952 metadata annotations that are
+	// defined within the input YANG, in the form module:name.
+	annotations []string
 }
 
 // mappedDefinitions find the set of directory and enumeration entities
@@ -673,22 +803,38 @@ func mappedDefinitions(yangFiles, includePaths []string, cfg GeneratorConfig) (m
 		return mappedYANGDefinitions{}, errs
 	}
 
+	// goyang does not apply deviations to the schema, hence where requested
+	// they are applied prior to the entities being extracted, such that the
+	// generated code reflects them.
+	var mods []*schemaModification
+	var unresolved []*UnresolvedDeviation
+	if cfg.ApplyDeviations {
+		mods, unresolved, errs = applyDeviations(modules)
+		if errs != nil {
+			return mappedYANGDefinitions{}, errs
+		}
+	}
+	mods = append(mods, findAugmentedEntries(modules)...)
+
 	// Extract the entities that are eligible to have code generated for
 	// them from the modules that are provided as an argument.
 	dirs := make(map[string]*yang.Entry)
 	enums := make(map[string]*yang.Entry)
+	rpcs := make(map[string]*yang.Entry)
+	notifications := make(map[string]*yang.Entry)
 	var rootElems []*yang.Entry
 	for _, module := range modules {
 		findMappableEntities(module, dirs, enums, cfg.ExcludeModules, cfg.CompressOCPaths)
+		findOperationEntities(module, rpcs, notifications, cfg.ExcludeModules)
 		if module == nil {
 			errs = append(errs, errors.New("found a nil module in the returned module set"))
 			continue
 		}
-		// Ensure that we do not try and traverse an empty module.
+		// Ensure that we do not try and traverse an empty module. The rpcs
+		// and notifications within the module are not data tree elements,
+		// and hence are not included in the root elements.
 		if module.Dir != nil {
-			for _, e := range module.Dir {
-				rootElems = append(rootElems, e)
-			}
+			rootElems = append(rootElems, children(module)...)
 		}
 	}
 	if errs != nil {
@@ -701,6 +847,16 @@ func mappedDefinitions(yangFiles, includePaths []string, cfg GeneratorConfig) (m
 		return mappedYANGDefinitions{}, []error{err}
 	}
 
+	// Add the contents of the input and output of each rpc, and of each
+	// notification to the schematree, such that leafrefs within them can
+	// be resolved.
+	bodies := operationBodies(rpcs, notifications)
+	for _, e := range bodies {
+		if err := schemaTreeChildrenAdd(st, e); err != nil {
+			return mappedYANGDefinitions{}, []error{err}
+		}
+	}
+
 	// If we were asked to generate a fake root entity, then go and find the top-level entities that
 	// we were asked for.
 	if cfg.GenerateFakeRoot {
@@ -709,10 +865,20 @@ func mappedDefinitions(yangFiles, includePaths []string, cfg GeneratorConfig) (m
 		}
 	}
 
+	// The input and output of rpcs, and notifications are mapped to
+	// directories in the same manner as containers. They are added after
+	// the fake root is created, since they are not children of it.
+	findOperationMappableEntities(bodies, dirs, enums, cfg.ExcludeModules, cfg.CompressOCPaths)
+
 	return mappedYANGDefinitions{
-		directoryEntries: dirs,
-		enumEntries:      enums,
-		schemaTree:       st,
+		directoryEntries:     dirs,
+		enumEntries:          enums,
+		schemaTree:           st,
+		rpcEntries:           rpcs,
+		notificationEntries:  notifications,
+		modifications:        mods,
+		unresolvedDeviations: unresolved,
+		annotations:          findAnnotations(modules),
 	}, nil
 }
 
@@ -825,6 +991,99 @@ func findMappableEntities(e *yang.Entry, dirs map[string]*yang.Entry, enums map[
 	}
 }
 
+// findOperationEntities finds the descendants of a yang.Entry (e) that
+// correspond to YANG rpc or action statements, and appends them to the rpcs
+// map, and those that correspond to YANG notification statements, appending
+// them to the notifications map. Both maps are keyed by the schema path. Since
+// goyang does not set the parent of the input and output entries of an rpc or
+// action, it is populated such that the path of their descendants is correct.
+// If e is within a module in excludeModules, it is skipped.
+func findOperationEntities(e *yang.Entry, rpcs, notifications map[string]*yang.Entry, excludeModules []string) {
+	pp := strings.Split(e.Path(), "/")
+	if len(pp) < 2 {
+		return
+	}
+	for _, s := range excludeModules {
+		if s == pp[1] {
+			return
+		}
+	}
+
+	for _, ch := range e.Dir {
+		switch {
+		case isRPC(ch):
+			if ch.RPC != nil {
+				for _, io := range []*yang.Entry{ch.RPC.Input, ch.RPC.Output} {
+					if io != nil {
+						io.Parent = ch
+					}
+				}
+			}
+			rpcs[ch.Path()] = ch
+		case isNotification(ch):
+			notifications[ch.Path()] = ch
+		case ch.IsDir():
+			findOperationEntities(ch, rpcs, notifications, excludeModules)
+		}
+	}
+}
+
+// operationBodies returns the input and output entries of the rpc or action
+// entries in rpcs, along with the entries in notifications, keyed by schema
+// path. These are the entries that are mapped to directories for rpcs,
+// actions and notifications in the generated code.
+func operationBodies(rpcs, notifications map[string]*yang.Entry) map[string]*yang.Entry {
+	bodies := map[string]*yang.Entry{}
+	for _, r := range rpcs {
+		if r.RPC == nil {
+			continue
+		}
+		for _, io := range []*yang.Entry{r.RPC.Input, r.RPC.Output} {
+			if io != nil && io.IsDir() {
+				bodies[io.Path()] = io
+			}
+		}
+	}
+	for p, n := range notifications {
+		if n.IsDir() {
+			bodies[p] = n
+		}
+	}
+	return bodies
+}
+
+// findOperationMappableEntities finds the entities within the supplied rpc
+// input and output, and notification entries that should be mapped in the
+// generated code. The entries themselves, and any of their descendants that
+// represent directories are appended to the dirs map, and any enumerated types
+// within them to the enums map, both keyed by schema path.
+func findOperationMappableEntities(bodies, dirs, enums map[string]*yang.Entry, excludeModules []string, compressPaths bool) {
+	for p, e := range bodies {
+		dirs[p] = e
+		findMappableEntities(e, dirs, enums, excludeModules, compressPaths)
+	}
+}
+
+// findServiceRootEntries returns the entities from the supplied set of
+// directories that a generated service should provide access to. These are
+// the fake root, if one is within dirs, and the containers at the root of the
+// YANG schema tree. Lists at the root of the tree are not included, since a
+// single message cannot be used to retrieve or replace the whole list.
+func findServiceRootEntries(dirs map[string]*yang.Entry, compressPaths bool) map[string]*yang.Entry {
+	roots := findRootEntries(dirs, compressPaths)
+	for p, e := range roots {
+		if e.IsList() {
+			delete(roots, p)
+		}
+	}
+	for p, e := range dirs {
+		if e.Node != nil && e.Node.NName() == rootElementNodeName {
+			roots[p] = e
+		}
+	}
+	return roots
+}
+
 // findRootEntries finds the entities that are at the root of the YANG schema tree,
 // and returns them.
 func findRootEntries(structs map[string]*yang.Entry, compressPaths bool) map[string]*yang.Entry {
@@ -848,8 +1107,10 @@ func findRootEntries(structs map[string]*yang.Entry, compressPaths bool) map[str
 			// tree, for example, the /interfaces/interface list.
 			// Since we never expect a top-level 'state' or 'config'
 			// container, then it is only such lists that must be
-			// identified.
-			if compressPaths && s.IsList() {
+			// identified. Lists within a notification are not at the
+			// root, since the notification itself is not compressed
+			// out of the schema.
+			if compressPaths && s.IsList() && !isOperationBody(s.Parent) {
 				rootEntries[n] = s
 			}
 		}
@@ -914,7 +1175,8 @@ func createFakeRoot(structs map[string]*yang.Entry, rootElems []*yang.Entry, roo
 // string, the defaultRootName will be used. If the fake root is not to be generated, the root level entities
 // will be included in the serialised struct definitions, in the case that compressPaths is set to true, then
 // those entities that have no parent in the compressed schema are also included (e.g., a list within a
-// surrounding container at the root).
+// surrounding container at the root). The entities that correspond to the input or output of an rpc, or
+// to a notification, that is defined at the root of a module are always included.
 func serialiseStructDefinitions(structs map[string]*yangDirectory, generateFakeRoot bool, fakeRootName string, compressPaths bool) ([]byte, error) {
 	entries := map[string]*yang.Entry{}
 	for _, e := range structs {
@@ -941,6 +1203,24 @@ func serialiseStructDefinitions(structs map[string]*yangDirectory, generateFakeR
 		schema = findRootEntries(entries, compressPaths)
 	}
 
+	// The input and output of rpcs, and notifications that are defined at
+	// the root of a module are not reachable from the root of the data tree,
+	// and hence are included in the schema individually. Those of actions,
+	// and of notifications defined within the data tree are reachable from
+	// the entry that they are defined within.
+	for n, e := range entries {
+		if !isOperationBody(e) {
+			continue
+		}
+		op := e
+		if !isNotification(e) {
+			op = e.Parent
+		}
+		if op.Parent != nil && isRoot(op.Parent) {
+			schema[n] = e
+		}
+	}
+
 	json, err := json.MarshalIndent(schema, "", "    ")
 	if err != nil {
 		return nil, err
@@ -25,6 +25,7 @@ import (
 
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ytypes"
 )
 
 const (
@@ -519,6 +520,39 @@ func TestSimpleStructs(t *testing.T) {
 		name:                "module with empty leaf",
 		inFiles:             []string{filepath.Join(TestRoot, "testdata/structs/empty.yang")},
 		wantStructsCodeFile: filepath.Join(TestRoot, "testdata/structs/empty.formatted-txt"),
+	}, {
+		name:                "module with rpcs, actions and notifications",
+		inFiles:             []string{filepath.Join(TestRoot, "testdata/structs/openconfig-operations.yang")},
+		wantStructsCodeFile: filepath.Join(TestRoot, "testdata/structs/openconfig-operations.formatted-txt"),
+	}, {
+		name:                "module with metadata annotations",
+		inFiles:             []string{filepath.Join(TestRoot, "testdata/structs/annotated.yang")},
+		inIncludePaths:      []string{filepath.Join(TestRoot, "testdata/structs/include")},
+		wantStructsCodeFile: filepath.Join(TestRoot, "testdata/structs/annotated.formatted-txt"),
+	}, {
+		name:    "module with rpcs, actions and notifications, with compression and fakeroot",
+		inFiles: []string{filepath.Join(TestRoot, "testdata/structs/openconfig-operations.yang")},
+		inConfig: GeneratorConfig{
+			CompressOCPaths:  true,
+			GenerateFakeRoot: true,
+		},
+		wantStructsCodeFile: filepath.Join(TestRoot, "testdata/structs/openconfig-operations-compress.formatted-txt"),
+	}, {
+		name:    "openconfig test with state leaves preferred",
+		inFiles: []string{filepath.Join(TestRoot, "testdata/structs/openconfig-config-state.yang")},
+		inConfig: GeneratorConfig{
+			CompressOCPaths:       true,
+			ConfigStatePreference: PreferState,
+		},
+		wantStructsCodeFile: filepath.Join(TestRoot, "testdata/structs/openconfig-config-state-prefer-state.formatted-txt"),
+	}, {
+		name:    "openconfig test with both config and state leaves",
+		inFiles: []string{filepath.Join(TestRoot, "testdata/structs/openconfig-config-state.yang")},
+		inConfig: GeneratorConfig{
+			CompressOCPaths:       true,
+			ConfigStatePreference: ConfigAndState,
+		},
+		wantStructsCodeFile: filepath.Join(TestRoot, "testdata/structs/openconfig-config-state-both.formatted-txt"),
 	}}
 
 	for _, tt := range tests {
@@ -600,6 +634,45 @@ func TestSimpleStructs(t *testing.T) {
 	}
 }
 
+// TestProcessModulesInvertMatch tests that processModules reads modules that
+// use the pattern modifier statement, which is not supported by Goyang, and
+// annotates leaves with the patterns that have the invert-match modifier.
+func TestProcessModulesInvertMatch(t *testing.T) {
+	entries, errs := processModules([]string{filepath.Join(TestRoot, "testdata/structs/invert-match.yang")}, []string{filepath.Join(TestRoot, "testdata/structs/include")}, yang.Options{})
+	if errs != nil {
+		t.Fatalf("processModules: got unexpected errors: %v", errs)
+	}
+
+	var mod *yang.Entry
+	for _, e := range entries {
+		if e.Name == "invert-match" {
+			mod = e
+		}
+	}
+	if mod == nil {
+		t.Fatalf("processModules: did not get module invert-match, got: %v", entries)
+	}
+
+	want := map[string][]string{
+		"name":     {"[xX][mM][lL].*"},
+		"derived":  {"[a-z]+", "[xX][mM][lL].*"},
+		"names":    {"[xX][mM][lL].*"},
+		"imported": {".*[0-9].*"},
+		"plain":    nil,
+	}
+	for name, wantPatterns := range want {
+		e := mod.Dir[name]
+		if e == nil {
+			t.Errorf("processModules: did not get leaf %s", name)
+			continue
+		}
+		got, _ := e.Annotation[ytypes.InvertMatchAnnotation].([]string)
+		if !reflect.DeepEqual(got, wantPatterns) {
+			t.Errorf("processModules: leaf %s did not have expected inverted patterns, got: %v, want: %v", name, got, wantPatterns)
+		}
+	}
+}
+
 func TestFindRootEntries(t *testing.T) {
 	tests := []struct {
 		name                       string
@@ -665,6 +738,24 @@ func TestFindRootEntries(t *testing.T) {
 		inRootName:                 "fakeroot",
 		wantCompressRootChildren:   []string{"foo", "leaf"},
 		wantUncompressRootChildren: []string{"foo", "leaf"},
+	}, {
+		name: "list within a notification at the root",
+		inStructs: map[string]*yang.Entry{
+			"/notification/list": {
+				Name:     "list",
+				Dir:      map[string]*yang.Entry{},
+				Kind:     yang.DirectoryEntry,
+				ListAttr: &yang.ListAttr{},
+				Parent: &yang.Entry{
+					Name: "notification",
+					Kind: yang.NotificationEntry,
+					Parent: &yang.Entry{
+						Name: "module",
+					},
+				},
+			},
+		},
+		inRootName: "fakeroot",
 	}}
 
 	for _, tt := range tests {
@@ -701,6 +792,64 @@ func TestFindRootEntries(t *testing.T) {
 	}
 }
 
+// TestOperationSchema tests that the schema for the structs that are generated
+// for the input and output of rpcs, and for notifications at the root of a
+// module are included within the serialised schema.
+func TestOperationSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		inConfig    GeneratorConfig
+		wantEntries map[string]string
+	}{{
+		name: "uncompressed schema",
+		wantEntries: map[string]string{
+			"OpenconfigOperations_Interfaces":      "/openconfig-operations/interfaces",
+			"OpenconfigOperations_Reboot_Input":    "/openconfig-operations/reboot/input",
+			"OpenconfigOperations_Reboot_Output":   "/openconfig-operations/reboot/output",
+			"OpenconfigOperations_LinkStateChange": "/openconfig-operations/link-state-change",
+		},
+	}, {
+		name: "compressed schema with fakeroot",
+		inConfig: GeneratorConfig{
+			CompressOCPaths:  true,
+			GenerateFakeRoot: true,
+		},
+		wantEntries: map[string]string{
+			"Device":          "/device",
+			"Reboot_Input":    "/openconfig-operations/reboot/input",
+			"Reboot_Output":   "/openconfig-operations/reboot/output",
+			"LinkStateChange": "/openconfig-operations/link-state-change",
+		},
+	}}
+
+	for _, tt := range tests {
+		tt.inConfig.GenerateJSONSchema = true
+		tt.inConfig.StoreRawSchema = true
+		cg := NewYANGCodeGenerator(&tt.inConfig)
+		got, err := cg.GenerateGoCode([]string{filepath.Join(TestRoot, "testdata/structs/openconfig-operations.yang")}, nil)
+		if err != nil {
+			t.Errorf("%s: GenerateGoCode(...): got unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		entries := map[string]*yang.Entry{}
+		if err := json.Unmarshal(got.RawJSONSchema, &entries); err != nil {
+			t.Errorf("%s: json.Unmarshal(...): could not unmarshal schema: %v", tt.name, err)
+			continue
+		}
+
+		gotEntries := map[string]string{}
+		for n, e := range entries {
+			p, _ := e.Annotation["schemapath"].(string)
+			gotEntries[n] = p
+		}
+
+		if diff := pretty.Compare(gotEntries, tt.wantEntries); diff != "" {
+			t.Errorf("%s: GenerateGoCode(...): did not get expected schema entries, diff(-got,+want):\n%s", tt.name, diff)
+		}
+	}
+}
+
 func TestGenerateProto3(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -841,6 +990,50 @@ func TestGenerateProto3(t *testing.T) {
 			"openconfig.enums":       filepath.Join(TestRoot, "testdata", "proto", "proto-enums-addid.enums.formatted-txt"),
 			"openconfig.proto_enums": filepath.Join(TestRoot, "testdata", "proto", "proto-enums-addid.formatted-txt"),
 		},
+	}, {
+		name:    "yang schema with rpcs and actions, and a generated service",
+		inFiles: []string{filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.yang")},
+		inConfig: GeneratorConfig{
+			GenerateFakeRoot: true,
+			ProtoOptions: ProtoOpts{
+				GenerateService: true,
+			},
+		},
+		wantOutputFiles: map[string]string{
+			"openconfig":                                  filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.openconfig.formatted-txt"),
+			"openconfig.proto_rpc":                        filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.proto_rpc.formatted-txt"),
+			"openconfig.proto_rpc.system":                 filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.proto_rpc.system.formatted-txt"),
+			"openconfig.proto_rpc.reboot":                 filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.proto_rpc.reboot.formatted-txt"),
+			"openconfig.proto_rpc.system.interface.reset": filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.proto_rpc.system.interface.reset.formatted-txt"),
+			"openconfig.service":                          filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.service.formatted-txt"),
+		},
+	}, {
+		name:    "yang schema with rpcs, and a named service with compression",
+		inFiles: []string{filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.yang")},
+		inConfig: GeneratorConfig{
+			CompressOCPaths: true,
+			ProtoOptions: ProtoOpts{
+				GenerateService:    true,
+				ServiceName:        "Config",
+				ServicePackageName: "config_service",
+			},
+		},
+		wantOutputFiles: map[string]string{
+			"openconfig":                        filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.compress.openconfig.formatted-txt"),
+			"openconfig.reboot":                 filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.compress.reboot.formatted-txt"),
+			"openconfig.system.interface.reset": filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.compress.system.interface.reset.formatted-txt"),
+			"openconfig.config_service":         filepath.Join(TestRoot, "testdata", "proto", "proto-rpc.compress.config_service.formatted-txt"),
+		},
+	}, {
+		name:    "yang schema with both config and state leaves mapped",
+		inFiles: []string{filepath.Join(TestRoot, "testdata", "structs", "openconfig-config-state.yang")},
+		inConfig: GeneratorConfig{
+			CompressOCPaths:       true,
+			ConfigStatePreference: ConfigAndState,
+		},
+		wantOutputFiles: map[string]string{
+			"openconfig": filepath.Join(TestRoot, "testdata", "proto", "openconfig-config-state.compress.formatted-txt"),
+		},
 	}}
 
 	for _, tt := range tests {
@@ -903,6 +1096,10 @@ func TestGenerateProto3(t *testing.T) {
 				fmt.Fprintf(&gotCodeBuf, "%v", gotEnum)
 			}
 
+			for _, gotService := range gotPkg.Services {
+				fmt.Fprintf(&gotCodeBuf, "%v", gotService)
+			}
+
 			if diff := pretty.Compare(gotCodeBuf.String(), string(wantCode)); diff != "" {
 				if diffl, _ := generateUnifiedDiff(gotCodeBuf.String(), string(wantCode)); diffl != "" {
 					diff = diffl
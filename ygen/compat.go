@@ -0,0 +1,439 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// ChangeKind describes the kind of a change between the code generated for
+// two versions of a YANG schema.
+type ChangeKind string
+
+const (
+	// TypeAdded indicates that a struct, message, enumeration or service
+	// was added.
+	TypeAdded ChangeKind = "type-added"
+	// TypeRemoved indicates that a struct, message, enumeration or service
+	// was removed.
+	TypeRemoved ChangeKind = "type-removed"
+	// MemberAdded indicates that a field, enumerated value or method was
+	// added to a type.
+	MemberAdded ChangeKind = "member-added"
+	// MemberRemoved indicates that a field, enumerated value or method was
+	// removed from a type.
+	MemberRemoved ChangeKind = "member-removed"
+	// MemberTypeChanged indicates that the type of a field, or the input
+	// or output type of a method was changed.
+	MemberTypeChanged ChangeKind = "member-type-changed"
+	// MemberPathChanged indicates that the schema path that a Go struct
+	// field is mapped to was changed.
+	MemberPathChanged ChangeKind = "member-path-changed"
+	// MemberNumberChanged indicates that the tag of a protobuf field, or the
+	// value of an enumerated value was changed.
+	MemberNumberChanged ChangeKind = "member-number-changed"
+)
+
+// breaking returns true if a change of kind k is not backwards compatible,
+// i.e., code that uses the previous version of the generated code cannot use
+// the new version without modification, or data serialised using one version
+// cannot be interpreted using the other.
+func (k ChangeKind) breaking() bool {
+	return k != TypeAdded && k != MemberAdded
+}
+
+// CompatibilityReport describes the differences between the code that is
+// generated for two versions of a YANG schema.
+type CompatibilityReport struct {
+	// Changes is the set of changes between the two versions, sorted by
+	// language, type and member.
+	Changes []*SchemaChange `json:"changes"`
+}
+
+// SchemaChange describes a single change between the code generated for two
+// versions of a YANG schema.
+type SchemaChange struct {
+	// Language is the language of the generated code that changed - "go"
+	// or "proto".
+	Language string `json:"language"`
+	// Kind is the kind of the change.
+	Kind ChangeKind `json:"kind"`
+	// Type is the name of the Go type, or the fully-qualified name of the
+	// protobuf message, enumeration or service that was changed.
+	Type string `json:"type"`
+	// Member is the name of the field, enumerated value or method that was
+	// changed. It is empty if the change is to the type itself.
+	Member string `json:"member,omitempty"`
+	// Old and New are the values of the changed property in the old and new
+	// versions of the generated code respectively.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+	// Breaking indicates whether the change is backwards incompatible.
+	Breaking bool `json:"breaking"`
+}
+
+// String returns a human-readable description of the SchemaChange.
+func (c *SchemaChange) String() string {
+	n := c.Type
+	if c.Member != "" {
+		n = fmt.Sprintf("%s.%s", c.Type, c.Member)
+	}
+	s := fmt.Sprintf("%s: %s %s", c.Language, c.Kind, n)
+	if c.Old != "" || c.New != "" {
+		s = fmt.Sprintf("%s (%q -> %q)", s, c.Old, c.New)
+	}
+	if c.Breaking {
+		s = fmt.Sprintf("BREAKING %s", s)
+	}
+	return s
+}
+
+// HasBreakingChanges returns true if any of the changes within the report are
+// backwards incompatible.
+func (r *CompatibilityReport) HasBreakingChanges() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedMember describes a member of a generated type - a struct or message
+// field, an enumerated value, or a service method.
+type generatedMember struct {
+	// typ is the type of a field, or the signature of a method.
+	typ string
+	// path is the schema path that a Go struct field is mapped to.
+	path string
+	// number is the tag of a protobuf field, or the value of an enumerated
+	// value.
+	number string
+}
+
+// generatedTypes is a map, keyed by type name, of the members of each type
+// within generated code, keyed by the member name.
+type generatedTypes map[string]map[string]*generatedMember
+
+// CheckCompatibility generates Go and protobuf code for two versions of a YANG
+// schema, described by the oldFiles and newFiles slices of YANG files, using
+// the current generator's configuration. The oldIncludePaths and
+// newIncludePaths arguments specify the paths that are searched for modules
+// imported or included by each version. It returns a CompatibilityReport
+// describing the differences between the names and types of the generated Go
+// structs and their fields, the generated protobuf messages, their fields and
+// tags, and the values of generated enumerations. List keys are compared
+// through the types of the fields that represent the list.
+func (cg *YANGCodeGenerator) CheckCompatibility(oldFiles, oldIncludePaths, newFiles, newIncludePaths []string) (*CompatibilityReport, *YANGCodeGeneratorError) {
+	oldGo, oldProto, errs := cg.generatedTypes(oldFiles, oldIncludePaths)
+	if errs != nil {
+		return nil, &YANGCodeGeneratorError{Errors: errs}
+	}
+	newGo, newProto, errs := cg.generatedTypes(newFiles, newIncludePaths)
+	if errs != nil {
+		return nil, &YANGCodeGeneratorError{Errors: errs}
+	}
+
+	r := &CompatibilityReport{Changes: []*SchemaChange{}}
+	r.compare("go", oldGo, newGo)
+	r.compare("proto", oldProto, newProto)
+	return r, nil
+}
+
+// generatedTypes generates Go and protobuf code for the schema described by
+// the supplied YANG files and include paths, and returns the types within the
+// generated Go and protobuf code respectively.
+func (cg *YANGCodeGenerator) generatedTypes(yangFiles, includePaths []string) (generatedTypes, generatedTypes, []error) {
+	// The goyang search path is global, hence is reset such that modules
+	// are not resolved using the include paths of another schema version.
+	path := yang.Path
+	defer func() { yang.Path = path }()
+
+	yang.Path = append([]string{}, path...)
+	goCode, err := NewYANGCodeGenerator(&cg.Config).GenerateGoCode(yangFiles, includePaths)
+	if err != nil {
+		return nil, nil, err.Errors
+	}
+	goTypes, gerr := parseGoTypes(goCode)
+	if gerr != nil {
+		return nil, nil, []error{gerr}
+	}
+
+	yang.Path = append([]string{}, path...)
+	protoCode, err := NewYANGCodeGenerator(&cg.Config).GenerateProto3(yangFiles, includePaths)
+	if err != nil {
+		return nil, nil, err.Errors
+	}
+	protoTypes, perr := parseProtoTypes(protoCode)
+	if perr != nil {
+		return nil, nil, []error{perr}
+	}
+	return goTypes, protoTypes, nil
+}
+
+// parseGoTypes parses the supplied generated Go code, returning the generated
+// structs and enumerated types within it. The members of a struct are its
+// fields, and the members of an enumerated type are its values.
+func parseGoTypes(code *GeneratedGoCode) (generatedTypes, error) {
+	src := strings.Join(append(append([]string{code.Header}, code.Structs...), code.Enums...), "\n")
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse generated Go code: %v", err)
+	}
+
+	gt := generatedTypes{}
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, s := range gd.Specs {
+			switch s := s.(type) {
+			case *ast.TypeSpec:
+				switch t := s.Type.(type) {
+				case *ast.StructType:
+					members := map[string]*generatedMember{}
+					for _, fd := range t.Fields.List {
+						m := &generatedMember{typ: types.ExprString(fd.Type)}
+						if fd.Tag != nil {
+							if tag, err := strconv.Unquote(fd.Tag.Value); err == nil {
+								m.path = reflect.StructTag(tag).Get("path")
+							}
+						}
+						for _, n := range fd.Names {
+							members[n.Name] = m
+						}
+					}
+					gt[s.Name.Name] = members
+				case *ast.Ident:
+					if strings.HasPrefix(s.Name.Name, goEnumPrefix) {
+						if _, ok := gt[s.Name.Name]; !ok {
+							gt[s.Name.Name] = map[string]*generatedMember{}
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				// Enumerated values are constants of the enumerated type.
+				t, ok := s.Type.(*ast.Ident)
+				if gd.Tok != token.CONST || !ok || len(s.Names) != 1 || len(s.Values) != 1 {
+					continue
+				}
+				v, ok := s.Values[0].(*ast.BasicLit)
+				if !ok {
+					continue
+				}
+				if _, ok := gt[t.Name]; !ok {
+					gt[t.Name] = map[string]*generatedMember{}
+				}
+				gt[t.Name][s.Names[0].Name] = &generatedMember{number: v.Value}
+			}
+		}
+	}
+	return gt, nil
+}
+
+// parseProtoTypes parses the supplied generated protobuf code, returning the
+// messages, enumerations and services within it, keyed by their
+// fully-qualified name. The members of a message are its fields, including
+// those within a oneof, the members of an enumeration are its values, and the
+// members of a service are its methods.
+func parseProtoTypes(code *GeneratedProto3) (generatedTypes, error) {
+	gt := generatedTypes{}
+	for pkg, p := range code.Packages {
+		for _, s := range append(append(append([]string{}, p.Messages...), p.Enums...), p.Services...) {
+			if err := parseProtoDefinitions(pkg, s, gt); err != nil {
+				return nil, fmt.Errorf("cannot parse generated protobuf code in package %s: %v", pkg, err)
+			}
+		}
+	}
+	return gt, nil
+}
+
+// protoScope is a message, enumeration, service or oneof within which a
+// generated protobuf definition is found.
+type protoScope struct {
+	kind string
+	name string
+}
+
+// parseProtoDefinitions parses the protobuf definitions within the code
+// generated for the package pkg, adding the types that are found to gt.
+func parseProtoDefinitions(pkg, code string, gt generatedTypes) error {
+	var stack []protoScope
+	for _, l := range strings.Split(code, "\n") {
+		l = strings.TrimSpace(l)
+		switch {
+		case l == "", strings.HasPrefix(l, "//"):
+			continue
+		case l == "}":
+			if len(stack) == 0 {
+				return fmt.Errorf("unexpected closing brace")
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		case strings.HasSuffix(l, "{"):
+			p := strings.Fields(l)
+			if len(p) != 3 {
+				return fmt.Errorf("invalid definition: %s", l)
+			}
+			name := p[1]
+			switch {
+			case p[0] == "oneof":
+				// The fields of a oneof are members of the enclosing
+				// message.
+				if len(stack) == 0 {
+					return fmt.Errorf("oneof %s outside of a message", name)
+				}
+				name = stack[len(stack)-1].name
+			case len(stack) != 0:
+				name = fmt.Sprintf("%s.%s", stack[len(stack)-1].name, name)
+			default:
+				name = fmt.Sprintf("%s.%s", pkg, name)
+			}
+			stack = append(stack, protoScope{kind: p[0], name: name})
+			if _, ok := gt[name]; !ok {
+				gt[name] = map[string]*generatedMember{}
+			}
+			continue
+		}
+
+		if len(stack) == 0 || !strings.HasSuffix(l, ";") {
+			return fmt.Errorf("unexpected statement: %s", l)
+		}
+		// Remove the options and the terminating semi-colon.
+		l = strings.TrimSuffix(l, ";")
+		if i := strings.Index(l, " ["); i != -1 {
+			l = l[:i]
+		}
+
+		scope := stack[len(stack)-1]
+		p := strings.Fields(l)
+		switch {
+		case p[0] == "reserved":
+			// Reserved tags are not members of the message.
+		case scope.kind == "service" && p[0] == "rpc":
+			n := strings.SplitN(strings.Join(p[1:], " "), "(", 2)
+			if len(n) != 2 {
+				return fmt.Errorf("invalid method: %s", l)
+			}
+			gt[scope.name][n[0]] = &generatedMember{typ: fmt.Sprintf("(%s", n[1])}
+		case scope.kind == "enum" && len(p) == 3 && p[1] == "=":
+			gt[scope.name][p[0]] = &generatedMember{number: p[2]}
+		case (scope.kind == "message" || scope.kind == "oneof") && len(p) >= 4 && p[len(p)-2] == "=":
+			gt[scope.name][p[len(p)-3]] = &generatedMember{
+				typ:    strings.Join(p[:len(p)-3], " "),
+				number: p[len(p)-1],
+			}
+		default:
+			return fmt.Errorf("unexpected statement in %s %s: %s", scope.kind, scope.name, l)
+		}
+	}
+	if len(stack) != 0 {
+		return fmt.Errorf("unterminated %s %s", stack[len(stack)-1].kind, stack[len(stack)-1].name)
+	}
+	return nil
+}
+
+// compare adds the changes between the old and new generated types for the
+// language lang to the report.
+func (r *CompatibilityReport) compare(lang string, old, new generatedTypes) {
+	add := func(kind ChangeKind, typ, member, o, n string) {
+		r.Changes = append(r.Changes, &SchemaChange{
+			Language: lang,
+			Kind:     kind,
+			Type:     typ,
+			Member:   member,
+			Old:      o,
+			New:      n,
+			Breaking: kind.breaking(),
+		})
+	}
+
+	for _, tn := range sortedTypeNames(old, new) {
+		om, inOld := old[tn]
+		nm, inNew := new[tn]
+		switch {
+		case !inNew:
+			add(TypeRemoved, tn, "", "", "")
+			continue
+		case !inOld:
+			add(TypeAdded, tn, "", "", "")
+			continue
+		}
+
+		for _, mn := range sortedMemberNames(om, nm) {
+			o, n := om[mn], nm[mn]
+			switch {
+			case n == nil:
+				add(MemberRemoved, tn, mn, "", "")
+			case o == nil:
+				add(MemberAdded, tn, mn, "", "")
+			default:
+				if o.typ != n.typ {
+					add(MemberTypeChanged, tn, mn, o.typ, n.typ)
+				}
+				if o.path != n.path {
+					add(MemberPathChanged, tn, mn, o.path, n.path)
+				}
+				if o.number != n.number {
+					add(MemberNumberChanged, tn, mn, o.number, n.number)
+				}
+			}
+		}
+	}
+}
+
+// sortedTypeNames returns the sorted union of the type names within a and b.
+func sortedTypeNames(a, b generatedTypes) []string {
+	names := map[string]bool{}
+	for _, t := range []generatedTypes{a, b} {
+		for n := range t {
+			names[n] = true
+		}
+	}
+	return sortedKeys(names)
+}
+
+// sortedMemberNames returns the sorted union of the member names within a and
+// b.
+func sortedMemberNames(a, b map[string]*generatedMember) []string {
+	names := map[string]bool{}
+	for _, m := range []map[string]*generatedMember{a, b} {
+		for n := range m {
+			names[n] = true
+		}
+	}
+	return sortedKeys(names)
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]bool) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	oldFiles := []string{filepath.Join(TestRoot, "testdata/compat/old/openconfig-compat.yang")}
+	newFiles := []string{filepath.Join(TestRoot, "testdata/compat/new/openconfig-compat.yang")}
+
+	tests := []struct {
+		name         string
+		inConfig     GeneratorConfig
+		inOldFiles   []string
+		inNewFiles   []string
+		want         *CompatibilityReport
+		wantBreaking bool
+		wantErr      bool
+	}{{
+		name:       "identical schemas",
+		inOldFiles: oldFiles,
+		inNewFiles: oldFiles,
+		want:       &CompatibilityReport{Changes: []*SchemaChange{}},
+	}, {
+		name:       "compressed schema with breaking changes",
+		inConfig:   GeneratorConfig{CompressOCPaths: true},
+		inOldFiles: oldFiles,
+		inNewFiles: newFiles,
+		want: &CompatibilityReport{Changes: []*SchemaChange{{
+			Language: "go",
+			Kind:     MemberAdded,
+			Type:     "E_OpenconfigCompat_System_Mode",
+			Member:   "OpenconfigCompat_System_Mode_MANAGED",
+		}, {
+			Language: "go",
+			Kind:     MemberAdded,
+			Type:     "System",
+			Member:   "DomainName",
+		}, {
+			Language: "go",
+			Kind:     MemberRemoved,
+			Type:     "System",
+			Member:   "Location",
+			Breaking: true,
+		}, {
+			Language: "go",
+			Kind:     MemberTypeChanged,
+			Type:     "System",
+			Member:   "Mtu",
+			Old:      "*uint16",
+			New:      "*uint32",
+			Breaking: true,
+		}, {
+			Language: "proto",
+			Kind:     MemberAdded,
+			Type:     "openconfig.System",
+			Member:   "domain_name",
+		}, {
+			Language: "proto",
+			Kind:     MemberRemoved,
+			Type:     "openconfig.System",
+			Member:   "location",
+			Breaking: true,
+		}, {
+			Language: "proto",
+			Kind:     MemberAdded,
+			Type:     "openconfig.System.Mode",
+			Member:   "MODE_MANAGED",
+		}}},
+		wantBreaking: true,
+	}, {
+		name:       "missing file",
+		inOldFiles: oldFiles,
+		inNewFiles: []string{filepath.Join(TestRoot, "testdata/compat/new/missing.yang")},
+		wantErr:    true,
+	}}
+
+	for _, tt := range tests {
+		cg := NewYANGCodeGenerator(&tt.inConfig)
+		got, err := cg.CheckCompatibility(tt.inOldFiles, nil, tt.inNewFiles, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckCompatibility(%v, nil, %v, nil): got unexpected error: %v, wantErr: %v", tt.name, tt.inOldFiles, tt.inNewFiles, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: CheckCompatibility(%v, nil, %v, nil): did not get expected report, diff(-got,+want):\n%s", tt.name, tt.inOldFiles, tt.inNewFiles, diff)
+		}
+		if gotBreaking := got.HasBreakingChanges(); gotBreaking != tt.wantBreaking {
+			t.Errorf("%s: CheckCompatibility(%v, nil, %v, nil): did not get expected breaking status, got: %v, want: %v", tt.name, tt.inOldFiles, tt.inNewFiles, gotBreaking, tt.wantBreaking)
+		}
+	}
+}
+
+func TestParseProtoDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		inCode  string
+		want    generatedTypes
+		wantErr bool
+	}{{
+		name: "message with enum, oneof and options",
+		inCode: `
+// Config represents the /a/config YANG schema element.
+message Config {
+  enum Mode {
+    MODE_UNSET = 0;
+    MODE_A = 1 [(yext.yang_name) = "a"];
+  }
+  reserved 3, 4;
+  Mode mode = 10 [(yext.schemapath) = "/a/config/mode"];
+  repeated ywrapper.StringValue names = 20;
+  oneof u {
+    string u_string = 30;
+    uint64 u_uint64 = 31;
+  }
+}
+`,
+		want: generatedTypes{
+			"pkg.Config": {
+				"mode":     {typ: "Mode", number: "10"},
+				"names":    {typ: "repeated ywrapper.StringValue", number: "20"},
+				"u_string": {typ: "string", number: "30"},
+				"u_uint64": {typ: "uint64", number: "31"},
+			},
+			"pkg.Config.Mode": {
+				"MODE_UNSET": {number: "0"},
+				"MODE_A":     {number: "1"},
+			},
+		},
+	}, {
+		name: "service",
+		inCode: `
+service Service {
+  // Reboot corresponds to the /reboot YANG rpc.
+  rpc Reboot(pkg.Input) returns (pkg.Output);
+  rpc Subscribe(pkg.SubscribeRequest) returns (stream pkg.Root);
+}
+`,
+		want: generatedTypes{
+			"pkg.Service": {
+				"Reboot":    {typ: "(pkg.Input) returns (pkg.Output)"},
+				"Subscribe": {typ: "(pkg.SubscribeRequest) returns (stream pkg.Root)"},
+			},
+		},
+	}, {
+		name:    "unterminated message",
+		inCode:  "message A {\n  string a = 1;\n",
+		wantErr: true,
+	}, {
+		name:    "statement outside of a definition",
+		inCode:  "string a = 1;\n",
+		wantErr: true,
+	}, {
+		name:    "invalid field",
+		inCode:  "message A {\n  string a;\n}\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		got := generatedTypes{}
+		if err := parseProtoDefinitions("pkg", tt.inCode, got); (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseProtoDefinitions(\"pkg\", %s, ...): got unexpected error: %v, wantErr: %v", tt.name, tt.inCode, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: parseProtoDefinitions(\"pkg\", %s, ...): did not get expected types, diff(-got,+want):\n%s", tt.name, tt.inCode, diff)
+		}
+	}
+}
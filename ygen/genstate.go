@@ -78,11 +78,19 @@ type genState struct {
 	// a path to be resolved into the calculated Protobuf package name that
 	// is to be used for it.
 	uniqueProtoPackages map[string]string
+	// configStatePreference specifies how the leaves of config and state
+	// containers are mapped when paths are compressed.
+	configStatePreference ConfigStatePreference
 	// generatedUnions stores a map, keyed by the output name for a union,
 	// that has already been output in the generated code. This ensures that
 	// where two entities re-use a union that has already been created (e.g.,
 	// a leafref to a union) then it is output only once in the generated code.
 	generatedUnions map[string]bool
+	// annotations stores the names of the RFC7952 metadata annotations that
+	// are defined within the schema, in the form module:name. Where the
+	// schema defines annotations, each generated Go struct has a field
+	// that stores the annotations of its nodes.
+	annotations []string
 }
 
 // newGenState creates a new genState instance, initialised with the default state
@@ -199,7 +207,7 @@ func (s *genState) buildDirectoryDefinitions(entries map[string]*yang.Entry, com
 
 			// Find the elements that should be rooted on this particular entity.
 			var fieldErr []error
-			elem.fields, fieldErr = findAllChildren(e, compressPaths)
+			elem.fields, fieldErr = findAllChildren(e, compressPaths, s.configStatePreference)
 			if fieldErr != nil {
 				errs = append(errs, fieldErr...)
 				continue
@@ -253,14 +261,20 @@ func (s *genState) findEnumSet(entries map[string]*yang.Entry, compressPaths, no
 		// path and swap 'state' for 'config' where it is found allowing us to check whether the
 		// state leaf has a corresponding config leaf, and if so, to ignore it. Note that a schema
 		// that is a valid OpenConfig schema has only a single instance of 'config' or 'state' in
-		// the path, therefore the below algorithm replaces only one element.
+		// the path, therefore the below algorithm replaces only one element. Where the state
+		// leaves are preferred, 'config' is swapped for 'state' instead, and where both are
+		// mapped, no elements are ignored.
+		preferred, duplicate := "config", "state"
+		if s.configStatePreference == PreferState {
+			preferred, duplicate = "state", "config"
+		}
 		for path, e := range entries {
 			parts := strings.Split(path, "/")
 
 			var newPath []string
 			for _, p := range parts {
-				if p == "state" {
-					p = "config"
+				if p == duplicate && s.configStatePreference != ConfigAndState {
+					p = preferred
 				}
 				newPath = append(newPath, p)
 			}
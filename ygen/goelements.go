@@ -28,6 +28,12 @@ const (
 	// Go code, such that an enumeration's name is of the form
 	//   <goEnumPrefix><EnumName>
 	goEnumPrefix string = "E_"
+	// ygotDecimal64Type is the name of the Go type that is used for
+	// fields that have a YANG type of decimal64.
+	ygotDecimal64Type string = "ygot.Decimal64"
+	// ygotInstanceIdentifierType is the name of the Go type that is used
+	// for fields that have a YANG type of instance-identifier.
+	ygotInstanceIdentifierType string = "ygot.InstanceIdentifier"
 )
 
 var (
@@ -35,19 +41,21 @@ var (
 	// produces, such that resolved types can be checked as to whether they are
 	// Go built in types.
 	validGoBuiltinTypes = map[string]bool{
-		"int8":              true,
-		"int16":             true,
-		"int32":             true,
-		"int64":             true,
-		"uint8":             true,
-		"uint16":            true,
-		"uint32":            true,
-		"uint64":            true,
-		"float64":           true,
-		"string":            true,
-		"bool":              true,
-		"interface{}":       true,
-		ygot.BinaryTypeName: true,
+		"int8":                     true,
+		"int16":                    true,
+		"int32":                    true,
+		"int64":                    true,
+		"uint8":                    true,
+		"uint16":                   true,
+		"uint32":                   true,
+		"uint64":                   true,
+		"float64":                  true,
+		ygotDecimal64Type:          true,
+		ygotInstanceIdentifierType: true,
+		"string":                   true,
+		"bool":                     true,
+		"interface{}":              true,
+		ygot.BinaryTypeName:        true,
 	}
 )
 
@@ -293,7 +301,10 @@ func findAllChildrenWithoutCompression(e *yang.Entry) (map[string]*yang.Entry, [
 //     that do not exist within the 'config' container). The logic implemented is to recurse into
 //     the config container, and select these leaves as direct children of the original parent.
 //     Any leaves that do not exist in the 'config' container but do within 'state' are operation
-//     state leaves, and hence are also mapped.
+//     state leaves, and hence are also mapped. The preference argument can be used to select the
+//     leaves of the 'state' container in preference to those of the 'config' container
+//     (PreferState), or to map the duplicated leaves of the 'state' container as distinct
+//     children named with the prefix "state-" (ConfigAndState).
 //
 //     Above, this means that /interfaces/interface has the admin-state and oper-state as direct
 //     children.
@@ -333,13 +344,21 @@ func findAllChildrenWithoutCompression(e *yang.Entry) (map[string]*yang.Entry, [
 // children of the specified node that are not choice or case statements themselves (i.e., leaf-a
 // and leaf-b in the above example).
 //
-func findAllChildren(e *yang.Entry, compressOCPaths bool) (map[string]*yang.Entry, []error) {
+func findAllChildren(e *yang.Entry, compressOCPaths bool, preference ConfigStatePreference) (map[string]*yang.Entry, []error) {
 	// If compression is not required, then we do not need to recurse into as many
 	// nodes, so return simply the first level direct children (other than choice or case).
 	if !compressOCPaths {
 		return findAllChildrenWithoutCompression(e)
 	}
 
+	// preferred is the name of the container whose leaves are mapped where
+	// they are duplicated in the other of the config and state containers,
+	// named by duplicate.
+	preferred, duplicate := "config", "state"
+	if preference == PreferState {
+		preferred, duplicate = "state", "config"
+	}
+
 	// orderedChildNames is used to provide an ordered list of the name of children
 	// to check.
 	var orderedChildNames []string
@@ -349,20 +368,21 @@ func findAllChildren(e *yang.Entry, compressOCPaths bool) (map[string]*yang.Entr
 	// schema there are duplicated leaves under config/ and state/ - and we want
 	// to provide the 'config' version of them to the mapping code. This is
 	// important as we care about the path that is handed to code that subsequently
-	// maps back to the uncompressed schema.
+	// maps back to the uncompressed schema. Where the state container is
+	// preferred, it is processed first instead.
 	//
 	// To achieve this then we build an orderedChildNames slice which specifies the
 	// order in which we should process the children of entry e.
 	if e.IsContainer() || e.IsList() {
-		if _, ok := e.Dir["config"]; ok {
-			orderedChildNames = append(orderedChildNames, "config")
+		if _, ok := e.Dir[preferred]; ok {
+			orderedChildNames = append(orderedChildNames, preferred)
 		}
 	}
 
-	// For all other entries in the directory, then append them after "config"
-	// (appended above) to the orderedChildren list.
+	// For all other entries in the directory, then append them after the
+	// preferred container (appended above) to the orderedChildren list.
 	for _, child := range children(e) {
-		if child.Name != "config" {
+		if child.Name != preferred {
 			orderedChildNames = append(orderedChildNames, child.Name)
 		}
 	}
@@ -384,18 +404,23 @@ func findAllChildren(e *yang.Entry, compressOCPaths bool) (map[string]*yang.Entr
 			// and "state" container to be removed from the schema.
 			// For example, /foo/bar/config/{a,b,c} becomes /foo/bar/{a,b,c}.
 			for _, configStateChild := range children(e.Dir[currChild]) {
-				// If we get an error for the state container then we ignore it as we
+				// If we get an error for the duplicate container then we ignore it as we
 				// expect that there are duplicates here for applied configuration leaves
 				// (those that appear both in the "config" and "state" container).
-				if e.Dir[currChild].Name == "state" {
+				if e.Dir[currChild].Name == duplicate {
 					// Ensure that choice/case nodes that are in the state container only
 					// do not get mapped. This is again specifically for the OpenConfig\
 					// routing policy model. We must ignore the error that is returned
 					// in this case, since if the choice/case is already defined in the
 					// config container then it will be duplicate.
-					if isChoiceOrCase(configStateChild) {
+					switch {
+					case isChoiceOrCase(configStateChild):
 						_ = addNonChoiceChildren(directChildren, configStateChild, nil)
-					} else {
+					case preference == ConfigAndState && directChildren[configStateChild.Name] != nil:
+						// The duplicated leaf is mapped as a distinct child, whose
+						// name is prefixed with the name of its container.
+						errs = addNewChild(directChildren, fmt.Sprintf("%s-%s", duplicate, configStateChild.Name), configStateChild, errs)
+					default:
 						_ = addNewChild(directChildren, configStateChild.Name, configStateChild, nil)
 					}
 				} else {
@@ -548,7 +573,9 @@ func (s *genState) yangTypeToGoType(args resolveTypeArgs, compressOCPaths bool)
 			isEnumeratedValue: true,
 		}, nil
 	case yang.Ydecimal64:
-		return &mappedType{nativeType: "float64"}, nil
+		// Decimal64 values are mapped to the ygot.Decimal64 type such that
+		// they can be stored without loss of precision.
+		return &mappedType{nativeType: ygotDecimal64Type}, nil
 	case yang.Yleafref:
 		// This is a leafref, so we check what the type of the leaf that it
 		// references is by looking it up in the schematree.
@@ -562,6 +589,10 @@ func (s *genState) yangTypeToGoType(args resolveTypeArgs, compressOCPaths bool)
 		// this is used to ensure that we can distinguish a binary field from
 		// a leaf-list of uint8s which is not possible if mapping to []byte.
 		return &mappedType{nativeType: ygot.BinaryTypeName}, nil
+	case yang.YinstanceIdentifier:
+		// Instance identifiers are mapped to the ygot.InstanceIdentifier
+		// type, which stores the path of the node that they identify.
+		return &mappedType{nativeType: ygotInstanceIdentifierType}, nil
 	default:
 		// Return an empty interface for the types that we do not currently
 		// support. Back-end validation is required for these types.
@@ -753,6 +784,12 @@ func (s *genState) buildListKey(e *yang.Entry, compressOCPaths bool) (*yangListA
 						}
 					}
 					keyleaf = d.Dir[targetLeaf]
+					// Where the leaves of the state container are preferred, the
+					// key is the leaf within the state container that duplicates
+					// the target, such that it is the leaf that is mapped.
+					if st, ok := e.Dir["state"]; ok && s.configStatePreference == PreferState && dir == "config" && st.Dir[targetLeaf] != nil {
+						keyleaf = st.Dir[targetLeaf]
+					}
 				}
 			}
 		}
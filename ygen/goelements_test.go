@@ -289,7 +289,7 @@ func TestFindChildren(t *testing.T) {
 
 	for _, tt := range tests {
 		for compress, expected := range map[bool][]yang.Entry{true: tt.wantCompressed, false: tt.wantUncompressed} {
-			elems, errs := findAllChildren(tt.inElement, compress)
+			elems, errs := findAllChildren(tt.inElement, compress, PreferConfig)
 			if tt.wantErr == nil && errs != nil {
 				t.Errorf("%s (compress: %v): errors %v for children of %s", tt.name, compress, errs, tt.inElement.Name)
 			} else {
@@ -329,6 +329,66 @@ func TestFindChildren(t *testing.T) {
 	}
 }
 
+// TestFindChildrenConfigStatePreference tests the mapping of leaves that are
+// duplicated within the config and state containers when paths are compressed.
+func TestFindChildrenConfigStatePreference(t *testing.T) {
+	// Build an interface whose config and state containers both have a name
+	// leaf, with a state-only counters leaf.
+	intf := &yang.Entry{
+		Name:     "interface",
+		Kind:     yang.DirectoryEntry,
+		ListAttr: &yang.ListAttr{},
+		Dir:      map[string]*yang.Entry{},
+	}
+	for _, c := range []string{"config", "state"} {
+		d := &yang.Entry{Name: c, Kind: yang.DirectoryEntry, Parent: intf, Dir: map[string]*yang.Entry{}}
+		leaves := []string{"name"}
+		if c == "state" {
+			leaves = append(leaves, "counters")
+		}
+		for _, l := range leaves {
+			d.Dir[l] = &yang.Entry{Name: l, Parent: d, Type: &yang.YangType{Kind: yang.Ystring}}
+		}
+		intf.Dir[c] = d
+	}
+
+	tests := []struct {
+		name         string
+		inPreference ConfigStatePreference
+		// want is a map, keyed by the name of each child, of the name of
+		// the container that it is expected to be within.
+		want map[string]string
+	}{{
+		name:         "config preferred",
+		inPreference: PreferConfig,
+		want:         map[string]string{"name": "config", "counters": "state"},
+	}, {
+		name:         "state preferred",
+		inPreference: PreferState,
+		want:         map[string]string{"name": "state", "counters": "state"},
+	}, {
+		name:         "config and state",
+		inPreference: ConfigAndState,
+		want:         map[string]string{"name": "config", "state-name": "state", "counters": "state"},
+	}}
+
+	for _, tt := range tests {
+		got, errs := findAllChildren(intf, true, tt.inPreference)
+		if errs != nil {
+			t.Errorf("%s: findAllChildren(%v, true, %v): got unexpected errors: %v", tt.name, intf.Name, tt.inPreference, errs)
+			continue
+		}
+
+		gotParents := map[string]string{}
+		for n, e := range got {
+			gotParents[n] = e.Parent.Name
+		}
+		if diff := pretty.Compare(gotParents, tt.want); diff != "" {
+			t.Errorf("%s: findAllChildren(%v, true, %v): did not get expected children, diff(-got,+want):\n%s", tt.name, intf.Name, tt.inPreference, diff)
+		}
+	}
+}
+
 // TestCamelCase tests the functionality that is provided by makeNameUnique and
 // entryCamelCaseName- ensuring
 // that following being converted to CamelCase, a name is unique within the set of
@@ -566,15 +626,19 @@ func TestYangTypeToGoType(t *testing.T) {
 	}, {
 		name: "decimal64",
 		in:   &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64"},
-		want: &mappedType{nativeType: "float64"},
+		want: &mappedType{nativeType: "ygot.Decimal64"},
 	}, {
 		name: "binary lookup resolution",
 		in:   &yang.YangType{Kind: yang.Ybinary, Name: "binary"},
 		want: &mappedType{nativeType: "Binary"},
 	}, {
 		name: "unknown lookup resolution",
-		in:   &yang.YangType{Kind: yang.YinstanceIdentifier, Name: "instanceIdentifier"},
+		in:   &yang.YangType{Kind: yang.Ybits, Name: "bits"},
 		want: &mappedType{nativeType: "interface{}"},
+	}, {
+		name: "instance-identifier resolution",
+		in:   &yang.YangType{Kind: yang.YinstanceIdentifier, Name: "instance-identifier"},
+		want: &mappedType{nativeType: "ygot.InstanceIdentifier"},
 	}, {
 		name: "simple empty resolution",
 		in:   &yang.YangType{Kind: yang.Yempty, Name: "empty"},
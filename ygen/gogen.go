@@ -46,6 +46,9 @@ const (
 	// DefaultGoyangImportPath is the default path for the goyang/pkg/yang library that
 	// is used in the generated code.
 	DefaultGoyangImportPath string = "github.com/openconfig/goyang/pkg/yang"
+	// annotationFieldName is the name of the field of generated structs that
+	// stores the RFC7952 metadata annotations of their nodes.
+	annotationFieldName string = "ΛMetadata"
 )
 
 // The methods in this file take the structs that have been generated by
@@ -308,6 +311,10 @@ type {{ .EmptyTypeName }} bool
 
 var (
 	SchemaTree map[string]*yang.Entry
+	// SchemaIndex allows the entries of SchemaTree to be found by their
+	// schema or data tree path, and by the GoStruct fields that they
+	// describe.
+	SchemaIndex *ytypes.SchemaIndex
 )
 
 func init() {
@@ -315,13 +322,16 @@ func init() {
 	if SchemaTree, err = ygot.GzipToSchema(ySchema); err != nil {
 		panic("schema error: " +  err.Error())
 	}
+	SchemaIndex = ytypes.NewSchemaIndex(SchemaTree)
 }
 
 // Unmarshal unmarshals data, which must be RFC7951 JSON format, into
 // destStruct, which must be non-nil and the correct GoStruct type. It returns
 // an error if the destStruct is not found in the schema or the data cannot be
-// unmarshaled.
-func Unmarshal(data []byte, destStruct ygot.GoStruct) error {
+// unmarshaled. The supplied options (opts) are used to control the behaviour
+// of the unmarshal function - for example, determining whether errors are
+// thrown for unknown fields in the input JSON.
+func Unmarshal(data []byte, destStruct ygot.GoStruct, opts ...ytypes.UnmarshalOpt) error {
 	tn := reflect.TypeOf(destStruct).Elem().Name()
 	schema, ok := SchemaTree[tn]
 	if !ok {
@@ -331,7 +341,21 @@ func Unmarshal(data []byte, destStruct ygot.GoStruct) error {
 	if err := json.Unmarshal([]byte(data), &jsonTree); err != nil {
 		return err
 	}
-	return ytypes.Unmarshal(schema, destStruct, jsonTree)
+	return ytypes.Unmarshal(schema, destStruct, jsonTree, opts...)
+}
+
+// UnmarshalYAML unmarshals data, which must be a YAML document encoding
+// RFC7951 JSON, into destStruct, which must be non-nil and the correct
+// GoStruct type. It returns an error if the destStruct is not found in the
+// schema or the data cannot be unmarshaled. The supplied options (opts) are
+// used to control the behaviour of the unmarshal function.
+func UnmarshalYAML(data []byte, destStruct ygot.GoStruct, opts ...ytypes.UnmarshalOpt) error {
+	tn := reflect.TypeOf(destStruct).Elem().Name()
+	schema, ok := SchemaTree[tn]
+	if !ok {
+		return fmt.Errorf("could not find schema for type %s", tn )
+	}
+	return ytypes.UnmarshalYAML(schema, destStruct, data, opts...)
 }
 
 {{- end }}
@@ -543,6 +567,13 @@ var ΛEnum = map[string]map[int64]ygot.EnumDefinition{
 			{{- if ne $valDef.DefiningModule "" -}}
 				, DefiningModule: "{{ $valDef.DefiningModule }}"
 			{{- end -}}
+			{{- if $valDef.Bases -}}
+				, Bases: []string{
+				{{- range $i, $base := $valDef.Bases -}}
+					{{ if $i }}, {{ end }}"{{ $base }}"
+				{{- end -}}
+				}
+			{{- end -}}
 		},
 		{{- end }}
 	},
@@ -796,8 +827,14 @@ func writeGoStruct(targetStruct *yangDirectory, goStructElements map[string]*yan
 		field := targetStruct.fields[fName]
 
 		// Make the name of the field into CamelCase. The definedStructFieldNames map is used as the
-		// context, such that the name generated is unique within this structure.
-		fieldName := makeNameUnique(entryCamelCaseName(field), definedStructFieldNames)
+		// context, such that the name generated is unique within this structure. Where the field
+		// was given a distinct name when it was mapped to the struct (e.g., a duplicated state leaf),
+		// then that name is used.
+		goFieldName := entryCamelCaseName(field)
+		if fName != field.Name {
+			goFieldName = yang.CamelCase(fName)
+		}
+		fieldName := makeNameUnique(goFieldName, definedStructFieldNames)
 		definedNameMap[fName] = &yangFieldMap{YANGName: fName, GoName: fieldName}
 
 		switch {
@@ -883,8 +920,13 @@ func writeGoStruct(targetStruct *yangDirectory, goStructElements map[string]*yan
 						tn := yang.CamelCase(t)
 						// Ensure that we sanitise the type name to be used in the
 						// output struct.
-						if t == "interface{}" {
+						switch t {
+						case "interface{}":
 							tn = "Interface"
+						case ygotDecimal64Type:
+							tn = "Decimal64"
+						case ygotInstanceIdentifierType:
+							tn = "InstanceIdentifier"
 						}
 						intf.Types[tn] = t
 						intf.TypeNames = append(intf.TypeNames, t)
@@ -969,6 +1011,17 @@ func writeGoStruct(targetStruct *yangDirectory, goStructElements map[string]*yan
 		structDef.Fields = append(structDef.Fields, fieldDef)
 	}
 
+	// Where the schema defines RFC7952 metadata annotations, a field is
+	// appended to the struct to store the annotations of its nodes. The name
+	// of the field cannot clash with those mapped from YANG identifiers.
+	if len(state.annotations) != 0 {
+		structDef.Fields = append(structDef.Fields, &goStructField{
+			Name: annotationFieldName,
+			Type: "ygot.NodeMetadata",
+			Tags: `ygotAnnotation:"true"`,
+		})
+	}
+
 	// structBuf is used to store the code associated with the struct defined for
 	// the target YANG entity.
 	var structBuf bytes.Buffer
@@ -1260,6 +1313,7 @@ func writeGoEnum(inputEnum *yangEnum) (goEnumCodeSnippet, error) {
 				// Append the defining module by looking at the root node of the
 				// identity - i.e., the module that defined it.
 				DefiningModule: yang.RootNode(valLookup[v]).Name,
+				Bases:          identityBases(inputEnum.entry.Type.IdentityBase, valLookup[v]),
 			}
 		}
 	default:
@@ -1380,6 +1434,81 @@ func findMapPaths(parent *yangDirectory, field *yang.Entry, compressOCPaths, abs
 	return mapPaths, nil
 }
 
+// identityBases returns the names of the identities that the identity i is
+// derived from, directly or indirectly, in the form module:identity, sorted
+// alphabetically. The base of the identityref that i is a value of is always
+// included where its defining module is known.
+func identityBases(base, i *yang.Identity) []string {
+	names := map[string]bool{}
+	if n := qualifiedIdentityName(base); n != "" {
+		names[n] = true
+	}
+	// Since YANG 1.0 identities have a single base, the ancestors of i are
+	// found by resolving the base of each identity in turn. Identities that
+	// have already been seen end the chain, such that malformed schemas that
+	// contain cycles are handled.
+	seen := map[*yang.Identity]bool{}
+	for b := resolveIdentityBase(i); b != nil && !seen[b]; b = resolveIdentityBase(b) {
+		seen[b] = true
+		if n := qualifiedIdentityName(b); n != "" {
+			names[n] = true
+		}
+	}
+
+	var bases []string
+	for n := range names {
+		bases = append(bases, n)
+	}
+	sort.Strings(bases)
+	return bases
+}
+
+// qualifiedIdentityName returns the name of the identity i in the form
+// module:identity, or the empty string if i is nil or its defining module is
+// not known.
+func qualifiedIdentityName(i *yang.Identity) string {
+	if i == nil || i.Name == "" {
+		return ""
+	}
+	m := yang.RootNode(i)
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s", m.Name, i.Name)
+}
+
+// resolveIdentityBase returns the identity that is named by the base
+// statement of the identity i, or nil if i has no base, or its base cannot be
+// found. The base is looked up in the module that it is qualified with, or
+// the module that defines i, along with any submodules that it includes.
+func resolveIdentityBase(i *yang.Identity) *yang.Identity {
+	if i.Base == nil || yang.RootNode(i) == nil {
+		return nil
+	}
+	prefix, name := "", i.Base.Name
+	if p := strings.Index(name, ":"); p != -1 {
+		prefix, name = name[:p], name[p+1:]
+	}
+	m := yang.FindModuleByPrefix(i, prefix)
+	if m == nil {
+		return nil
+	}
+	mods := []*yang.Module{m}
+	for _, inc := range m.Include {
+		if inc.Module != nil {
+			mods = append(mods, inc.Module)
+		}
+	}
+	for _, m := range mods {
+		for _, id := range m.Identity {
+			if id.Name == name {
+				return id
+			}
+		}
+	}
+	return nil
+}
+
 // generateEnumMap outputs a map from the enumMapTemplate. It takes an input of
 // a map corresponding to the enumerated types that are defined in the input YANG
 // schema, keyed by their generating Go name. The values of the map for each key is
@@ -326,6 +326,185 @@ func (t *InputStruct) To_Module_InputStruct_U1_Union(i interface{}) (Module_Inpu
 		return nil, fmt.Errorf("cannot convert %v to Module_InputStruct_U1_Union, unknown union type, got: %T, want any of [int8, string]", i, i)
 	}
 }
+`,
+		},
+	}, {
+		name: "struct with decimal64 leaf and union",
+		inStructToMap: &yangDirectory{
+			name: "InputStruct",
+			fields: map[string]*yang.Entry{
+				"d1": {
+					Name: "d1",
+					Parent: &yang.Entry{
+						Name: "input-struct",
+						Parent: &yang.Entry{
+							Name: "module",
+							Node: &yang.Module{
+								Name: "exmod",
+							},
+						},
+					},
+					Node: &yang.Leaf{Parent: &yang.Module{Name: "exmod"}},
+					Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2},
+				},
+				"u1": {
+					Name: "u1",
+					Parent: &yang.Entry{
+						Name: "input-struct",
+						Parent: &yang.Entry{
+							Name: "module",
+							Node: &yang.Module{
+								Name: "exmod",
+							},
+						},
+					},
+					Node: &yang.Leaf{Parent: &yang.Module{Name: "exmod"}},
+					Type: &yang.YangType{
+						Kind: yang.Yunion,
+						Type: []*yang.YangType{
+							{Kind: yang.Ystring},
+							{Kind: yang.Ydecimal64, FractionDigits: 2},
+						},
+					},
+				},
+			},
+			path: []string{"", "module", "input-struct"},
+		},
+		inUniqueDirectoryNames: map[string]string{"/module/input-struct": "InputStruct"},
+		wantCompressed: wantGoStructOut{
+			structs: `
+// InputStruct represents the /module/input-struct YANG schema element.
+type InputStruct struct {
+	D1	*ygot.Decimal64	` + "`" + `path:"/input-struct/d1"` + "`" + `
+	U1	InputStruct_U1_Union	` + "`" + `path:"/input-struct/u1"` + "`" + `
+}
+
+// IsYANGGoStruct ensures that InputStruct implements the yang.GoStruct
+// interface. This allows functions that need to handle this struct to
+// identify it as being generated by ygen.
+func (*InputStruct) IsYANGGoStruct() {}
+`,
+			methods: `
+// Validate validates s against the YANG schema corresponding to its type.
+func (s *InputStruct) Validate() error {
+	if err := ytypes.Validate(SchemaTree["InputStruct"], s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ΛEnumTypeMap returns a map, keyed by YANG schema path, of the enumerated types
+// that are included in the generated code.
+func (t *InputStruct) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTypes }
+`,
+			interfaces: `
+// InputStruct_U1_Union is an interface that is implemented by valid types for the union
+// for the leaf /module/input-struct/u1 within the YANG schema.
+type InputStruct_U1_Union interface {
+	Is_InputStruct_U1_Union()
+}
+
+// InputStruct_U1_Union_Decimal64 is used when /module/input-struct/u1
+// is to be set to a ygot.Decimal64 value.
+type InputStruct_U1_Union_Decimal64 struct {
+	Decimal64	ygot.Decimal64
+}
+
+// Is_InputStruct_U1_Union ensures that InputStruct_U1_Union_Decimal64
+// implements the InputStruct_U1_Union interface.
+func (*InputStruct_U1_Union_Decimal64) Is_InputStruct_U1_Union() {}
+
+// InputStruct_U1_Union_String is used when /module/input-struct/u1
+// is to be set to a string value.
+type InputStruct_U1_Union_String struct {
+	String	string
+}
+
+// Is_InputStruct_U1_Union ensures that InputStruct_U1_Union_String
+// implements the InputStruct_U1_Union interface.
+func (*InputStruct_U1_Union_String) Is_InputStruct_U1_Union() {}
+
+// To_InputStruct_U1_Union takes an input interface{} and attempts to convert it to a struct
+// which implements the InputStruct_U1_Union union. It returns an error if the interface{} supplied
+// cannot be converted to a type within the union.
+func (t *InputStruct) To_InputStruct_U1_Union(i interface{}) (InputStruct_U1_Union, error) {
+	switch v := i.(type) {
+	case ygot.Decimal64:
+		return &InputStruct_U1_Union_Decimal64{v}, nil
+	case string:
+		return &InputStruct_U1_Union_String{v}, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %v to InputStruct_U1_Union, unknown union type, got: %T, want any of [string, ygot.Decimal64]", i, i)
+	}
+}
+`,
+		},
+		wantUncompressed: wantGoStructOut{
+			structs: `
+// InputStruct represents the /module/input-struct YANG schema element.
+type InputStruct struct {
+	D1	*ygot.Decimal64	` + "`" + `path:"/input-struct/d1"` + "`" + `
+	U1	Module_InputStruct_U1_Union	` + "`" + `path:"/input-struct/u1"` + "`" + `
+}
+
+// IsYANGGoStruct ensures that InputStruct implements the yang.GoStruct
+// interface. This allows functions that need to handle this struct to
+// identify it as being generated by ygen.
+func (*InputStruct) IsYANGGoStruct() {}
+`,
+			methods: `
+// Validate validates s against the YANG schema corresponding to its type.
+func (s *InputStruct) Validate() error {
+	if err := ytypes.Validate(SchemaTree["InputStruct"], s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ΛEnumTypeMap returns a map, keyed by YANG schema path, of the enumerated types
+// that are included in the generated code.
+func (t *InputStruct) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTypes }
+`,
+			interfaces: `
+// Module_InputStruct_U1_Union is an interface that is implemented by valid types for the union
+// for the leaf /module/input-struct/u1 within the YANG schema.
+type Module_InputStruct_U1_Union interface {
+	Is_Module_InputStruct_U1_Union()
+}
+
+// Module_InputStruct_U1_Union_Decimal64 is used when /module/input-struct/u1
+// is to be set to a ygot.Decimal64 value.
+type Module_InputStruct_U1_Union_Decimal64 struct {
+	Decimal64	ygot.Decimal64
+}
+
+// Is_Module_InputStruct_U1_Union ensures that Module_InputStruct_U1_Union_Decimal64
+// implements the Module_InputStruct_U1_Union interface.
+func (*Module_InputStruct_U1_Union_Decimal64) Is_Module_InputStruct_U1_Union() {}
+
+// Module_InputStruct_U1_Union_String is used when /module/input-struct/u1
+// is to be set to a string value.
+type Module_InputStruct_U1_Union_String struct {
+	String	string
+}
+
+// Is_Module_InputStruct_U1_Union ensures that Module_InputStruct_U1_Union_String
+// implements the Module_InputStruct_U1_Union interface.
+func (*Module_InputStruct_U1_Union_String) Is_Module_InputStruct_U1_Union() {}
+
+// To_Module_InputStruct_U1_Union takes an input interface{} and attempts to convert it to a struct
+// which implements the Module_InputStruct_U1_Union union. It returns an error if the interface{} supplied
+// cannot be converted to a type within the union.
+func (t *InputStruct) To_Module_InputStruct_U1_Union(i interface{}) (Module_InputStruct_U1_Union, error) {
+	switch v := i.(type) {
+	case ygot.Decimal64:
+		return &Module_InputStruct_U1_Union_Decimal64{v}, nil
+	case string:
+		return &Module_InputStruct_U1_Union_String{v}, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %v to Module_InputStruct_U1_Union, unknown union type, got: %T, want any of [string, ygot.Decimal64]", i, i)
+	}
+}
 `,
 		},
 	}, {
@@ -1418,3 +1597,63 @@ var ΛEnum = map[string]map[int64]ygot.EnumDefinition{
 		}
 	}
 }
+
+func TestIdentityBases(t *testing.T) {
+	baseMod := &yang.Module{Name: "base-mod", Prefix: &yang.Value{Name: "bm"}}
+	root := &yang.Identity{Name: "ROOT", Parent: baseMod}
+	baseMod.Identity = []*yang.Identity{root}
+
+	mod := &yang.Module{
+		Name:   "mod",
+		Prefix: &yang.Value{Name: "m"},
+		Import: []*yang.Import{{Name: "base-mod", Prefix: &yang.Value{Name: "bm"}, Module: baseMod}},
+	}
+	child := &yang.Identity{Name: "CHILD", Parent: mod, Base: &yang.Value{Name: "bm:ROOT"}}
+	grandchild := &yang.Identity{Name: "GRANDCHILD", Parent: mod, Base: &yang.Value{Name: "CHILD"}}
+	loop := &yang.Identity{Name: "LOOP", Parent: mod, Base: &yang.Value{Name: "m:LOOP"}}
+	dangling := &yang.Identity{Name: "DANGLING", Parent: mod, Base: &yang.Value{Name: "unknown:ROOT"}}
+	mod.Identity = []*yang.Identity{child, grandchild, loop, dangling}
+
+	tests := []struct {
+		name   string
+		inBase *yang.Identity
+		inID   *yang.Identity
+		want   []string
+	}{{
+		name:   "identity derived directly from base",
+		inBase: root,
+		inID:   child,
+		want:   []string{"base-mod:ROOT"},
+	}, {
+		name:   "identity derived indirectly from base",
+		inBase: root,
+		inID:   grandchild,
+		want:   []string{"base-mod:ROOT", "mod:CHILD"},
+	}, {
+		name:   "identityref base within the chain",
+		inBase: child,
+		inID:   grandchild,
+		want:   []string{"base-mod:ROOT", "mod:CHILD"},
+	}, {
+		name:   "base without defining module",
+		inBase: &yang.Identity{Name: "ROOT"},
+		inID:   child,
+		want:   []string{"base-mod:ROOT"},
+	}, {
+		name:   "identity derived from itself",
+		inBase: loop,
+		inID:   loop,
+		want:   []string{"mod:LOOP"},
+	}, {
+		name:   "base that cannot be resolved",
+		inBase: &yang.Identity{Name: "ROOT"},
+		inID:   dangling,
+		want:   nil,
+	}}
+
+	for _, tt := range tests {
+		if got := identityBases(tt.inBase, tt.inID); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: identityBases(%s, %s): got: %v, want: %v", tt.name, tt.inBase.Name, tt.inID.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"fmt"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+const (
+	// augmentModification is the kind of modification that is recorded for
+	// a node that is added to the schema by an augment statement.
+	augmentModification = "augment"
+	// notSupportedDeviation is the argument of a deviate statement that
+	// removes a node from the schema.
+	notSupportedDeviation = "not-supported"
+	// addDeviation is the argument of a deviate statement that adds
+	// properties to a node.
+	addDeviation = "add"
+	// replaceDeviation is the argument of a deviate statement that replaces
+	// properties of a node.
+	replaceDeviation = "replace"
+	// deleteDeviation is the argument of a deviate statement that deletes
+	// properties from a node.
+	deleteDeviation = "delete"
+)
+
+// schemaModification records a modification that is made to a node of the
+// schema by an augment or deviation statement.
+type schemaModification struct {
+	// entry is the node of the schema that is modified. In the case that
+	// the node is removed from the schema, the entry retains its parent
+	// such that its path can be determined.
+	entry *yang.Entry
+	// module is the name of the module that contains the augment or
+	// deviation statement.
+	module string
+	// kind is the kind of modification - augmentModification, or the
+	// argument of the deviate statement.
+	kind string
+	// properties is the set of properties of the node that are modified
+	// by a deviation, in the order in which they are specified.
+	properties []string
+	// oldType is the type of the node prior to the modification. It is
+	// set only when the type of the node is replaced.
+	oldType *yang.YangType
+}
+
+// findAugmentedEntries returns a schemaModification for each node that is
+// added to the schema by an augment statement within the supplied modules.
+// Since goyang merges the contents of augments into their target when the
+// modules are processed, the nodes are found within the augmented target.
+func findAugmentedEntries(modules []*yang.Entry) []*schemaModification {
+	var mods []*schemaModification
+	for _, m := range modules {
+		mn, ok := m.Node.(*yang.Module)
+		if !ok {
+			continue
+		}
+		for _, a := range mn.Augment {
+			target := m.Find(a.Name)
+			if target == nil {
+				// Augments that cannot be resolved are reported by goyang
+				// when the modules are processed.
+				continue
+			}
+			for _, n := range sortedEntryKeys(yang.ToEntry(a).Dir) {
+				if ch, ok := target.Dir[n]; ok {
+					mods = append(mods, &schemaModification{
+						entry:  ch,
+						module: m.Name,
+						kind:   augmentModification,
+					})
+				}
+			}
+		}
+	}
+	return mods
+}
+
+// applyDeviations applies the deviation statements within the supplied
+// modules to the schema, and returns a schemaModification for each deviate
+// statement that was applied. Nodes that are marked not-supported are
+// removed from the schema. The type, default, config, min-elements and
+// max-elements properties of a node are updated according to the deviation.
+// Other properties are recorded, but are not reflected in the schema, since
+// they do not affect the generated code. Deviations whose target cannot be
+// found are returned as an UnresolvedDeviation, and are otherwise ignored. It
+// returns an error if a deviation is not valid.
+func applyDeviations(modules []*yang.Entry) ([]*schemaModification, []*UnresolvedDeviation, []error) {
+	var mods []*schemaModification
+	var unresolved []*UnresolvedDeviation
+	var errs []error
+	for _, m := range modules {
+		mn, ok := m.Node.(*yang.Module)
+		if !ok {
+			continue
+		}
+		for _, d := range mn.Deviation {
+			target := m.Find(d.Name)
+			if target == nil {
+				unresolved = append(unresolved, &UnresolvedDeviation{Module: m.Name, Target: d.Name})
+				continue
+			}
+			for _, dv := range d.Deviate {
+				mod, err := applyDeviate(target, dv)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid deviation of %s: %v", m.Name, d.Name, err))
+					continue
+				}
+				mod.module = m.Name
+				mods = append(mods, mod)
+			}
+		}
+	}
+	return mods, unresolved, errs
+}
+
+// applyDeviate applies the deviate statement dv to the entry e, returning a
+// schemaModification describing the change that was made.
+func applyDeviate(e *yang.Entry, dv *yang.Deviate) (*schemaModification, error) {
+	mod := &schemaModification{entry: e, kind: dv.Name}
+
+	switch dv.Name {
+	case notSupportedDeviation:
+		if e.Parent == nil {
+			return nil, fmt.Errorf("cannot remove module %s", e.Name)
+		}
+		delete(e.Parent.Dir, e.Name)
+		return mod, nil
+	case addDeviation, replaceDeviation, deleteDeviation:
+	default:
+		return nil, fmt.Errorf("unknown deviate argument %q", dv.Name)
+	}
+
+	if dv.Type != nil {
+		if dv.Name != replaceDeviation {
+			return nil, fmt.Errorf("type can only be replaced, got: %s", dv.Name)
+		}
+		if dv.Type.YangType == nil {
+			return nil, fmt.Errorf("could not resolve type %s", dv.Type.Name)
+		}
+		mod.oldType = e.Type
+		e.Type = dv.Type.YangType
+		mod.properties = append(mod.properties, "type")
+	}
+
+	if dv.Default != nil {
+		if dv.Name == deleteDeviation {
+			e.Default = ""
+		} else {
+			e.Default = dv.Default.Name
+		}
+		mod.properties = append(mod.properties, "default")
+	}
+
+	if dv.Config != nil {
+		switch dv.Config.Name {
+		case "true":
+			e.Config = yang.TSTrue
+		case "false":
+			e.Config = yang.TSFalse
+		default:
+			return nil, fmt.Errorf("invalid config value %s", dv.Config.Name)
+		}
+		mod.properties = append(mod.properties, "config")
+	}
+
+	for _, p := range []struct {
+		name string
+		val  *yang.Value
+		set  func(*yang.ListAttr, *yang.Value)
+	}{
+		{"min-elements", dv.MinElements, func(l *yang.ListAttr, v *yang.Value) { l.MinElements = v }},
+		{"max-elements", dv.MaxElements, func(l *yang.ListAttr, v *yang.Value) { l.MaxElements = v }},
+	} {
+		if p.val == nil {
+			continue
+		}
+		if e.ListAttr != nil {
+			p.set(e.ListAttr, p.val)
+		}
+		mod.properties = append(mod.properties, p.name)
+	}
+
+	if dv.Mandatory != nil {
+		mod.properties = append(mod.properties, "mandatory")
+	}
+	if dv.Units != nil {
+		mod.properties = append(mod.properties, "units")
+	}
+	if len(dv.Must) != 0 {
+		mod.properties = append(mod.properties, "must")
+	}
+	if len(dv.Unique) != 0 {
+		mod.properties = append(mod.properties, "unique")
+	}
+
+	return mod, nil
+}
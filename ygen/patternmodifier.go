@@ -0,0 +1,239 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ytypes"
+)
+
+const (
+	// modifierKeyword is the keyword of the YANG 1.1 modifier statement of
+	// a pattern.
+	modifierKeyword = "modifier"
+	// modifierExtKeyword is the keyword that modifier statements are
+	// rewritten to before a module is parsed by goyang, which does not
+	// support the modifier statement. Since the keyword has a prefix, goyang
+	// stores the statement as an extension of the pattern.
+	modifierExtKeyword = "ygot-modifier:modifier"
+	// invertMatch is the only valid argument of the modifier statement.
+	invertMatch = "invert-match"
+)
+
+// readModule reads the YANG module or submodule named by name into ms in the
+// same manner as ms.Read, having rewritten the modifier statements of its
+// patterns such that they can be parsed by goyang. The modules and submodules
+// that it imports or includes are read in the same manner, such that goyang
+// does not read them itself when the modules are processed. Where a file
+// cannot be found, it is left to goyang to find it, or to report the error.
+func readModule(ms *yang.Modules, name string) error {
+	path, data, err := findYANGFile(name)
+	if err != nil {
+		return ms.Read(name)
+	}
+
+	ss, err := yang.Parse(data, path)
+	if err != nil {
+		return err
+	}
+	if data, err = rewritePatternModifiers(data, ss); err != nil {
+		return err
+	}
+	if err := ms.Parse(data, path); err != nil {
+		return err
+	}
+
+	for _, s := range ss {
+		for _, sub := range s.SubStatements() {
+			if sub.Keyword != "import" && sub.Keyword != "include" {
+				continue
+			}
+			if ms.Modules[sub.Argument] != nil || ms.SubModules[sub.Argument] != nil {
+				continue
+			}
+			if _, _, err := findYANGFile(sub.Argument); err != nil {
+				continue
+			}
+			if err := readModule(ms, sub.Argument); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findYANGFile returns the path and contents of the YANG file named by name.
+// Where name does not contain a / and does not have a .yang suffix, it is
+// treated as a module name and .yang is appended to it. The file is looked up
+// relative to the current directory, and then within each directory in
+// yang.Path. The directory that a file is found in relative to the current
+// directory is added to yang.Path, as is done by goyang.
+func findYANGFile(name string) (string, string, error) {
+	slash := strings.Contains(name, "/")
+	if !slash && !strings.HasSuffix(name, ".yang") {
+		name += ".yang"
+	}
+
+	if data, err := ioutil.ReadFile(name); err == nil {
+		yang.AddPath(filepath.Dir(name))
+		return name, string(data), nil
+	}
+	if !slash {
+		for _, dir := range yang.Path {
+			n := filepath.Join(dir, name)
+			if data, err := ioutil.ReadFile(n); err == nil {
+				return n, string(data), nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("no such file: %s", name)
+}
+
+// rewritePatternModifiers returns data, which is the source of the parsed
+// statements ss, with the keyword of each modifier statement of a pattern
+// replaced by modifierExtKeyword. An error is returned if the argument of a
+// modifier statement is not invert-match.
+func rewritePatternModifiers(data string, ss []*yang.Statement) (string, error) {
+	// cols stores the 1-based column of each modifier keyword, keyed by
+	// the 1-based line number.
+	cols := map[int][]int{}
+	var errs []string
+	var find func(s *yang.Statement)
+	find = func(s *yang.Statement) {
+		for _, sub := range s.SubStatements() {
+			if s.Keyword == "pattern" && sub.Keyword == modifierKeyword {
+				if sub.Argument != invertMatch {
+					errs = append(errs, fmt.Sprintf("%s: unsupported pattern modifier %q", sub.Location(), sub.Argument))
+					continue
+				}
+				line, col, err := statementPosition(sub)
+				if err != nil {
+					errs = append(errs, err.Error())
+					continue
+				}
+				cols[line] = append(cols[line], col)
+			}
+			find(sub)
+		}
+	}
+	for _, s := range ss {
+		find(s)
+	}
+	if errs != nil {
+		return "", fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	if len(cols) == 0 {
+		return data, nil
+	}
+
+	lines := strings.Split(data, "\n")
+	for line, cs := range cols {
+		// Replace from the end of the line, such that the columns of the
+		// remaining keywords are unchanged.
+		sort.Sort(sort.Reverse(sort.IntSlice(cs)))
+		l := []rune(lines[line-1])
+		for _, c := range cs {
+			if c < 1 || !strings.HasPrefix(string(l[c-1:]), modifierKeyword) {
+				return "", fmt.Errorf("line %d:%d: cannot find pattern modifier statement", line, c)
+			}
+			l = append(l[:c-1], append([]rune(modifierExtKeyword), l[c-1+len(modifierKeyword):]...)...)
+		}
+		lines[line-1] = string(l)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// statementPosition returns the 1-based line and column of the statement s,
+// as reported by its location.
+func statementPosition(s *yang.Statement) (int, int, error) {
+	loc := s.Location()
+	parts := strings.Split(loc, ":")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("%s: cannot determine the position of statement %s", loc, s.Keyword)
+	}
+	line, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: cannot determine the position of statement %s", loc, s.Keyword)
+	}
+	col, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: cannot determine the position of statement %s", loc, s.Keyword)
+	}
+	return line, col, nil
+}
+
+// annotateInvertedPatterns sets the ytypes.InvertMatchAnnotation of each leaf
+// and leaf-list entry within the tree rooted at e whose type has patterns with
+// the invert-match modifier, such that they can be used when validating the
+// values of the leaf.
+func annotateInvertedPatterns(e *yang.Entry) {
+	var t *yang.Type
+	switch n := e.Node.(type) {
+	case *yang.Leaf:
+		t = n.Type
+	case *yang.LeafList:
+		t = n.Type
+	}
+	if ps := invertedPatterns(t, map[*yang.Type]bool{}); len(ps) != 0 {
+		if e.Annotation == nil {
+			e.Annotation = map[string]interface{}{}
+		}
+		e.Annotation[ytypes.InvertMatchAnnotation] = ps
+	}
+
+	for _, ch := range e.Dir {
+		annotateInvertedPatterns(ch)
+	}
+	if e.RPC != nil {
+		for _, ch := range []*yang.Entry{e.RPC.Input, e.RPC.Output} {
+			if ch != nil {
+				annotateInvertedPatterns(ch)
+			}
+		}
+	}
+}
+
+// invertedPatterns returns the patterns with the invert-match modifier of the
+// type statement t, the types that it is derived from, and its union member
+// types. The types that have already been checked are stored in seen.
+func invertedPatterns(t *yang.Type, seen map[*yang.Type]bool) []string {
+	if t == nil || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	var ps []string
+	for _, p := range t.Pattern {
+		for _, ext := range p.Extensions {
+			if ext.Keyword == modifierExtKeyword && ext.Argument == invertMatch {
+				ps = append(ps, p.Name)
+			}
+		}
+	}
+	for _, u := range t.Type {
+		ps = append(ps, invertedPatterns(u, seen)...)
+	}
+	if t.YangType != nil {
+		ps = append(ps, invertedPatterns(t.YangType.Base, seen)...)
+	}
+	return ps
+}
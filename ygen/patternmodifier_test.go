@@ -0,0 +1,73 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+func TestRewritePatternModifiers(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		want          string
+		wantErrSubstr string
+	}{{
+		name: "no modifier",
+		in:   "module m {\n  leaf a { type string { pattern \"a\"; } }\n}\n",
+		want: "module m {\n  leaf a { type string { pattern \"a\"; } }\n}\n",
+	}, {
+		name: "modifier on its own line",
+		in:   "module m {\n  leaf a { type string { pattern \"a\" {\n\tmodifier invert-match;\n  } } }\n}\n",
+		want: "module m {\n  leaf a { type string { pattern \"a\" {\n\tygot-modifier:modifier invert-match;\n  } } }\n}\n",
+	}, {
+		name: "multiple modifiers on a line after non-ASCII text",
+		in:   "module m {\n  leaf a { description \"é\"; type string { pattern \"a\" { modifier invert-match; } pattern \"b\" { modifier \"invert-match\"; } } }\n}\n",
+		want: "module m {\n  leaf a { description \"é\"; type string { pattern \"a\" { ygot-modifier:modifier invert-match; } pattern \"b\" { ygot-modifier:modifier \"invert-match\"; } } }\n}\n",
+	}, {
+		name: "modifier outside a pattern is unchanged",
+		in:   "module m {\n  m:modifier invert-match;\n}\n",
+		want: "module m {\n  m:modifier invert-match;\n}\n",
+	}, {
+		name:          "unsupported modifier",
+		in:            "module m {\n  leaf a { type string { pattern \"a\" { modifier other; } } }\n}\n",
+		wantErrSubstr: `unsupported pattern modifier "other"`,
+	}}
+
+	for _, tt := range tests {
+		ss, err := yang.Parse(tt.in, "m.yang")
+		if err != nil {
+			t.Errorf("%s: yang.Parse: got unexpected error: %v", tt.name, err)
+			continue
+		}
+		got, err := rewritePatternModifiers(tt.in, ss)
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: rewritePatternModifiers: got unexpected error: %v, want error containing: %q", tt.name, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: rewritePatternModifiers: did not get expected error, want error containing: %q", tt.name, tt.wantErrSubstr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: rewritePatternModifiers: did not get expected source, got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -71,6 +71,8 @@ func (s *genState) yangTypeToProtoType(args resolveTypeArgs, pargs resolveProtoT
 		return &mappedType{nativeType: "ywrapper.StringValue"}, nil
 	case yang.Ydecimal64:
 		return &mappedType{nativeType: "ywrapper.Decimal64Value"}, nil
+	case yang.Ybinary:
+		return &mappedType{nativeType: "ywrapper.BytesValue"}, nil
 	case yang.Yleafref:
 		// We look up the leafref in the schema tree to be able to
 		// determine what type to map to.
@@ -101,13 +103,21 @@ func (s *genState) yangTypeToProtoType(args resolveTypeArgs, pargs resolveProtoT
 			nativeType:        s.protoIdentityName(pargs, args.contextEntry.Type.IdentityBase),
 			isEnumeratedValue: true,
 		}, nil
+	case yang.Ybits:
+		// Bits are mapped to an enumeration that is embedded within the
+		// message, in the same way as an enumeration leaf. Since more than
+		// one bit can be set, the field that uses the enumeration is a
+		// repeated field.
+		if args.contextEntry == nil {
+			return nil, fmt.Errorf("cannot map bits without context entry: %v", args)
+		}
+		return &mappedType{
+			nativeType:        yang.CamelCase(args.contextEntry.Name),
+			isEnumeratedValue: true,
+		}, nil
 	case yang.Yunion:
 		return s.protoUnionType(args, pargs)
 	default:
-		// TODO(robjs): Implement types that are missing within this function.
-		// Missing types are:
-		//  - binary
-		//  - bits
 		// We cannot return an interface{} in protobuf, so therefore
 		// we just throw an error with types that we cannot map.
 		return nil, fmt.Errorf("unimplemented type: %v", args.yangType.Kind)
@@ -141,6 +151,8 @@ func (s *genState) yangTypeToProtoScalarType(args resolveTypeArgs, pargs resolve
 		// Decimal64 continues to be a message even when we are mapping scalars
 		// as there is not an equivalent Protobuf type.
 		return &mappedType{nativeType: "ywrapper.Decimal64Value"}, nil
+	case yang.Ybinary:
+		return &mappedType{nativeType: "bytes"}, nil
 	case yang.Yleafref:
 		target, err := s.resolveLeafrefTarget(args.yangType.Path, args.contextEntry)
 		if err != nil {
@@ -169,10 +181,11 @@ func (s *genState) yangTypeToProtoScalarType(args resolveTypeArgs, pargs resolve
 		}, nil
 	case yang.Yunion:
 		return s.protoUnionType(args, pargs)
+	case yang.Ybits:
+		// Bits are mapped to a repeated field, which cannot be used within
+		// a oneof, or a list key.
+		return nil, fmt.Errorf("bits type cannot be mapped to a scalar protobuf type: %v", args)
 	default:
-		// TODO(robjs): implement missing types.
-		//	- binary
-		//	- bits
 		return nil, fmt.Errorf("unimplemented type in scalar generation: %s", args.yangType.Kind)
 	}
 }
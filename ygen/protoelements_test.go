@@ -29,6 +29,7 @@ func TestYangTypeToProtoType(t *testing.T) {
 		wantScalar             *mappedType
 		wantSame               bool
 		wantErr                bool
+		wantScalarErr          bool
 	}{{
 		name: "integer types",
 		in: []resolveTypeArgs{
@@ -89,11 +90,21 @@ func TestYangTypeToProtoType(t *testing.T) {
 		wantWrapper: &mappedType{nativeType: "ywrapper.Decimal64Value"},
 		wantSame:    true,
 	}, {
-		name: "unmapped types",
-		in: []resolveTypeArgs{
-			{yangType: &yang.YangType{Kind: yang.Ybinary}},
-			{yangType: &yang.YangType{Kind: yang.Ybits}},
-		},
+		name:        "binary",
+		in:          []resolveTypeArgs{{yangType: &yang.YangType{Kind: yang.Ybinary}}},
+		wantWrapper: &mappedType{nativeType: "ywrapper.BytesValue"},
+		wantScalar:  &mappedType{nativeType: "bytes"},
+	}, {
+		name: "bits",
+		in: []resolveTypeArgs{{
+			yangType:     &yang.YangType{Kind: yang.Ybits},
+			contextEntry: &yang.Entry{Name: "flags"},
+		}},
+		wantWrapper:   &mappedType{nativeType: "Flags", isEnumeratedValue: true},
+		wantScalarErr: true,
+	}, {
+		name:    "bits without context entry",
+		in:      []resolveTypeArgs{{yangType: &yang.YangType{Kind: yang.Ybits}}},
 		wantErr: true,
 	}, {
 		name: "union of string, uint32",
@@ -288,7 +299,7 @@ func TestYangTypeToProtoType(t *testing.T) {
 			}
 
 			gotScalar, err := s.yangTypeToProtoScalarType(st, rpt)
-			if (err != nil) != tt.wantErr {
+			if (err != nil) != (tt.wantErr || tt.wantScalarErr) {
 				t.Errorf("%s: yangTypeToProtoScalarType(%v, basePackage, enumPackage): got unexpected error: %v", tt.name, tt.in, err)
 			}
 
@@ -34,6 +34,12 @@ const (
 	// is included in the output data. The string specified has .proto appended to it when
 	// output.
 	protoAnyPackage = "google/protobuf/any"
+	// protoEmptyType is the name of the type used for the input or output of an RPC
+	// method that has no fields.
+	protoEmptyType = "google.protobuf.Empty"
+	// protoEmptyPackage is the name of the import to be used when the google.protobuf.Empty
+	// type is referenced.
+	protoEmptyPackage = "google/protobuf/empty"
 	// protoListKeyMessageSuffix specifies the suffix that should be added to a list's name
 	// to specify the repeated message that makes up the list's key. The repeated message is
 	// called <ListNameInCamelCase><protoListKeyMessageSuffix>.
@@ -51,6 +57,12 @@ const (
 	// defaultYextPath defines the default import path for the yext.proto file, excluding
 	// the filename.
 	DefaultYextPath = "github.com/openconfig/ygot/proto/yext"
+	// DefaultServiceName defines the default name of the gRPC service that is
+	// generated when generating proto3 code.
+	DefaultServiceName = "Service"
+	// DefaultServicePackageName defines the default name of the package within
+	// the base package that the generated gRPC service is defined in.
+	DefaultServicePackageName = "service"
 	// protoSchemaAnnotationOption specifies the name of the FieldOption used to annotate
 	// schemapaths into a protobuf message.
 	protoSchemaAnnotationOption = "(yext.schemapath)"
@@ -65,6 +77,7 @@ type protoMsgField struct {
 	Options     []*protoOption   //Extensions is the set of field extensions that should be specified for the field.
 	IsOneOf     bool             // IsOneOf indicates that the field is a oneof and hence consists of multiple subfields.
 	OneOfFields []*protoMsgField // OneOfFields contains the set of fields within the oneof
+	tagKey      string           // tagKey is the string from which Tag was derived, used to record the tag in a ProtoFieldLock. It is empty for tags that are not derived from the schema.
 }
 
 // protoOption describes a protobuf (message or field) option.
@@ -82,6 +95,8 @@ type protoMsg struct {
 	Fields   []*protoMsgField         // Fields is a slice of the fields that are within the message.
 	Imports  []string                 // Imports is a slice of strings that contains the relative import paths that are required by this message.
 	Enums    map[string]*protoMsgEnum // Enums lists the embedded enumerations within the message.
+	Reserved []uint32                 // Reserved lists the tags of fields that have been removed from the message.
+	lockPath string                   // lockPath identifies the message within a ProtoFieldLock where YANGPath is not unique to the message.
 }
 
 // protoMsgEnum represents an embedded enumeration within a protobuf message.
@@ -104,6 +119,21 @@ type protoEnum struct {
 	ValuePrefix string                   // ValuePrefix contains the string prefix that should be prepended to each value within the enumerated type.
 }
 
+// protoService describes a gRPC service.
+type protoService struct {
+	Name    string      // Name is the name of the service.
+	Methods []*protoRPC // Methods is the set of RPC methods within the service.
+}
+
+// protoRPC describes a method of a gRPC service.
+type protoRPC struct {
+	Name         string // Name is the name of the RPC method.
+	Description  string // Description describes the schema element that the method corresponds to, used in comments.
+	InputType    string // InputType is the protobuf type of the method's input.
+	OutputType   string // OutputType is the protobuf type of the method's output.
+	StreamOutput bool   // StreamOutput indicates whether the method returns a stream of outputs.
+}
+
 // proto3Header describes the header of a Protobuf3 package.
 type proto3Header struct {
 	PackageName            string   // PackageName is the name of the package that is to be output.
@@ -158,6 +188,9 @@ message {{ .Name }} {
     {{- end }}
   }
 {{- end -}}
+{{- if .Reserved }}
+  reserved {{ range $i, $tag := .Reserved }}{{ if ne $i 0 }}, {{ end }}{{ $tag }}{{ end }};
+{{- end -}}
 {{- range $idx, $field := .Fields }}
   {{ if $field.IsOneOf -}}
   oneof {{ $field.Name }} {
@@ -233,15 +266,29 @@ enum {{ .Name }} {
   ;
 {{- end }}
 }
+`
+
+	// protoServiceTemplate is the template used to generate a gRPC service which
+	// has methods for retrieving and setting the root entities of the schema, and
+	// for the rpc and action statements within the schema.
+	protoServiceTemplate = `
+// {{ .Name }} is a gRPC service generated from the YANG schema.
+service {{ .Name }} {
+{{- range $rpc := .Methods }}
+  // {{ $rpc.Name }} {{ $rpc.Description }}.
+  rpc {{ $rpc.Name }}({{ $rpc.InputType }}) returns ({{ if $rpc.StreamOutput }}stream {{ end }}{{ $rpc.OutputType }});
+{{- end }}
+}
 `
 
 	// protoTemplates is the set of templates that are referenced during protbuf
 	// code generation.
 	protoTemplates = map[string]*template.Template{
-		"header": makeTemplate("header", protoHeaderTemplate),
-		"msg":    makeTemplate("msg", protoMessageTemplate),
-		"list":   makeTemplate("list", protoListKeyTemplate),
-		"enum":   makeTemplate("enum", protoEnumTemplate),
+		"header":  makeTemplate("header", protoHeaderTemplate),
+		"msg":     makeTemplate("msg", protoMessageTemplate),
+		"list":    makeTemplate("list", protoListKeyTemplate),
+		"enum":    makeTemplate("enum", protoEnumTemplate),
+		"service": makeTemplate("service", protoServiceTemplate),
 	}
 )
 
@@ -275,12 +322,13 @@ type generatedProto3Message struct {
 
 // protoMsgConfig defines the set of configuration options required to generate a Protobuf message.
 type protoMsgConfig struct {
-	compressPaths       bool   // compressPaths indicates whether path compression should be enabled.
-	basePackageName     string // basePackageName specifies the package name that is the base for all child packages.
-	enumPackageName     string // enumPackageName specifies the package in which global enum definitions are specified.
-	baseImportPath      string // baseImportPath specifies the path that should be used for importing the generated files.
-	annotateSchemaPaths bool   // annotateSchemaPaths uses the yext protobuf field extensions to annotate the paths from the schema into the output protobuf.
-	annotateEnumNames   bool   // annotateEnumNames uses the yext protobuf enum value extensions to annoate the original YANG name for an enum into the output protobuf.
+	compressPaths       bool            // compressPaths indicates whether path compression should be enabled.
+	basePackageName     string          // basePackageName specifies the package name that is the base for all child packages.
+	enumPackageName     string          // enumPackageName specifies the package in which global enum definitions are specified.
+	baseImportPath      string          // baseImportPath specifies the path that should be used for importing the generated files.
+	annotateSchemaPaths bool            // annotateSchemaPaths uses the yext protobuf field extensions to annotate the paths from the schema into the output protobuf.
+	annotateEnumNames   bool            // annotateEnumNames uses the yext protobuf enum value extensions to annoate the original YANG name for an enum into the output protobuf.
+	fieldLock           *ProtoFieldLock // fieldLock, if non-nil, specifies the tags previously assigned to fields, and is updated with the tags assigned to the message's fields.
 }
 
 // writeProto3Message outputs the generated Protobuf3 code for a particular protobuf message. It takes:
@@ -312,6 +360,15 @@ func writeProto3Msg(msg *yangDirectory, msgs map[string]*yangDirectory, state *g
 		return nil, errs
 	}
 
+	for i := range msgDefs {
+		if err := assignProtoFieldTags(&msgDefs[i], cfg.fieldLock); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
 	var b bytes.Buffer
 	imports := map[string]interface{}{}
 	for _, msgDef := range msgDefs {
@@ -382,6 +439,7 @@ func genProto3Msg(msg *yangDirectory, msgs map[string]*yangDirectory, state *gen
 			continue
 		}
 		fieldDef.Tag = t
+		fieldDef.tagKey = field.Path()
 
 		switch {
 		case field.IsList():
@@ -434,6 +492,7 @@ func genProto3Msg(msg *yangDirectory, msgs map[string]*yangDirectory, state *gen
 		case field.IsLeaf() || field.IsLeafList():
 			d, err := protoLeafDefinition(fieldDef.Name, protoDefinitionArgs{
 				field:             field,
+				mappedName:        name,
 				definedFieldNames: definedFieldNames,
 				state:             state,
 				basePackageName:   cfg.basePackageName,
@@ -470,10 +529,10 @@ func genProto3Msg(msg *yangDirectory, msgs map[string]*yangDirectory, state *gen
 				imports[filepath.Join(cfg.baseImportPath, cfg.basePackageName, cfg.enumPackageName)] = true
 			}
 
-			if field.ListAttr != nil {
+			if field.ListAttr != nil || d.repeated {
 				fieldDef.IsRepeated = true
 			}
-		case isAnydata(field):
+		case isAnydata(field) || isAnyxml(field):
 			fieldDef.Type = protoAnyType
 			imports[protoAnyPackage] = true
 		default:
@@ -501,6 +560,115 @@ func genProto3Msg(msg *yangDirectory, msgs map[string]*yangDirectory, state *gen
 	return append(msgDefs, msgDef), errs
 }
 
+// generatedProto3Service stores the generated code for a gRPC service.
+type generatedProto3Service struct {
+	serviceCode     string   // serviceCode contains the proto3 definition of the service.
+	requiredImports []string // requiredImports contains the imports that are required by the generated service.
+}
+
+// protoServiceConfig defines the set of configuration options required to generate a gRPC service.
+type protoServiceConfig struct {
+	name            string // name is the name of the service.
+	compressPaths   bool   // compressPaths indicates whether path compression is enabled.
+	basePackageName string // basePackageName specifies the package name that is the base for all child packages.
+	baseImportPath  string // baseImportPath specifies the path that should be used for importing the generated files.
+}
+
+// writeProto3Service outputs the generated Protobuf3 code for a gRPC service. For
+// each of the entries in roots, which are expected to be the root entities of the
+// schema, Get, Set and Subscribe methods are generated which retrieve, replace and
+// stream the message corresponding to the entity. For each YANG rpc or action
+// in rpcs, a method is generated whose input and output are the messages that
+// correspond to the rpc's input and output statements. Such messages must already
+// have had names assigned to them in the supplied generator state. Roots and rpcs
+// are both keyed by schema path. The configuration of the service is specified by
+// cfg. It returns a generatedProto3Service containing the service definition, and
+// the imports that it requires.
+func writeProto3Service(roots, rpcs map[string]*yang.Entry, state *genState, cfg protoServiceConfig) (*generatedProto3Service, error) {
+	svc := &protoService{Name: cfg.name}
+	imports := map[string]interface{}{}
+	methodNames := map[string]bool{}
+
+	// msgType returns the type name, relative to the base package, that is used
+	// to refer to the message generated for e, and records the import required
+	// for it.
+	msgType := func(e *yang.Entry) string {
+		if e == nil {
+			imports[protoEmptyPackage] = true
+			return protoEmptyType
+		}
+		pkg := state.protobufPackage(e, cfg.compressPaths)
+		imports[filepath.Join(cfg.baseImportPath, cfg.basePackageName, strings.Replace(pkg, ".", "/", -1))] = true
+		n := state.protoMsgName(e, cfg.compressPaths)
+		if pkg == "" {
+			return n
+		}
+		return fmt.Sprintf("%s.%s", pkg, n)
+	}
+
+	for _, p := range sortedEntryKeys(roots) {
+		e := roots[p]
+		t := msgType(e)
+		n := state.protoMsgName(e, cfg.compressPaths)
+		imports[protoEmptyPackage] = true
+
+		elem := fmt.Sprintf("the %s YANG schema element", p)
+		if e.Node != nil && e.Node.NName() == rootElementNodeName {
+			elem = "the root of the YANG schema"
+		}
+
+		svc.Methods = append(svc.Methods, &protoRPC{
+			Name:        makeNameUnique(fmt.Sprintf("Get%s", n), methodNames),
+			Description: fmt.Sprintf("retrieves %s", elem),
+			InputType:   protoEmptyType,
+			OutputType:  t,
+		}, &protoRPC{
+			Name:        makeNameUnique(fmt.Sprintf("Set%s", n), methodNames),
+			Description: fmt.Sprintf("replaces %s", elem),
+			InputType:   t,
+			OutputType:  protoEmptyType,
+		}, &protoRPC{
+			Name:         makeNameUnique(fmt.Sprintf("Subscribe%s", n), methodNames),
+			Description:  fmt.Sprintf("streams the contents of %s as it changes", elem),
+			InputType:    protoEmptyType,
+			OutputType:   t,
+			StreamOutput: true,
+		})
+	}
+
+	for _, p := range sortedEntryKeys(rpcs) {
+		r := rpcs[p]
+		var in, out *yang.Entry
+		if r.RPC != nil {
+			in, out = r.RPC.Input, r.RPC.Output
+		}
+
+		// Actions are defined within the data tree, and hence their name is
+		// qualified by their parent to ensure that it is meaningful.
+		kind, name := "rpc", yang.CamelCase(r.Name)
+		if r.Node != nil && r.Node.Kind() == "action" {
+			kind, name = "action", fmt.Sprintf("%s%s", yang.CamelCase(r.Parent.Name), name)
+		}
+
+		svc.Methods = append(svc.Methods, &protoRPC{
+			Name:        makeNameUnique(name, methodNames),
+			Description: fmt.Sprintf("corresponds to the %s YANG %s", p, kind),
+			InputType:   msgType(in),
+			OutputType:  msgType(out),
+		})
+	}
+
+	var b bytes.Buffer
+	if err := protoTemplates["service"].Execute(&b, svc); err != nil {
+		return nil, err
+	}
+
+	return &generatedProto3Service{
+		serviceCode:     b.String(),
+		requiredImports: stringKeys(imports),
+	}, nil
+}
+
 // protoDefinitionArgs is used as the input argument when YANG is being mapped to protobuf.
 type protoDefinitionArgs struct {
 	field               *yang.Entry               // field is the yang.Entry for which the proto output is being defined, in the case that the definition is for an individual entry.
@@ -515,6 +683,7 @@ type protoDefinitionArgs struct {
 	annotateSchemaPaths bool                      // annotateSchemaPaths defines whether fields should have their schema path annotated to them.
 	annotateEnumNames   bool                      // annotateEnumNames defines whether values within enumerations should be annotated with their original name in the YANG schema.
 	parentPackage       string                    // parentPackage stores the name of the protobuf package that the field's parent is within.
+	mappedName          string                    // mappedName is the name that the field is mapped to within its parent, where it differs from the field's YANG name (e.g., a duplicated state leaf).
 }
 
 // writeProtoEnums takes a map of enumerated types within the YANG schema and
@@ -639,6 +808,26 @@ func genProtoEnum(field *yang.Entry, annotateEnumNames bool) (*protoMsgEnum, err
 	return &protoMsgEnum{Values: eval}, nil
 }
 
+// genProtoBitsEnum takes an input yang.Entry that contains a bits type and
+// returns a protoMsgEnum that contains its definition within the proto schema.
+// Each bit is mapped to an enum value, whose number is the bit's position plus
+// one, such that the zero value can represent unset values. If the
+// annotateEnumNames bool is set, then the original YANG name is stored with
+// each enum value.
+func genProtoBitsEnum(field *yang.Entry, annotateEnumNames bool) (*protoMsgEnum, error) {
+	if field.Type.Bit == nil {
+		return nil, fmt.Errorf("bits type for %s does not define any bits", field.Path())
+	}
+
+	eval := map[int64]protoEnumValue{
+		0: {ProtoLabel: protoEnumZeroName},
+	}
+	for n, v := range field.Type.Bit.NameMap() {
+		eval[v+1] = toProtoEnumValue(safeProtoIdentifierName(n), n, annotateEnumNames)
+	}
+	return &protoMsgEnum{Values: eval}, nil
+}
+
 // protoListDefinition takes an input field described by a yang.Entry, the generator context (the set of proto messages, and the generator
 // state), along with whether path compression is enabled and generates the proto message definition for the list. It returns the type
 // that the field within the parent should be mapped to, and an optional key proto definition (in the case of keyed lists).
@@ -695,6 +884,7 @@ type protoDefinedLeaf struct {
 	enums       map[string]*protoMsgEnum // enums defines the set of enumerated values that are required for this leaf within the parent message.
 	oneofs      []*protoMsgField         // oneofs defines the set of types within the leaf, if the returned leaf type is a protobuf oneof.
 	repeatedMsg *protoMsg                // repeatedMsgs returns a message that should be repeated for this leaf, used in the case of a leaf-list of unions.
+	repeated    bool                     // repeated indicates that the field for the leaf is a repeated field, used in the case of a bits leaf.
 }
 
 // protoLeafDefinition takes an input leafName, and a set of protoDefinitionArgs specifying the context
@@ -717,6 +907,15 @@ func protoLeafDefinition(leafName string, args protoDefinitionArgs) (*protoDefin
 		enums:     map[string]*protoMsgEnum{},
 	}
 
+	// Enumerations that are embedded within the message are named according
+	// to the leaf. Where the leaf is mapped to a field that is named
+	// differently, the enumeration is named according to the field, such
+	// that it is distinguished from that of the leaf that it duplicates.
+	enumName := protoType.nativeType
+	if args.mappedName != "" && args.mappedName != args.field.Name {
+		enumName = yang.CamelCase(args.mappedName)
+	}
+
 	switch {
 	case isSimpleEnumerationType(args.field.Type):
 		// For fields that are simple enumerations within a message, then we embed an enumeration
@@ -726,9 +925,23 @@ func protoLeafDefinition(leafName string, args protoDefinitionArgs) (*protoDefin
 			return nil, err
 		}
 
-		d.protoType = makeNameUnique(protoType.nativeType, args.definedFieldNames)
+		d.protoType = makeNameUnique(enumName, args.definedFieldNames)
 		d.enums = map[string]*protoMsgEnum{}
 		d.enums[d.protoType] = e
+	case args.field.Type.Kind == yang.Ybits:
+		// Bits are mapped to an enumeration embedded within the message, with
+		// each bit that is set being an entry in a repeated field.
+		if args.field.IsLeafList() {
+			return nil, fmt.Errorf("unimplemented: leaf-list of bits %s", args.field.Path())
+		}
+		e, err := genProtoBitsEnum(args.field, args.annotateEnumNames)
+		if err != nil {
+			return nil, err
+		}
+
+		d.protoType = makeNameUnique(enumName, args.definedFieldNames)
+		d.enums[d.protoType] = e
+		d.repeated = true
 	case isEnumType(args.field.Type):
 		d.globalEnum = true
 	case isUnionType(args.field.Type) && protoType.unionTypes != nil:
@@ -818,6 +1031,9 @@ func genListKeyProto(listPackage string, listName string, args protoDefinitionAr
 		Name:     n,
 		YANGPath: args.field.Path(),
 		Enums:    map[string]*protoMsgEnum{},
+		// The list's own message has the same YANGPath, hence a distinct
+		// path is used to record the key message's tags.
+		lockPath: fmt.Sprintf("%s %s", args.field.Path(), protoListKeyMessageSuffix),
 	}
 
 	if listPackage != "" {
@@ -998,14 +1214,16 @@ func unionFieldToOneOf(fieldName string, e *yang.Entry, mtype *mappedType, annot
 		// such that we have unique inputs for each option. We make the name lower-case
 		// as it is conventional that protobuf field names are lowercase separated by
 		// underscores.
-		ft, err := fieldTag(fmt.Sprintf("%s_%s", e.Path(), strings.ToLower(tn)))
+		tk := fmt.Sprintf("%s_%s", e.Path(), strings.ToLower(tn))
+		ft, err := fieldTag(tk)
 		if err != nil {
 			return nil, fmt.Errorf("could not calculate tag number for %s, type %s in oneof", e.Path(), tn)
 		}
 		st := &protoMsgField{
-			Name: fmt.Sprintf("%s_%s", fieldName, strings.ToLower(tn)),
-			Type: t,
-			Tag:  ft,
+			Name:   fmt.Sprintf("%s_%s", fieldName, strings.ToLower(tn)),
+			Type:   t,
+			Tag:    ft,
+			tagKey: tk,
 		}
 		oofs = append(oofs, st)
 	}
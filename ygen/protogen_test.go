@@ -93,13 +93,15 @@ func TestGenProto3Msg(t *testing.T) {
 				Name:     "MessageName",
 				YANGPath: "/root/message-name",
 				Fields: []*protoMsgField{{
-					Tag:  410095931,
-					Name: "field_one",
-					Type: "ywrapper.StringValue",
+					Tag:    410095931,
+					Name:   "field_one",
+					tagKey: "/field-one",
+					Type:   "ywrapper.StringValue",
 				}, {
-					Tag:  25944937,
-					Name: "field_two",
-					Type: "ywrapper.IntValue",
+					Tag:    25944937,
+					Name:   "field_two",
+					tagKey: "/field-two",
+					Type:   "ywrapper.IntValue",
 				}},
 			},
 		},
@@ -158,20 +160,24 @@ func TestGenProto3Msg(t *testing.T) {
 				Fields: []*protoMsgField{{
 					Tag:     410095931,
 					Name:    "field_one",
+					tagKey:  "/field-one",
 					Type:    "",
 					IsOneOf: true,
 					OneOfFields: []*protoMsgField{{
-						Tag:  225170402,
-						Name: "field_one_sint64",
-						Type: "sint64",
+						Tag:    225170402,
+						Name:   "field_one_sint64",
+						tagKey: "/field-one_sint64",
+						Type:   "sint64",
 					}, {
-						Tag:  299030977,
-						Name: "field_one_string",
-						Type: "string",
+						Tag:    299030977,
+						Name:   "field_one_string",
+						tagKey: "/field-one_string",
+						Type:   "string",
 					}},
 				}, {
 					Tag:        332121324,
 					Name:       "field_two",
+					tagKey:     "/parent/field-two",
 					Type:       "ParentFieldTwoUnion",
 					IsRepeated: true,
 				}},
@@ -180,13 +186,15 @@ func TestGenProto3Msg(t *testing.T) {
 				Name:     "ParentFieldTwoUnion",
 				YANGPath: "/parent/field-two union field field-two",
 				Fields: []*protoMsgField{{
-					Tag:  305727351,
-					Name: "field_two_basederivedenumenum",
-					Type: "base.enums.BaseDerivedEnumEnum",
+					Tag:    305727351,
+					Name:   "field_two_basederivedenumenum",
+					tagKey: "/parent/field-two_basederivedenumenum",
+					Type:   "base.enums.BaseDerivedEnumEnum",
 				}, {
-					Tag:  226381575,
-					Name: "field_two_sint64",
-					Type: "sint64",
+					Tag:    226381575,
+					Name:   "field_two_sint64",
+					tagKey: "/parent/field-two_sint64",
+					Type:   "sint64",
 				}},
 			},
 		},
@@ -243,12 +251,14 @@ func TestGenProto3Msg(t *testing.T) {
 				Fields: []*protoMsgField{{
 					Tag:        299656613,
 					Name:       "leaf_list",
+					tagKey:     "/leaf-list",
 					Type:       "ywrapper.StringValue",
 					IsRepeated: true,
 				}, {
-					Tag:  17594927,
-					Name: "container_child",
-					Type: "a_message.ContainerChild",
+					Tag:    17594927,
+					Name:   "container_child",
+					tagKey: "/root/a-message/container-child",
+					Type:   "a_message.ContainerChild",
 				}},
 				Imports: []string{"base/a_message"},
 			},
@@ -305,12 +315,14 @@ func TestGenProto3Msg(t *testing.T) {
 				Fields: []*protoMsgField{{
 					Tag:        299656613,
 					Name:       "leaf_list",
+					tagKey:     "/leaf-list",
 					Type:       "ywrapper.StringValue",
 					IsRepeated: true,
 				}, {
-					Tag:  17594927,
-					Name: "container_child",
-					Type: "root.a_message.ContainerChild",
+					Tag:    17594927,
+					Name:   "container_child",
+					tagKey: "/root/a-message/container-child",
+					Type:   "root.a_message.ContainerChild",
 				}},
 				Imports: []string{"base/root/a_message"},
 			},
@@ -380,6 +392,7 @@ func TestGenProto3Msg(t *testing.T) {
 				YANGPath: "/a-message-with-a-list/list",
 				Fields: []*protoMsgField{{
 					Name:       "list",
+					tagKey:     "/a-message-with-a-list/list",
 					Type:       "ListKey",
 					Tag:        200573382,
 					IsRepeated: true,
@@ -471,13 +484,15 @@ func TestGenProto3Msg(t *testing.T) {
 				YANGPath: "/message-with-anydata",
 				Imports:  []string{"google/protobuf/any"},
 				Fields: []*protoMsgField{{
-					Tag:  453452743,
-					Name: "any_data",
-					Type: "google.protobuf.Any",
+					Tag:    453452743,
+					Name:   "any_data",
+					tagKey: "/any-data",
+					Type:   "google.protobuf.Any",
 				}, {
-					Tag:  463279904,
-					Name: "leaf",
-					Type: "ywrapper.StringValue",
+					Tag:    463279904,
+					Name:   "leaf",
+					tagKey: "/leaf",
+					Type:   "ywrapper.StringValue",
 				}},
 			},
 		},
@@ -519,9 +534,10 @@ func TestGenProto3Msg(t *testing.T) {
 				Name:     "MessageWithAnnotations",
 				YANGPath: "/one/two",
 				Fields: []*protoMsgField{{
-					Name: "leaf",
-					Tag:  60047678,
-					Type: "ywrapper.StringValue",
+					Name:   "leaf",
+					tagKey: "/one/two/leaf",
+					Tag:    60047678,
+					Type:   "ywrapper.StringValue",
 					Options: []*protoOption{{
 						Name:  "(yext.schemapath)",
 						Value: `"/two/leaf"`,
@@ -609,6 +625,10 @@ func TestWriteProtoMsg(t *testing.T) {
 	enumeratedLeafDef.Set("ONE", int64(1))
 	enumeratedLeafDef.Set("FORTYTWO", int64(42))
 
+	bitsLeafDef := yang.NewBitfield()
+	bitsLeafDef.Set("FIRST", int64(0))
+	bitsLeafDef.Set("THIRD", int64(2))
+
 	tests := []struct {
 		name                   string
 		inMsg                  *yangDirectory
@@ -794,6 +814,92 @@ message MessageName {
   }
   Enum enum = 278979784;
 }
+`,
+		},
+	}, {
+		name: "simple message with bits, binary and anyxml fields",
+		inMsg: &yangDirectory{
+			name: "MessageName",
+			entry: &yang.Entry{
+				Name: "message-name",
+				Kind: yang.DirectoryEntry,
+				Parent: &yang.Entry{
+					Name: "module",
+					Kind: yang.DirectoryEntry,
+				},
+			},
+			fields: map[string]*yang.Entry{
+				"flags": {
+					Name: "flags",
+					Kind: yang.LeafEntry,
+					Parent: &yang.Entry{
+						Name: "message-name",
+						Parent: &yang.Entry{
+							Name: "module",
+						},
+					},
+					Type: &yang.YangType{
+						Name: "bits",
+						Kind: yang.Ybits,
+						Bit:  bitsLeafDef,
+					},
+				},
+				"data": {
+					Name: "data",
+					Kind: yang.LeafEntry,
+					Parent: &yang.Entry{
+						Name: "message-name",
+						Parent: &yang.Entry{
+							Name: "module",
+						},
+					},
+					Type: &yang.YangType{Kind: yang.Ybinary},
+				},
+				"xml": {
+					Name: "xml",
+					Kind: yang.AnyXMLEntry,
+					Parent: &yang.Entry{
+						Name: "message-name",
+						Parent: &yang.Entry{
+							Name: "module",
+						},
+					},
+				},
+			},
+			path: []string{"", "module", "message-name"},
+		},
+		inBasePackageName: "base",
+		inEnumPackageName: "enums",
+		wantCompress: generatedProto3Message{
+			packageName: "",
+			messageCode: `
+// MessageName represents the /module/message-name YANG schema element.
+message MessageName {
+  enum Flags {
+    FLAGS_UNSET = 0;
+    FLAGS_FIRST = 1;
+    FLAGS_THIRD = 3;
+  }
+  ywrapper.BytesValue data = 210384623;
+  repeated Flags flags = 502072460;
+  google.protobuf.Any xml = 243473434;
+}
+`,
+		},
+		wantUncompress: generatedProto3Message{
+			packageName: "module",
+			messageCode: `
+// MessageName represents the /module/message-name YANG schema element.
+message MessageName {
+  enum Flags {
+    FLAGS_UNSET = 0;
+    FLAGS_FIRST = 1;
+    FLAGS_THIRD = 3;
+  }
+  ywrapper.BytesValue data = 210384623;
+  repeated Flags flags = 502072460;
+  google.protobuf.Any xml = 243473434;
+}
 `,
 		},
 	}, {
@@ -1055,6 +1161,7 @@ func TestGenListKeyProto(t *testing.T) {
 		wantMsg: &protoMsg{
 			Name:     "listKey",
 			YANGPath: "/list",
+			lockPath: "/list Key",
 			Fields: []*protoMsgField{{
 				Tag:  1,
 				Name: "key",
@@ -1106,18 +1213,21 @@ func TestGenListKeyProto(t *testing.T) {
 		wantMsg: &protoMsg{
 			Name:     "listKey",
 			YANGPath: "/list",
+			lockPath: "/list Key",
 			Fields: []*protoMsgField{{
 				Tag:     1,
 				Name:    "key",
 				IsOneOf: true,
 				OneOfFields: []*protoMsgField{{
-					Tag:  232819104,
-					Name: "key_sint64",
-					Type: "sint64",
+					Tag:    232819104,
+					Name:   "key_sint64",
+					tagKey: "/key_sint64",
+					Type:   "sint64",
 				}, {
-					Tag:  470483267,
-					Name: "key_string",
-					Type: "string",
+					Tag:    470483267,
+					Name:   "key_string",
+					tagKey: "/key_string",
+					Type:   "string",
 				}},
 			}, {
 				Tag:  2,
@@ -1166,6 +1276,7 @@ func TestGenListKeyProto(t *testing.T) {
 		wantMsg: &protoMsg{
 			Name:     "listKey",
 			YANGPath: "/list",
+			lockPath: "/list Key",
 			Fields: []*protoMsgField{{
 				Tag:  1,
 				Name: "key",
@@ -1370,13 +1481,15 @@ func TestUnionFieldToOneOf(t *testing.T) {
 			},
 		},
 		wantFields: []*protoMsgField{{
-			Tag:  171677331,
-			Name: "FieldName_sint64",
-			Type: "sint64",
+			Tag:    171677331,
+			Name:   "FieldName_sint64",
+			tagKey: "/field-name_sint64",
+			Type:   "sint64",
 		}, {
-			Tag:  173535000,
-			Name: "FieldName_string",
-			Type: "string",
+			Tag:    173535000,
+			Name:   "FieldName_string",
+			tagKey: "/field-name_string",
+			Type:   "string",
 		}},
 		wantEnums: map[string]*protoMsgEnum{},
 	}, {
@@ -1398,13 +1511,15 @@ func TestUnionFieldToOneOf(t *testing.T) {
 			},
 		},
 		wantFields: []*protoMsgField{{
-			Tag:  173535000,
-			Name: "FieldName_string",
-			Type: "string",
+			Tag:    173535000,
+			Name:   "FieldName_string",
+			tagKey: "/field-name_string",
+			Type:   "string",
 		}, {
-			Tag:  328616554,
-			Name: "FieldName_decimal64",
-			Type: "ywrapper.Decimal64",
+			Tag:    328616554,
+			Name:   "FieldName_decimal64",
+			tagKey: "/field-name_decimal64",
+			Type:   "ywrapper.Decimal64",
 		}},
 		wantEnums: map[string]*protoMsgEnum{},
 	}, {
@@ -1431,13 +1546,15 @@ func TestUnionFieldToOneOf(t *testing.T) {
 		},
 		inAnnotateEnumNames: true,
 		wantFields: []*protoMsgField{{
-			Tag:  29065580,
-			Name: "FieldName_someenumtype",
-			Type: "SomeEnumType",
+			Tag:    29065580,
+			Name:   "FieldName_someenumtype",
+			tagKey: "/field-name_someenumtype",
+			Type:   "SomeEnumType",
 		}, {
-			Tag:  173535000,
-			Name: "FieldName_string",
-			Type: "string",
+			Tag:    173535000,
+			Name:   "FieldName_string",
+			tagKey: "/field-name_string",
+			Type:   "string",
 		}},
 		wantEnums: map[string]*protoMsgEnum{
 			"FieldName": {
@@ -1472,13 +1589,15 @@ func TestUnionFieldToOneOf(t *testing.T) {
 			Name:     "ParentFieldNameUnion",
 			YANGPath: "/parent/field-name union field field-name",
 			Fields: []*protoMsgField{{
-				Tag:  85114709,
-				Name: "FieldName_string",
-				Type: "string",
+				Tag:    85114709,
+				Name:   "FieldName_string",
+				tagKey: "/parent/field-name_string",
+				Type:   "string",
 			}, {
-				Tag:  192993976,
-				Name: "FieldName_uint64",
-				Type: "uint64",
+				Tag:    192993976,
+				Name:   "FieldName_uint64",
+				tagKey: "/parent/field-name_uint64",
+				Type:   "uint64",
 			}},
 		},
 	}}
@@ -0,0 +1,192 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ProtoFieldLock records the field numbers that have been assigned to the
+// fields of generated protobuf messages. Supplying the lock from a previous
+// code generation run ensures that fields retain their field numbers across
+// revisions of the input schema, such that the generated protobufs remain wire
+// compatible. The lock is designed to be stored as a JSON file alongside the
+// generated protobufs.
+type ProtoFieldLock struct {
+	// Messages stores the field number assignments for each generated
+	// message, keyed by the YANG path that the message corresponds to. The
+	// messages that represent the keys of YANG lists have the suffix " Key"
+	// appended to the path of the list.
+	Messages map[string]*ProtoMessageLock `json:"messages"`
+}
+
+// ProtoMessageLock records the field numbers assigned within a single
+// generated protobuf message.
+type ProtoMessageLock struct {
+	// Fields maps the identifier of each field in the message to the field
+	// number assigned to it. The identifier is the schema path of the field,
+	// or for members of a oneof, the schema path with the name of the type
+	// of the member appended.
+	Fields map[string]uint32 `json:"fields,omitempty"`
+	// Reserved is the sorted set of field numbers that were assigned to
+	// fields that no longer exist in the message, and hence cannot be
+	// reused.
+	Reserved []uint32 `json:"reserved,omitempty"`
+}
+
+// NewProtoFieldLock returns an empty ProtoFieldLock.
+func NewProtoFieldLock() *ProtoFieldLock {
+	return &ProtoFieldLock{Messages: map[string]*ProtoMessageLock{}}
+}
+
+// UnmarshalProtoFieldLock parses the JSON-encoded ProtoFieldLock in b,
+// returning an error if it is not valid.
+func UnmarshalProtoFieldLock(b []byte) (*ProtoFieldLock, error) {
+	l := NewProtoFieldLock()
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("could not unmarshal field lock: %v", err)
+	}
+	if l.Messages == nil {
+		l.Messages = map[string]*ProtoMessageLock{}
+	}
+	return l, nil
+}
+
+// Marshal returns the JSON encoding of the ProtoFieldLock.
+func (l *ProtoFieldLock) Marshal() ([]byte, error) {
+	return json.MarshalIndent(l, "", "  ")
+}
+
+// copy returns a deep copy of the ProtoFieldLock, such that it can be updated
+// without modifying the lock supplied by the caller. A nil lock is copied to
+// an empty lock.
+func (l *ProtoFieldLock) copy() *ProtoFieldLock {
+	n := NewProtoFieldLock()
+	if l == nil {
+		return n
+	}
+	for p, m := range l.Messages {
+		nm := &ProtoMessageLock{
+			Fields:   map[string]uint32{},
+			Reserved: append([]uint32{}, m.Reserved...),
+		}
+		for k, v := range m.Fields {
+			nm.Fields[k] = v
+		}
+		n.Messages[p] = nm
+	}
+	return n
+}
+
+// lockableFields returns the fields of msg whose tags are derived from the
+// schema, and hence can be recorded in a ProtoFieldLock. Oneof fields are
+// expanded into their member fields, since the oneof itself has no tag.
+func lockableFields(msg *protoMsg) []*protoMsgField {
+	var fields []*protoMsgField
+	for _, f := range msg.Fields {
+		fs := []*protoMsgField{f}
+		if f.IsOneOf {
+			fs = f.OneOfFields
+		}
+		for _, of := range fs {
+			if of.tagKey != "" {
+				fields = append(fields, of)
+			}
+		}
+	}
+	return fields
+}
+
+// assignProtoFieldTags sets the tags of the fields within msg according to the
+// supplied lock. Fields that are present in the lock retain the tag recorded
+// for them, whereas new fields are assigned the tag derived from their schema
+// path. Tags for fields that are in the lock, but no longer in msg, are
+// reserved within the message. An error is returned if two fields are assigned
+// the same tag, or a new field's tag was previously reserved. If lock is
+// non-nil, it is updated to reflect the tags assigned within msg.
+func assignProtoFieldTags(msg *protoMsg, lock *ProtoFieldLock) error {
+	fields := lockableFields(msg)
+
+	path := msg.YANGPath
+	if msg.lockPath != "" {
+		path = msg.lockPath
+	}
+
+	ml := &ProtoMessageLock{}
+	if lock != nil && lock.Messages[path] != nil {
+		ml = lock.Messages[path]
+	}
+
+	reserved := map[uint32]bool{}
+	for _, t := range ml.Reserved {
+		reserved[t] = true
+	}
+
+	// Assign the tags of fields that are in the lock first, such that new
+	// fields whose derived tag is already in use are reported as colliding
+	// rather than the locked field.
+	used := map[uint32]string{}
+	var newFields []*protoMsgField
+	for _, f := range fields {
+		t, ok := ml.Fields[f.tagKey]
+		if !ok {
+			newFields = append(newFields, f)
+			continue
+		}
+		if other, ok := used[t]; ok {
+			return fmt.Errorf("proto: locked tag %d for field %s in message %s is also locked for field %s", t, f.tagKey, msg.Name, other)
+		}
+		f.Tag = t
+		used[t] = f.tagKey
+	}
+
+	for _, f := range newFields {
+		if other, ok := used[f.Tag]; ok {
+			return fmt.Errorf("proto: tag %d for field %s in message %s collides with field %s, a tag must be assigned to one of the fields in the field lock", f.Tag, f.tagKey, msg.Name, other)
+		}
+		if reserved[f.Tag] {
+			return fmt.Errorf("proto: tag %d for field %s in message %s collides with a reserved tag, a tag must be assigned to the field in the field lock", f.Tag, f.tagKey, msg.Name)
+		}
+		used[f.Tag] = f.tagKey
+	}
+
+	nml := &ProtoMessageLock{Fields: map[string]uint32{}}
+	for t, k := range used {
+		nml.Fields[k] = t
+	}
+	for k, t := range ml.Fields {
+		if _, ok := nml.Fields[k]; !ok {
+			reserved[t] = true
+		}
+	}
+	for t := range reserved {
+		nml.Reserved = append(nml.Reserved, t)
+	}
+	sort.Slice(nml.Reserved, func(i, j int) bool { return nml.Reserved[i] < nml.Reserved[j] })
+
+	msg.Reserved = nml.Reserved
+	if lock == nil {
+		return nil
+	}
+	// Messages that have neither fields nor reserved tags, which were not
+	// previously locked, are not recorded in the lock.
+	if len(nml.Fields) == 0 && len(nml.Reserved) == 0 && lock.Messages[path] == nil {
+		return nil
+	}
+	lock.Messages[path] = nml
+	return nil
+}
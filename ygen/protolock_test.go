@@ -0,0 +1,377 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestAssignProtoFieldTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		inMsg    *protoMsg
+		inLock   *ProtoFieldLock
+		wantTags map[string]uint32
+		// wantReserved is the set of tags expected to be reserved within
+		// the message.
+		wantReserved []uint32
+		// wantLock is the expected lock after the tags have been assigned.
+		wantLock *ProtoFieldLock
+		wantErr  string
+	}{{
+		name: "no lock",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+				{Name: "two", Tag: 1002, tagKey: "/msg/two"},
+			},
+		},
+		wantTags: map[string]uint32{"one": 1001, "two": 1002},
+	}, {
+		name: "empty lock is populated",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+				{Name: "two", Tag: 1002, tagKey: "/msg/two"},
+			},
+		},
+		inLock:   NewProtoFieldLock(),
+		wantTags: map[string]uint32{"one": 1001, "two": 1002},
+		wantLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/one": 1001, "/msg/two": 1002}},
+			},
+		},
+	}, {
+		name: "locked tag is retained",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+				{Name: "two", Tag: 1002, tagKey: "/msg/two"},
+			},
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/one": 42}},
+			},
+		},
+		wantTags: map[string]uint32{"one": 42, "two": 1002},
+		wantLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/one": 42, "/msg/two": 1002}},
+			},
+		},
+	}, {
+		name: "removed field is reserved",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+			},
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {
+					Fields:   map[string]uint32{"/msg/one": 1001, "/msg/two": 1002},
+					Reserved: []uint32{1003},
+				},
+			},
+		},
+		wantTags:     map[string]uint32{"one": 1001},
+		wantReserved: []uint32{1002, 1003},
+		wantLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {
+					Fields:   map[string]uint32{"/msg/one": 1001},
+					Reserved: []uint32{1002, 1003},
+				},
+			},
+		},
+	}, {
+		name: "all fields removed are reserved",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {
+					Fields:   map[string]uint32{"/msg/one": 1001, "/msg/two": 1002},
+					Reserved: []uint32{1003},
+				},
+			},
+		},
+		wantTags:     map[string]uint32{},
+		wantReserved: []uint32{1001, 1002, 1003},
+		wantLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {
+					Fields:   map[string]uint32{},
+					Reserved: []uint32{1001, 1002, 1003},
+				},
+			},
+		},
+	}, {
+		name: "message without fields is not locked",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+		},
+		inLock:   NewProtoFieldLock(),
+		wantTags: map[string]uint32{},
+		wantLock: NewProtoFieldLock(),
+	}, {
+		name: "oneof fields are locked",
+		inMsg: &protoMsg{
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{{
+				Name:    "union",
+				Tag:     1001,
+				tagKey:  "/msg/union",
+				IsOneOf: true,
+				OneOfFields: []*protoMsgField{
+					{Name: "union_string", Tag: 1002, tagKey: "/msg/union_string"},
+					{Name: "union_uint64", Tag: 1003, tagKey: "/msg/union_uint64"},
+				},
+			}},
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/union_string": 42}},
+			},
+		},
+		wantTags: map[string]uint32{"union": 1001, "union_string": 42, "union_uint64": 1003},
+		wantLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/union_string": 42, "/msg/union_uint64": 1003}},
+			},
+		},
+	}, {
+		name: "list key message uses lock path",
+		inMsg: &protoMsg{
+			YANGPath: "/list",
+			lockPath: "/list Key",
+			Fields: []*protoMsgField{
+				{Name: "key", Tag: 1},
+				{Name: "other", Tag: 1001, tagKey: "/list/other"},
+			},
+		},
+		inLock:   NewProtoFieldLock(),
+		wantTags: map[string]uint32{"key": 1, "other": 1001},
+		wantLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/list Key": {Fields: map[string]uint32{"/list/other": 1001}},
+			},
+		},
+	}, {
+		name: "message without schema derived tags is not locked",
+		inMsg: &protoMsg{
+			YANGPath: "/list",
+			Fields: []*protoMsgField{
+				{Name: "key", Tag: 1},
+				{Name: "list", Tag: 2},
+			},
+		},
+		inLock:   NewProtoFieldLock(),
+		wantTags: map[string]uint32{"key": 1, "list": 2},
+		wantLock: NewProtoFieldLock(),
+	}, {
+		name: "collision between new fields",
+		inMsg: &protoMsg{
+			Name:     "Msg",
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+				{Name: "two", Tag: 1001, tagKey: "/msg/two"},
+			},
+		},
+		wantErr: "proto: tag 1001 for field /msg/two in message Msg collides with field /msg/one",
+	}, {
+		name: "new field collides with locked field",
+		inMsg: &protoMsg{
+			Name:     "Msg",
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+				{Name: "two", Tag: 1002, tagKey: "/msg/two"},
+			},
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/two": 1001}},
+			},
+		},
+		wantErr: "proto: tag 1001 for field /msg/one in message Msg collides with field /msg/two",
+	}, {
+		name: "new field collides with reserved tag",
+		inMsg: &protoMsg{
+			Name:     "Msg",
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+			},
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Reserved: []uint32{1001}},
+			},
+		},
+		wantErr: "proto: tag 1001 for field /msg/one in message Msg collides with a reserved tag",
+	}, {
+		name: "tag locked for two fields",
+		inMsg: &protoMsg{
+			Name:     "Msg",
+			YANGPath: "/msg",
+			Fields: []*protoMsgField{
+				{Name: "one", Tag: 1001, tagKey: "/msg/one"},
+				{Name: "two", Tag: 1002, tagKey: "/msg/two"},
+			},
+		},
+		inLock: &ProtoFieldLock{
+			Messages: map[string]*ProtoMessageLock{
+				"/msg": {Fields: map[string]uint32{"/msg/one": 42, "/msg/two": 42}},
+			},
+		},
+		wantErr: "proto: locked tag 42 for field /msg/two in message Msg is also locked for field /msg/one",
+	}}
+
+	for _, tt := range tests {
+		err := assignProtoFieldTags(tt.inMsg, tt.inLock)
+		if err != nil {
+			if tt.wantErr == "" || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("%s: assignProtoFieldTags(%v, %v): did not get expected error, got: %v, want: %s", tt.name, tt.inMsg, tt.inLock, err, tt.wantErr)
+			}
+			continue
+		}
+		if tt.wantErr != "" {
+			t.Errorf("%s: assignProtoFieldTags(%v, %v): did not get expected error, got: nil, want: %s", tt.name, tt.inMsg, tt.inLock, tt.wantErr)
+			continue
+		}
+
+		gotTags := map[string]uint32{}
+		for _, f := range tt.inMsg.Fields {
+			gotTags[f.Name] = f.Tag
+			for _, of := range f.OneOfFields {
+				gotTags[of.Name] = of.Tag
+			}
+		}
+		if diff := pretty.Compare(gotTags, tt.wantTags); diff != "" {
+			t.Errorf("%s: assignProtoFieldTags(%v, %v): did not get expected tags, diff(-got,+want):\n%s", tt.name, tt.inMsg, tt.inLock, diff)
+		}
+
+		if diff := pretty.Compare(tt.inMsg.Reserved, tt.wantReserved); diff != "" {
+			t.Errorf("%s: assignProtoFieldTags(%v, %v): did not get expected reserved tags, diff(-got,+want):\n%s", tt.name, tt.inMsg, tt.inLock, diff)
+		}
+
+		if diff := pretty.Compare(tt.inLock, tt.wantLock); diff != "" {
+			t.Errorf("%s: assignProtoFieldTags(%v, %v): did not get expected lock, diff(-got,+want):\n%s", tt.name, tt.inMsg, tt.inLock, diff)
+		}
+	}
+}
+
+func TestProtoFieldLockMarshal(t *testing.T) {
+	in := &ProtoFieldLock{
+		Messages: map[string]*ProtoMessageLock{
+			"/msg": {
+				Fields:   map[string]uint32{"/msg/one": 1001},
+				Reserved: []uint32{1002},
+			},
+		},
+	}
+
+	b, err := in.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal(): got unexpected error: %v", err)
+	}
+
+	got, err := UnmarshalProtoFieldLock(b)
+	if err != nil {
+		t.Fatalf("UnmarshalProtoFieldLock(%s): got unexpected error: %v", b, err)
+	}
+
+	if diff := pretty.Compare(got, in); diff != "" {
+		t.Errorf("UnmarshalProtoFieldLock(%s): did not get expected lock, diff(-got,+want):\n%s", b, diff)
+	}
+
+	if _, err := UnmarshalProtoFieldLock([]byte("{")); err == nil {
+		t.Errorf("UnmarshalProtoFieldLock({): did not get expected error")
+	}
+
+	got, err = UnmarshalProtoFieldLock([]byte("{}"))
+	if err != nil {
+		t.Fatalf("UnmarshalProtoFieldLock({}): got unexpected error: %v", err)
+	}
+	if got.Messages == nil {
+		t.Errorf("UnmarshalProtoFieldLock({}): did not get initialised message map")
+	}
+}
+
+func TestGenerateProto3FieldLock(t *testing.T) {
+	inLock := &ProtoFieldLock{
+		Messages: map[string]*ProtoMessageLock{
+			"/proto-test-a/parent": {
+				Fields: map[string]uint32{
+					"/proto-test-a/parent/child":   4242,
+					"/proto-test-a/parent/removed": 4343,
+				},
+			},
+		},
+	}
+
+	cg := NewYANGCodeGenerator(&GeneratorConfig{
+		CompressOCPaths: true,
+		ProtoOptions: ProtoOpts{
+			FieldLock: inLock,
+		},
+	})
+
+	inFiles := []string{filepath.Join(TestRoot, "testdata", "proto", "proto-test-a.yang")}
+	got, err := cg.GenerateProto3(inFiles, nil)
+	if err != nil {
+		t.Fatalf("GenerateProto3(%v, nil): got unexpected error: %v", inFiles, err)
+	}
+
+	pkg, ok := got.Packages["openconfig"]
+	if !ok {
+		t.Fatalf("GenerateProto3(%v, nil): did not get expected package openconfig, got: %v", inFiles, got.Packages)
+	}
+	code := strings.Join(pkg.Messages, "")
+	for _, want := range []string{"reserved 4343;", "parent.Child child = 4242;"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("GenerateProto3(%v, nil): generated code did not contain %q, got:\n%s", inFiles, want, code)
+		}
+	}
+
+	wantParent := &ProtoMessageLock{
+		Fields:   map[string]uint32{"/proto-test-a/parent/child": 4242},
+		Reserved: []uint32{4343},
+	}
+	if diff := pretty.Compare(got.FieldLock.Messages["/proto-test-a/parent"], wantParent); diff != "" {
+		t.Errorf("GenerateProto3(%v, nil): did not get expected lock for parent, diff(-got,+want):\n%s", inFiles, diff)
+	}
+
+	if _, ok := got.FieldLock.Messages["/proto-test-a/parent/child"]; !ok {
+		t.Errorf("GenerateProto3(%v, nil): did not get lock for new message /proto-test-a/parent/child, got: %v", inFiles, got.FieldLock.Messages)
+	}
+
+	if len(inLock.Messages["/proto-test-a/parent"].Reserved) != 0 {
+		t.Errorf("GenerateProto3(%v, nil): input lock was modified, got: %v", inFiles, inLock.Messages["/proto-test-a/parent"])
+	}
+}
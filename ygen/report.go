@@ -0,0 +1,288 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// GenerationReport describes the nodes of the YANG schema that were modified
+// by augment or deviation statements within the input modules, and how the
+// modifications altered the generated Go and protobuf code. It is designed to
+// be written as a JSON file alongside the generated code, such that changes to
+// the generated types can be traced back to the module that caused them.
+type GenerationReport struct {
+	// Nodes is the set of modified nodes, sorted by path.
+	Nodes []*ReportNode `json:"nodes"`
+	// UnresolvedDeviations is the set of deviation statements whose target
+	// could not be found within the schema, and hence were not applied.
+	UnresolvedDeviations []*UnresolvedDeviation `json:"unresolved_deviations,omitempty"`
+}
+
+// UnresolvedDeviation describes a deviation statement whose target node could
+// not be found within the schema.
+type UnresolvedDeviation struct {
+	// Module is the name of the module that contains the deviation.
+	Module string `json:"module"`
+	// Target is the path of the target node, as specified in the deviation.
+	Target string `json:"target"`
+}
+
+// ReportNode describes a single modification that was made to a node of the
+// YANG schema.
+type ReportNode struct {
+	// Path is the schema path of the node that was modified.
+	Path string `json:"path"`
+	// Module is the name of the module that contains the augment or
+	// deviation statement that modified the node.
+	Module string `json:"module"`
+	// Modification is the kind of the modification - "augment" for nodes
+	// that were added to the schema by an augment statement, or the argument
+	// of the deviate statement (not-supported, add, replace or delete).
+	Modification string `json:"modification"`
+	// Properties is the set of properties of the node that were modified by
+	// a deviation (e.g., type, default, config).
+	Properties []string `json:"properties,omitempty"`
+	// Go describes the Go type that the node is mapped to. It is nil if the
+	// node is not mapped to a Go type.
+	Go *ReportEntity `json:"go,omitempty"`
+	// Proto describes the protobuf type that the node is mapped to. It is nil
+	// if the node is not mapped to a protobuf type.
+	Proto *ReportEntity `json:"proto,omitempty"`
+}
+
+// ReportEntity describes the generated type that a node of the schema is
+// mapped to before and after a modification. For containers and lists, the
+// type is the name of the generated struct or message, and for leaves and
+// leaf-lists, it is the type of the field that the node is mapped to. An
+// empty Before indicates that the node was added, and an empty After that it
+// was removed.
+type ReportEntity struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Marshal returns the JSON encoding of the GenerationReport.
+func (r *GenerationReport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// GenerateReport takes a slice of strings containing the path to a set of YANG
+// files which contain YANG modules, and a second slice of strings which
+// specifies the set of paths that are to be searched for associated models
+// (e.g., modules that are included by the specified set of modules, or
+// submodules of those modules). It returns a GenerationReport describing the
+// nodes of the schema that are modified by augment or deviation statements
+// within the modules, and how the modifications alter the code that is
+// generated by GenerateGoCode and GenerateProto3 using the same configuration.
+// Deviations are included only when the ApplyDeviations option is set in the
+// generator's configuration.
+func (cg *YANGCodeGenerator) GenerateReport(yangFiles, includePaths []string) (*GenerationReport, *YANGCodeGeneratorError) {
+	mdef, errs := mappedDefinitions(yangFiles, includePaths, cg.Config)
+	if errs != nil {
+		return nil, &YANGCodeGeneratorError{Errors: errs}
+	}
+
+	// Separate state is used for each language, since the names that are
+	// generated are unique within a language's context.
+	rg := &reportGenerator{
+		compressPaths:         cg.Config.CompressOCPaths,
+		configStatePreference: cg.Config.ConfigStatePreference,
+		genFakeRoot:           cg.Config.GenerateFakeRoot,
+		gs:                    newGenState(),
+		ps:                    newGenState(),
+		pargs: resolveProtoTypeArgs{
+			basePackageName: cg.Config.ProtoOptions.BasePackageName,
+			enumPackageName: cg.Config.ProtoOptions.EnumPackageName,
+		},
+	}
+	if rg.pargs.basePackageName == "" {
+		rg.pargs.basePackageName = DefaultBasePackageName
+	}
+	if rg.pargs.enumPackageName == "" {
+		rg.pargs.enumPackageName = DefaultEnumPackageName
+	}
+
+	if errs := rg.init(mdef); errs != nil {
+		return nil, &YANGCodeGeneratorError{Errors: errs}
+	}
+
+	report := &GenerationReport{
+		Nodes:                []*ReportNode{},
+		UnresolvedDeviations: mdef.unresolvedDeviations,
+	}
+	ye := NewYANGCodeGeneratorError()
+	for _, m := range mdef.modifications {
+		n, err := rg.reportNode(m)
+		if err != nil {
+			ye.Errors = append(ye.Errors, err)
+			continue
+		}
+		report.Nodes = append(report.Nodes, n)
+	}
+	if len(ye.Errors) != 0 {
+		return nil, ye
+	}
+
+	sort.SliceStable(report.Nodes, func(i, j int) bool {
+		a, b := report.Nodes[i], report.Nodes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Module < b.Module
+	})
+	return report, nil
+}
+
+// reportGenerator stores the state required to determine the generated types
+// that modified schema nodes are mapped to.
+type reportGenerator struct {
+	compressPaths         bool
+	configStatePreference ConfigStatePreference
+	genFakeRoot           bool
+	// gs and ps are the generator state for Go and protobuf respectively.
+	gs, ps *genState
+	// goDirs and protoDirs are the directories that are generated for the
+	// schema in Go and protobuf respectively, keyed by the path of the
+	// entry that they correspond to.
+	goDirs, protoDirs map[string]*yangDirectory
+	pargs             resolveProtoTypeArgs
+}
+
+// init populates the generator state for Go and protobuf with the names that
+// are used for the directories and enumerated types that are generated for
+// the schema described by mdef.
+func (r *reportGenerator) init(mdef mappedYANGDefinitions) []error {
+	for _, s := range []*genState{r.gs, r.ps} {
+		s.schematree = mdef.schemaTree
+		s.configStatePreference = r.configStatePreference
+	}
+
+	var errs []error
+	var err []error
+	if _, err = r.gs.findEnumSet(mdef.enumEntries, r.compressPaths, false); err != nil {
+		errs = append(errs, err...)
+	}
+	if _, err = r.ps.findEnumSet(mdef.enumEntries, r.compressPaths, true); err != nil {
+		errs = append(errs, err...)
+	}
+	if r.goDirs, err = r.gs.buildDirectoryDefinitions(mdef.directoryEntries, r.compressPaths, r.genFakeRoot, golang); err != nil {
+		errs = append(errs, err...)
+	}
+	if r.protoDirs, err = r.ps.buildDirectoryDefinitions(mdef.directoryEntries, r.compressPaths, r.genFakeRoot, protobuf); err != nil {
+		errs = append(errs, err...)
+	}
+	return errs
+}
+
+// reportNode returns the ReportNode describing the modification m.
+func (r *reportGenerator) reportNode(m *schemaModification) (*ReportNode, error) {
+	n := &ReportNode{
+		Path:         schemaTreePath(m.entry),
+		Module:       m.module,
+		Modification: m.kind,
+		Properties:   m.properties,
+	}
+
+	e := m.entry
+	switch {
+	case e.IsLeaf(), e.IsLeafList():
+		after, err := r.leafTypes(e, e.Type)
+		if err != nil {
+			return nil, err
+		}
+		before := after
+		if m.oldType != nil {
+			if before, err = r.leafTypes(e, m.oldType); err != nil {
+				return nil, err
+			}
+		}
+		n.Go, n.Proto = reportEntities(m.kind, before, after)
+	case e.IsDir():
+		after := r.dirNames(e)
+		if after == nil {
+			// Directories that have no type generated for them (e.g., config
+			// and state containers when paths are compressed) are not reported.
+			return n, nil
+		}
+		n.Go, n.Proto = reportEntities(m.kind, after, after)
+	}
+	return n, nil
+}
+
+// reportEntities returns the ReportEntity for Go and protobuf for a node that
+// is modified by a modification of the kind specified. The before and after
+// arguments are two element slices containing the Go type followed by the
+// protobuf type of the node before and after the modification.
+func reportEntities(kind string, before, after []string) (*ReportEntity, *ReportEntity) {
+	switch kind {
+	case augmentModification:
+		before = []string{"", ""}
+	case notSupportedDeviation:
+		after = []string{"", ""}
+	}
+	return &ReportEntity{Before: before[0], After: after[0]}, &ReportEntity{Before: before[1], After: after[1]}
+}
+
+// leafTypes returns the Go and protobuf types of the field that the leaf or
+// leaf-list e is mapped to when it is of type t.
+func (r *reportGenerator) leafTypes(e *yang.Entry, t *yang.YangType) ([]string, error) {
+	args := resolveTypeArgs{yangType: t, contextEntry: e}
+	gt, err := r.gs.yangTypeToGoType(args, r.compressPaths)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot map to Go type: %v", e.Path(), err)
+	}
+	pt, err := r.ps.yangTypeToProtoType(args, r.pargs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot map to protobuf type: %v", e.Path(), err)
+	}
+	if e.IsLeafList() {
+		return []string{fmt.Sprintf("[]%s", gt.nativeType), fmt.Sprintf("repeated %s", pt.nativeType)}, nil
+	}
+	return []string{gt.nativeType, pt.nativeType}, nil
+}
+
+// dirNames returns the name of the Go struct and the fully-qualified name of
+// the protobuf message that the directory e is mapped to. Since nodes that are
+// removed from the schema do not have directories built for them, their names
+// are determined as though they had been generated. It returns nil if no
+// struct or message is generated for e.
+func (r *reportGenerator) dirNames(e *yang.Entry) []string {
+	if r.compressPaths && !isOCCompressedValidElement(e) {
+		return nil
+	}
+
+	var goName, protoName string
+	if d, ok := r.goDirs[e.Path()]; ok {
+		goName = d.name
+	} else {
+		goName = r.gs.goStructName(e, r.compressPaths, r.genFakeRoot)
+	}
+	if d, ok := r.protoDirs[e.Path()]; ok {
+		protoName = d.name
+	} else {
+		protoName = r.ps.protoMsgName(e, r.compressPaths)
+	}
+
+	pkg := r.pargs.basePackageName
+	if p := r.ps.protobufPackage(e, r.compressPaths); p != "" {
+		pkg = fmt.Sprintf("%s.%s", pkg, p)
+	}
+	return []string{goName, fmt.Sprintf("%s.%s", pkg, protoName)}
+}
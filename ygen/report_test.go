@@ -0,0 +1,318 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+func TestGenerateReport(t *testing.T) {
+	inFiles := []string{
+		filepath.Join(TestRoot, "testdata/report/openconfig-report-target.yang"),
+		filepath.Join(TestRoot, "testdata/report/openconfig-report-deviations.yang"),
+	}
+
+	// Nodes whose generated types do not depend on path compression.
+	motd := &ReportNode{
+		Path:         "/openconfig-report-target/system/config/motd",
+		Module:       "openconfig-report-deviations",
+		Modification: "augment",
+		Go:           &ReportEntity{After: "string"},
+		Proto:        &ReportEntity{After: "ywrapper.StringValue"},
+	}
+	timezone := &ReportNode{
+		Path:         "/openconfig-report-target/system/config/timezone",
+		Module:       "openconfig-report-deviations",
+		Modification: "add",
+		Properties:   []string{"default"},
+		Go:           &ReportEntity{Before: "string", After: "string"},
+		Proto:        &ReportEntity{Before: "ywrapper.StringValue", After: "ywrapper.StringValue"},
+	}
+	bootTime := &ReportNode{
+		Path:         "/openconfig-report-target/system/state/boot-time",
+		Module:       "openconfig-report-deviations",
+		Modification: "replace",
+		Properties:   []string{"type"},
+		Go:           &ReportEntity{Before: "uint32", After: "uint64"},
+		Proto:        &ReportEntity{Before: "ywrapper.UintValue", After: "ywrapper.UintValue"},
+	}
+
+	tests := []struct {
+		name             string
+		inFiles          []string
+		inConfig         GeneratorConfig
+		want             *GenerationReport
+		wantErrSubstring string
+	}{{
+		name:     "uncompressed",
+		inFiles:  inFiles,
+		inConfig: GeneratorConfig{ApplyDeviations: true},
+		want: &GenerationReport{Nodes: []*ReportNode{motd, timezone, {
+			Path:         "/openconfig-report-target/system/dns",
+			Module:       "openconfig-report-deviations",
+			Modification: "not-supported",
+			Go:           &ReportEntity{Before: "OpenconfigReportTarget_System_Dns"},
+			Proto:        &ReportEntity{Before: "openconfig.openconfig_report_target.system.Dns"},
+		}, {
+			Path:         "/openconfig-report-target/system/ntp",
+			Module:       "openconfig-report-deviations",
+			Modification: "augment",
+			Go:           &ReportEntity{After: "OpenconfigReportTarget_System_Ntp"},
+			Proto:        &ReportEntity{After: "openconfig.openconfig_report_target.system.Ntp"},
+		}, bootTime}},
+	}, {
+		name:     "compressed",
+		inFiles:  inFiles,
+		inConfig: GeneratorConfig{CompressOCPaths: true, ApplyDeviations: true},
+		want: &GenerationReport{Nodes: []*ReportNode{motd, timezone, {
+			// The dns container is not mapped to a struct when paths
+			// are compressed.
+			Path:         "/openconfig-report-target/system/dns",
+			Module:       "openconfig-report-deviations",
+			Modification: "not-supported",
+		}, {
+			Path:         "/openconfig-report-target/system/ntp",
+			Module:       "openconfig-report-deviations",
+			Modification: "augment",
+			Go:           &ReportEntity{After: "System_Ntp"},
+			Proto:        &ReportEntity{After: "openconfig.system.Ntp"},
+		}, bootTime}},
+	}, {
+		name:    "deviations not applied",
+		inFiles: inFiles,
+		want: &GenerationReport{Nodes: []*ReportNode{motd, {
+			Path:         "/openconfig-report-target/system/ntp",
+			Module:       "openconfig-report-deviations",
+			Modification: "augment",
+			Go:           &ReportEntity{After: "OpenconfigReportTarget_System_Ntp"},
+			Proto:        &ReportEntity{After: "openconfig.openconfig_report_target.system.Ntp"},
+		}}},
+	}, {
+		name:     "no modifications",
+		inFiles:  inFiles[:1],
+		inConfig: GeneratorConfig{ApplyDeviations: true},
+		want:     &GenerationReport{Nodes: []*ReportNode{}},
+	}, {
+		name: "deviation of missing node",
+		inFiles: []string{
+			inFiles[0],
+			filepath.Join(TestRoot, "testdata/report/openconfig-report-bad-deviation.yang"),
+		},
+		inConfig: GeneratorConfig{ApplyDeviations: true},
+		want: &GenerationReport{
+			Nodes: []*ReportNode{},
+			UnresolvedDeviations: []*UnresolvedDeviation{{
+				Module: "openconfig-report-bad-deviation",
+				Target: "/t:system/t:ntp",
+			}},
+		},
+	}}
+
+	for _, tt := range tests {
+		cg := NewYANGCodeGenerator(&tt.inConfig)
+		got, err := cg.GenerateReport(tt.inFiles, nil)
+		if err != nil {
+			if tt.wantErrSubstring == "" || !strings.Contains(err.Error(), tt.wantErrSubstring) {
+				t.Errorf("%s: GenerateReport(%v, nil): got unexpected error: %v, want error containing: %q", tt.name, tt.inFiles, err, tt.wantErrSubstring)
+			}
+			continue
+		}
+		if tt.wantErrSubstring != "" {
+			t.Errorf("%s: GenerateReport(%v, nil): did not get expected error, want: %q", tt.name, tt.inFiles, tt.wantErrSubstring)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: GenerateReport(%v, nil): did not get expected report, diff(-got,+want):\n%s", tt.name, tt.inFiles, diff)
+		}
+	}
+}
+
+func TestApplyDeviate(t *testing.T) {
+	newLeaf := func() *yang.Entry {
+		p := &yang.Entry{Name: "container", Kind: yang.DirectoryEntry, Dir: map[string]*yang.Entry{}}
+		p.Dir["leaf"] = &yang.Entry{
+			Name:   "leaf",
+			Kind:   yang.LeafEntry,
+			Parent: p,
+			Type:   &yang.YangType{Kind: yang.Ystring},
+		}
+		return p.Dir["leaf"]
+	}
+
+	tests := []struct {
+		name    string
+		inEntry *yang.Entry
+		inDev   *yang.Deviate
+		// wantDeleted indicates that the entry is expected to be removed
+		// from its parent.
+		wantDeleted    bool
+		wantProperties []string
+		wantDefault    string
+		wantConfig     yang.TriState
+		wantType       yang.TypeKind
+		wantErr        bool
+	}{{
+		name:        "not-supported",
+		inEntry:     newLeaf(),
+		inDev:       &yang.Deviate{Name: "not-supported"},
+		wantDeleted: true,
+		wantType:    yang.Ystring,
+	}, {
+		name:    "not-supported without parent",
+		inEntry: &yang.Entry{Name: "module"},
+		inDev:   &yang.Deviate{Name: "not-supported"},
+		wantErr: true,
+	}, {
+		name:    "replace type and config",
+		inEntry: newLeaf(),
+		inDev: &yang.Deviate{
+			Name:   "replace",
+			Type:   &yang.Type{Name: "uint8", YangType: &yang.YangType{Kind: yang.Yuint8}},
+			Config: &yang.Value{Name: "false"},
+		},
+		wantProperties: []string{"type", "config"},
+		wantConfig:     yang.TSFalse,
+		wantType:       yang.Yuint8,
+	}, {
+		name:    "add default and units",
+		inEntry: newLeaf(),
+		inDev: &yang.Deviate{
+			Name:    "add",
+			Default: &yang.Value{Name: "foo"},
+			Units:   &yang.Value{Name: "seconds"},
+		},
+		wantProperties: []string{"default", "units"},
+		wantDefault:    "foo",
+		wantType:       yang.Ystring,
+	}, {
+		name: "delete default",
+		inEntry: func() *yang.Entry {
+			e := newLeaf()
+			e.Default = "foo"
+			return e
+		}(),
+		inDev: &yang.Deviate{
+			Name:    "delete",
+			Default: &yang.Value{Name: "foo"},
+		},
+		wantProperties: []string{"default"},
+		wantType:       yang.Ystring,
+	}, {
+		name:    "add type",
+		inEntry: newLeaf(),
+		inDev: &yang.Deviate{
+			Name: "add",
+			Type: &yang.Type{Name: "uint8", YangType: &yang.YangType{Kind: yang.Yuint8}},
+		},
+		wantErr: true,
+	}, {
+		name:    "invalid config",
+		inEntry: newLeaf(),
+		inDev: &yang.Deviate{
+			Name:   "replace",
+			Config: &yang.Value{Name: "fish"},
+		},
+		wantErr: true,
+	}, {
+		name:    "unknown argument",
+		inEntry: newLeaf(),
+		inDev:   &yang.Deviate{Name: "remove"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		got, err := applyDeviate(tt.inEntry, tt.inDev)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: applyDeviate(%v, %v): got unexpected error: %v, wantErr: %v", tt.name, tt.inEntry, tt.inDev, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if got.kind != tt.inDev.Name {
+			t.Errorf("%s: applyDeviate(%v, %v): did not get expected kind, got: %s, want: %s", tt.name, tt.inEntry, tt.inDev, got.kind, tt.inDev.Name)
+		}
+		if diff := pretty.Compare(got.properties, tt.wantProperties); diff != "" {
+			t.Errorf("%s: applyDeviate(%v, %v): did not get expected properties, diff(-got,+want):\n%s", tt.name, tt.inEntry, tt.inDev, diff)
+		}
+
+		e := tt.inEntry
+		if _, ok := e.Parent.Dir[e.Name]; ok == tt.wantDeleted {
+			t.Errorf("%s: applyDeviate(%v, %v): entry was not deleted as expected, in parent: %v, wantDeleted: %v", tt.name, tt.inEntry, tt.inDev, ok, tt.wantDeleted)
+		}
+		if e.Default != tt.wantDefault {
+			t.Errorf("%s: applyDeviate(%v, %v): did not get expected default, got: %s, want: %s", tt.name, tt.inEntry, tt.inDev, e.Default, tt.wantDefault)
+		}
+		if e.Config != tt.wantConfig {
+			t.Errorf("%s: applyDeviate(%v, %v): did not get expected config, got: %v, want: %v", tt.name, tt.inEntry, tt.inDev, e.Config, tt.wantConfig)
+		}
+		if e.Type.Kind != tt.wantType {
+			t.Errorf("%s: applyDeviate(%v, %v): did not get expected type, got: %v, want: %v", tt.name, tt.inEntry, tt.inDev, e.Type.Kind, tt.wantType)
+		}
+	}
+}
+
+func TestMappedDefinitionsDeviations(t *testing.T) {
+	target := filepath.Join(TestRoot, "testdata/report/openconfig-report-target.yang")
+	deviations := filepath.Join(TestRoot, "testdata/report/openconfig-report-deviations.yang")
+	badDeviation := filepath.Join(TestRoot, "testdata/report/openconfig-report-bad-deviation.yang")
+	const dns = "/openconfig-report-target/system/dns"
+
+	tests := []struct {
+		name           string
+		inFiles        []string
+		inConfig       GeneratorConfig
+		wantDNS        bool
+		wantUnresolved int
+	}{{
+		name:    "deviations not applied",
+		inFiles: []string{target, deviations},
+		wantDNS: true,
+	}, {
+		name:     "deviations applied",
+		inFiles:  []string{target, deviations},
+		inConfig: GeneratorConfig{ApplyDeviations: true},
+	}, {
+		name:    "unresolved deviation not applied",
+		inFiles: []string{target, badDeviation},
+		wantDNS: true,
+	}, {
+		name:           "unresolved deviation applied",
+		inFiles:        []string{target, badDeviation},
+		inConfig:       GeneratorConfig{ApplyDeviations: true},
+		wantDNS:        true,
+		wantUnresolved: 1,
+	}}
+
+	for _, tt := range tests {
+		mdef, errs := mappedDefinitions(tt.inFiles, nil, tt.inConfig)
+		if errs != nil {
+			t.Errorf("%s: mappedDefinitions: got unexpected errors: %v", tt.name, errs)
+			continue
+		}
+		if _, ok := mdef.directoryEntries[dns]; ok != tt.wantDNS {
+			t.Errorf("%s: mappedDefinitions: got directory %s: %v, want: %v", tt.name, dns, ok, tt.wantDNS)
+		}
+		if got := len(mdef.unresolvedDeviations); got != tt.wantUnresolved {
+			t.Errorf("%s: mappedDefinitions: got %d unresolved deviations, want: %d", tt.name, got, tt.wantUnresolved)
+		}
+	}
+}
@@ -16,24 +16,59 @@ package ygen
 
 import (
 	"bytes"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/openconfig/goyang/pkg/yang"
 )
 
 // children returns all child elements of a directory element e that are not
-// RPC entries.
+// RPC or notification entries.
 func children(e *yang.Entry) []*yang.Entry {
 	var entries []*yang.Entry
 
 	for _, e := range e.Dir {
-		if e.RPC == nil {
+		if !isRPC(e) && !isNotification(e) {
 			entries = append(entries, e)
 		}
 	}
 	return entries
 }
 
+// isRPC returns true if the entry is a YANG rpc or action statement. Since
+// goyang only populates the RPC field of the entry when an input or output
+// statement is present, the kind of the node is also checked.
+func isRPC(e *yang.Entry) bool {
+	if e.RPC != nil {
+		return true
+	}
+	if e.Node == nil {
+		return false
+	}
+	switch e.Node.Kind() {
+	case "rpc", "action":
+		return true
+	}
+	return false
+}
+
+// isNotification returns true if the entry is a YANG notification statement.
+func isNotification(e *yang.Entry) bool {
+	return e.Kind == yang.NotificationEntry
+}
+
+// isOperationBody returns true if the entry is the input or output statement
+// of a YANG rpc or action, or a notification. Such entries are not part of the
+// data tree, but are mapped in the same way as containers in generated code.
+func isOperationBody(e *yang.Entry) bool {
+	switch e.Kind {
+	case yang.InputEntry, yang.OutputEntry, yang.NotificationEntry:
+		return true
+	}
+	return false
+}
+
 // hasOnlyChild returns true if the directory passed to it only has a single
 // element below it.
 func hasOnlyChild(e *yang.Entry) bool {
@@ -75,6 +110,11 @@ func isAnydata(e *yang.Entry) bool {
 	return e.Kind == yang.AnyDataEntry
 }
 
+// isAnyxml returns true if the entry is an Anyxml node.
+func isAnyxml(e *yang.Entry) bool {
+	return e.Kind == yang.AnyXMLEntry
+}
+
 // isOCCompressedValidElement returns true if the element would be output in the
 // compressed YANG code.
 func isOCCompressedValidElement(e *yang.Entry) bool {
@@ -299,6 +339,17 @@ func stringKeys(m map[string]interface{}) []string {
 	return ss
 }
 
+// sortedEntryKeys returns the keys of the supplied map of yang.Entry pointers
+// as a sorted slice of strings.
+func sortedEntryKeys(m map[string]*yang.Entry) []string {
+	ss := []string{}
+	for k := range m {
+		ss = append(ss, k)
+	}
+	sort.Strings(ss)
+	return ss
+}
+
 // listKeyFieldsMap returns a map[string]bool where the keys of the map
 // are the fields that are the keys of the list described by the supplied
 // yang.Entry. In the case the yang.Entry does not described a keyed list,
@@ -316,3 +367,38 @@ func listKeyFieldsMap(e *yang.Entry) map[string]bool {
 func entrySchemaPath(e *yang.Entry) string {
 	return slicePathToString(append([]string{""}, traverseElementSchemaPath(e)[1:]...))
 }
+
+// metadataModuleName is the name of the module that defines the RFC7952
+// annotation extension.
+const metadataModuleName = "ietf-yang-metadata"
+
+// findAnnotations returns the names of the RFC7952 metadata annotations that
+// are defined by the modules supplied, in the form module:name, sorted
+// alphabetically. Annotations are defined by top-level statements that use
+// the annotation extension of the ietf-yang-metadata module.
+func findAnnotations(modules []*yang.Entry) []string {
+	names := map[string]bool{}
+	for _, m := range modules {
+		mod, ok := m.Node.(*yang.Module)
+		if !ok {
+			continue
+		}
+		for _, ext := range m.Exts {
+			p := strings.Index(ext.Keyword, ":")
+			if p == -1 || ext.Keyword[p+1:] != "annotation" {
+				continue
+			}
+			if em := yang.FindModuleByPrefix(mod, ext.Keyword[:p]); em == nil || em.Name != metadataModuleName {
+				continue
+			}
+			names[fmt.Sprintf("%s:%s", m.Name, ext.Argument)] = true
+		}
+	}
+
+	var out []string
+	for n := range names {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -15,6 +15,7 @@
 package ygen
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
@@ -241,6 +242,32 @@ func TestYangChildren(t *testing.T) {
 			"state":  true,
 			"rpc":    false,
 		},
+	}, {
+		name: "test container with rpc and action entries without input or output",
+		inEntry: &yang.Entry{
+			Dir: map[string]*yang.Entry{
+				"rpc":    {Name: "rpc", Node: &yang.RPC{Name: "rpc"}},
+				"action": {Name: "action", Node: &yang.Action{Name: "action"}},
+				"config": {Name: "config", Node: &yang.Container{Name: "config"}},
+			},
+		},
+		wantChildNames: map[string]bool{
+			"config": true,
+			"rpc":    false,
+			"action": false,
+		},
+	}, {
+		name: "test container with a notification entry",
+		inEntry: &yang.Entry{
+			Dir: map[string]*yang.Entry{
+				"notification": {Name: "notification", Kind: yang.NotificationEntry},
+				"config":       {Name: "config", Kind: yang.DirectoryEntry},
+			},
+		},
+		wantChildNames: map[string]bool{
+			"config":       true,
+			"notification": false,
+		},
 	}}
 
 	for _, tt := range tests {
@@ -580,3 +607,39 @@ func TestResolveLeafrefTargetType(t *testing.T) {
 		}
 	}
 }
+
+func TestFindAnnotations(t *testing.T) {
+	mod := &yang.Module{
+		Name:   "mod",
+		Prefix: &yang.Value{Name: "m"},
+		Import: []*yang.Import{{
+			Name:   "ietf-yang-metadata",
+			Prefix: &yang.Value{Name: "md"},
+			Module: &yang.Module{Name: "ietf-yang-metadata"},
+		}, {
+			Name:   "other",
+			Prefix: &yang.Value{Name: "o"},
+			Module: &yang.Module{Name: "other"},
+		}},
+	}
+	in := []*yang.Entry{{
+		Name: "mod",
+		Node: mod,
+		Exts: []*yang.Statement{
+			{Keyword: "md:annotation", Argument: "b"},
+			{Keyword: "md:annotation", Argument: "a"},
+			{Keyword: "o:annotation", Argument: "not-metadata"},
+			{Keyword: "md:other", Argument: "not-annotation"},
+			{Keyword: "x:annotation", Argument: "unknown-prefix"},
+		},
+	}, {
+		Name: "no-module",
+		Node: &yang.Container{Name: "no-module"},
+		Exts: []*yang.Statement{{Keyword: "md:annotation", Argument: "c"}},
+	}}
+
+	want := []string{"mod:a", "mod:b"}
+	if got := findAnnotations(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("findAnnotations(%v): did not get expected annotations, got: %v, want: %v", in, got, want)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	// maxFractionDigits is the maximum value of the fraction-digits
+	// statement of a YANG decimal64 type, as per RFC6020 Section 9.3.4.
+	maxFractionDigits = 18
+)
+
+// Decimal64 is the type used for fields that have a YANG type of decimal64.
+// The value is stored as an integer number of Digits, and a Precision which
+// is the number of digits that follow the decimal point, such that the value
+// represented is Digits * 10^-Precision. Using this representation, rather
+// than a float64, ensures that values can be stored without loss of
+// precision. Values that are generated from a schema use the fraction-digits
+// of the YANG type as their Precision.
+type Decimal64 struct {
+	// Digits is the value of the decimal64 number, with the decimal
+	// point removed.
+	Digits int64
+	// Precision is the number of digits of Digits that follow the decimal
+	// point.
+	Precision uint8
+}
+
+// IsYANGLeafValue is a marker method that indicates that Decimal64 is a
+// struct that represents the value of a single YANG leaf, rather than a YANG
+// container or list member.
+func (Decimal64) IsYANGLeafValue() {}
+
+// ParseDecimal64 parses the decimal number in s into a Decimal64 with the
+// specified precision. An error is returned if s is not a valid decimal number,
+// if it has more significant fraction digits than precision, or if it cannot
+// be represented within the range of a decimal64 value.
+func ParseDecimal64(s string, precision uint8) (Decimal64, error) {
+	if precision > maxFractionDigits {
+		return Decimal64{}, fmt.Errorf("precision %d is greater than the maximum of %d", precision, maxFractionDigits)
+	}
+
+	num := s
+	var sign string
+	if len(num) > 0 && (num[0] == '-' || num[0] == '+') {
+		sign, num = num[:1], num[1:]
+	}
+
+	// As per RFC6020 Section 9.3.1, there must be at least one digit before
+	// the decimal point, and at least one digit after it where it is present.
+	intPart, fracPart := num, ""
+	i := strings.Index(num, ".")
+	if i >= 0 {
+		intPart, fracPart = num[:i], num[i+1:]
+	}
+	if intPart == "" || (i >= 0 && fracPart == "") {
+		return Decimal64{}, fmt.Errorf("%q is not a valid decimal number", s)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		if strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+			return Decimal64{}, fmt.Errorf("%q is not a valid decimal number", s)
+		}
+	}
+
+	// Trailing zeros in the fraction do not change the value, so can be
+	// dropped where there are more fraction digits than the precision.
+	if len(fracPart) > int(precision) {
+		if strings.TrimRight(fracPart[precision:], "0") != "" {
+			return Decimal64{}, fmt.Errorf("%q has more than %d fraction digits", s, precision)
+		}
+		fracPart = fracPart[:precision]
+	}
+	fracPart += strings.Repeat("0", int(precision)-len(fracPart))
+
+	d, err := strconv.ParseInt(sign+intPart+fracPart, 10, 64)
+	if err != nil {
+		return Decimal64{}, fmt.Errorf("%q cannot be represented as a decimal64 with %d fraction digits", s, precision)
+	}
+	return Decimal64{Digits: d, Precision: precision}, nil
+}
+
+// String returns the canonical string representation of the Decimal64, as
+// defined by RFC6020 Section 9.3.2. There is always at least one digit before
+// and after the decimal point, and no other leading or trailing zeros.
+func (d Decimal64) String() string {
+	abs := uint64(d.Digits)
+	var sign string
+	if d.Digits < 0 {
+		sign = "-"
+		abs = uint64(-(d.Digits + 1)) + 1
+	}
+
+	s := strconv.FormatUint(abs, 10)
+	if p := int(d.Precision); len(s) <= p {
+		s = strings.Repeat("0", p-len(s)+1) + s
+	}
+
+	intPart, fracPart := s[:len(s)-int(d.Precision)], strings.TrimRight(s[len(s)-int(d.Precision):], "0")
+	if fracPart == "" {
+		fracPart = "0"
+	}
+	return fmt.Sprintf("%s%s.%s", sign, intPart, fracPart)
+}
+
+// Float64 returns the closest float64 value to the Decimal64.
+func (d Decimal64) Float64() float64 {
+	// The canonical string representation is always a valid float.
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// MarshalJSON implements the json.Marshaler interface, such that a Decimal64
+// is marshalled to a JSON string, as specified by RFC7951 Section 6.1.
+func (d Decimal64) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// TypedValue returns the gNMI TypedValue that corresponds to the Decimal64.
+// Since the gNMI Decimal64 message stores the digits as an unsigned integer,
+// negative values are encoded as the closest float64 value, using the FloatVal
+// field.
+func (d Decimal64) TypedValue() (*gnmipb.TypedValue, error) {
+	if d.Digits < 0 {
+		return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_FloatVal{float32(d.Float64())}}, nil
+	}
+	return &gnmipb.TypedValue{
+		Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: uint64(d.Digits), Precision: uint32(d.Precision)}},
+	}, nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestParseDecimal64(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		inPrecision uint8
+		want        Decimal64
+		wantErr     bool
+	}{{
+		name:        "simple decimal",
+		in:          "42.42",
+		inPrecision: 2,
+		want:        Decimal64{Digits: 4242, Precision: 2},
+	}, {
+		name:        "negative decimal",
+		in:          "-1.5",
+		inPrecision: 1,
+		want:        Decimal64{Digits: -15, Precision: 1},
+	}, {
+		name:        "explicit positive sign",
+		in:          "+1.5",
+		inPrecision: 1,
+		want:        Decimal64{Digits: 15, Precision: 1},
+	}, {
+		name:        "fewer fraction digits than precision",
+		in:          "0.1",
+		inPrecision: 4,
+		want:        Decimal64{Digits: 1000, Precision: 4},
+	}, {
+		name:        "integer value",
+		in:          "7",
+		inPrecision: 2,
+		want:        Decimal64{Digits: 700, Precision: 2},
+	}, {
+		name:        "no integer digits",
+		in:          ".5",
+		inPrecision: 1,
+		wantErr:     true,
+	}, {
+		name:        "no fraction digits",
+		in:          "5.",
+		inPrecision: 1,
+		wantErr:     true,
+	}, {
+		name:        "only a sign",
+		in:          "-",
+		inPrecision: 1,
+		wantErr:     true,
+	}, {
+		name:        "trailing zeros beyond precision",
+		in:          "1.2000",
+		inPrecision: 1,
+		want:        Decimal64{Digits: 12, Precision: 1},
+	}, {
+		name:        "largest value",
+		in:          "922337203685477580.7",
+		inPrecision: 1,
+		want:        Decimal64{Digits: math.MaxInt64, Precision: 1},
+	}, {
+		name:        "smallest value",
+		in:          "-922337203685477580.8",
+		inPrecision: 1,
+		want:        Decimal64{Digits: math.MinInt64, Precision: 1},
+	}, {
+		name:        "too many fraction digits",
+		in:          "1.23",
+		inPrecision: 1,
+		wantErr:     true,
+	}, {
+		name:        "overflow",
+		in:          "922337203685477580.8",
+		inPrecision: 1,
+		wantErr:     true,
+	}, {
+		name:        "invalid characters",
+		in:          "1.2e3",
+		inPrecision: 2,
+		wantErr:     true,
+	}, {
+		name:        "empty string",
+		in:          "",
+		inPrecision: 2,
+		wantErr:     true,
+	}, {
+		name:        "only a decimal point",
+		in:          "-.",
+		inPrecision: 2,
+		wantErr:     true,
+	}, {
+		name:        "precision too large",
+		in:          "1.0",
+		inPrecision: 19,
+		wantErr:     true,
+	}}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal64(tt.in, tt.inPrecision)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseDecimal64(%q, %d): got unexpected error: %v, wantErr: %v", tt.name, tt.in, tt.inPrecision, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%s: ParseDecimal64(%q, %d): did not get expected value, got: %#v, want: %#v", tt.name, tt.in, tt.inPrecision, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal64String(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Decimal64
+		want string
+	}{{
+		name: "simple value",
+		in:   Decimal64{Digits: 4242, Precision: 2},
+		want: "42.42",
+	}, {
+		name: "trailing zeros removed",
+		in:   Decimal64{Digits: 15000, Precision: 4},
+		want: "1.5",
+	}, {
+		name: "leading zero added",
+		in:   Decimal64{Digits: 5, Precision: 3},
+		want: "0.005",
+	}, {
+		name: "zero",
+		in:   Decimal64{Digits: 0, Precision: 2},
+		want: "0.0",
+	}, {
+		name: "zero precision",
+		in:   Decimal64{Digits: 42, Precision: 0},
+		want: "42.0",
+	}, {
+		name: "negative value",
+		in:   Decimal64{Digits: -25, Precision: 1},
+		want: "-2.5",
+	}, {
+		name: "negative value less than one",
+		in:   Decimal64{Digits: -25, Precision: 3},
+		want: "-0.025",
+	}, {
+		name: "smallest value",
+		in:   Decimal64{Digits: math.MinInt64, Precision: 18},
+		want: "-9.223372036854775808",
+	}}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("%s: (%#v).String(): did not get expected value, got: %s, want: %s", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal64Float64(t *testing.T) {
+	tests := []struct {
+		in   Decimal64
+		want float64
+	}{
+		{Decimal64{Digits: 4242, Precision: 2}, 42.42},
+		{Decimal64{Digits: -15, Precision: 1}, -1.5},
+		{Decimal64{Digits: 0, Precision: 0}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.Float64(); got != tt.want {
+			t.Errorf("(%#v).Float64(): did not get expected value, got: %v, want: %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal64MarshalJSON(t *testing.T) {
+	got, err := Decimal64{Digits: -4242, Precision: 3}.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON(): got unexpected error: %v", err)
+	}
+	if want := `"-4.242"`; string(got) != want {
+		t.Errorf("MarshalJSON(): did not get expected JSON, got: %s, want: %s", got, want)
+	}
+}
+
+func TestDecimal64TypedValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      Decimal64
+		want    *gnmipb.TypedValue
+		wantErr bool
+	}{{
+		name: "positive value",
+		in:   Decimal64{Digits: 4242, Precision: 2},
+		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 4242, Precision: 2}}},
+	}, {
+		name: "negative value",
+		in:   Decimal64{Digits: -4242, Precision: 2},
+		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_FloatVal{-42.42}},
+	}}
+
+	for _, tt := range tests {
+		got, err := tt.in.TypedValue()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: (%#v).TypedValue(): got unexpected error: %v, wantErr: %v", tt.name, tt.in, err, tt.wantErr)
+			continue
+		}
+		if !proto.Equal(got, tt.want) {
+			t.Errorf("%s: (%#v).TypedValue(): did not get expected value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
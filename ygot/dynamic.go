@@ -0,0 +1,643 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+)
+
+// DynamicNode is a node of a data tree whose structure is described by a
+// yang.Entry that is loaded at runtime, rather than by a generated GoStruct.
+// A DynamicNode represents the root of a data tree, a YANG container, or a
+// member of a YANG list, and stores the values of its children keyed by their
+// name within the schema:
+//   - leaves are stored as a Go value corresponding to their YANG type;
+//   - leaf-lists are stored as a []interface{} of such values;
+//   - containers are stored as a *DynamicNode;
+//   - lists are stored as a []*DynamicNode, in the order in which their
+//     members were added.
+//
+// The value of a leaf is a string for the string, enumeration (the name of the
+// enumerated value), identityref (the name of the identity, without a module
+// prefix) and bits (a space-separated list of bit names) types. Integer types
+// are stored as the Go integer type of the same size and signedness, decimal64
+// as Decimal64, instance-identifier as InstanceIdentifier, boolean as bool,
+// empty as a bool that is true when the leaf is present, and binary as []byte.
+// A leaf with a union type stores a value of one of its member types, and a
+// leafref a value of the type of the leaf that it refers to.
+//
+// DynamicNode implements GoStruct, such that a data tree can be rendered using
+// ConstructIETFJSON, ConstructInternalJSON and TogNMINotifications, and be
+// validated and unmarshalled using the ytypes library, in the same way as
+// generated code.
+type DynamicNode struct {
+	schema *yang.Entry
+	values map[string]interface{}
+}
+
+// NewDynamicNode returns an empty DynamicNode described by the supplied
+// schema, which must be a container or a list.
+func NewDynamicNode(schema *yang.Entry) (*DynamicNode, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("nil schema supplied for DynamicNode")
+	}
+	if !schema.IsDir() || util.IsChoiceOrCase(schema) {
+		return nil, fmt.Errorf("schema %s is not a container or list", schema.Name)
+	}
+	return &DynamicNode{schema: schema, values: map[string]interface{}{}}, nil
+}
+
+// IsYANGGoStruct ensures that DynamicNode implements the GoStruct interface.
+func (*DynamicNode) IsYANGGoStruct() {}
+
+// Schema returns the yang.Entry describing the node.
+func (n *DynamicNode) Schema() *yang.Entry {
+	return n.schema
+}
+
+// ChildSchema returns the yang.Entry of the child of the node with the
+// specified name, which may be prefixed with the name of a module. Children
+// that are within choice and case statements are returned as though they were
+// direct children of the node, since such statements do not appear in the data
+// tree.
+func (n *DynamicNode) ChildSchema(name string) (*yang.Entry, error) {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	if e := findDataChild(n.schema, name); e != nil {
+		return e, nil
+	}
+	return nil, fmt.Errorf("%s is not a child of schema %s", name, n.schema.Name)
+}
+
+// findDataChild returns the child of schema with the specified name, searching
+// within any choice and case statements that are children of schema. It returns
+// nil if no such child exists.
+func findDataChild(schema *yang.Entry, name string) *yang.Entry {
+	if e, ok := schema.Dir[name]; ok && !util.IsChoiceOrCase(e) {
+		return e
+	}
+	for _, e := range schema.Dir {
+		if util.IsChoiceOrCase(e) {
+			if ch := findDataChild(e, name); ch != nil {
+				return ch
+			}
+		}
+	}
+	return nil
+}
+
+// Names returns the sorted names of the children of the node that are set.
+func (n *DynamicNode) Names() []string {
+	var names []string
+	for k := range n.values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the value of the child of the node with the specified name, or
+// nil if it is not set.
+func (n *DynamicNode) Get(name string) interface{} {
+	cs, err := n.ChildSchema(name)
+	if err != nil {
+		return nil
+	}
+	return n.values[cs.Name]
+}
+
+// Delete removes the child of the node with the specified name.
+func (n *DynamicNode) Delete(name string) {
+	if cs, err := n.ChildSchema(name); err == nil {
+		delete(n.values, cs.Name)
+	}
+}
+
+// Set sets the value of the leaf or leaf-list child of the node with the
+// specified name to v. The value of a leaf-list may be supplied as any slice,
+// and is stored as a []interface{}. Setting a nil value removes the child. The
+// type of v is not checked against the schema; ytypes.Validate should be used
+// to validate the node once it has been populated.
+func (n *DynamicNode) Set(name string, v interface{}) error {
+	cs, err := n.ChildSchema(name)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		delete(n.values, cs.Name)
+		return nil
+	}
+
+	switch {
+	case cs.IsLeaf():
+		switch v.(type) {
+		case *DynamicNode, []*DynamicNode, []interface{}:
+			return fmt.Errorf("invalid value %v (%T) for leaf %s", v, v, cs.Name)
+		}
+	case cs.IsLeafList():
+		if _, ok := v.([]interface{}); !ok {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice {
+				return fmt.Errorf("invalid value %v (%T) for leaf-list %s, must be a slice", v, v, cs.Name)
+			}
+			l := []interface{}{}
+			for i := 0; i < rv.Len(); i++ {
+				l = append(l, rv.Index(i).Interface())
+			}
+			v = l
+		}
+	default:
+		return fmt.Errorf("%s is not a leaf or leaf-list", cs.Name)
+	}
+	n.values[cs.Name] = v
+	return nil
+}
+
+// Container returns the container child of the node with the specified name,
+// creating it if it does not exist.
+func (n *DynamicNode) Container(name string) (*DynamicNode, error) {
+	cs, err := n.ChildSchema(name)
+	if err != nil {
+		return nil, err
+	}
+	if !cs.IsContainer() {
+		return nil, fmt.Errorf("%s is not a container", cs.Name)
+	}
+	if c, ok := n.values[cs.Name].(*DynamicNode); ok {
+		return c, nil
+	}
+	c, err := NewDynamicNode(cs)
+	if err != nil {
+		return nil, err
+	}
+	n.values[cs.Name] = c
+	return c, nil
+}
+
+// ListEntries returns the members of the list child of the node with the
+// specified name.
+func (n *DynamicNode) ListEntries(name string) []*DynamicNode {
+	l, _ := n.Get(name).([]*DynamicNode)
+	return l
+}
+
+// ListEntry returns the member of the list child of the node with the
+// specified name whose keys have the supplied values, which are keyed by the
+// name of the key leaf. It returns nil if no such member exists.
+func (n *DynamicNode) ListEntry(name string, keys map[string]interface{}) *DynamicNode {
+	for _, e := range n.ListEntries(name) {
+		if e.hasKeys(keys) {
+			return e
+		}
+	}
+	return nil
+}
+
+// NewListEntry adds a member to the list child of the node with the specified
+// name, and returns it. The keys of the member are supplied keyed by the name
+// of the key leaf, and must specify a value for each key of the list. No keys
+// may be specified for a list that does not have keys. An error is returned if
+// a member with the same keys already exists.
+func (n *DynamicNode) NewListEntry(name string, keys map[string]interface{}) (*DynamicNode, error) {
+	cs, err := n.ChildSchema(name)
+	if err != nil {
+		return nil, err
+	}
+	if !cs.IsList() {
+		return nil, fmt.Errorf("%s is not a list", cs.Name)
+	}
+
+	kn := strings.Fields(cs.Key)
+	if len(keys) != len(kn) {
+		return nil, fmt.Errorf("list %s has keys %v, got %d key values", cs.Name, kn, len(keys))
+	}
+	e, err := NewDynamicNode(cs)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range kn {
+		v, ok := keys[k]
+		if !ok {
+			return nil, fmt.Errorf("missing value for key %s of list %s", k, cs.Name)
+		}
+		if err := e.Set(k, v); err != nil {
+			return nil, err
+		}
+	}
+	if len(kn) != 0 && n.ListEntry(cs.Name, keys) != nil {
+		return nil, fmt.Errorf("list %s already contains a member with keys %v", cs.Name, keys)
+	}
+
+	n.values[cs.Name] = append(n.ListEntries(cs.Name), e)
+	return e, nil
+}
+
+// hasKeys reports whether the node has the supplied key values.
+func (n *DynamicNode) hasKeys(keys map[string]interface{}) bool {
+	for k, v := range keys {
+		if !reflect.DeepEqual(n.values[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ΛListKeyMap returns the values of the keys of a node that is a member of a
+// keyed list, keyed by the name of the key leaf. It implements the
+// KeyHelperGoStruct interface.
+func (n *DynamicNode) ΛListKeyMap() (map[string]interface{}, error) {
+	kn := strings.Fields(n.schema.Key)
+	if !n.schema.IsList() || len(kn) == 0 {
+		return nil, fmt.Errorf("%s is not a keyed list", n.schema.Name)
+	}
+	km := map[string]interface{}{}
+	for _, k := range kn {
+		v, ok := n.values[k]
+		if !ok {
+			return nil, fmt.Errorf("nil value for key %s of list %s", k, n.schema.Name)
+		}
+		km[k] = v
+	}
+	return km, nil
+}
+
+// entryModule returns the name of the module that instantiates the schema
+// node e within the data tree, as used to qualify the names of nodes in
+// RFC7951 JSON. It returns an empty string if the module cannot be
+// determined, such as where the schema was not parsed from YANG.
+func entryModule(e *yang.Entry) string {
+	root := e
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	if _, ok := root.Node.(*yang.Module); !ok {
+		return ""
+	}
+	m, err := e.InstantiatingModule()
+	if err != nil {
+		return ""
+	}
+	return m
+}
+
+// identityModule returns the name of the module that defines the identity
+// with the specified name that is a valid value of the type t, or an empty
+// string if there is no such identity.
+func identityModule(t *yang.YangType, name string) string {
+	switch t.Kind {
+	case yang.Yidentityref:
+		if t.IdentityBase == nil {
+			return ""
+		}
+		if id := t.IdentityBase.GetValue(name); id != nil {
+			m := yang.RootNode(id)
+			if m == nil {
+				return ""
+			}
+			if m.BelongsTo != nil {
+				return m.BelongsTo.Name
+			}
+			return m.Name
+		}
+	case yang.Yunion:
+		for _, ut := range t.Type {
+			if m := identityModule(ut, name); m != "" {
+				return m
+			}
+		}
+	}
+	return ""
+}
+
+// constructDynamicJSON is the equivalent of constructJSON for a DynamicNode.
+// The names of the children of the node are qualified with the name of the
+// module that instantiates them when RFC7951 JSON is output with module names
+// appended, and the module differs from parentMod.
+func constructDynamicJSON(n *DynamicNode, parentMod string, args jsonOutputConfig) (map[string]interface{}, error) {
+	var errs errlist.List
+	appmod := args.jType == RFC7951 && args.rfc7951Config != nil && args.rfc7951Config.AppendModuleName
+
+	jsonout := map[string]interface{}{}
+	for _, name := range n.Names() {
+		cs, err := n.ChildSchema(name)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		k, pmod := name, parentMod
+		if m := entryModule(cs); m != "" {
+			if appmod && m != parentMod {
+				k = fmt.Sprintf("%s:%s", m, name)
+			}
+			pmod = m
+		}
+
+		// Where metadata is output, the path of the child is tracked such
+		// that its annotations can be found.
+		cargs := args
+		var ann map[string]interface{}
+		if args.metadata() != nil {
+			cargs.path = args.path.Copy()
+			if err := cargs.path.AppendName(name); err != nil {
+				errs.Add(err)
+				continue
+			}
+			if !cs.IsList() {
+				if ann, err = args.annotations(cargs.path); err != nil {
+					errs.Add(err)
+					continue
+				}
+			}
+		}
+
+		var value interface{}
+		switch v := n.values[name].(type) {
+		case *DynamicNode:
+			c, err := constructDynamicJSON(v, pmod, cargs)
+			if err != nil {
+				errs.Add(err)
+				continue
+			}
+			if len(c) != 0 {
+				value = c
+			}
+		case []*DynamicNode:
+			value, err = constructDynamicListJSON(cs, v, pmod, cargs)
+		case []interface{}:
+			var l []interface{}
+			for _, e := range v {
+				ev, lerr := dynamicLeafJSON(cs, e, args)
+				if lerr != nil {
+					err = lerr
+					break
+				}
+				l = append(l, ev)
+			}
+			value = l
+		default:
+			value, err = dynamicLeafJSON(cs, v, args)
+		}
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		if value != nil {
+			jsonout[k] = value
+			addAnnotations(jsonout, k, value, ann, cs.IsLeafList())
+		}
+	}
+
+	if errs.Err() != nil {
+		return nil, errs.Err()
+	}
+	return jsonout, nil
+}
+
+// constructDynamicListJSON returns the JSON representation of the members l
+// of the list described by schema. As for generated code, keyed lists are
+// output as a JSON object, keyed by the values of the list keys, in internal
+// JSON, and as an array in all other cases.
+func constructDynamicListJSON(schema *yang.Entry, l []*DynamicNode, parentMod string, args jsonOutputConfig) (interface{}, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+
+	kn := strings.Fields(schema.Key)
+	if len(kn) == 0 {
+		// The members of unkeyed lists cannot be identified.
+		args.path = nil
+	}
+	if args.jType == Internal && len(kn) != 0 {
+		vals := map[string]interface{}{}
+		for _, e := range l {
+			var kp []string
+			for _, k := range kn {
+				kp = append(kp, fmt.Sprintf("%v", e.values[k]))
+			}
+			j, err := constructDynamicJSON(e, parentMod, args)
+			if err != nil {
+				return nil, err
+			}
+			vals[strings.Join(kp, " ")] = j
+		}
+		return vals, nil
+	}
+
+	vals := []interface{}{}
+	for _, e := range l {
+		eargs := args
+		var ann map[string]interface{}
+		if args.metadata() != nil {
+			var err error
+			if eargs.path, err = dynamicListEntryPath(e, args.path); err != nil {
+				return nil, err
+			}
+			if ann, err = args.annotations(eargs.path); err != nil {
+				return nil, err
+			}
+		}
+		j, err := constructDynamicJSON(e, parentMod, eargs)
+		if err != nil {
+			return nil, err
+		}
+		addAnnotations(nil, "", j, ann, false)
+		vals = append(vals, j)
+	}
+	return vals, nil
+}
+
+// dynamicLeafJSON returns the JSON representation of the value v of the leaf,
+// or leaf-list member, described by schema. It returns nil for an empty leaf
+// that is not present.
+func dynamicLeafJSON(schema *yang.Entry, v interface{}, args jsonOutputConfig) (interface{}, error) {
+	schema, err := util.ResolveIfLeafRef(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	switch val := v.(type) {
+	case []byte:
+		return binaryBase64(val), nil
+	case bool:
+		if schema.Type != nil && schema.Type.Kind == yang.Yempty {
+			switch {
+			case !val:
+				return nil, nil
+			case args.jType == RFC7951:
+				return []interface{}{nil}, nil
+			}
+		}
+		return val, nil
+	case string:
+		if args.jType == RFC7951 && args.rfc7951Config != nil && args.rfc7951Config.AppendModuleName && schema.Type != nil {
+			if m := identityModule(schema.Type, val); m != "" {
+				return fmt.Sprintf("%s:%s", m, val), nil
+			}
+		}
+		return val, nil
+	}
+
+	if args.jType == RFC7951 {
+		return writeIETFScalarJSON(v), nil
+	}
+	return v, nil
+}
+
+// dynamicBinary is the type used for the values of binary leaves of a
+// DynamicNode when they are supplied to a leafVisitor, such that they are
+// distinguished from leaf-lists of uint8 values.
+type dynamicBinary []byte
+
+// walkDynamic is the equivalent of walk for a DynamicNode. The children of
+// the node are visited in the order of their names.
+func (w *leafWalker) walkDynamic(n *DynamicNode, parent *gnmiPath, parentMod string) error {
+	errs := &w.errs
+
+	// children stores the containers and lists that are children of n, such
+	// that they can be walked once the leaves of n have been visited.
+	type child struct {
+		path   *gnmiPath
+		schema *yang.Entry
+		nodes  []*DynamicNode
+		module string
+	}
+	var children []child
+
+	for _, name := range n.Names() {
+		cs, err := n.ChildSchema(name)
+		if err != nil {
+			errs.Add(fmt.Errorf("%v->%s: %v", parent, name, err))
+			continue
+		}
+		p := parent.Copy()
+		if err := p.AppendName(name); err != nil {
+			errs.Add(err)
+			continue
+		}
+		mod := entryModule(cs)
+		if err := w.qualifyPath(p, parent.Len(), mod, parentMod); err != nil {
+			errs.Add(err)
+			continue
+		}
+		if mod == "" {
+			mod = parentMod
+		}
+
+		var val interface{}
+		switch v := n.values[name].(type) {
+		case *DynamicNode:
+			children = append(children, child{p, cs, []*DynamicNode{v}, mod})
+			continue
+		case []*DynamicNode:
+			if len(strings.Fields(cs.Key)) == 0 {
+				errs.Add(fmt.Errorf("unimplemented: keyless list cannot be output: %v", p))
+				continue
+			}
+			children = append(children, child{p, cs, v, mod})
+			continue
+		case []interface{}:
+			var l []interface{}
+			for _, e := range v {
+				l = append(l, dynamicLeafValue(e))
+			}
+			val = l
+		case bool:
+			if !v && cs.Type != nil && cs.Type.Kind == yang.Yempty {
+				continue
+			}
+			val = v
+		default:
+			val = dynamicLeafValue(v)
+		}
+		if err := w.visit(parent, p, val, cs); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range children {
+		for _, cn := range c.nodes {
+			p := c.path
+			if c.schema.IsList() {
+				var err error
+				if p, err = dynamicListEntryPath(cn, c.path); err != nil {
+					errs.Add(err)
+					continue
+				}
+			}
+			if w.subtrees {
+				if err := w.visit(parent, p, cn, c.schema); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := w.walkDynamic(cn, p, c.module); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dynamicLeafValue returns the value of a leaf of a DynamicNode that is
+// supplied to a leafVisitor.
+func dynamicLeafValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return dynamicBinary(b)
+	}
+	return v
+}
+
+// dynamicListEntryPath returns the path of the list member n, where p is the
+// path of the list. For paths that are a slice of strings, the values of the
+// keys are appended to the path in the order in which they are specified by
+// the schema, and for PathElem paths they are added as the keys of the last
+// element.
+func dynamicListEntryPath(n *DynamicNode, p *gnmiPath) (*gnmiPath, error) {
+	km, err := n.ΛListKeyMap()
+	if err != nil {
+		return nil, err
+	}
+
+	np := p.Copy()
+	if np.isStringSlicePath() {
+		for _, k := range strings.Fields(n.schema.Key) {
+			np.stringSlicePath = append(np.stringSlicePath, fmt.Sprintf("%v", km[k]))
+		}
+		return np, nil
+	}
+
+	e, err := np.LastPathElem()
+	if err != nil {
+		return nil, err
+	}
+	ne := *e
+	ne.Key = map[string]string{}
+	for k, v := range km {
+		ne.Key[k] = fmt.Sprintf("%v", v)
+	}
+	if err := np.SetIndex(np.Len()-1, &ne); err != nil {
+		return nil, err
+	}
+	return np, nil
+}
@@ -0,0 +1,426 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const dynamicTestModule = `
+module dyn-a {
+  prefix "a";
+  namespace "urn:dyn-a";
+
+  identity base-id;
+  identity id-one { base base-id; }
+
+  container top {
+    leaf name { type string; }
+    leaf count { type uint64; }
+    leaf ratio { type decimal64 { fraction-digits 2; } }
+    leaf enabled { type empty; }
+    leaf data { type binary; }
+    leaf kind { type enumeration { enum RED; enum BLUE; } }
+    leaf id { type identityref { base base-id; } }
+    leaf-list tags { type string; }
+    choice mode {
+      case fast { leaf speed { type uint32; } }
+      case slow { leaf delay { type uint32; } }
+    }
+    list item {
+      key "id";
+      leaf id { type uint32; }
+      leaf value { type int16; }
+    }
+  }
+}
+`
+
+const dynamicTestAugmentModule = `
+module dyn-b {
+  prefix "b";
+  namespace "urn:dyn-b";
+
+  import dyn-a { prefix a; }
+
+  augment "/a:top" {
+    leaf extra { type string; }
+  }
+}
+`
+
+// dynamicTestSchema returns the schema of the dyn-a module, as augmented by
+// the dyn-b module.
+func dynamicTestSchema(t *testing.T) *yang.Entry {
+	ms := yang.NewModules()
+	for n, m := range map[string]string{"dyn-a.yang": dynamicTestModule, "dyn-b.yang": dynamicTestAugmentModule} {
+		if err := ms.Parse(m, n); err != nil {
+			t.Fatalf("cannot parse test module %s: %v", n, err)
+		}
+	}
+	if errs := ms.Process(); len(errs) != 0 {
+		t.Fatalf("cannot process test modules: %v", errs)
+	}
+	// Entries must be built for all modules, such that augments are applied.
+	entries := map[string]*yang.Entry{}
+	for n, m := range ms.Modules {
+		entries[n] = yang.ToEntry(m)
+	}
+	return entries["dyn-a"]
+}
+
+// dynamicTestTree returns a populated data tree for the dyn-a module.
+func dynamicTestTree(t *testing.T) *DynamicNode {
+	root, err := NewDynamicNode(dynamicTestSchema(t))
+	if err != nil {
+		t.Fatalf("NewDynamicNode: got unexpected error: %v", err)
+	}
+	top, err := root.Container("top")
+	if err != nil {
+		t.Fatalf("Container(top): got unexpected error: %v", err)
+	}
+	for k, v := range map[string]interface{}{
+		"name":    "eth0",
+		"count":   uint64(42),
+		"ratio":   Decimal64{Digits: 150, Precision: 2},
+		"enabled": true,
+		"data":    []byte("hi"),
+		"kind":    "RED",
+		"id":      "id-one",
+		"tags":    []string{"a", "b"},
+		"speed":   uint32(10),
+		"b:extra": "e",
+	} {
+		if err := top.Set(k, v); err != nil {
+			t.Fatalf("Set(%s, %v): got unexpected error: %v", k, v, err)
+		}
+	}
+	item, err := top.NewListEntry("item", map[string]interface{}{"id": uint32(1)})
+	if err != nil {
+		t.Fatalf("NewListEntry(item): got unexpected error: %v", err)
+	}
+	if err := item.Set("value", int16(-3)); err != nil {
+		t.Fatalf("Set(value): got unexpected error: %v", err)
+	}
+	return root
+}
+
+func TestDynamicNode(t *testing.T) {
+	schema := dynamicTestSchema(t)
+	if _, err := NewDynamicNode(schema.Dir["top"].Dir["name"]); err == nil {
+		t.Errorf("NewDynamicNode(leaf): did not get expected error")
+	}
+
+	root := dynamicTestTree(t)
+	top := root.Get("top").(*DynamicNode)
+
+	if got, want := top.Get("a:name"), "eth0"; got != want {
+		t.Errorf("Get(a:name): did not get expected value, got: %v, want: %v", got, want)
+	}
+	if got, want := top.Get("tags"), []interface{}{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(tags): did not get expected value, got: %v, want: %v", got, want)
+	}
+	if got, want := top.Names(), []string{"count", "data", "enabled", "extra", "id", "item", "kind", "name", "ratio", "speed", "tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names(): did not get expected names, got: %v, want: %v", got, want)
+	}
+	if c, err := root.Container("top"); err != nil || c != top {
+		t.Errorf("Container(top): did not return existing container, got: %v, err: %v", c, err)
+	}
+
+	tests := []struct {
+		name          string
+		fn            func() error
+		wantErrSubstr string
+	}{{
+		name:          "set unknown child",
+		fn:            func() error { return top.Set("missing", "x") },
+		wantErrSubstr: "missing is not a child of schema top",
+	}, {
+		name:          "set list",
+		fn:            func() error { return top.Set("item", "x") },
+		wantErrSubstr: "item is not a leaf or leaf-list",
+	}, {
+		name:          "set leaf-list to scalar",
+		fn:            func() error { return top.Set("tags", "x") },
+		wantErrSubstr: "must be a slice",
+	}, {
+		name: "container of leaf",
+		fn: func() error {
+			_, err := top.Container("name")
+			return err
+		},
+		wantErrSubstr: "name is not a container",
+	}, {
+		name: "list entry with missing key",
+		fn: func() error {
+			_, err := top.NewListEntry("item", map[string]interface{}{"value": int16(1)})
+			return err
+		},
+		wantErrSubstr: "missing value for key id",
+	}, {
+		name: "duplicate list entry",
+		fn: func() error {
+			_, err := top.NewListEntry("item", map[string]interface{}{"id": uint32(1)})
+			return err
+		},
+		wantErrSubstr: "already contains a member with keys",
+	}}
+
+	for _, tt := range tests {
+		if err := tt.fn(); err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+			t.Errorf("%s: did not get expected error, got: %v, want error containing: %q", tt.name, err, tt.wantErrSubstr)
+		}
+	}
+
+	item := top.ListEntry("item", map[string]interface{}{"id": uint32(1)})
+	if item == nil {
+		t.Fatalf("ListEntry(item, 1): did not find list member")
+	}
+	if got, err := item.ΛListKeyMap(); err != nil || !reflect.DeepEqual(got, map[string]interface{}{"id": uint32(1)}) {
+		t.Errorf("ΛListKeyMap(): did not get expected keys, got: %v, err: %v", got, err)
+	}
+	if got := top.ListEntry("item", map[string]interface{}{"id": uint32(2)}); got != nil {
+		t.Errorf("ListEntry(item, 2): got unexpected list member %v", got)
+	}
+
+	top.Delete("name")
+	if got := top.Get("name"); got != nil {
+		t.Errorf("Get(name): got value %v after Delete", got)
+	}
+}
+
+func TestConstructDynamicJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(GoStruct) (map[string]interface{}, error)
+		want string
+	}{{
+		name: "RFC7951 JSON with module names",
+		fn: func(s GoStruct) (map[string]interface{}, error) {
+			return ConstructIETFJSON(s, &RFC7951JSONConfig{AppendModuleName: true})
+		},
+		want: `{
+		  "dyn-a:top": {
+		    "count": "42",
+		    "data": "aGk=",
+		    "dyn-b:extra": "e",
+		    "enabled": [null],
+		    "id": "dyn-a:id-one",
+		    "item": [{"id": 1, "value": -3}],
+		    "kind": "RED",
+		    "name": "eth0",
+		    "ratio": "1.5",
+		    "speed": 10,
+		    "tags": ["a", "b"]
+		  }
+		}`,
+	}, {
+		name: "RFC7951 JSON without module names",
+		fn: func(s GoStruct) (map[string]interface{}, error) {
+			return ConstructIETFJSON(s, &RFC7951JSONConfig{})
+		},
+		want: `{
+		  "top": {
+		    "count": "42",
+		    "data": "aGk=",
+		    "extra": "e",
+		    "enabled": [null],
+		    "id": "id-one",
+		    "item": [{"id": 1, "value": -3}],
+		    "kind": "RED",
+		    "name": "eth0",
+		    "ratio": "1.5",
+		    "speed": 10,
+		    "tags": ["a", "b"]
+		  }
+		}`,
+	}, {
+		name: "internal JSON",
+		fn:   ConstructInternalJSON,
+		want: `{
+		  "top": {
+		    "count": 42,
+		    "data": "aGk=",
+		    "extra": "e",
+		    "enabled": true,
+		    "id": "id-one",
+		    "item": {"1": {"id": 1, "value": -3}},
+		    "kind": "RED",
+		    "name": "eth0",
+		    "ratio": "1.5",
+		    "speed": 10,
+		    "tags": ["a", "b"]
+		  }
+		}`,
+	}}
+
+	for _, tt := range tests {
+		got, err := tt.fn(dynamicTestTree(t))
+		if err != nil {
+			t.Errorf("%s: got unexpected error: %v", tt.name, err)
+			continue
+		}
+		js, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("%s: cannot marshal JSON: %v", tt.name, err)
+			continue
+		}
+		var gotJSON, wantJSON interface{}
+		if err := json.Unmarshal(js, &gotJSON); err != nil {
+			t.Errorf("%s: cannot unmarshal output JSON: %v", tt.name, err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(tt.want), &wantJSON); err != nil {
+			t.Errorf("%s: cannot unmarshal expected JSON: %v", tt.name, err)
+			continue
+		}
+		if diff := pretty.Compare(gotJSON, wantJSON); diff != "" {
+			t.Errorf("%s: did not get expected JSON, diff(-got,+want):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestDynamicNodegNMINotifications(t *testing.T) {
+	root := dynamicTestTree(t)
+	top := root.Get("top").(*DynamicNode)
+	for _, n := range []string{"count", "data", "enabled", "extra", "id", "kind", "ratio", "speed"} {
+		top.Delete(n)
+	}
+
+	elemPath := func(elems ...*gnmipb.PathElem) *gnmipb.Path {
+		return &gnmipb.Path{Elem: elems}
+	}
+	elem := func(name string) *gnmipb.PathElem {
+		return &gnmipb.PathElem{Name: name}
+	}
+
+	tests := []struct {
+		name string
+		cfg  GNMINotificationsConfig
+		want []*gnmipb.Update
+	}{{
+		name: "PathElem paths",
+		cfg:  GNMINotificationsConfig{UsePathElem: true},
+		want: []*gnmipb.Update{{
+			Path: elemPath(elem("top"), elem("name")),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth0"}},
+		}, {
+			Path: elemPath(elem("top"), elem("tags")),
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{
+					{Value: &gnmipb.TypedValue_StringVal{"a"}},
+					{Value: &gnmipb.TypedValue_StringVal{"b"}},
+				},
+			}}},
+		}, {
+			Path: elemPath(elem("top"), &gnmipb.PathElem{Name: "item", Key: map[string]string{"id": "1"}}, elem("id")),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{1}},
+		}, {
+			Path: elemPath(elem("top"), &gnmipb.PathElem{Name: "item", Key: map[string]string{"id": "1"}}, elem("value")),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_IntVal{-3}},
+		}},
+	}, {
+		name: "string slice paths",
+		cfg:  GNMINotificationsConfig{},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Element: []string{"top", "name"}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth0"}},
+		}, {
+			Path: &gnmipb.Path{Element: []string{"top", "tags"}},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{
+					{Value: &gnmipb.TypedValue_StringVal{"a"}},
+					{Value: &gnmipb.TypedValue_StringVal{"b"}},
+				},
+			}}},
+		}, {
+			Path: &gnmipb.Path{Element: []string{"top", "item", "1", "id"}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{1}},
+		}, {
+			Path: &gnmipb.Path{Element: []string{"top", "item", "1", "value"}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_IntVal{-3}},
+		}},
+	}, {
+		name: "JSON_IETF subtrees",
+		cfg:  GNMINotificationsConfig{UsePathElem: true, JSONIETFSubtrees: true},
+		want: []*gnmipb.Update{{
+			Path: elemPath(elem("top")),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{[]byte(`{"dyn-a:item":[{"id":1,"value":-3}],"dyn-a:name":"eth0","dyn-a:tags":["a","b"]}`)}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		got, err := TogNMINotifications(root, 42, tt.cfg)
+		if err != nil {
+			t.Errorf("%s: got unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("%s: did not get expected number of notifications, got: %d, want: 1", tt.name, len(got))
+			continue
+		}
+		if len(got[0].Update) != len(tt.want) {
+			t.Errorf("%s: did not get expected number of updates, got: %v, want: %v", tt.name, got[0].Update, tt.want)
+			continue
+		}
+		for i, u := range got[0].Update {
+			if !proto.Equal(u, tt.want[i]) {
+				t.Errorf("%s: update %d: did not get expected update, got: %s, want: %s", tt.name, i, proto.CompactTextString(u), proto.CompactTextString(tt.want[i]))
+			}
+		}
+	}
+}
+
+func TestDynamicNodegNMINotificationsModules(t *testing.T) {
+	root := dynamicTestTree(t)
+	top := root.Get("top").(*DynamicNode)
+	for _, n := range top.Names() {
+		if n != "name" && n != "extra" {
+			top.Delete(n)
+		}
+	}
+
+	got, err := TogNMINotifications(root, 42, GNMINotificationsConfig{
+		UsePathElem:        true,
+		OriginFromModule:   true,
+		PrependModuleNames: true,
+	})
+	if err != nil {
+		t.Fatalf("TogNMINotifications: got unexpected error: %v", err)
+	}
+	want := []*gnmipb.Notification{{
+		Timestamp: 42,
+		Prefix:    &gnmipb.Path{Origin: "dyn-a"},
+		Update: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dyn-a:top"}, {Name: "dyn-b:extra"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"e"}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dyn-a:top"}, {Name: "name"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth0"}},
+		}},
+	}}
+	if len(got) != len(want) || !proto.Equal(got[0], want[0]) {
+		t.Errorf("TogNMINotifications: did not get expected notifications, got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// IsDerivedFrom determines whether the identity value v is derived, directly
+// or indirectly, from the identity base. base is specified in the form
+// module:identity, or as the name of the identity alone, in which case the
+// module that defines it is not compared. It returns an error if v is not a
+// valid value of its enumerated type. Since an identity is not derived from
+// itself, false is returned if v is the identity base.
+func IsDerivedFrom(v GoEnum, base string) (bool, error) {
+	def, err := enumDefinition(v)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range def.Bases {
+		if b == base || !strings.Contains(base, ":") && strings.HasSuffix(b, ":"+base) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// enumDefinition returns the definition of the value v of a GoEnum. It
+// returns an error if v is the UNSET value of its type, or is not a value of
+// the type.
+func enumDefinition(v GoEnum) (EnumDefinition, error) {
+	e := reflect.ValueOf(v)
+	if e.Int() == 0 {
+		return EnumDefinition{}, fmt.Errorf("value of %T is UNSET", v)
+	}
+	def, ok := v.ΛMap()[e.Type().Name()][e.Int()]
+	if !ok {
+		return EnumDefinition{}, fmt.Errorf("%d is not a valid value of %T", e.Int(), v)
+	}
+	return def, nil
+}
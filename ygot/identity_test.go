@@ -0,0 +1,100 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"testing"
+)
+
+// EIdentityTest is a derived int64 type used to represent the values of an
+// identityref in the tests of IsDerivedFrom.
+type EIdentityTest int64
+
+// IsYANGGoEnum ensures that EIdentityTest implements the GoEnum interface.
+func (EIdentityTest) IsYANGGoEnum() {}
+
+// ΛMap returns the enumeration dictionary associated with EIdentityTest.
+func (EIdentityTest) ΛMap() map[string]map[int64]EnumDefinition {
+	return map[string]map[int64]EnumDefinition{
+		"EIdentityTest": {
+			1: {Name: "ETHERNET", DefiningModule: "iana-if-type", Bases: []string{"ietf-interfaces:interface-type"}},
+			2: {Name: "FAST_ETHERNET", DefiningModule: "vendor", Bases: []string{"iana-if-type:ETHERNET", "ietf-interfaces:interface-type"}},
+		},
+	}
+}
+
+func TestIsDerivedFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		inValue GoEnum
+		inBase  string
+		want    bool
+		wantErr string
+	}{{
+		name:    "direct base",
+		inValue: EIdentityTest(1),
+		inBase:  "ietf-interfaces:interface-type",
+		want:    true,
+	}, {
+		name:    "indirect base",
+		inValue: EIdentityTest(2),
+		inBase:  "ietf-interfaces:interface-type",
+		want:    true,
+	}, {
+		name:    "base without module",
+		inValue: EIdentityTest(2),
+		inBase:  "ETHERNET",
+		want:    true,
+	}, {
+		name:    "base with different module",
+		inValue: EIdentityTest(2),
+		inBase:  "other:ETHERNET",
+	}, {
+		name:    "identity is not derived from itself",
+		inValue: EIdentityTest(1),
+		inBase:  "iana-if-type:ETHERNET",
+	}, {
+		name:    "partial name does not match",
+		inValue: EIdentityTest(1),
+		inBase:  "type",
+	}, {
+		name:    "unset value",
+		inValue: EIdentityTest(0),
+		inBase:  "ietf-interfaces:interface-type",
+		wantErr: "value of ygot.EIdentityTest is UNSET",
+	}, {
+		name:    "unknown value",
+		inValue: EIdentityTest(42),
+		inBase:  "ietf-interfaces:interface-type",
+		wantErr: "42 is not a valid value of ygot.EIdentityTest",
+	}}
+
+	for _, tt := range tests {
+		got, err := IsDerivedFrom(tt.inValue, tt.inBase)
+		if err != nil {
+			if tt.wantErr == "" || err.Error() != tt.wantErr {
+				t.Errorf("%s: IsDerivedFrom(%v, %s): got unexpected error: %v, want: %q", tt.name, tt.inValue, tt.inBase, err, tt.wantErr)
+			}
+			continue
+		}
+		if tt.wantErr != "" {
+			t.Errorf("%s: IsDerivedFrom(%v, %s): did not get expected error, want: %q", tt.name, tt.inValue, tt.inBase, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: IsDerivedFrom(%v, %s): got %v, want %v", tt.name, tt.inValue, tt.inBase, got, tt.want)
+		}
+	}
+}
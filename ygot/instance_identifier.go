@@ -0,0 +1,254 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// LeafListValueKey is the key of a gNMI PathElem that identifies an entry of
+// a leaf-list by its value, corresponding to the "." predicate of a YANG
+// instance-identifier, e.g., /mod:a/b[.='value'].
+const LeafListValueKey = "."
+
+// InstanceIdentifier is the type used for fields that have a YANG type of
+// instance-identifier. Path is the absolute path of the data tree node that
+// is identified. The names of the elements of Path, and of their keys, are
+// stored as they are specified within the string form of the identifier, such
+// that they include a module name where one is specified, e.g., the
+// identifier /mod:a/b[name='x'] has the elements "mod:a" and "b", where b has
+// the key "name" with the value "x". An entry of a leaf-list is identified by
+// the LeafListValueKey key.
+type InstanceIdentifier struct {
+	Path *gnmipb.Path
+}
+
+// IsYANGLeafValue is a marker method that indicates that InstanceIdentifier
+// is a struct that represents the value of a single YANG leaf, rather than a
+// YANG container or list member.
+func (InstanceIdentifier) IsYANGLeafValue() {}
+
+// ParseInstanceIdentifier parses the string form of a YANG instance-identifier,
+// as defined by RFC7951 Section 6.11, e.g., /mod:a/b[name='x']/c. Key values
+// may be enclosed in either single or double quotes. Positional predicates,
+// which can only be used with lists and leaf-lists that are ordered-by user
+// and do not identify an entry by its contents, are not supported.
+func ParseInstanceIdentifier(s string) (InstanceIdentifier, error) {
+	p := &instanceIDParser{pathStringParser{s: s}}
+	path, err := p.parse()
+	if err != nil {
+		return InstanceIdentifier{}, fmt.Errorf("invalid instance-identifier %q: %v", s, err)
+	}
+	return InstanceIdentifier{Path: path}, nil
+}
+
+// String returns the string form of the InstanceIdentifier as defined by
+// RFC7951 Section 6.11. Keys are output in alphabetical order, and their
+// values are enclosed in single quotes, or in double quotes where they
+// contain a single quote. A value that contains both single and double quotes
+// cannot be represented, and causes MarshalJSON and TypedValue to return an
+// error.
+func (i InstanceIdentifier) String() string {
+	s, _ := i.format()
+	return s
+}
+
+// MarshalJSON implements the json.Marshaler interface, such that an
+// InstanceIdentifier is marshalled to a JSON string, as specified by RFC7951
+// Section 6.11.
+func (i InstanceIdentifier) MarshalJSON() ([]byte, error) {
+	s, err := i.format()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.Quote(s)), nil
+}
+
+// TypedValue returns the gNMI TypedValue that corresponds to the
+// InstanceIdentifier, which is a string TypedValue containing its string
+// form.
+func (i InstanceIdentifier) TypedValue() (*gnmipb.TypedValue, error) {
+	s, err := i.format()
+	if err != nil {
+		return nil, err
+	}
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{s}}, nil
+}
+
+// format returns the string form of the InstanceIdentifier, or an error if it
+// cannot be represented.
+func (i InstanceIdentifier) format() (string, error) {
+	var b bytes.Buffer
+	var errs []string
+	for _, e := range i.Path.GetElem() {
+		b.WriteString("/")
+		b.WriteString(e.GetName())
+
+		var keys []string
+		for k := range e.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := e.GetKey()[k]
+			q := "'"
+			if strings.Contains(v, "'") {
+				if strings.Contains(v, `"`) {
+					errs = append(errs, fmt.Sprintf("value %q of key %s of element %s contains both single and double quotes", v, k, e.GetName()))
+				}
+				q = `"`
+			}
+			fmt.Fprintf(&b, "[%s=%s%s%s]", k, q, v, q)
+		}
+	}
+	if len(errs) != 0 {
+		return b.String(), fmt.Errorf("cannot represent instance-identifier %s: %s", b.String(), strings.Join(errs, ", "))
+	}
+	return b.String(), nil
+}
+
+// instanceIDParser parses the string form of an instance-identifier, which
+// is a path string with elements and predicates of a more restricted form.
+type instanceIDParser struct {
+	pathStringParser
+}
+
+// parse parses the whole of the string form of the instance-identifier,
+// returning the path that it describes.
+func (p *instanceIDParser) parse() (*gnmipb.Path, error) {
+	if p.s != "" && p.s[0] != '/' {
+		return nil, p.errorf("expected /")
+	}
+	path := &gnmipb.Path{}
+	err := p.elements(func() error {
+		name, err := p.nodeName()
+		if err != nil {
+			return err
+		}
+		e := &gnmipb.PathElem{Name: name}
+		if err := p.predicates(e, p.predicate); err != nil {
+			return err
+		}
+		if _, ok := e.Key[LeafListValueKey]; ok && len(e.Key) != 1 {
+			return fmt.Errorf("element %s has both leaf-list and key predicates", e.Name)
+		}
+		path.Elem = append(path.Elem, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(path.Elem) == 0 {
+		return nil, fmt.Errorf("path does not identify a node")
+	}
+	return path, nil
+}
+
+// predicate parses a predicate following its opening '[', returning the key
+// that it specifies and its value.
+func (p *instanceIDParser) predicate() (string, string, error) {
+	p.skipSpace()
+	var key string
+	switch {
+	case p.consume('.'):
+		key = LeafListValueKey
+	case !p.done() && isDigit(p.s[p.i]):
+		return "", "", p.errorf("positional predicates are not supported")
+	default:
+		var err error
+		if key, err = p.nodeName(); err != nil {
+			return "", "", err
+		}
+	}
+	p.skipSpace()
+	if !p.consume('=') {
+		return "", "", p.errorf("expected = in predicate")
+	}
+	p.skipSpace()
+	val, err := p.quotedString()
+	if err != nil {
+		return "", "", err
+	}
+	p.skipSpace()
+	if !p.consume(']') {
+		return "", "", p.errorf("expected ] to end predicate")
+	}
+	return key, val, nil
+}
+
+// nodeName parses a node name, which may be qualified by a module name, of
+// the form [module:]identifier.
+func (p *instanceIDParser) nodeName() (string, error) {
+	start := p.i
+	if err := p.identifier(); err != nil {
+		return "", err
+	}
+	if p.consume(':') {
+		if err := p.identifier(); err != nil {
+			return "", err
+		}
+	}
+	return p.s[start:p.i], nil
+}
+
+// identifier parses a YANG identifier, as defined by RFC7950 Section 6.2.
+func (p *instanceIDParser) identifier() error {
+	if p.done() || !isIdentifierStart(p.s[p.i]) {
+		return p.errorf("expected identifier")
+	}
+	for p.i++; !p.done() && (isIdentifierStart(p.s[p.i]) || isDigit(p.s[p.i]) || p.s[p.i] == '-' || p.s[p.i] == '.'); p.i++ {
+	}
+	return nil
+}
+
+// quotedString parses a string that is enclosed in single or double quotes,
+// returning its contents.
+func (p *instanceIDParser) quotedString() (string, error) {
+	if p.done() || (p.s[p.i] != '\'' && p.s[p.i] != '"') {
+		return "", p.errorf("expected quoted value")
+	}
+	q := p.s[p.i]
+	end := strings.IndexByte(p.s[p.i+1:], q)
+	if end == -1 {
+		return "", p.errorf("unterminated quoted value")
+	}
+	v := p.s[p.i+1 : p.i+1+end]
+	p.i += end + 2
+	return v, nil
+}
+
+// skipSpace consumes any whitespace at the current position.
+func (p *instanceIDParser) skipSpace() {
+	for !p.done() && strings.IndexByte(" \t\r\n", p.s[p.i]) != -1 {
+		p.i++
+	}
+}
+
+// isIdentifierStart returns true if c can be the first character of a YANG
+// identifier.
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit returns true if c is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
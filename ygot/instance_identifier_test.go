@@ -0,0 +1,171 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kylelemons/godebug/pretty"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestParseInstanceIdentifier(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		want          *gnmipb.Path
+		wantString    string
+		wantErrSubstr string
+	}{{
+		name:       "single element",
+		in:         "/mod:a",
+		want:       &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "mod:a"}}},
+		wantString: "/mod:a",
+	}, {
+		name: "list entry with keys",
+		in:   `/mod:a/b[name='x'][other:id="1"]/c`,
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "mod:a"},
+			{Name: "b", Key: map[string]string{"name": "x", "other:id": "1"}},
+			{Name: "c"},
+		}},
+		wantString: "/mod:a/b[name='x'][other:id='1']/c",
+	}, {
+		name: "whitespace within predicates",
+		in:   `/a[ name = 'x y' ]`,
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "a", Key: map[string]string{"name": "x y"}},
+		}},
+		wantString: "/a[name='x y']",
+	}, {
+		name: "key values containing special characters",
+		in:   `/a[k1="it's"][k2='/b[c="d"]']`,
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "a", Key: map[string]string{"k1": "it's", "k2": `/b[c="d"]`}},
+		}},
+		wantString: `/a[k1="it's"][k2='/b[c="d"]']`,
+	}, {
+		name: "leaf-list entry",
+		in:   "/mod:a/b[.='v']",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "mod:a"},
+			{Name: "b", Key: map[string]string{LeafListValueKey: "v"}},
+		}},
+		wantString: "/mod:a/b[.='v']",
+	}, {
+		name:          "empty string",
+		in:            "",
+		wantErrSubstr: "path does not identify a node",
+	}, {
+		name:          "root path",
+		in:            "/",
+		wantErrSubstr: "path does not identify a node",
+	}, {
+		name:          "relative path",
+		in:            "a/b",
+		wantErrSubstr: "expected / at position 0",
+	}, {
+		name:          "trailing slash",
+		in:            "/a/",
+		wantErrSubstr: "expected element at position 3",
+	}, {
+		name:          "invalid identifier",
+		in:            "/1a",
+		wantErrSubstr: "expected identifier",
+	}, {
+		name:          "unquoted key value",
+		in:            "/a[name=x]",
+		wantErrSubstr: "expected quoted value",
+	}, {
+		name:          "unterminated key value",
+		in:            "/a[name='x]",
+		wantErrSubstr: "unterminated quoted value",
+	}, {
+		name:          "unterminated predicate",
+		in:            "/a[name='x'",
+		wantErrSubstr: "expected ] to end predicate",
+	}, {
+		name:          "positional predicate",
+		in:            "/a[1]",
+		wantErrSubstr: "positional predicates are not supported",
+	}, {
+		name:          "duplicate key",
+		in:            "/a[name='x'][name='y']",
+		wantErrSubstr: "more than one value for key name",
+	}, {
+		name:          "leaf-list and key predicates",
+		in:            "/a[.='x'][name='y']",
+		wantErrSubstr: "both leaf-list and key predicates",
+	}}
+
+	for _, tt := range tests {
+		got, err := ParseInstanceIdentifier(tt.in)
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: ParseInstanceIdentifier(%q): got unexpected error: %v, want error containing: %q", tt.name, tt.in, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: ParseInstanceIdentifier(%q): did not get expected error, got: %v, want error containing: %q", tt.name, tt.in, got.Path, tt.wantErrSubstr)
+			continue
+		}
+		if !proto.Equal(got.Path, tt.want) {
+			t.Errorf("%s: ParseInstanceIdentifier(%q): did not get expected path, got: %v, want: %v", tt.name, tt.in, pretty.Sprint(got.Path), pretty.Sprint(tt.want))
+		}
+		if s := got.String(); s != tt.wantString {
+			t.Errorf("%s: ParseInstanceIdentifier(%q).String(): got: %s, want: %s", tt.name, tt.in, s, tt.wantString)
+		}
+	}
+}
+
+func TestInstanceIdentifierEncoding(t *testing.T) {
+	iid := InstanceIdentifier{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+		{Name: "mod:a"},
+		{Name: "b", Key: map[string]string{"name": "x"}},
+	}}}
+
+	j, err := json.Marshal(map[string]interface{}{"iid": iid})
+	if err != nil {
+		t.Fatalf("json.Marshal: got unexpected error: %v", err)
+	}
+	if got, want := string(j), `{"iid":"/mod:a/b[name='x']"}`; got != want {
+		t.Errorf("json.Marshal: got: %s, want: %s", got, want)
+	}
+
+	tv, err := iid.TypedValue()
+	if err != nil {
+		t.Fatalf("TypedValue: got unexpected error: %v", err)
+	}
+	if want := (&gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"/mod:a/b[name='x']"}}); !proto.Equal(tv, want) {
+		t.Errorf("TypedValue: got: %v, want: %v", tv, want)
+	}
+
+	// A key value containing both single and double quotes cannot be
+	// represented.
+	bad := InstanceIdentifier{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+		{Name: "a", Key: map[string]string{"name": `it's "x"`}},
+	}}}
+	if _, err := bad.MarshalJSON(); err == nil || !strings.Contains(err.Error(), "contains both single and double quotes") {
+		t.Errorf("MarshalJSON: got error: %v, want error for quotes", err)
+	}
+	if _, err := bad.TypedValue(); err == nil {
+		t.Errorf("TypedValue: did not get expected error for quotes")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Metadata stores the metadata that is associated with a node of a data
+// tree.
+type Metadata struct {
+	// Timestamp is the time at which the node last changed, expressed in
+	// nanoseconds since the Unix epoch. If zero, the timestamp of the node
+	// is not known.
+	Timestamp int64
+	// Annotations stores the values of the RFC7952 metadata annotations of
+	// the node, keyed by the name of the annotation qualified by the name
+	// of the module that defines it, e.g., ietf-origin:origin.
+	Annotations map[string]interface{}
+}
+
+// copy returns a copy of md, whose annotations can be modified independently
+// of those of md.
+func (md *Metadata) copy() *Metadata {
+	if md == nil {
+		return nil
+	}
+	n := &Metadata{Timestamp: md.Timestamp}
+	if md.Annotations != nil {
+		n.Annotations = map[string]interface{}{}
+		for k, v := range md.Annotations {
+			n.Annotations[k] = v
+		}
+	}
+	return n
+}
+
+// NodeMetadata is a sidecar that stores the metadata of the nodes of a
+// GoStruct, which is used when the GoStruct is rendered to gNMI
+// Notifications or RFC7951 JSON. It is keyed by the path of each node
+// relative to the rendered GoStruct, in the form returned by PathToString,
+// without an origin or module names, e.g., /interfaces/interface[name=eth0].
+// The key of a node rendered using a string slice path is the path string of
+// the string slice path, e.g., /interfaces/interface/eth0.
+//
+// A NodeMetadata is also used as the annotation field of GoStructs that are
+// generated for schemas which define RFC7952 metadata annotations, tagged
+// with ygotAnnotation:"true". Such a field stores the annotations of the
+// leaves and leaf-lists of the GoStruct, keyed by their path relative to the
+// GoStruct, e.g., /config/description, along with those of the container or
+// list entry that the GoStruct represents, keyed by the path "/".
+type NodeMetadata map[string]*Metadata
+
+// Set sets the metadata of the node with the path p, relative to the
+// GoStruct that the NodeMetadata describes, to md. The origin of p, and the
+// module names of its elements, are ignored.
+func (m NodeMetadata) Set(p *gnmipb.Path, md *Metadata) error {
+	k, err := metadataKey(&gnmiPath{stringSlicePath: p.GetElement(), pathElemPath: p.GetElem()})
+	if err != nil {
+		return err
+	}
+	m[k] = md
+	return nil
+}
+
+// Get returns the metadata of the node with the path p, relative to the
+// GoStruct that the NodeMetadata describes, or nil if it has none. The
+// origin of p, and the module names of its elements, are ignored.
+func (m NodeMetadata) Get(p *gnmipb.Path) (*Metadata, error) {
+	return m.lookup(&gnmiPath{stringSlicePath: p.GetElement(), pathElemPath: p.GetElem()})
+}
+
+// Delete removes the metadata of the node with the path p, relative to the
+// GoStruct that the NodeMetadata describes. The origin of p, and the module
+// names of its elements, are ignored.
+func (m NodeMetadata) Delete(p *gnmipb.Path) error {
+	k, err := metadataKey(&gnmiPath{stringSlicePath: p.GetElement(), pathElemPath: p.GetElem()})
+	if err != nil {
+		return err
+	}
+	delete(m, k)
+	return nil
+}
+
+// lookup returns the metadata of the node with the path p, or nil if it has
+// none.
+func (m NodeMetadata) lookup(p *gnmiPath) (*Metadata, error) {
+	if m == nil {
+		return nil, nil
+	}
+	k, err := metadataKey(p)
+	if err != nil {
+		return nil, err
+	}
+	return m[k], nil
+}
+
+// metadataKey returns the key of the node with the path p within a
+// NodeMetadata, which is its path string with any module names removed.
+func metadataKey(p *gnmiPath) (string, error) {
+	pp := &gnmipb.Path{}
+	switch {
+	case p.isStringSlicePath() && p.isPathElemPath():
+		return "", fmt.Errorf("invalid path %v, both the Element and Elem fields are set", p)
+	case p.isStringSlicePath():
+		pp.Element = p.stringSlicePath
+	default:
+		for _, e := range p.pathElemPath {
+			ne := &gnmipb.PathElem{Name: util.StripModulePrefix(e.Name), Key: e.Key}
+			pp.Elem = append(pp.Elem, ne)
+		}
+	}
+	return PathToString(pp)
+}
+
+// annotationJSON returns the RFC7952 JSON encoding of the annotations within
+// md, which includes its timestamp where tsAnnotation is non-empty. It
+// returns nil if there are no annotations to be encoded.
+func annotationJSON(md *Metadata, tsAnnotation string) map[string]interface{} {
+	if md == nil {
+		return nil
+	}
+	a := map[string]interface{}{}
+	for k, v := range md.Annotations {
+		a[k] = writeIETFScalarJSON(v)
+	}
+	if tsAnnotation != "" && md.Timestamp != 0 {
+		a[tsAnnotation] = strconv.FormatInt(md.Timestamp, 10)
+	}
+	if len(a) == 0 {
+		return nil
+	}
+	return a
+}
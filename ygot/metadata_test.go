@@ -0,0 +1,504 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kylelemons/godebug/pretty"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestNodeMetadata(t *testing.T) {
+	m := NodeMetadata{}
+	md := &Metadata{Timestamp: 42}
+	in := &gnmipb.Path{Origin: "openconfig", Elem: []*gnmipb.PathElem{
+		{Name: "m:a"},
+		{Name: "b", Key: map[string]string{"k2": "2", "k1": "1"}},
+	}}
+	if err := m.Set(in, md); err != nil {
+		t.Fatalf("Set(%v): got unexpected error: %v", in, err)
+	}
+	if got, want := m, (NodeMetadata{"/a/b[k1=1][k2=2]": md}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Set(%v): did not get expected metadata, got: %v, want: %v", in, got, want)
+	}
+
+	lookup := &gnmipb.Path{Elem: []*gnmipb.PathElem{
+		{Name: "a"},
+		{Name: "m:b", Key: map[string]string{"k1": "1", "k2": "2"}},
+	}}
+	if got, err := m.Get(lookup); err != nil || got != md {
+		t.Errorf("Get(%v): got: %v, err: %v, want: %v", lookup, got, err, md)
+	}
+	missing := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "a"}}}
+	if got, err := m.Get(missing); err != nil || got != nil {
+		t.Errorf("Get(%v): got: %v, err: %v, want: nil", missing, got, err)
+	}
+	invalid := &gnmipb.Path{Element: []string{"a"}, Elem: []*gnmipb.PathElem{{Name: "a"}}}
+	if err := m.Set(invalid, md); err == nil {
+		t.Errorf("Set(%v): did not get expected error", invalid)
+	}
+
+	if err := m.Delete(lookup); err != nil {
+		t.Fatalf("Delete(%v): got unexpected error: %v", lookup, err)
+	}
+	if len(m) != 0 {
+		t.Errorf("Delete(%v): did not get expected empty metadata, got: %v", lookup, m)
+	}
+}
+
+func TestGNMINotificationsMetadata(t *testing.T) {
+	inStruct := &pathElemExample{
+		StringField: String("foo"),
+		List: map[string]*pathElemExampleChild{
+			"p1": {Val: String("p1"), OtherField: Uint8(42)},
+		},
+	}
+
+	listElem := &gnmipb.PathElem{Name: "list", Key: map[string]string{"val": "p1"}}
+	md := NodeMetadata{}
+	for _, p := range []*gnmipb.Path{
+		{Elem: []*gnmipb.PathElem{{Name: "string-field"}}},
+		{Elem: []*gnmipb.PathElem{listElem, {Name: "other-field"}}},
+	} {
+		if err := md.Set(p, &Metadata{Timestamp: 100}); err != nil {
+			t.Fatalf("cannot set metadata for %v: %v", p, err)
+		}
+	}
+
+	strUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "string-field"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"foo"}},
+	}
+	valUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem, {Name: "val"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+	}
+	cfgValUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem, {Name: "config"}, {Name: "val"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+	}
+	otherUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem, {Name: "other-field"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{42}},
+	}
+
+	tests := []struct {
+		name     string
+		inConfig GNMINotificationsConfig
+		want     []*gnmipb.Notification
+	}{{
+		name:     "grouped by timestamp",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Metadata: md},
+		want: []*gnmipb.Notification{{
+			Timestamp: 100,
+			Update:    []*gnmipb.Update{strUpd, otherUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{valUpd, cfgValUpd},
+		}},
+	}, {
+		name: "grouped by timestamp with limited number of updates",
+		inConfig: GNMINotificationsConfig{
+			UsePathElem: true,
+			Metadata:    md,
+			MaxUpdates:  1,
+		},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{valUpd},
+		}, {
+			Timestamp: 100,
+			Update:    []*gnmipb.Update{strUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{cfgValUpd},
+		}, {
+			Timestamp: 100,
+			Update:    []*gnmipb.Update{otherUpd},
+		}},
+	}, {
+		name: "grouped by timestamp with one pending notification",
+		inConfig: GNMINotificationsConfig{
+			UsePathElem:             true,
+			Metadata:                md,
+			MaxPendingNotifications: 1,
+		},
+		want: []*gnmipb.Notification{{
+			Timestamp: 100,
+			Update:    []*gnmipb.Update{strUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{valUpd, cfgValUpd},
+		}, {
+			Timestamp: 100,
+			Update:    []*gnmipb.Update{otherUpd},
+		}},
+	}, {
+		name: "grouped by timestamp and prefix with configured prefix",
+		inConfig: GNMINotificationsConfig{
+			UsePathElem:    true,
+			PathElemPrefix: []*gnmipb.PathElem{{Name: "root"}},
+			GroupByPrefix:  true,
+			Metadata:       md,
+		},
+		want: []*gnmipb.Notification{{
+			Timestamp: 100,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "root"}}},
+			Update:    []*gnmipb.Update{strUpd},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "root"}, listElem}},
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "val"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+			}, {
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "config"}, {Name: "val"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+			}},
+		}, {
+			Timestamp: 100,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "root"}, listElem}},
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "other-field"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{42}},
+			}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		var got []*gnmipb.Notification
+		err := StreamgNMINotifications(inStruct, 42, tt.inConfig, func(n *gnmipb.Notification) error {
+			got = append(got, n)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): got unexpected error: %v", tt.name, inStruct, tt.inConfig, err)
+			continue
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): did not get expected number of Notifications, got: %v, want: %v", tt.name, inStruct, tt.inConfig, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !proto.Equal(got[i], tt.want[i]) {
+				t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): did not get expected Notification %d, got: %s, want: %s", tt.name, inStruct, tt.inConfig, i, proto.MarshalTextString(got[i]), proto.MarshalTextString(tt.want[i]))
+			}
+		}
+	}
+}
+
+// metadataExample is used to test the output of metadata annotations in
+// RFC7951 JSON.
+type metadataExample struct {
+	Name  *string                         `path:"name" module:"m"`
+	Tags  []string                        `path:"tags" module:"m"`
+	Child *metadataExampleChild           `path:"child" module:"m"`
+	List  map[string]*metadataExampleList `path:"list" module:"m"`
+}
+
+func (*metadataExample) IsYANGGoStruct() {}
+
+type metadataExampleChild struct {
+	Value *int64 `path:"config/value" module:"m"`
+}
+
+func (*metadataExampleChild) IsYANGGoStruct() {}
+
+type metadataExampleList struct {
+	Key *string `path:"key" module:"m"`
+}
+
+func (*metadataExampleList) IsYANGGoStruct() {}
+
+func (l *metadataExampleList) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"key": *l.Key}, nil
+}
+
+func TestConstructIETFJSONMetadata(t *testing.T) {
+	inStruct := &metadataExample{
+		Name:  String("n"),
+		Tags:  []string{"a", "b"},
+		Child: &metadataExampleChild{Value: Int64(5)},
+		List: map[string]*metadataExampleList{
+			"a": {Key: String("a")},
+			"b": {Key: String("b")},
+		},
+	}
+	md := NodeMetadata{
+		"/name":               {Timestamp: 100, Annotations: map[string]interface{}{"ietf-origin:origin": "ietf-origin:intended"}},
+		"/tags":               {Annotations: map[string]interface{}{"m:note": "x"}},
+		"/child":              {Annotations: map[string]interface{}{"ietf-netconf:operation": "merge"}},
+		"/child/config/value": {Timestamp: 7},
+		"/list[key=a]":        {Annotations: map[string]interface{}{"m:count": uint64(1)}},
+	}
+
+	tests := []struct {
+		name     string
+		inConfig *RFC7951JSONConfig
+		want     string
+	}{{
+		name:     "annotations with timestamps",
+		inConfig: &RFC7951JSONConfig{Metadata: md, TimestampAnnotation: "m:last-change"},
+		want: `{
+			"name": "n",
+			"@name": {"ietf-origin:origin": "ietf-origin:intended", "m:last-change": "100"},
+			"tags": ["a", "b"],
+			"@tags": [{"m:note": "x"}, {"m:note": "x"}],
+			"child": {
+				"@": {"ietf-netconf:operation": "merge"},
+				"config": {"value": "5", "@value": {"m:last-change": "7"}}
+			},
+			"list": [{"key": "a", "@": {"m:count": "1"}}, {"key": "b"}]
+		}`,
+	}, {
+		name:     "annotations without timestamps and with module names",
+		inConfig: &RFC7951JSONConfig{Metadata: md, AppendModuleName: true},
+		want: `{
+			"m:name": "n",
+			"@m:name": {"ietf-origin:origin": "ietf-origin:intended"},
+			"m:tags": ["a", "b"],
+			"@m:tags": [{"m:note": "x"}, {"m:note": "x"}],
+			"m:child": {
+				"@": {"ietf-netconf:operation": "merge"},
+				"config": {"value": "5"}
+			},
+			"m:list": [{"key": "a", "@": {"m:count": "1"}}, {"key": "b"}]
+		}`,
+	}, {
+		name:     "no metadata",
+		inConfig: &RFC7951JSONConfig{},
+		want: `{
+			"name": "n",
+			"tags": ["a", "b"],
+			"child": {"config": {"value": "5"}},
+			"list": [{"key": "a"}, {"key": "b"}]
+		}`,
+	}}
+
+	for _, tt := range tests {
+		got, err := ConstructIETFJSON(inStruct, tt.inConfig)
+		if err != nil {
+			t.Errorf("%s: ConstructIETFJSON: got unexpected error: %v", tt.name, err)
+			continue
+		}
+		if diff := jsonDiff(t, got, tt.want); diff != "" {
+			t.Errorf("%s: ConstructIETFJSON: did not get expected JSON, diff(-got,+want):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestConstructIETFJSONDynamicMetadata(t *testing.T) {
+	root := dynamicTestTree(t)
+	top := root.Get("top").(*DynamicNode)
+	for _, n := range top.Names() {
+		if n != "name" && n != "item" {
+			top.Delete(n)
+		}
+	}
+	md := NodeMetadata{
+		"/top":            {Annotations: map[string]interface{}{"m:a": "top"}},
+		"/top/name":       {Annotations: map[string]interface{}{"m:a": "name"}},
+		"/top/item[id=1]": {Annotations: map[string]interface{}{"m:a": "item"}},
+	}
+
+	got, err := ConstructIETFJSON(root, &RFC7951JSONConfig{Metadata: md})
+	if err != nil {
+		t.Fatalf("ConstructIETFJSON: got unexpected error: %v", err)
+	}
+	want := `{
+		"top": {
+			"@": {"m:a": "top"},
+			"name": "eth0",
+			"@name": {"m:a": "name"},
+			"item": [{"@": {"m:a": "item"}, "id": 1, "value": -3}]
+		}
+	}`
+	if diff := jsonDiff(t, got, want); diff != "" {
+		t.Errorf("ConstructIETFJSON: did not get expected JSON, diff(-got,+want):\n%s", diff)
+	}
+}
+
+// annotatedExample is used to test the output of the metadata annotations
+// that are stored within the annotation field of a GoStruct.
+type annotatedExample struct {
+	Name      *string                           `path:"name" module:"m"`
+	Tags      []string                          `path:"tags" module:"m"`
+	Child     *annotatedExampleChild            `path:"child" module:"m"`
+	List      map[string]*annotatedExampleEntry `path:"list" module:"m"`
+	ΛMetadata NodeMetadata                      `ygotAnnotation:"true"`
+}
+
+func (*annotatedExample) IsYANGGoStruct() {}
+
+type annotatedExampleChild struct {
+	Value     *int64       `path:"config/value" module:"m"`
+	ΛMetadata NodeMetadata `ygotAnnotation:"true"`
+}
+
+func (*annotatedExampleChild) IsYANGGoStruct() {}
+
+type annotatedExampleEntry struct {
+	Key       *string      `path:"key" module:"m"`
+	ΛMetadata NodeMetadata `ygotAnnotation:"true"`
+}
+
+func (*annotatedExampleEntry) IsYANGGoStruct() {}
+
+func (e *annotatedExampleEntry) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"key": *e.Key}, nil
+}
+
+func TestConstructIETFJSONAnnotationFields(t *testing.T) {
+	inStruct := &annotatedExample{
+		Name: String("n"),
+		Tags: []string{"a"},
+		Child: &annotatedExampleChild{
+			Value: Int64(5),
+			ΛMetadata: NodeMetadata{
+				"/":             {Annotations: map[string]interface{}{"m:a": "child"}},
+				"/config/value": {Timestamp: 7, Annotations: map[string]interface{}{"m:a": "value"}},
+			},
+		},
+		List: map[string]*annotatedExampleEntry{
+			"k": {
+				Key:       String("k"),
+				ΛMetadata: NodeMetadata{"/": {Annotations: map[string]interface{}{"m:a": "entry"}}},
+			},
+		},
+		ΛMetadata: NodeMetadata{
+			"/name": {Annotations: map[string]interface{}{"m:a": "name", "m:b": "name"}},
+			"/tags": {Annotations: map[string]interface{}{"m:a": "tags"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		inStruct GoStruct
+		inConfig *RFC7951JSONConfig
+		want     string
+	}{{
+		name:     "annotations within GoStructs",
+		inStruct: inStruct,
+		inConfig: &RFC7951JSONConfig{TimestampAnnotation: "m:ts"},
+		want: `{
+			"name": "n",
+			"@name": {"m:a": "name", "m:b": "name"},
+			"tags": ["a"],
+			"@tags": [{"m:a": "tags"}],
+			"child": {
+				"@": {"m:a": "child"},
+				"config": {"value": "5", "@value": {"m:a": "value", "m:ts": "7"}}
+			},
+			"list": [{"key": "k", "@": {"m:a": "entry"}}]
+		}`,
+	}, {
+		name:     "annotations within GoStructs and metadata",
+		inStruct: inStruct,
+		inConfig: &RFC7951JSONConfig{Metadata: NodeMetadata{
+			"/name":        {Annotations: map[string]interface{}{"m:b": "override"}},
+			"/child":       {Annotations: map[string]interface{}{"m:c": "child"}},
+			"/list[key=k]": {Annotations: map[string]interface{}{"m:a": "override"}},
+		}},
+		want: `{
+			"name": "n",
+			"@name": {"m:a": "name", "m:b": "override"},
+			"tags": ["a"],
+			"@tags": [{"m:a": "tags"}],
+			"child": {
+				"@": {"m:a": "child", "m:c": "child"},
+				"config": {"value": "5", "@value": {"m:a": "value"}}
+			},
+			"list": [{"key": "k", "@": {"m:a": "override"}}]
+		}`,
+	}, {
+		name:     "only annotations",
+		inStruct: &annotatedExample{Child: &annotatedExampleChild{ΛMetadata: NodeMetadata{"/": {Annotations: map[string]interface{}{"m:a": uint64(1)}}}}},
+		want:     `{"child": {"@": {"m:a": "1"}}}`,
+	}}
+
+	for _, tt := range tests {
+		got, err := ConstructIETFJSON(tt.inStruct, tt.inConfig)
+		if err != nil {
+			t.Errorf("%s: ConstructIETFJSON: got unexpected error: %v", tt.name, err)
+			continue
+		}
+		if diff := jsonDiff(t, got, tt.want); diff != "" {
+			t.Errorf("%s: ConstructIETFJSON: did not get expected JSON, diff(-got,+want):\n%s", tt.name, diff)
+		}
+	}
+
+	internal, err := ConstructInternalJSON(inStruct)
+	if err != nil {
+		t.Fatalf("ConstructInternalJSON: got unexpected error: %v", err)
+	}
+	want := `{"name": "n", "tags": ["a"], "child": {"config": {"value": 5}}, "list": {"k": {"key": "k"}}}`
+	if diff := jsonDiff(t, internal, want); diff != "" {
+		t.Errorf("ConstructInternalJSON: did not get expected JSON, diff(-got,+want):\n%s", diff)
+	}
+}
+
+func TestCopyAnnotationFields(t *testing.T) {
+	src := &annotatedExample{
+		Name:      String("n"),
+		ΛMetadata: NodeMetadata{"/name": {Timestamp: 1, Annotations: map[string]interface{}{"m:a": "a"}}},
+	}
+	got, err := DeepCopy(src)
+	if err != nil {
+		t.Fatalf("DeepCopy(%v): got unexpected error: %v", src, err)
+	}
+	if diff := pretty.Compare(got, src); diff != "" {
+		t.Errorf("DeepCopy(%v): did not get expected copy, diff(-got,+want):\n%s", src, diff)
+	}
+	got.(*annotatedExample).ΛMetadata["/name"].Annotations["m:a"] = "b"
+	if v := src.ΛMetadata["/name"].Annotations["m:a"]; v != "a" {
+		t.Errorf("DeepCopy(%v): annotations of the copy are not independent, source has annotation value %v", src, v)
+	}
+
+	dst := &annotatedExample{ΛMetadata: NodeMetadata{"/tags": {Timestamp: 2}}}
+	if err := copyStruct(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()); err != nil {
+		t.Fatalf("copyStruct(%v, %v): got unexpected error: %v", dst, src, err)
+	}
+	if len(dst.ΛMetadata) != 2 || dst.ΛMetadata["/tags"].Timestamp != 2 || dst.ΛMetadata["/name"].Timestamp != 1 {
+		t.Errorf("copyStruct(%v, %v): did not get expected merged metadata, got: %v", dst, src, dst.ΛMetadata)
+	}
+
+	overlap := &annotatedExample{ΛMetadata: NodeMetadata{"/name": {Timestamp: 3}}}
+	if err := copyStruct(reflect.ValueOf(overlap).Elem(), reflect.ValueOf(src).Elem()); err == nil {
+		t.Errorf("copyStruct(%v, %v): did not get expected error for overlapping metadata", overlap, src)
+	}
+}
+
+// jsonDiff returns the difference between the JSON encoding of got, and the
+// JSON string want, or the empty string if they are equivalent.
+func jsonDiff(t *testing.T, got interface{}, want string) string {
+	js, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal(%v): got unexpected error: %v", got, err)
+	}
+	var g, w interface{}
+	if err := json.Unmarshal(js, &g); err != nil {
+		t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", want, err)
+	}
+	return pretty.Compare(g, w)
+}
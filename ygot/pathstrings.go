@@ -0,0 +1,415 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Refer to: https://github.com/openconfig/reference/blob/master/rpc/gnmi/gnmi-path-strings.md.
+
+var (
+	// nameEscaper escapes the characters of an element name that would
+	// otherwise end the element within a path string.
+	nameEscaper = strings.NewReplacer(`\`, `\\`, `/`, `\/`, `[`, `\[`)
+	// stringSliceEscaper escapes the characters of an element of a string
+	// slice path that would otherwise end the element within a path string.
+	stringSliceEscaper = strings.NewReplacer(`\`, `\\`, `/`, `\/`)
+	// keyNameEscaper escapes the characters of a key name that would
+	// otherwise end the name within a path string.
+	keyNameEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, `]`, `\]`)
+	// keyValueEscaper escapes the characters of a key value that would
+	// otherwise end the value within a path string.
+	keyValueEscaper = strings.NewReplacer(`\`, `\\`, `]`, `\]`)
+)
+
+// StringToPath parses the gNMI path string s, returning the PathElem based
+// gNMI path that it describes, e.g.,
+// /interfaces/interface[name=eth0]/state/counters. The string may be prefixed
+// by the origin of the path, followed by a colon, e.g., openconfig:/interfaces.
+// Within the string, the '/' and '[' characters of an element name, the '='
+// and ']' characters of a key name, and the ']' character of a key value must
+// be escaped by a preceding '\', as must the '\' character itself. The leading
+// '/' of the path may be omitted, and the string "/" describes an empty path.
+func StringToPath(s string) (*gnmipb.Path, error) {
+	p := &pathStringParser{s: s}
+	path, err := p.parse(newPathElemGNMIPath(nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path string %q: %v", s, err)
+	}
+	return path, nil
+}
+
+// StringToStringSlicePath parses the gNMI path string s, returning the
+// string slice based gNMI path that it describes, which is stored in the
+// Element field of the path as used in gNMI 0.3.1 and below. Each element of
+// the path string, separated by '/', is an element of the path, such that
+// the keys of a list entry are elements of their own, e.g.,
+// /interfaces/interface/eth0/state. The '/' and '\' characters within an
+// element must be escaped by a preceding '\'. The path string may be
+// prefixed by an origin as for StringToPath.
+func StringToStringSlicePath(s string) (*gnmipb.Path, error) {
+	p := &pathStringParser{s: s}
+	path, err := p.parse(newStringSliceGNMIPath(nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path string %q: %v", s, err)
+	}
+	return path, nil
+}
+
+// StringToSchemaPath parses the gNMI path string s as StringToPath does, and
+// checks that the element and key names of the path are valid for the schema
+// tree described by schema, to which the path is relative. Module prefixes of
+// the names are ignored. Where schema is the fake root of generated code, the
+// path of each of its children is the path within the data tree, such that
+// the containers that were removed by path compression are expected. The
+// values of keys are not checked, and may be omitted or set to the wildcard
+// value, and the path is not checked beyond its first wildcard element, "*"
+// or "...".
+func StringToSchemaPath(s string, schema *yang.Entry) (*gnmipb.Path, error) {
+	path, err := StringToPath(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSchemaPath(schema, path.GetElem()); err != nil {
+		return nil, fmt.Errorf("invalid path string %q for schema %s: %v", s, schema.Name, err)
+	}
+	return path, nil
+}
+
+// PathToString returns the gNMI path string form of the gNMI path p, which
+// may be a PathElem or string slice based path, such that it can be parsed
+// by StringToPath or StringToStringSlicePath respectively. Keys are output in
+// alphabetical order. An error is returned if p is not a valid path.
+func PathToString(p *gnmipb.Path) (string, error) {
+	g := &gnmiPath{stringSlicePath: p.GetElement(), pathElemPath: p.GetElem()}
+	if g.stringSlicePath == nil && g.pathElemPath == nil {
+		g.pathElemPath = []*gnmipb.PathElem{}
+	}
+	if !g.isValid() {
+		return "", fmt.Errorf("invalid path %v, both the Element and Elem fields are set", p)
+	}
+	if strings.ContainsAny(p.GetOrigin(), "/[") {
+		return "", fmt.Errorf("invalid origin %q of path %v", p.GetOrigin(), p)
+	}
+
+	var b bytes.Buffer
+	if p.GetOrigin() != "" {
+		b.WriteString(p.GetOrigin() + ":")
+	}
+	if g.Len() == 0 {
+		b.WriteString("/")
+	}
+	for i := 0; i < g.Len(); i++ {
+		b.WriteString("/")
+		if g.isStringSlicePath() {
+			e, err := g.StringElemAt(i)
+			if err != nil {
+				return "", err
+			}
+			if e == "" {
+				return "", fmt.Errorf("invalid path %v, element %d is empty", p, i)
+			}
+			b.WriteString(stringSliceEscaper.Replace(e))
+			continue
+		}
+
+		e, err := g.PathElemAt(i)
+		if err != nil {
+			return "", err
+		}
+		if e.GetName() == "" {
+			return "", fmt.Errorf("invalid path %v, element %d has no name", p, i)
+		}
+		b.WriteString(nameEscaper.Replace(e.GetName()))
+
+		var keys []string
+		for k := range e.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "" {
+				return "", fmt.Errorf("invalid path %v, element %s has a key with no name", p, e.GetName())
+			}
+			fmt.Fprintf(&b, "[%s=%s]", keyNameEscaper.Replace(k), keyValueEscaper.Replace(e.GetKey()[k]))
+		}
+	}
+	return b.String(), nil
+}
+
+// pathStringParser parses a gNMI path string, s. i is the index of the next
+// character of s to be consumed.
+type pathStringParser struct {
+	s string
+	i int
+}
+
+// parse parses the whole of the path string, appending each of the elements
+// that it describes to the empty path g, and returns the resulting gNMI path.
+func (p *pathStringParser) parse(g *gnmiPath) (*gnmipb.Path, error) {
+	origin := p.origin()
+	if err := p.elements(func() error { return p.element(g) }); err != nil {
+		return nil, err
+	}
+
+	path, err := g.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		path = &gnmipb.Path{}
+	}
+	path.Origin = origin
+	return path, nil
+}
+
+// elements parses the elements of the path, which are separated by '/',
+// calling elem to parse each of them. The leading '/' of the path is
+// optional.
+func (p *pathStringParser) elements(elem func() error) error {
+	p.consume('/')
+	if p.done() {
+		return nil
+	}
+	for {
+		if err := elem(); err != nil {
+			return err
+		}
+		if p.done() {
+			return nil
+		}
+		if !p.consume('/') {
+			return p.errorf("expected /")
+		}
+		if p.done() {
+			return p.errorf("expected element")
+		}
+	}
+}
+
+// origin consumes the origin prefix of the path string, if there is one,
+// returning the origin. The origin is any text before the first unescaped
+// '/' of the path string that is followed by ":/", or the whole of a path
+// string that ends with ':' and does not contain '/'.
+func (p *pathStringParser) origin() string {
+	for i := 0; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '/', '[':
+			return ""
+		case ':':
+			if i != 0 && (i == len(p.s)-1 || p.s[i+1] == '/') {
+				p.i = i + 1
+				return p.s[:i]
+			}
+		}
+	}
+	return ""
+}
+
+// element parses a single element of the path string, appending it to g.
+// Where g is a PathElem path, the element may be followed by any number of
+// key predicates of the form [name=value].
+func (p *pathStringParser) element(g *gnmiPath) error {
+	if g.isStringSlicePath() {
+		e, err := p.until("/", "element")
+		if err != nil {
+			return err
+		}
+		return g.AppendName(e)
+	}
+
+	name, err := p.until("/[", "element name")
+	if err != nil {
+		return err
+	}
+	e := &gnmipb.PathElem{Name: name}
+	if err := p.predicates(e, p.keyPredicate); err != nil {
+		return err
+	}
+	if err := g.AppendName(name); err != nil {
+		return err
+	}
+	return g.SetIndex(g.Len()-1, e)
+}
+
+// predicates parses any number of predicates of the path element e, calling
+// pred to parse each of them following its opening '[', and adds the key that
+// each predicate specifies to e.
+func (p *pathStringParser) predicates(e *gnmipb.PathElem, pred func() (string, string, error)) error {
+	for p.consume('[') {
+		k, v, err := pred()
+		if err != nil {
+			return err
+		}
+		if _, ok := e.Key[k]; ok {
+			return fmt.Errorf("element %s has more than one value for key %s", e.Name, k)
+		}
+		if e.Key == nil {
+			e.Key = map[string]string{}
+		}
+		e.Key[k] = v
+	}
+	return nil
+}
+
+// keyPredicate parses a key predicate of the form name=value], returning the
+// name and value of the key.
+func (p *pathStringParser) keyPredicate() (string, string, error) {
+	k, err := p.until("=]", "key name")
+	if err != nil {
+		return "", "", err
+	}
+	if !p.consume('=') {
+		return "", "", p.errorf("expected = after key name %s", k)
+	}
+	v, err := p.value()
+	if err != nil {
+		return "", "", err
+	}
+	return k, v, nil
+}
+
+// value parses the value of a key, up to and including the ']' that ends
+// it. The value may be empty.
+func (p *pathStringParser) value() (string, error) {
+	var b bytes.Buffer
+	for ; !p.done(); p.i++ {
+		switch c := p.s[p.i]; c {
+		case '\\':
+			if p.i++; p.done() {
+				return "", p.errorf("unterminated escape sequence")
+			}
+			b.WriteByte(p.s[p.i])
+		case ']':
+			p.i++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("expected ] to end key value")
+}
+
+// until parses the non-empty text up to the first unescaped character that
+// is within stop, or the end of the path string, returning the text with any
+// escape sequences removed. desc describes the text for use in errors.
+func (p *pathStringParser) until(stop, desc string) (string, error) {
+	var b bytes.Buffer
+	for ; !p.done() && strings.IndexByte(stop, p.s[p.i]) == -1; p.i++ {
+		if p.s[p.i] == '\\' {
+			if p.i++; p.done() {
+				return "", p.errorf("unterminated escape sequence")
+			}
+		}
+		b.WriteByte(p.s[p.i])
+	}
+	if b.Len() == 0 {
+		return "", p.errorf("expected %s", desc)
+	}
+	return b.String(), nil
+}
+
+// consume consumes the character c if it is at the current position,
+// returning true if it was consumed.
+func (p *pathStringParser) consume(c byte) bool {
+	if !p.done() && p.s[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// done returns true if all of the input has been consumed.
+func (p *pathStringParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+// errorf returns an error describing a problem at the current position.
+func (p *pathStringParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), p.i)
+}
+
+// checkSchemaPath checks that the names of the path elements elems, and of
+// their keys, are valid for the schema tree described by schema, to which the
+// path is relative. It returns an error if they are not.
+func checkSchemaPath(schema *yang.Entry, elems []*gnmipb.PathElem) error {
+	for len(elems) != 0 {
+		if isWildcardElem(elems[0]) {
+			return nil
+		}
+		if !schema.IsDir() {
+			return fmt.Errorf("path traverses non-container node %s", schema.Name)
+		}
+		// The path is only matched up to its first wildcard element.
+		var names []string
+		for _, e := range elems {
+			if isWildcardElem(e) {
+				break
+			}
+			names = append(names, e.GetName())
+		}
+		ch, n := util.DataPathChild(schema, names)
+		if ch == nil {
+			return fmt.Errorf("element %s not found in schema %s", elems[0].GetName(), schema.Name)
+		}
+		// Only the elements that name the child are checked where the path
+		// ends, or contains a wildcard, within the data path of the child.
+		m := n
+		if len(names) < m {
+			m = len(names)
+		}
+		for i, e := range elems[:m] {
+			if i == n-1 && ch.IsList() {
+				break
+			}
+			if len(e.GetKey()) != 0 {
+				return fmt.Errorf("element %s specifies keys, but is not a list", e.GetName())
+			}
+		}
+		if m < n {
+			return nil
+		}
+		if ch.IsList() {
+			keys := map[string]bool{}
+			for _, k := range strings.Fields(ch.Key) {
+				keys[k] = true
+			}
+			for k := range elems[n-1].GetKey() {
+				if !keys[util.StripModulePrefix(k)] {
+					return fmt.Errorf("element %s specifies key %s, which is not a key of list %s", elems[n-1].GetName(), k, ch.Name)
+				}
+			}
+		}
+		schema, elems = ch, elems[n:]
+	}
+	return nil
+}
+
+// isWildcardElem returns true if the path element e is a wildcard, which
+// matches either any single element, or any number of elements.
+func isWildcardElem(e *gnmipb.PathElem) bool {
+	return e.GetName() == "*" || e.GetName() == "..."
+}
@@ -0,0 +1,416 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestStringToPath(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		want          *gnmipb.Path
+		wantString    string
+		wantErrSubstr string
+	}{{
+		name: "path with keys",
+		in:   "/interfaces/interface[name=eth0]/state/counters",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "eth0"}},
+			{Name: "state"},
+			{Name: "counters"},
+		}},
+		wantString: "/interfaces/interface[name=eth0]/state/counters",
+	}, {
+		name: "multiple keys with module prefixes",
+		in:   "/oc-if:interfaces/interface[name=eth0]/subinterfaces/subinterface[oc-if:index=0][b=1]",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "oc-if:interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "eth0"}},
+			{Name: "subinterfaces"},
+			{Name: "subinterface", Key: map[string]string{"oc-if:index": "0", "b": "1"}},
+		}},
+		wantString: "/oc-if:interfaces/interface[name=eth0]/subinterfaces/subinterface[b=1][oc-if:index=0]",
+	}, {
+		name: "escaped characters in key values",
+		in:   `/a[name=x\]y][other=a\=b=c][slash=eth0/1][esc=\\]`,
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "a", Key: map[string]string{"name": "x]y", "other": "a=b=c", "slash": "eth0/1", "esc": `\`}},
+		}},
+		wantString: `/a[esc=\\][name=x\]y][other=a=b=c][slash=eth0/1]`,
+	}, {
+		name: "escaped characters in names",
+		in:   `/a\/b/c[k\=1=v]`,
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "a/b"},
+			{Name: "c", Key: map[string]string{"k=1": "v"}},
+		}},
+		wantString: `/a\/b/c[k\=1=v]`,
+	}, {
+		name: "empty key value",
+		in:   "/a[name=]",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "a", Key: map[string]string{"name": ""}},
+		}},
+		wantString: "/a[name=]",
+	}, {
+		name: "origin",
+		in:   "openconfig:/interfaces/interface[name=eth0]",
+		want: &gnmipb.Path{Origin: "openconfig", Elem: []*gnmipb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "eth0"}},
+		}},
+		wantString: "openconfig:/interfaces/interface[name=eth0]",
+	}, {
+		name:       "origin with empty path",
+		in:         "openconfig:",
+		want:       &gnmipb.Path{Origin: "openconfig"},
+		wantString: "openconfig:/",
+	}, {
+		name: "relative path with module prefix",
+		in:   "oc-if:state/counters",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "oc-if:state"},
+			{Name: "counters"},
+		}},
+		wantString: "/oc-if:state/counters",
+	}, {
+		name: "wildcards",
+		in:   "/interfaces/interface[name=*]/.../counters",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "*"}},
+			{Name: "..."},
+			{Name: "counters"},
+		}},
+		wantString: "/interfaces/interface[name=*]/.../counters",
+	}, {
+		name:       "root path",
+		in:         "/",
+		want:       &gnmipb.Path{},
+		wantString: "/",
+	}, {
+		name:       "empty string",
+		in:         "",
+		want:       &gnmipb.Path{},
+		wantString: "/",
+	}, {
+		name:          "trailing slash",
+		in:            "/a/",
+		wantErrSubstr: "expected element at position 3",
+	}, {
+		name:          "empty element",
+		in:            "/a//b",
+		wantErrSubstr: "expected element name at position 3",
+	}, {
+		name:          "unterminated key",
+		in:            "/a[name=x",
+		wantErrSubstr: "expected ] to end key value",
+	}, {
+		name:          "key without value",
+		in:            "/a[name]",
+		wantErrSubstr: "expected = after key name name",
+	}, {
+		name:          "key without name",
+		in:            "/a[=x]",
+		wantErrSubstr: "expected key name at position 3",
+	}, {
+		name:          "text after key",
+		in:            "/a[name=x]b",
+		wantErrSubstr: "expected / at position 10",
+	}, {
+		name:          "unterminated escape sequence",
+		in:            `/a\`,
+		wantErrSubstr: "unterminated escape sequence",
+	}, {
+		name:          "duplicate key",
+		in:            "/a[name=x][name=y]",
+		wantErrSubstr: "element a has more than one value for key name",
+	}}
+
+	for _, tt := range tests {
+		got, err := StringToPath(tt.in)
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: StringToPath(%q): got unexpected error: %v, want error containing: %q", tt.name, tt.in, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: StringToPath(%q): did not get expected error, got: %v, want error containing: %q", tt.name, tt.in, got, tt.wantErrSubstr)
+			continue
+		}
+		if !proto.Equal(got, tt.want) {
+			t.Errorf("%s: StringToPath(%q): did not get expected path, got: %v, want: %v", tt.name, tt.in, pretty.Sprint(got), pretty.Sprint(tt.want))
+		}
+
+		s, err := PathToString(got)
+		if err != nil {
+			t.Errorf("%s: PathToString(%v): got unexpected error: %v", tt.name, got, err)
+			continue
+		}
+		if s != tt.wantString {
+			t.Errorf("%s: PathToString(%v): got: %s, want: %s", tt.name, got, s, tt.wantString)
+		}
+	}
+}
+
+func TestStringToStringSlicePath(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            string
+		want          *gnmipb.Path
+		wantString    string
+		wantErrSubstr string
+	}{{
+		name:       "path with keys as elements",
+		in:         "/interfaces/interface/eth0/state",
+		want:       &gnmipb.Path{Element: []string{"interfaces", "interface", "eth0", "state"}},
+		wantString: "/interfaces/interface/eth0/state",
+	}, {
+		name:       "escaped slash",
+		in:         `/interfaces/interface/eth0\/1`,
+		want:       &gnmipb.Path{Element: []string{"interfaces", "interface", "eth0/1"}},
+		wantString: `/interfaces/interface/eth0\/1`,
+	}, {
+		name:       "brackets are not treated as keys",
+		in:         "/interfaces/interface[name=eth0]",
+		want:       &gnmipb.Path{Element: []string{"interfaces", "interface[name=eth0]"}},
+		wantString: "/interfaces/interface[name=eth0]",
+	}, {
+		name:       "origin",
+		in:         "openconfig:/interfaces",
+		want:       &gnmipb.Path{Origin: "openconfig", Element: []string{"interfaces"}},
+		wantString: "openconfig:/interfaces",
+	}, {
+		name:          "trailing slash",
+		in:            "/a/",
+		wantErrSubstr: "expected element at position 3",
+	}}
+
+	for _, tt := range tests {
+		got, err := StringToStringSlicePath(tt.in)
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: StringToStringSlicePath(%q): got unexpected error: %v, want error containing: %q", tt.name, tt.in, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: StringToStringSlicePath(%q): did not get expected error, got: %v, want error containing: %q", tt.name, tt.in, got, tt.wantErrSubstr)
+			continue
+		}
+		if !proto.Equal(got, tt.want) {
+			t.Errorf("%s: StringToStringSlicePath(%q): did not get expected path, got: %v, want: %v", tt.name, tt.in, pretty.Sprint(got), pretty.Sprint(tt.want))
+		}
+
+		s, err := PathToString(got)
+		if err != nil {
+			t.Errorf("%s: PathToString(%v): got unexpected error: %v", tt.name, got, err)
+			continue
+		}
+		if s != tt.wantString {
+			t.Errorf("%s: PathToString(%v): got: %s, want: %s", tt.name, got, s, tt.wantString)
+		}
+	}
+}
+
+func TestPathToStringErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            *gnmipb.Path
+		wantErrSubstr string
+	}{{
+		name: "both Element and Elem set",
+		in: &gnmipb.Path{
+			Element: []string{"a"},
+			Elem:    []*gnmipb.PathElem{{Name: "a"}},
+		},
+		wantErrSubstr: "both the Element and Elem fields are set",
+	}, {
+		name:          "element without a name",
+		in:            &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "a"}, {}}},
+		wantErrSubstr: "element 1 has no name",
+	}, {
+		name:          "empty string slice element",
+		in:            &gnmipb.Path{Element: []string{""}},
+		wantErrSubstr: "element 0 is empty",
+	}, {
+		name:          "key without a name",
+		in:            &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "a", Key: map[string]string{"": "x"}}}},
+		wantErrSubstr: "element a has a key with no name",
+	}, {
+		name:          "invalid origin",
+		in:            &gnmipb.Path{Origin: "a/b"},
+		wantErrSubstr: `invalid origin "a/b"`,
+	}}
+
+	for _, tt := range tests {
+		if got, err := PathToString(tt.in); err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+			t.Errorf("%s: PathToString(%v): got: %s, error: %v, want error containing: %q", tt.name, tt.in, got, err, tt.wantErrSubstr)
+		}
+	}
+}
+
+// pathStringSchema returns a schema containing a fake root, whose children
+// include a list whose surrounding container was removed by path compression.
+func pathStringSchema() *yang.Entry {
+	intf := &yang.Entry{
+		Name:       "interface",
+		Kind:       yang.DirectoryEntry,
+		ListAttr:   &yang.ListAttr{},
+		Key:        "name",
+		Annotation: map[string]interface{}{"schemapath": "/openconfig-interfaces/interfaces/interface"},
+		Dir: map[string]*yang.Entry{
+			"name": {Name: "name", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}},
+			"state": {
+				Name: "state",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"counters": {Name: "counters", Kind: yang.DirectoryEntry, Dir: map[string]*yang.Entry{}},
+					"mtu":      {Name: "mtu", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yuint16}},
+				},
+			},
+		},
+	}
+	system := &yang.Entry{
+		Name:       "system",
+		Kind:       yang.DirectoryEntry,
+		Annotation: map[string]interface{}{"schemapath": "/openconfig-system/system"},
+		Dir: map[string]*yang.Entry{
+			"mode": {
+				Name: "mode",
+				Kind: yang.ChoiceEntry,
+				Dir: map[string]*yang.Entry{
+					"a": {
+						Name: "a",
+						Kind: yang.CaseEntry,
+						Dir: map[string]*yang.Entry{
+							"hostname": {Name: "hostname", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}},
+						},
+					},
+				},
+			},
+		},
+	}
+	root := &yang.Entry{
+		Name:       "device",
+		Kind:       yang.DirectoryEntry,
+		Annotation: map[string]interface{}{"isFakeRoot": true},
+		Dir:        map[string]*yang.Entry{"interface": intf, "system": system},
+	}
+	for _, e := range []*yang.Entry{intf, system} {
+		e.Parent = root
+		for _, ch := range e.Dir {
+			ch.Parent = e
+		}
+	}
+	return root
+}
+
+func TestStringToSchemaPath(t *testing.T) {
+	root := pathStringSchema()
+
+	tests := []struct {
+		name          string
+		in            string
+		inSchema      *yang.Entry
+		wantErrSubstr string
+	}{{
+		name:     "list entry",
+		in:       "/interfaces/interface[name=eth0]/state/counters",
+		inSchema: root,
+	}, {
+		name:     "module prefixes",
+		in:       "/openconfig-interfaces:interfaces/interface[openconfig-interfaces:name=eth0]/state",
+		inSchema: root,
+	}, {
+		name:     "all list entries",
+		in:       "/interfaces/interface/state/mtu",
+		inSchema: root,
+	}, {
+		name:     "container removed by path compression",
+		in:       "/interfaces",
+		inSchema: root,
+	}, {
+		name:     "wildcard within data path of child",
+		in:       "/interfaces/*/state",
+		inSchema: root,
+	}, {
+		name:     "node within choice",
+		in:       "/system/hostname",
+		inSchema: root,
+	}, {
+		name:     "unchecked path after wildcard",
+		in:       "/interfaces/interface[name=eth0]/.../unknown",
+		inSchema: root,
+	}, {
+		name:     "path relative to list",
+		in:       "state/mtu",
+		inSchema: root.Dir["interface"],
+	}, {
+		name:          "unknown element",
+		in:            "/interfaces/interface[name=eth0]/config",
+		inSchema:      root,
+		wantErrSubstr: "element config not found in schema interface",
+	}, {
+		name:          "unknown key",
+		in:            "/interfaces/interface[id=1]",
+		inSchema:      root,
+		wantErrSubstr: "element interface specifies key id, which is not a key of list interface",
+	}, {
+		name:          "keys for container",
+		in:            "/interfaces[name=eth0]/interface",
+		inSchema:      root,
+		wantErrSubstr: "element interfaces specifies keys, but is not a list",
+	}, {
+		name:          "path traversing leaf",
+		in:            "/interfaces/interface/state/mtu/value",
+		inSchema:      root,
+		wantErrSubstr: "path traverses non-container node mtu",
+	}, {
+		name:          "unparseable path",
+		in:            "/interfaces[",
+		inSchema:      root,
+		wantErrSubstr: "expected key name",
+	}}
+
+	for _, tt := range tests {
+		got, err := StringToSchemaPath(tt.in, tt.inSchema)
+		switch {
+		case err != nil && (tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr)):
+			t.Errorf("%s: StringToSchemaPath(%q): got unexpected error: %v, want error containing: %q", tt.name, tt.in, err, tt.wantErrSubstr)
+		case err == nil && tt.wantErrSubstr != "":
+			t.Errorf("%s: StringToSchemaPath(%q): did not get expected error, got: %v, want error containing: %q", tt.name, tt.in, got, tt.wantErrSubstr)
+		case err == nil:
+			want, err := StringToPath(tt.in)
+			if err != nil {
+				t.Fatalf("%s: StringToPath(%q): got unexpected error: %v", tt.name, tt.in, err)
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("%s: StringToSchemaPath(%q): got: %v, want: %v", tt.name, tt.in, got, want)
+			}
+		}
+	}
+}
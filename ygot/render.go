@@ -15,15 +15,19 @@
 package ygot
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/openconfig/gnmi/errlist"
 	"github.com/openconfig/gnmi/value"
+	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
 
 	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
@@ -38,12 +42,6 @@ const (
 	EmptyTypeName string = "YANGEmpty"
 )
 
-// path stores the elements of a path for a particular leaf,
-// such that it can be used as a key for maps.
-type path struct {
-	p *gnmiPath
-}
-
 // gnmiPath provides a wrapper for gNMI path types, particularly
 // containing the Element-based paths which are used in gNMI pre-0.3.1 and
 // PathElem-based paths which are used in gNMI 0.4.0 and above.
@@ -56,6 +54,9 @@ type gnmiPath struct {
 	// isAbsolute determines whether the stored path is absolute (when set), or relative
 	// when unset.
 	isAbsolute bool
+	// origin stores the origin of the path, which is output in the origin
+	// field of the gNMI Path message when it is non-empty.
+	origin string
 }
 
 // newStringSliceGNMIPath returns a new gnmiPath with a string slice path.
@@ -94,7 +95,7 @@ func (g *gnmiPath) isPathElemPath() bool {
 
 // Copy returns a copy of the current gnmiPath.
 func (g *gnmiPath) Copy() *gnmiPath {
-	n := &gnmiPath{}
+	n := &gnmiPath{origin: g.origin}
 	if g.isStringSlicePath() {
 		n.stringSlicePath = make([]string, len(g.stringSlicePath))
 		copy(n.stringSlicePath, g.stringSlicePath)
@@ -202,14 +203,15 @@ func (g *gnmiPath) ToProto() (*gnmipb.Path, error) {
 		return nil, errors.New("invalid path")
 	}
 
-	if g.Len() == 0 {
+	switch {
+	case g.Len() == 0 && g.origin == "":
 		return nil, nil
+	case g.Len() == 0:
+		return &gnmipb.Path{Origin: g.origin}, nil
+	case g.isStringSlicePath():
+		return &gnmipb.Path{Element: g.stringSlicePath, Origin: g.origin}, nil
 	}
-
-	if g.isStringSlicePath() {
-		return &gnmipb.Path{Element: g.stringSlicePath}, nil
-	}
-	return &gnmipb.Path{Elem: g.pathElemPath}, nil
+	return &gnmipb.Path{Elem: g.pathElemPath, Origin: g.origin}, nil
 }
 
 // isSameType returns true if the path supplied is the same type as the
@@ -264,58 +266,433 @@ type GNMINotificationsConfig struct {
 	// of PathElem messages. This path format is used by gNMI 0.4.0 and
 	// above. Used if PathElem is set.
 	PathElemPrefix []*gnmipb.PathElem
+	// MaxUpdates specifies the maximum number of updates that are included
+	// in a single Notification message. If set to zero, the number of
+	// updates in a Notification is not limited.
+	MaxUpdates int
+	// MaxBytes specifies the maximum size, in bytes, of the serialised
+	// form of a single Notification message. An update which cannot fit
+	// within MaxBytes is sent in a Notification of its own. If set to zero,
+	// the size of a Notification is not limited.
+	MaxBytes int
+	// GroupByPrefix specifies whether the updates within each Notification
+	// should share the path of the GoStruct that contains them as a common
+	// prefix. If set, the Prefix field of each Notification is the path of
+	// the container or list member whose leaves it contains (including the
+	// prefix specified by StringSlicePrefix or PathElemPrefix), and the
+	// paths of its updates are relative to this prefix.
+	GroupByPrefix bool
+	// Schema is the schema of the GoStruct being rendered. It is used to
+	// determine the YANG type of each leaf where the encoding of the leaf
+	// depends upon it, as it does when Decimal64 is set.
+	Schema *yang.Entry
+	// Decimal64 specifies that YANG decimal64 leaves that are stored as
+	// float64 values should be encoded using the Decimal64 TypedValue, with
+	// the precision set to the fraction-digits of the leaf's type. If unset,
+	// or if Schema is not specified, such leaves are encoded as floating
+	// point values. Leaves that are stored using the Decimal64 type are
+	// always encoded using the Decimal64 TypedValue. Since the Decimal64
+	// TypedValue cannot represent negative values, they are always encoded
+	// as floating point values.
+	Decimal64 bool
+	// JSONIETFSubtrees specifies that each container and list member
+	// that is a direct child of the rendered GoStruct should be output as
+	// a single update, at the path of the container or list member, whose
+	// value is the RFC7951 JSON encoding of the subtree (JSON_IETF
+	// encoding in gNMI). Leaves of the rendered GoStruct itself continue
+	// to be output as scalar values. Binary leaves are always encoded
+	// as bytes when output as scalar values.
+	JSONIETFSubtrees bool
+	// Origin specifies the origin of the paths of the output
+	// Notifications, which is set in the Prefix field of each
+	// Notification.
+	Origin string
+	// OriginFromModule specifies that the origin of the path of each
+	// update should be the name of the YANG module that defines the first
+	// element of its path, such that data from different modules, such as
+	// OpenConfig and native vendor modules, can be output together. The
+	// module is determined from the module tags of generated GoStructs, or
+	// from the schema of a DynamicNode. Updates with different origins are
+	// output in separate Notifications, with the origin set in the Prefix
+	// field of each Notification. Where the module of a path cannot be
+	// determined, Origin is used.
+	OriginFromModule bool
+	// PrependModuleNames specifies that the names of the PathElem messages
+	// of the output paths should be qualified by the name of the YANG
+	// module that defines them, in the form module:name, where the element
+	// is the first element of a path within the rendered GoStruct, or is
+	// defined in a different module to its parent, as per the encoding of
+	// member names in RFC7951 JSON. The elements of PathElemPrefix are
+	// not modified. Used if UsePathElem is set.
+	PrependModuleNames bool
+	// Metadata stores the metadata of the nodes of the rendered GoStruct.
+	// Where the metadata of a leaf, or of a subtree that is output as a
+	// JSON_IETF value, specifies its timestamp, the leaf is output in a
+	// Notification with that timestamp, rather than the timestamp supplied
+	// for the GoStruct. The updates with each timestamp are grouped into
+	// Notifications of their own, subject to MaxUpdates, MaxBytes,
+	// GroupByPrefix and MaxPendingNotifications.
+	Metadata NodeMetadata
+	// MaxPendingNotifications specifies the maximum number of
+	// Notifications, each with a distinct timestamp or origin, that are
+	// held in memory whilst they are being built. Where an update requires
+	// a new Notification and the limit has been reached, the Notification
+	// that was started first is sent, such that updates with its timestamp
+	// and origin that are rendered later are output in a further
+	// Notification. If set to zero, DefaultMaxPendingNotifications is used.
+	MaxPendingNotifications int
 }
 
+// DefaultMaxPendingNotifications is the maximum number of Notifications that
+// are held in memory whilst rendering a GoStruct, where the
+// MaxPendingNotifications field of the GNMINotificationsConfig is not set.
+const DefaultMaxPendingNotifications = 16
+
 // TogNMINotifications takes an input GoStruct and renders it to slice of
 // Notification messages, marked with the specified timestamp. The configuration
-// provided determines the path format utilised, and the prefix to be included
-// in the message if relevant.
+// provided determines the path format utilised, the prefix to be included
+// in the message if relevant, and how the updates are split across
+// Notification messages.
 func TogNMINotifications(s GoStruct, ts int64, cfg GNMINotificationsConfig) ([]*gnmipb.Notification, error) {
+	var msgs []*gnmipb.Notification
+	err := StreamgNMINotifications(s, ts, cfg, func(n *gnmipb.Notification) error {
+		msgs = append(msgs, n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
 
+// StreamgNMINotifications takes an input GoStruct and renders it to a series
+// of Notification messages, marked with the specified timestamp, each of which
+// is supplied to the send function as soon as it is complete. Unlike
+// TogNMINotifications, the updates for the entire GoStruct are not held in
+// memory, such that when the MaxUpdates or MaxBytes fields of the supplied
+// configuration are set, large GoStructs can be rendered using a bounded
+// amount of memory.
+//
+// If send returns an error, rendering is stopped and the error is returned.
+// Errors encountered when rendering the GoStruct do not stop rendering; they
+// are returned once all valid leaves have been sent.
+func StreamgNMINotifications(s GoStruct, ts int64, cfg GNMINotificationsConfig, send func(*gnmipb.Notification) error) error {
 	var pfx *gnmiPath
 	if cfg.UsePathElem {
 		pfx = newPathElemGNMIPath(cfg.PathElemPrefix)
 	} else {
 		pfx = newStringSliceGNMIPath(cfg.StringSlicePrefix)
 	}
+	pfx.origin = cfg.Origin
+
+	b := &notificationBuilder{
+		ts:            ts,
+		pfx:           pfx,
+		maxUpdates:    cfg.MaxUpdates,
+		maxBytes:      cfg.MaxBytes,
+		maxPending:    cfg.MaxPendingNotifications,
+		groupByPrefix: cfg.GroupByPrefix,
+		decimal64:     cfg.Decimal64,
+		metadata:      cfg.Metadata,
+		send:          send,
+	}
+
+	w := &leafWalker{
+		visit:            b.add,
+		subtrees:         cfg.JSONIETFSubtrees,
+		originFromModule: cfg.OriginFromModule,
+		moduleNames:      cfg.PrependModuleNames,
+	}
+	if err := w.findUpdatedLeaves(s, cfg.Schema, pfx); err != nil {
+		if b.sendErr != nil {
+			return b.sendErr
+		}
+		// Send the leaves that were rendered successfully before returning
+		// the error.
+		if ferr := b.flush(); ferr != nil {
+			return ferr
+		}
+		return err
+	}
 
-	leaves := map[*path]interface{}{}
-	if err := findUpdatedLeaves(leaves, s, pfx); err != nil {
-		return nil, err
+	return b.flush()
+}
+
+// notificationBuilder accumulates updates into gNMI Notification messages,
+// sending each message once it reaches the configured size limits.
+type notificationBuilder struct {
+	// ts is the timestamp used for each Notification, unless the metadata
+	// of a leaf specifies its timestamp.
+	ts int64
+	// pfx is the prefix used for each Notification when groupByPrefix is
+	// not set.
+	pfx *gnmiPath
+	// maxUpdates and maxBytes are the maximum number of updates, and the
+	// maximum size in bytes of each Notification. Zero indicates no limit.
+	maxUpdates, maxBytes int
+	// maxPending is the maximum number of Notifications that are built
+	// concurrently. Zero indicates DefaultMaxPendingNotifications.
+	maxPending int
+	// groupByPrefix indicates that a new Notification should be started for
+	// each GoStruct, with the path of the GoStruct as its prefix.
+	groupByPrefix bool
+	// decimal64 indicates that decimal64 leaves should be encoded using the
+	// Decimal64 TypedValue, where their schema is known.
+	decimal64 bool
+	// metadata stores the metadata of the nodes being rendered, which
+	// specifies the timestamp of each leaf.
+	metadata NodeMetadata
+	// send is the function that each completed Notification is supplied to.
+	send func(*gnmipb.Notification) error
+	// sendErr stores the error returned by send, if any.
+	sendErr error
+
+	// pending stores the Notifications currently being built, keyed by
+	// their timestamp and the origin of their prefix, and order stores
+	// their keys in the order in which they were started. curPfx is the
+	// prefix of the pending Notifications.
+	pending map[pendingKey]*pendingNotification
+	order   []pendingKey
+	curPfx  *gnmiPath
+}
+
+// pendingKey is the key of a Notification that is being built by a
+// notificationBuilder. Only updates with the same timestamp and origin can be
+// added to the same Notification.
+type pendingKey struct {
+	ts     int64
+	origin string
+}
+
+// pendingNotification is a Notification that is being built by a
+// notificationBuilder, along with the size of its serialised form, in bytes.
+type pendingNotification struct {
+	n    *gnmipb.Notification
+	size int
+}
+
+// add appends the leaf with path p, value v and schema, found within the
+// GoStruct at path parent, to the Notification currently being built for its
+// timestamp and origin. If v is itself a GoStruct, it is rendered as a
+// JSON_IETF value. If the Notification cannot accommodate the leaf, it is
+// sent, and a new Notification is started. Where starting a Notification
+// would exceed the maximum number of pending Notifications, the oldest
+// pending Notification is sent first. add is a leafVisitor.
+func (b *notificationBuilder) add(parent, p *gnmiPath, v interface{}, schema *yang.Entry) error {
+	npfx := b.pfx
+	if b.groupByPrefix {
+		npfx = parent
+		// Since the leaves of a GoStruct are visited contiguously, and share
+		// the same parent, the parent is compared by identity.
+		if len(b.pending) != 0 && b.curPfx != parent {
+			if err := b.flush(); err != nil {
+				return err
+			}
+		}
 	}
 
-	msgs, err := leavesToNotifications(leaves, ts, pfx)
+	rp, err := p.StripPrefix(npfx)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	var u *gnmipb.Update
+	switch gs, isStruct := v.(GoStruct); {
+	case isStruct:
+		u, err = subtreeToUpdate(rp, gs)
+	default:
+		u, err = leafToUpdate(rp, v)
+		if err == nil && b.decimal64 {
+			err = encodeDecimal64(u.Val, schema)
+		}
+	}
+	if err != nil {
+		return err
 	}
 
-	return msgs, nil
+	k := pendingKey{ts: b.ts, origin: p.origin}
+	if b.metadata != nil {
+		mp, err := p.StripPrefix(b.pfx)
+		if err != nil {
+			return err
+		}
+		md, err := b.metadata.lookup(mp)
+		if err != nil {
+			return err
+		}
+		if md != nil && md.Timestamp != 0 {
+			k.ts = md.Timestamp
+		}
+	}
+
+	us := proto.Size(u)
+	// Each update is encoded as a length-delimited field with a single byte
+	// tag within the Notification.
+	us += 1 + proto.SizeVarint(uint64(us))
+
+	pn := b.pending[k]
+	if pn != nil && len(pn.n.Update) > 0 {
+		if (b.maxUpdates > 0 && len(pn.n.Update) >= b.maxUpdates) || (b.maxBytes > 0 && pn.size+us > b.maxBytes) {
+			if err := b.sendPending(k); err != nil {
+				return err
+			}
+			pn = nil
+		}
+	}
+
+	if pn == nil {
+		maxPending := b.maxPending
+		if maxPending <= 0 {
+			maxPending = DefaultMaxPendingNotifications
+		}
+		if len(b.order) >= maxPending {
+			if err := b.sendPending(b.order[0]); err != nil {
+				return err
+			}
+		}
+		opfx := npfx.Copy()
+		opfx.origin = p.origin
+		ppfx, err := opfx.ToProto()
+		if err != nil {
+			return err
+		}
+		pn = &pendingNotification{n: &gnmipb.Notification{
+			Timestamp: k.ts,
+			Prefix:    ppfx,
+		}}
+		pn.size = proto.Size(pn.n)
+		if b.pending == nil {
+			b.pending = map[pendingKey]*pendingNotification{}
+		}
+		b.pending[k] = pn
+		b.order = append(b.order, k)
+		b.curPfx = npfx
+	}
+
+	pn.n.Update = append(pn.n.Update, u)
+	pn.size += us
+	return nil
 }
 
-// findUpdatedLeaves appends the valid leaves that are within the supplied
-// GoStruct (assumed to the rooted at parentPath) to the supplied leaves map.
-// If errors are encountered they are appended to the errlist.List supplied. If
-// the GoStruct contains fields that are themselves structured objects (YANG
-// lists, or containers - represented as maps or struct pointers), the function
-// is called recursively on them.
-func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPath) error {
-	var errs errlist.List
+// sendPending sends the pending Notification with the key k.
+func (b *notificationBuilder) sendPending(k pendingKey) error {
+	n := b.pending[k].n
+	delete(b.pending, k)
+	for i, ok := range b.order {
+		if ok == k {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+	if err := b.send(n); err != nil {
+		b.sendErr = err
+		return err
+	}
+	return nil
+}
+
+// flush sends each of the pending Notifications, in the order in which they
+// were started.
+func (b *notificationBuilder) flush() error {
+	for len(b.order) != 0 {
+		if err := b.sendPending(b.order[0]); err != nil {
+			return err
+		}
+	}
+	b.curPfx = nil
+	return nil
+}
 
+// leafVisitor is a function that is called for each populated leaf that is
+// found when walking a GoStruct. It is supplied with the path of the GoStruct
+// that contains the leaf, the path of the leaf itself, the leaf's value and its
+// schema, which is nil if the schema of the GoStruct is not known.
+type leafVisitor func(parent, leaf *gnmiPath, val interface{}, schema *yang.Entry) error
+
+// leafWalker walks a GoStruct, calling visit for each of the populated leaves
+// within it.
+type leafWalker struct {
+	// visit is the function called for each leaf.
+	visit leafVisitor
+	// subtrees indicates that the containers and list members that are
+	// children of the walked GoStruct should be supplied to visit as
+	// GoStructs, rather than walked.
+	subtrees bool
+	// originFromModule indicates that the origin of each path should be
+	// set to the name of the module that defines its first element.
+	originFromModule bool
+	// moduleNames indicates that the names of the PathElems of each path
+	// should be qualified by the name of their module, where it differs
+	// from that of their parent.
+	moduleNames bool
+	// errs stores the errors encountered whilst walking.
+	errs errlist.List
+}
+
+// findUpdatedLeaves calls visit for each of the valid leaves that are within
+// the supplied GoStruct (assumed to the rooted at parentPath, and described by
+// schema, which may be nil). If the GoStruct contains fields that are
+// themselves structured objects (YANG lists, or containers - represented as
+// maps or struct pointers), they are walked recursively, after the leaves of
+// the GoStruct itself have been visited. Errors encountered whilst walking the
+// GoStruct do not stop the walk, and are returned once it has completed. If
+// visit returns an error, the walk is stopped, and the error is returned.
+func (w *leafWalker) findUpdatedLeaves(s GoStruct, schema *yang.Entry, parent *gnmiPath) error {
+	if err := w.walk(s, schema, parent, ""); err != nil {
+		return err
+	}
+	return w.errs.Err()
+}
+
+// walk implements the walk of the GoStruct s, rooted at parent, for
+// findUpdatedLeaves. parentMod is the name of the module that defines s, or
+// the empty string if s is the GoStruct being rendered. Errors encountered
+// whilst walking the GoStruct are appended to the walker's errors. It returns
+// an error only if visit returns an error.
+func (w *leafWalker) walk(s GoStruct, schema *yang.Entry, parent *gnmiPath, parentMod string) error {
+	errs := &w.errs
 	if !parent.isValid() {
-		return fmt.Errorf("invalid parent specified: %v", parent)
+		errs.Add(fmt.Errorf("invalid parent specified: %v", parent))
+		return nil
 	}
 
 	if s == nil {
 		errs.Add(fmt.Errorf("input struct for %v was nil", parent))
-		return errs.Err()
+		return nil
+	}
+
+	if d, ok := s.(*DynamicNode); ok {
+		return w.walkDynamic(d, parent, parentMod)
 	}
 
 	sval := reflect.ValueOf(s).Elem()
 	stype := sval.Type()
 
+	// visitAll calls visit for the value v at each of the paths in ps.
+	visitAll := func(ps []*gnmiPath, v interface{}, vschema *yang.Entry) error {
+		for _, p := range ps {
+			if err := w.visit(parent, p, v, vschema); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// children stores the fields of s that are containers or lists, such
+	// that they can be walked once the leaves of s have been visited.
+	type child struct {
+		fval     reflect.Value
+		mapPaths []*gnmiPath
+		schema   *yang.Entry
+		module   string
+	}
+	var children []child
+
 	for i := 0; i < sval.NumField(); i++ {
 		fval := sval.Field(i)
 		ftype := stype.Field(i)
+		if util.IsYgotAnnotation(ftype) {
+			continue
+		}
 
 		mapPaths, err := structTagToLibPaths(ftype, parent)
 		if err != nil {
@@ -323,6 +700,21 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 			continue
 		}
 
+		// fmod is the module that the children of the field are defined
+		// within. Where the field has an empty path, its children are
+		// qualified as though they were children of s.
+		fmod := parentMod
+		if mod, ok := ftype.Tag.Lookup("module"); ok {
+			for _, p := range mapPaths {
+				if err := w.qualifyPath(p, parent.Len(), mod, parentMod); err != nil {
+					errs.Add(fmt.Errorf("%v->%s: %v", parent, ftype.Name, err))
+				}
+			}
+			if mapPaths[0].Len() != parent.Len() {
+				fmod = mod
+			}
+		}
+
 		// Handle nil values, and enumerations specifically.
 		switch fval.Kind() {
 		case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
@@ -331,36 +723,25 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 			}
 		}
 
+		var fschema *yang.Entry
+		if schema != nil {
+			if fschema, err = util.ChildSchema(schema, ftype); err != nil {
+				errs.Add(fmt.Errorf("%v->%s: %v", parent, ftype.Name, err))
+				continue
+			}
+		}
+
 		switch fval.Kind() {
 		case reflect.Map:
-			// We need to map each child along with its key value.
-			for _, k := range fval.MapKeys() {
-				childPath, err := mapValuePath(k, fval.MapIndex(k), mapPaths[0])
-				if err != nil {
-					errs.Add(err)
-					continue
-				}
-
-				goStruct, ok := fval.MapIndex(k).Interface().(GoStruct)
-				if !ok {
-					errs.Add(fmt.Errorf("%v: was not a valid GoStruct", mapPaths[0]))
-					continue
-				}
-				errs.Add(findUpdatedLeaves(leaves, goStruct, childPath))
-			}
+			children = append(children, child{fval, mapPaths, fschema, fmod})
 		case reflect.Ptr:
 			// Determine whether this is a pointer to a struct (another YANG container), or a leaf.
-			switch fval.Elem().Kind() {
-			case reflect.Struct:
-				goStruct, ok := fval.Interface().(GoStruct)
-				if !ok {
-					errs.Add(fmt.Errorf("%v: was not a valid GoStruct", mapPaths[0]))
-					continue
-				}
-				errs.Add(findUpdatedLeaves(leaves, goStruct, mapPaths[0]))
+			switch {
+			case util.IsValueStructPtr(fval):
+				children = append(children, child{fval, mapPaths, fschema, fmod})
 			default:
-				for _, p := range mapPaths {
-					leaves[&path{p}] = fval.Elem().Interface()
+				if err := visitAll(mapPaths, fval.Elem().Interface(), fschema); err != nil {
+					return err
 				}
 			}
 		case reflect.Slice:
@@ -371,8 +752,8 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 				continue
 			}
 			// This is a leaf-list, so add it as though it were a leaf.
-			for _, p := range mapPaths {
-				leaves[&path{p}] = fval.Interface()
+			if err := visitAll(mapPaths, fval.Interface(), fschema); err != nil {
+				return err
 			}
 		case reflect.Int64:
 			name, set, err := enumFieldToString(fval, false)
@@ -386,10 +767,9 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 				continue
 			}
 
-			for _, p := range mapPaths {
-				leaves[&path{p}] = name
+			if err := visitAll(mapPaths, name, fschema); err != nil {
+				return err
 			}
-			continue
 		case reflect.Interface:
 			// This is a union value.
 			val, err := unionInterfaceValue(fval, false)
@@ -398,24 +778,98 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 				continue
 			}
 
-			for _, p := range mapPaths {
-				leaves[&path{p}] = val
+			if err := visitAll(mapPaths, val, fschema); err != nil {
+				return err
 			}
-			continue
 		}
 	}
-	return errs.Err()
+
+	// descend walks the GoStruct gs at path p, defined within the module
+	// gmod, or supplies it to visit if subtrees are being output.
+	descend := func(gs GoStruct, gschema *yang.Entry, p *gnmiPath, gmod string) error {
+		if w.subtrees {
+			return w.visit(parent, p, gs, gschema)
+		}
+		return w.walk(gs, gschema, p, gmod)
+	}
+
+	for _, c := range children {
+		switch c.fval.Kind() {
+		case reflect.Map:
+			// We need to map each child along with its key value.
+			for _, k := range c.fval.MapKeys() {
+				childPath, err := mapValuePath(k, c.fval.MapIndex(k), c.mapPaths[0])
+				if err != nil {
+					errs.Add(err)
+					continue
+				}
+
+				goStruct, ok := c.fval.MapIndex(k).Interface().(GoStruct)
+				if !ok {
+					errs.Add(fmt.Errorf("%v: was not a valid GoStruct", c.mapPaths[0]))
+					continue
+				}
+				if err := descend(goStruct, c.schema, childPath, c.module); err != nil {
+					return err
+				}
+			}
+		case reflect.Ptr:
+			goStruct, ok := c.fval.Interface().(GoStruct)
+			if !ok {
+				errs.Add(fmt.Errorf("%v: was not a valid GoStruct", c.mapPaths[0]))
+				continue
+			}
+			if err := descend(goStruct, c.schema, c.mapPaths[0], c.module); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// qualifyPath updates the path p, which consists of the path of a GoStruct
+// of length n followed by the elements of the path of one of its fields, to
+// reflect that the field is defined within the module mod, and the GoStruct
+// within parentMod, which is empty if the GoStruct is the one being
+// rendered. Where the origin of paths is determined by their module, the
+// origin of p is set if the GoStruct is the one being rendered. Where module
+// names are prepended to PathElem paths, and mod differs from parentMod, the
+// name of an element of the field's path is qualified by mod. As for RFC7951
+// JSON, this is the first element of the field's path, unless the path is
+// absolute and the field is not at the root, in which case the last element
+// of the path is qualified.
+func (w *leafWalker) qualifyPath(p *gnmiPath, n int, mod, parentMod string) error {
+	if mod == "" || mod == parentMod {
+		return nil
+	}
+	if w.originFromModule && parentMod == "" {
+		p.origin = mod
+	}
+	if !w.moduleNames || !p.isPathElemPath() || p.Len() == n {
+		return nil
+	}
+
+	i := n
+	if p.isAbsolute && parentMod != "" {
+		i = p.Len() - 1
+	}
+	e, err := p.PathElemAt(i)
+	if err != nil {
+		return err
+	}
+	ne := *e
+	ne.Name = fmt.Sprintf("%s:%s", mod, e.Name)
+	return p.SetIndex(i, &ne)
 }
 
 // mapValuePath calculates the gNMI Path of a map element with the specified
 // key and value. The format of the path returned depends on the input format
 // of the parentPath.
 func mapValuePath(key, value reflect.Value, parentPath *gnmiPath) (*gnmiPath, error) {
-	childPath := &gnmiPath{}
-
 	if parentPath == nil {
 		return nil, fmt.Errorf("nil map paths supplied to mapValuePath for %v %v", key.Interface(), value.Interface())
 	}
+	childPath := &gnmiPath{origin: parentPath.origin}
 
 	if parentPath.isStringSlicePath() {
 		keyval, err := keyValueAsString(key.Interface())
@@ -530,7 +984,7 @@ func keyValueAsString(v interface{}) (string, error) {
 func sliceToScalarArray(v []interface{}) (*gnmipb.ScalarArray, error) {
 	arr := &gnmipb.ScalarArray{}
 	for _, e := range v {
-		tv, err := value.FromScalar(e)
+		tv, err := scalarToTypedValue(e)
 		if err != nil {
 			return nil, err
 		}
@@ -539,67 +993,162 @@ func sliceToScalarArray(v []interface{}) (*gnmipb.ScalarArray, error) {
 	return arr, nil
 }
 
-// leavesToNotifications takes an input map of leaves, and outputs a slice of
-// notifications that corresponds to the leaf update, the supplied timestamp is
-// used in the set of notifications. If an error is encountered it is returned.
-// TODO(robjs): Currently, we return only a single Notification, but this is
-// likely to be suboptimal since it results in very large Notifications for particular
-// structs. There should be some fragmentation of Updates across Notification messages
-// in a future implementation. We return a slice to keep the API stable.
-func leavesToNotifications(leaves map[*path]interface{}, ts int64, pfx *gnmiPath) ([]*gnmipb.Notification, error) {
-	n := &gnmipb.Notification{
-		Timestamp: ts,
-	}
-
-	p, err := pfx.ToProto()
+// leafToUpdate takes an input leaf value, and outputs the gNMI Update message
+// that corresponds to it, using the supplied path, which is relative to the
+// prefix of the Notification that the update is to be included in. If an error
+// is encountered it is returned.
+func leafToUpdate(p *gnmiPath, v interface{}) (*gnmipb.Update, error) {
+	ppath, err := p.ToProto()
 	if err != nil {
 		return nil, err
 	}
-	n.Prefix = p
+	u := &gnmipb.Update{Path: ppath}
 
-	for pk, v := range leaves {
-		path, err := pk.p.StripPrefix(pfx)
-		if err != nil {
-			return nil, err
-		}
+	switch val := reflect.ValueOf(v); val.Kind() {
+	case reflect.Slice:
+		switch {
+		case reflect.TypeOf(v).Name() == BinaryTypeName, reflect.TypeOf(v) == reflect.TypeOf(dynamicBinary{}):
+			// This is a binary type which is defined as a []byte, so
+			// we encode it as bytes.
+			u.Val = &gnmipb.TypedValue{Value: &gnmipb.TypedValue_BytesVal{val.Bytes()}}
+		default:
+			sval, err := leaflistToSlice(val, false)
+			if err != nil {
+				return nil, err
+			}
 
-		ppath, err := path.ToProto()
+			arr, err := sliceToScalarArray(sval)
+			if err != nil {
+				return nil, err
+			}
+			u.Val = &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{arr}}
+		}
+	default:
+		val, err := scalarToTypedValue(v)
 		if err != nil {
 			return nil, err
 		}
-		u := &gnmipb.Update{Path: ppath}
+		u.Val = val
+	}
 
-		switch val := reflect.ValueOf(v); val.Kind() {
-		case reflect.Slice:
-			switch {
-			case reflect.TypeOf(v).Name() == BinaryTypeName:
-				// This is a binary type which is defined as a []byte, so
-				// we encode it as bytes.
-				u.Val = &gnmipb.TypedValue{Value: &gnmipb.TypedValue_BytesVal{val.Bytes()}}
-			default:
-				sval, err := leaflistToSlice(val, false)
-				if err != nil {
-					return nil, err
-				}
+	return u, nil
+}
 
-				arr, err := sliceToScalarArray(sval)
-				if err != nil {
-					return nil, err
-				}
-				u.Val = &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{arr}}
+// scalarToTypedValue returns the gNMI TypedValue that corresponds to the
+// scalar value v. Decimal64 values are encoded using the Decimal64 TypedValue,
+// and InstanceIdentifier values as a string TypedValue containing their string
+// form. All other values are handled by value.FromScalar.
+func scalarToTypedValue(v interface{}) (*gnmipb.TypedValue, error) {
+	switch sv := v.(type) {
+	case Decimal64:
+		return sv.TypedValue()
+	case InstanceIdentifier:
+		return sv.TypedValue()
+	}
+	return value.FromScalar(v)
+}
+
+// subtreeToUpdate takes an input GoStruct, and outputs a gNMI Update message
+// with the supplied path, whose value is the RFC7951 JSON encoding of the
+// GoStruct.
+func subtreeToUpdate(p *gnmiPath, s GoStruct) (*gnmipb.Update, error) {
+	ppath, err := p.ToProto()
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := ConstructIETFJSON(s, &RFC7951JSONConfig{AppendModuleName: true})
+	if err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(j)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal JSON: %v", err)
+	}
+
+	return &gnmipb.Update{
+		Path: ppath,
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{js}},
+	}, nil
+}
+
+// encodeDecimal64 rewrites the floating point values within the TypedValue tv,
+// which holds the value of the leaf or leaf-list described by schema, as
+// Decimal64 values, with the precision specified by the fraction-digits of the
+// leaf's type. tv is left unchanged if schema is nil, or the leaf's type does
+// not include decimal64. Values that cannot be represented as a Decimal64,
+// such as negative values, remain floating point values.
+func encodeDecimal64(tv *gnmipb.TypedValue, schema *yang.Entry) error {
+	if schema == nil {
+		return nil
+	}
+	schema, err := util.ResolveIfLeafRef(schema)
+	if err != nil {
+		return err
+	}
+	if schema.Type == nil {
+		return nil
+	}
+
+	// Floating point values can only be produced by decimal64 leaves, such
+	// that where the type is a union, the first decimal64 member is used.
+	digits, ok := fractionDigits(schema.Type)
+	if !ok {
+		return nil
+	}
+
+	switch v := tv.GetValue().(type) {
+	case *gnmipb.TypedValue_FloatVal:
+		if d, ok := floatToDecimal64(float64(v.FloatVal), digits); ok {
+			tv.Value = &gnmipb.TypedValue_DecimalVal{d}
+		}
+	case *gnmipb.TypedValue_LeaflistVal:
+		for _, e := range v.LeaflistVal.GetElement() {
+			fv, ok := e.GetValue().(*gnmipb.TypedValue_FloatVal)
+			if !ok {
+				continue
 			}
-		default:
-			val, err := value.FromScalar(v)
-			if err != nil {
-				return nil, err
+			if d, ok := floatToDecimal64(float64(fv.FloatVal), digits); ok {
+				e.Value = &gnmipb.TypedValue_DecimalVal{d}
 			}
-			u.Val = val
 		}
+	}
+	return nil
+}
 
-		n.Update = append(n.Update, u)
+// fractionDigits returns the fraction-digits of the YANG type t, or of the
+// first decimal64 member of t if it is a union. It returns false if t does not
+// include a decimal64 type.
+func fractionDigits(t *yang.YangType) (int, bool) {
+	switch t.Kind {
+	case yang.Ydecimal64:
+		return t.FractionDigits, true
+	case yang.Yunion:
+		for _, ut := range t.Type {
+			if d, ok := fractionDigits(ut); ok {
+				return d, true
+			}
+		}
 	}
+	return 0, false
+}
 
-	return []*gnmipb.Notification{n}, nil
+// floatToDecimal64 returns the gNMI Decimal64 message corresponding to f,
+// rounded to the specified number of fraction digits. It returns false if f
+// cannot be represented by the Decimal64 message, which stores its digits as
+// an unsigned integer, such that negative values cannot be represented.
+func floatToDecimal64(f float64, digits int) (*gnmipb.Decimal64, bool) {
+	if f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	// Formatting the value as a decimal string avoids the rounding errors
+	// that are introduced by scaling the value as a float.
+	s := strings.Replace(strconv.FormatFloat(f, 'f', digits, 64), ".", "", 1)
+	d, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &gnmipb.Decimal64{Digits: d, Precision: uint32(digits)}, true
 }
 
 // leaflistToSlice takes a reflect.Value that represents a leaf list in the YANG schema
@@ -650,6 +1199,15 @@ func leaflistToSlice(val reflect.Value, appendModuleName bool) ([]interface{}, e
 			sval = append(sval, e.Float())
 		case reflect.Bool:
 			sval = append(sval, e.Bool())
+		case reflect.Struct:
+			// The only structs that can be within a leaf-list are a
+			// Decimal64 or an InstanceIdentifier.
+			switch d := e.Interface().(type) {
+			case Decimal64, InstanceIdentifier:
+				sval = append(sval, d)
+			default:
+				return nil, fmt.Errorf("unknown struct type within a leaflist: %v", e.Type().Name())
+			}
 		case reflect.Interface:
 			// Occurs in two cases:
 			// 1) Where there is a leaflist of mixed types.
@@ -727,7 +1285,15 @@ func appendTypedValue(l []interface{}, v reflect.Value, appendModuleName bool) (
 		return append(l, ival.(float64)), nil
 	case reflect.Bool:
 		return append(l, ival.(bool)), nil
+	case reflect.Struct:
+		switch d := ival.(type) {
+		case Decimal64, InstanceIdentifier:
+			return append(l, d), nil
+		}
 	case reflect.Slice:
+		if b, ok := ival.(dynamicBinary); ok {
+			return append(l, []byte(b)), nil
+		}
 		if v.Type().Name() != BinaryTypeName {
 			return nil, fmt.Errorf("unknown type within a slice: %v", v.Type().Name())
 		}
@@ -743,6 +1309,19 @@ type RFC7951JSONConfig struct {
 	// elements that are defined within a different YANG module than their
 	// parent.
 	AppendModuleName bool
+	// Metadata stores the metadata of the nodes of the rendered GoStruct.
+	// The annotations of each node are output as RFC7952 metadata
+	// annotations, which are encoded within the "@" member of a container
+	// or list entry, or within the "@name" member of the parent of a leaf
+	// or leaf-list with the member name "name". The annotations that are
+	// stored within the annotation fields of the GoStructs being rendered
+	// are also output. Where an annotation of a node is found in both, the
+	// value within Metadata is output.
+	Metadata NodeMetadata
+	// TimestampAnnotation specifies the name of the annotation, in the
+	// form module:name, that the timestamp of each node's metadata is
+	// output as. If unset, timestamps are not output.
+	TimestampAnnotation string
 }
 
 // ConstructIETFJSON marshals a supplied GoStruct to a map, suitable for
@@ -754,6 +1333,7 @@ func ConstructIETFJSON(s GoStruct, args *RFC7951JSONConfig) (map[string]interfac
 	return constructJSON(s, "", jsonOutputConfig{
 		jType:         RFC7951,
 		rfc7951Config: args,
+		path:          newPathElemGNMIPath(nil),
 	})
 }
 
@@ -774,6 +1354,92 @@ type jsonOutputConfig struct {
 	// rfc7951Config stores the configuration to be used when outputting RFC7951
 	// JSON.
 	rfc7951Config *RFC7951JSONConfig
+	// path is the PathElem path of the GoStruct being rendered, relative to
+	// the GoStruct supplied to the JSON renderer, which is used to find the
+	// metadata of its nodes. It is nil where the path is not known, in
+	// which case no metadata is output.
+	path *gnmiPath
+}
+
+// metadata returns the metadata of the nodes being rendered, or nil if no
+// metadata is to be output.
+func (c jsonOutputConfig) metadata() NodeMetadata {
+	if c.jType != RFC7951 || c.rfc7951Config == nil || c.path == nil {
+		return nil
+	}
+	return c.rfc7951Config.Metadata
+}
+
+// timestampAnnotation returns the name of the annotation that timestamps are
+// output as, or the empty string if they are not output.
+func (c jsonOutputConfig) timestampAnnotation() string {
+	if c.rfc7951Config == nil {
+		return ""
+	}
+	return c.rfc7951Config.TimestampAnnotation
+}
+
+// annotations returns the RFC7952 JSON encoding of the annotations of the
+// node with the path p, or nil if it has none.
+func (c jsonOutputConfig) annotations(p *gnmiPath) (map[string]interface{}, error) {
+	md, err := c.metadata().lookup(p)
+	if err != nil || md == nil {
+		return nil, err
+	}
+	return annotationJSON(md, c.timestampAnnotation()), nil
+}
+
+// structMetadata returns the metadata that is stored within the annotation
+// field of the struct sval, or nil if it has none or RFC7951 JSON is not
+// being output.
+func (c jsonOutputConfig) structMetadata(sval reflect.Value) NodeMetadata {
+	if c.jType != RFC7951 {
+		return nil
+	}
+	for i := 0; i < sval.NumField(); i++ {
+		if util.IsYgotAnnotation(sval.Type().Field(i)) {
+			md, _ := sval.Field(i).Interface().(NodeMetadata)
+			return md
+		}
+	}
+	return nil
+}
+
+// mergeAnnotations adds the annotations within src to dst, replacing those
+// with the same name, and returns the result.
+func mergeAnnotations(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		return src
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// addAnnotations adds the RFC7952 annotations ann of the node with the member
+// name k, and the JSON value v, to the JSON object parent that contains it.
+// The annotations of a container or list entry are added to the "@" member of
+// v itself, along with any that it already contains. The annotations of a
+// leaf-list are added for each of its entries.
+func addAnnotations(parent map[string]interface{}, k string, v interface{}, ann map[string]interface{}, isLeafList bool) {
+	if ann == nil {
+		return
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		existing, _ := m["@"].(map[string]interface{})
+		m["@"] = mergeAnnotations(existing, ann)
+		return
+	}
+	if l, ok := v.([]interface{}); ok && isLeafList {
+		al := make([]interface{}, len(l))
+		for i := range l {
+			al[i] = ann
+		}
+		parent["@"+k] = al
+		return
+	}
+	parent["@"+k] = ann
 }
 
 // constructJSON marshals a GoStruct to a map[string]interface{} which can be
@@ -784,6 +1450,10 @@ type jsonOutputConfig struct {
 // supplied jsonOutputConfig. Returns an error if the GoStruct cannot be rendered
 // to JSON.
 func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[string]interface{}, error) {
+	if d, ok := s.(*DynamicNode); ok {
+		return constructDynamicJSON(d, parentMod, args)
+	}
+
 	var errs errlist.List
 
 	sval := reflect.ValueOf(s).Elem()
@@ -793,9 +1463,16 @@ func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[str
 	// json.Marshal(Text)?
 	jsonout := map[string]interface{}{}
 
+	// smd is the metadata that is stored within s itself, keyed by the path
+	// of each node relative to s.
+	smd := args.structMetadata(sval)
+
 	for i := 0; i < sval.NumField(); i++ {
 		field := sval.Field(i)
 		fType := stype.Field(i)
+		if util.IsYgotAnnotation(fType) {
+			continue
+		}
 
 		// Determine whether we should append a module name to the path in RFC7951
 		// output mode.
@@ -824,7 +1501,57 @@ func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[str
 			continue
 		}
 
-		value, err := constructJSONValue(field, pmod, args)
+		// Where metadata is output, the data tree paths of the field are
+		// tracked, such that the annotations of the field and its children
+		// can be found.
+		cargs := args
+		var dataPaths []*gnmiPath
+		isLeafList := field.Kind() == reflect.Slice && fType.Type.Name() != BinaryTypeName && !util.IsTypeStructPtr(fType.Type.Elem())
+		if args.metadata() != nil {
+			if dataPaths, err = structTagToLibPaths(fType, args.path); err != nil {
+				errs.Add(fmt.Errorf("%s: %v", fType.Name, err))
+				continue
+			}
+			cargs.path = dataPaths[0]
+			if field.Kind() == reflect.Slice && !isLeafList {
+				// The members of unkeyed lists cannot be identified.
+				cargs.path = nil
+			}
+		}
+		var relPaths []*gnmiPath
+		if smd != nil {
+			if relPaths, err = structTagToLibPaths(fType, newPathElemGNMIPath(nil)); err != nil {
+				errs.Add(fmt.Errorf("%s: %v", fType.Name, err))
+				continue
+			}
+		}
+		// annotations returns the annotations of the field when it is output
+		// at the ith of its paths. The annotations of lists are output
+		// within each list entry. Where the same annotation is stored within
+		// s and the supplied metadata, the latter is output.
+		annotations := func(i int) map[string]interface{} {
+			if field.Kind() == reflect.Map || (field.Kind() == reflect.Slice && !isLeafList) || mapPaths[i].Len() == 0 {
+				return nil
+			}
+			var ann map[string]interface{}
+			if relPaths != nil {
+				md, err := smd.lookup(relPaths[i])
+				if err != nil {
+					errs.Add(err)
+				}
+				ann = annotationJSON(md, args.timestampAnnotation())
+			}
+			if dataPaths != nil {
+				a, err := args.annotations(dataPaths[i])
+				if err != nil {
+					errs.Add(err)
+				}
+				ann = mergeAnnotations(ann, a)
+			}
+			return ann
+		}
+
+		value, err := constructJSONValue(field, pmod, cargs)
 		if err != nil {
 			errs.Add(err)
 			continue
@@ -838,7 +1565,7 @@ func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[str
 			continue
 		}
 
-		for _, p := range mapPaths {
+		for i, p := range mapPaths {
 			v, ok := value.(map[string]interface{})
 			switch p.Len() {
 			case 0:
@@ -867,6 +1594,7 @@ func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[str
 					pelem = fmt.Sprintf("%s:%s", appmod, pelem)
 				}
 				jsonout[pelem] = value
+				addAnnotations(jsonout, pelem, value, annotations(i), isLeafList)
 			default:
 				var nilParent bool
 				parent := jsonout
@@ -915,11 +1643,19 @@ func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[str
 					k = fmt.Sprintf("%s:%s", appmod, k)
 				}
 				parent[k] = value
+				addAnnotations(parent, k, value, annotations(i), isLeafList)
 
 			}
 		}
 	}
 
+	// The annotations of s itself are output within its "@" member.
+	md, err := smd.lookup(newPathElemGNMIPath(nil))
+	if err != nil {
+		errs.Add(err)
+	}
+	addAnnotations(nil, "", jsonout, annotationJSON(md, args.timestampAnnotation()), false)
+
 	if errs.Err() != nil {
 		return nil, errs.Err()
 	}
@@ -928,13 +1664,19 @@ func constructJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[str
 }
 
 // writeIETFScalarJSON takes an input scalar value, and returns it in the format
-// that is expected in IETF RFC7951 JSON. Per this specification, uint64, int64
-// and float64 values are represented as strings.
+// that is expected in IETF RFC7951 JSON. Per this specification, uint64, int64,
+// float64, Decimal64 and InstanceIdentifier values are represented as strings.
 func writeIETFScalarJSON(i interface{}) interface{} {
 	switch reflect.ValueOf(i).Kind() {
 	case reflect.Uint64, reflect.Int64, reflect.Float64:
 		return fmt.Sprintf("%v", i)
 	}
+	switch v := i.(type) {
+	case Decimal64:
+		return v.String()
+	case InstanceIdentifier:
+		return v.String()
+	}
 	return i
 }
 
@@ -980,8 +1722,8 @@ func constructMapJSON(field reflect.Value, parentMod string, args jsonOutputConf
 		// are stored as strings.
 		for _, k := range field.MapKeys() {
 			var kn string
-			switch k.Kind() {
-			case reflect.Struct:
+			switch {
+			case util.IsValueStruct(k):
 				// Handle the case of a multikey list.
 				var kp []string
 				for j := 0; j < k.NumField(); j++ {
@@ -993,7 +1735,7 @@ func constructMapJSON(field reflect.Value, parentMod string, args jsonOutputConf
 					kp = append(kp, fmt.Sprintf("%v", keyval))
 				}
 				kn = strings.Join(kp, " ")
-			case reflect.Int64:
+			case k.Kind() == reflect.Int64:
 				keyval, err := keyValue(k, false)
 				if err != nil {
 					errs.Add(fmt.Errorf("invalid enumerated key: %v", err))
@@ -1037,11 +1779,26 @@ func constructMapJSON(field reflect.Value, parentMod string, args jsonOutputConf
 			continue
 		}
 
-		val, err := constructJSON(goStruct, parentMod, args)
+		eargs := args
+		var ann map[string]interface{}
+		if args.metadata() != nil {
+			var err error
+			if eargs.path, err = mapValuePath(k, field.MapIndex(k), args.path); err != nil {
+				errs.Add(err)
+				continue
+			}
+			if ann, err = args.annotations(eargs.path); err != nil {
+				errs.Add(err)
+				continue
+			}
+		}
+
+		val, err := constructJSON(goStruct, parentMod, eargs)
 		if err != nil {
 			errs.Add(err)
 			continue
 		}
+		addAnnotations(nil, "", val, ann, false)
 
 		switch args.jType {
 		case RFC7951:
@@ -1089,8 +1846,8 @@ func constructJSONValue(field reflect.Value, parentMod string, args jsonOutputCo
 			errs.Add(err)
 		}
 	case reflect.Ptr:
-		switch field.Elem().Kind() {
-		case reflect.Struct:
+		switch {
+		case util.IsValueStructPtr(field):
 			goStruct, ok := field.Interface().(GoStruct)
 			if !ok {
 				return nil, fmt.Errorf("cannot map struct %v, invalid GoStruct", field)
@@ -15,12 +15,15 @@
 package ygot
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+
 	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
@@ -267,6 +270,14 @@ func TestGNMIPathToProto(t *testing.T) {
 		name:      "empty path elem path",
 		inPath:    newPathElemGNMIPath([]*gnmipb.PathElem{}),
 		wantProto: nil,
+	}, {
+		name:      "path with origin",
+		inPath:    &gnmiPath{pathElemPath: []*gnmipb.PathElem{{Name: "one"}}, origin: "openconfig"},
+		wantProto: &gnmipb.Path{Origin: "openconfig", Elem: []*gnmipb.PathElem{{Name: "one"}}},
+	}, {
+		name:      "empty path with origin",
+		inPath:    &gnmiPath{pathElemPath: []*gnmipb.PathElem{}, origin: "openconfig"},
+		wantProto: &gnmipb.Path{Origin: "openconfig"},
 	}, {
 		name:    "invalid path",
 		inPath:  &gnmiPath{stringSlicePath: []string{"one"}, pathElemPath: []*gnmipb.PathElem{{Name: "bar"}}},
@@ -656,6 +667,21 @@ func TestSliceToScalarArray(t *testing.T) {
 				{Value: &gnmipb.TypedValue_StringVal{"towel"}},
 			},
 		},
+	}, {
+		name: "scalar array with decimal64 values",
+		in:   []interface{}{Decimal64{Digits: 4242, Precision: 2}, Decimal64{Digits: 1, Precision: 0}},
+		want: &gnmipb.ScalarArray{
+			Element: []*gnmipb.TypedValue{
+				{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 4242, Precision: 2}}},
+				{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 1, Precision: 0}}},
+			},
+		},
+	}, {
+		name: "scalar array with a negative decimal64 value",
+		in:   []interface{}{Decimal64{Digits: -1, Precision: 0}},
+		want: &gnmipb.ScalarArray{
+			Element: []*gnmipb.TypedValue{{Value: &gnmipb.TypedValue_FloatVal{-1}}},
+		},
 	}, {
 		name:    "scalar array with an unmappable type",
 		in:      []interface{}{uint8(1), struct{ val string }{"hello"}},
@@ -1303,6 +1329,616 @@ func TestTogNMINotifications(t *testing.T) {
 	}
 }
 
+func TestStreamgNMINotifications(t *testing.T) {
+	inStruct := &pathElemExample{
+		StringField: String("foo"),
+		List: map[string]*pathElemExampleChild{
+			"p1": {Val: String("p1"), OtherField: Uint8(42)},
+		},
+	}
+
+	listElem := &gnmipb.PathElem{Name: "list", Key: map[string]string{"val": "p1"}}
+	strUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "string-field"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"foo"}},
+	}
+	valUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem, {Name: "val"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+	}
+	cfgValUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem, {Name: "config"}, {Name: "val"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+	}
+	otherUpd := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem, {Name: "other-field"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{42}},
+	}
+
+	tests := []struct {
+		name     string
+		inConfig GNMINotificationsConfig
+		want     []*gnmipb.Notification
+	}{{
+		name:     "no limits",
+		inConfig: GNMINotificationsConfig{UsePathElem: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{strUpd, valUpd, cfgValUpd, otherUpd},
+		}},
+	}, {
+		name:     "limited number of updates",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, MaxUpdates: 3},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{strUpd, valUpd, cfgValUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{otherUpd},
+		}},
+	}, {
+		name:     "limited size smaller than any update",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, MaxBytes: 1},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{strUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{valUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{cfgValUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{otherUpd},
+		}},
+	}, {
+		name: "limited size fitting two updates",
+		inConfig: GNMINotificationsConfig{
+			UsePathElem: true,
+			MaxBytes:    proto.Size(&gnmipb.Notification{Timestamp: 42, Update: []*gnmipb.Update{cfgValUpd, otherUpd}}),
+		},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{strUpd, valUpd},
+		}, {
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{cfgValUpd, otherUpd},
+		}},
+	}, {
+		name:     "grouped by prefix",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, GroupByPrefix: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update:    []*gnmipb.Update{strUpd},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{listElem}},
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "val"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+			}, {
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "config"}, {Name: "val"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+			}, {
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "other-field"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{42}},
+			}},
+		}},
+	}, {
+		name: "grouped by prefix with configured prefix",
+		inConfig: GNMINotificationsConfig{
+			UsePathElem:    true,
+			PathElemPrefix: []*gnmipb.PathElem{{Name: "root"}},
+			GroupByPrefix:  true,
+			MaxUpdates:     2,
+		},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "root"}}},
+			Update:    []*gnmipb.Update{strUpd},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "root"}, listElem}},
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "val"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+			}, {
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "config"}, {Name: "val"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"p1"}},
+			}},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "root"}, listElem}},
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "other-field"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{42}},
+			}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		var got []*gnmipb.Notification
+		err := StreamgNMINotifications(inStruct, 42, tt.inConfig, func(n *gnmipb.Notification) error {
+			got = append(got, n)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): got unexpected error: %v", tt.name, inStruct, tt.inConfig, err)
+			continue
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): did not get expected number of Notifications, got: %d, want: %d", tt.name, inStruct, tt.inConfig, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if !proto.Equal(got[i], tt.want[i]) {
+				t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): did not get expected Notification %d, got: %s, want: %s", tt.name, inStruct, tt.inConfig, i, proto.MarshalTextString(got[i]), proto.MarshalTextString(tt.want[i]))
+			}
+			if max := tt.inConfig.MaxBytes; max > 0 && len(got[i].Update) > 1 && proto.Size(got[i]) > max {
+				t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): Notification %d exceeds maximum size, got: %d bytes, want: <= %d bytes", tt.name, inStruct, tt.inConfig, i, proto.Size(got[i]), max)
+			}
+		}
+	}
+}
+
+func TestStreamgNMINotificationsSendError(t *testing.T) {
+	inStruct := &pathElemExample{
+		StringField: String("foo"),
+		List: map[string]*pathElemExampleChild{
+			"p1": {Val: String("p1"), OtherField: Uint8(42)},
+			"p2": {Val: String("p2"), OtherField: Uint8(84)},
+		},
+	}
+
+	sendErr := errors.New("send failed")
+	var calls int
+	err := StreamgNMINotifications(inStruct, 42, GNMINotificationsConfig{UsePathElem: true, MaxUpdates: 1}, func(*gnmipb.Notification) error {
+		calls++
+		return sendErr
+	})
+	if err != sendErr {
+		t.Errorf("StreamgNMINotifications: did not get expected error, got: %v, want: %v", err, sendErr)
+	}
+	if calls != 1 {
+		t.Errorf("StreamgNMINotifications: did not stop after error, got %d calls to send, want: 1", calls)
+	}
+}
+
+// decimalExample is used to test the schema-guided encoding of decimal64
+// leaves.
+type decimalExample struct {
+	Str      *string              `path:"str"`
+	Dec      *float64             `path:"dec"`
+	DecList  []float64            `path:"dec-list"`
+	DecUnion *float64             `path:"dec-union"`
+	Child    *decimalExampleChild `path:"child"`
+}
+
+func (*decimalExample) IsYANGGoStruct() {}
+
+type decimalExampleChild struct {
+	Val *float64 `path:"val"`
+}
+
+func (*decimalExampleChild) IsYANGGoStruct() {}
+
+func TestGNMINotificationsDecimal64(t *testing.T) {
+	decimalType := func(digits int) *yang.YangType {
+		return &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: digits}
+	}
+	schema := &yang.Entry{
+		Name: "root",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"str": {Name: "str", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}},
+			"dec": {Name: "dec", Kind: yang.LeafEntry, Type: decimalType(2)},
+			"dec-list": {
+				Name:     "dec-list",
+				Kind:     yang.LeafEntry,
+				ListAttr: &yang.ListAttr{},
+				Type:     decimalType(1),
+			},
+			"dec-union": {
+				Name: "dec-union",
+				Kind: yang.LeafEntry,
+				Type: &yang.YangType{
+					Kind: yang.Yunion,
+					Type: []*yang.YangType{{Kind: yang.Ystring}, decimalType(4)},
+				},
+			},
+			"child": {
+				Name: "child",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"val": {Name: "val", Kind: yang.LeafEntry, Type: decimalType(3)},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		inStruct *decimalExample
+		inConfig GNMINotificationsConfig
+		want     []*gnmipb.Update
+		wantErr  bool
+	}{{
+		name: "decimal64 leaves",
+		inStruct: &decimalExample{
+			Str:      String("foo"),
+			Dec:      Float64(1.5),
+			DecList:  []float64{0.1, 2.5},
+			DecUnion: Float64(3.14159),
+			Child:    &decimalExampleChild{Val: Float64(42)},
+		},
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Schema: schema, Decimal64: true},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "str"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"foo"}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 150, Precision: 2}}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec-list"}}},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{
+					{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 1, Precision: 1}}},
+					{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 25, Precision: 1}}},
+				},
+			}}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec-union"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 31416, Precision: 4}}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "child"}, {Name: "val"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 42000, Precision: 3}}},
+		}},
+	}, {
+		name:     "decimal64 leaves without schema",
+		inStruct: &decimalExample{Dec: Float64(1.5)},
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Decimal64: true},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_FloatVal{1.5}},
+		}},
+	}, {
+		name:     "decimal64 encoding not requested",
+		inStruct: &decimalExample{Dec: Float64(1.5)},
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Schema: schema},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_FloatVal{1.5}},
+		}},
+	}, {
+		name:     "negative decimal64 value",
+		inStruct: &decimalExample{Dec: Float64(-1.5)},
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Schema: schema, Decimal64: true},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_FloatVal{-1.5}},
+		}},
+	}, {
+		name:     "decimal64 leaf-list with negative values",
+		inStruct: &decimalExample{DecList: []float64{2.5, -2.5}},
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Schema: schema, Decimal64: true},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec-list"}}},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{
+					{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 25, Precision: 1}}},
+					{Value: &gnmipb.TypedValue_FloatVal{-2.5}},
+				},
+			}}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		got, err := TogNMINotifications(tt.inStruct, 42, tt.inConfig)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: TogNMINotifications(%v, 42, %v): did not get expected error status, got: %v, wantErr: %v", tt.name, tt.inStruct, tt.inConfig, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		want := []*gnmipb.Notification{{Timestamp: 42, Update: tt.want}}
+		if !notificationSetEqual(got, want) {
+			t.Errorf("%s: TogNMINotifications(%v, 42, %v): did not get expected Notifications, got: %v, want: %v", tt.name, tt.inStruct, tt.inConfig, got, want)
+		}
+	}
+}
+
+func TestGNMINotificationsDecimal64Type(t *testing.T) {
+	tests := []struct {
+		name     string
+		inStruct *decimal64JSONExample
+		want     []*gnmipb.Update
+		wantErr  bool
+	}{{
+		name: "decimal64 leaf and leaf-list",
+		inStruct: &decimal64JSONExample{
+			Dec:     &Decimal64{Digits: 4242, Precision: 2},
+			DecList: []Decimal64{{Digits: 15, Precision: 1}},
+		},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 4242, Precision: 2}}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec-list"}}},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 15, Precision: 1}}}},
+			}}},
+		}},
+	}, {
+		name: "negative decimal64 leaf and leaf-list",
+		inStruct: &decimal64JSONExample{
+			Dec:     &Decimal64{Digits: -125, Precision: 2},
+			DecList: []Decimal64{{Digits: 15, Precision: 1}, {Digits: -15, Precision: 1}},
+		},
+		want: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_FloatVal{-1.25}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "dec-list"}}},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{
+					{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 15, Precision: 1}}},
+					{Value: &gnmipb.TypedValue_FloatVal{-1.5}},
+				},
+			}}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		got, err := TogNMINotifications(tt.inStruct, 42, GNMINotificationsConfig{UsePathElem: true})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: TogNMINotifications(%v, 42, ...): did not get expected error status, got: %v, wantErr: %v", tt.name, tt.inStruct, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		want := []*gnmipb.Notification{{Timestamp: 42, Update: tt.want}}
+		if !notificationSetEqual(got, want) {
+			t.Errorf("%s: TogNMINotifications(%v, 42, ...): did not get expected Notifications, got: %v, want: %v", tt.name, tt.inStruct, got, want)
+		}
+	}
+}
+
+func TestGNMINotificationsInstanceIdentifier(t *testing.T) {
+	in := &instanceIDExample{
+		Ref:     &InstanceIdentifier{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "m:a"}, {Name: "b", Key: map[string]string{"k": "v"}}}}},
+		RefList: []InstanceIdentifier{mustInstanceIdentifier("/m:a/c")},
+	}
+	got, err := TogNMINotifications(in, 42, GNMINotificationsConfig{UsePathElem: true})
+	if err != nil {
+		t.Fatalf("TogNMINotifications(%v, 42, ...): got unexpected error: %v", in, err)
+	}
+	want := []*gnmipb.Notification{{
+		Timestamp: 42,
+		Update: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "ref"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"/m:a/b[k='v']"}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "ref-list"}}},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{{Value: &gnmipb.TypedValue_StringVal{"/m:a/c"}}},
+			}}},
+		}},
+	}}
+	if !notificationSetEqual(got, want) {
+		t.Errorf("TogNMINotifications(%v, 42, ...): did not get expected Notifications, got: %v, want: %v", in, got, want)
+	}
+}
+
+func TestGNMINotificationsOrigin(t *testing.T) {
+	inStruct := &ietfRenderExample{
+		F1: String("one"),
+		F2: String("two"),
+		F3: &ietfRenderExampleChild{
+			F4: String("four"),
+			F5: String("five"),
+		},
+	}
+
+	elemPath := func(names ...string) *gnmipb.Path {
+		p := &gnmipb.Path{}
+		for _, n := range names {
+			p.Elem = append(p.Elem, &gnmipb.PathElem{Name: n})
+		}
+		return p
+	}
+	update := func(p *gnmipb.Path, v string) *gnmipb.Update {
+		return &gnmipb.Update{Path: p, Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{v}}}
+	}
+
+	tests := []struct {
+		name     string
+		inConfig GNMINotificationsConfig
+		want     []*gnmipb.Notification
+	}{{
+		name:     "fixed origin",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, Origin: "openconfig"},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "openconfig"},
+			Update: []*gnmipb.Update{
+				update(elemPath("f1"), "one"),
+				update(elemPath("config", "f2"), "two"),
+				update(elemPath("f3", "config", "f4"), "four"),
+				update(elemPath("f3", "f5"), "five"),
+			},
+		}},
+	}, {
+		name:     "fixed origin with prefix",
+		inConfig: GNMINotificationsConfig{StringSlicePrefix: []string{"root"}, Origin: "openconfig"},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "openconfig", Element: []string{"root"}},
+			Update: []*gnmipb.Update{
+				update(&gnmipb.Path{Element: []string{"f1"}}, "one"),
+				update(&gnmipb.Path{Element: []string{"config", "f2"}}, "two"),
+				update(&gnmipb.Path{Element: []string{"f3", "config", "f4"}}, "four"),
+				update(&gnmipb.Path{Element: []string{"f3", "f5"}}, "five"),
+			},
+		}},
+	}, {
+		name:     "origin from module",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, OriginFromModule: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f1mod"},
+			Update: []*gnmipb.Update{
+				update(elemPath("f1"), "one"),
+				update(elemPath("f3", "config", "f4"), "four"),
+				update(elemPath("f3", "f5"), "five"),
+			},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f2mod"},
+			Update:    []*gnmipb.Update{update(elemPath("config", "f2"), "two")},
+		}},
+	}, {
+		name:     "module-prefixed names",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, PrependModuleNames: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update: []*gnmipb.Update{
+				update(elemPath("f1mod:f1"), "one"),
+				update(elemPath("f2mod:config", "f2"), "two"),
+				update(elemPath("f1mod:f3", "f42mod:config", "f4"), "four"),
+				update(elemPath("f1mod:f3", "f5"), "five"),
+			},
+		}},
+	}, {
+		name: "origin from module and module-prefixed names grouped by prefix",
+		inConfig: GNMINotificationsConfig{
+			UsePathElem:        true,
+			OriginFromModule:   true,
+			PrependModuleNames: true,
+			GroupByPrefix:      true,
+		},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f1mod"},
+			Update:    []*gnmipb.Update{update(elemPath("f1mod:f1"), "one")},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f2mod"},
+			Update:    []*gnmipb.Update{update(elemPath("f2mod:config", "f2"), "two")},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f1mod", Elem: []*gnmipb.PathElem{{Name: "f1mod:f3"}}},
+			Update: []*gnmipb.Update{
+				update(elemPath("f42mod:config", "f4"), "four"),
+				update(elemPath("f5"), "five"),
+			},
+		}},
+	}, {
+		name:     "origin from module with string slice paths",
+		inConfig: GNMINotificationsConfig{OriginFromModule: true, PrependModuleNames: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f1mod"},
+			Update: []*gnmipb.Update{
+				update(&gnmipb.Path{Element: []string{"f1"}}, "one"),
+				update(&gnmipb.Path{Element: []string{"f3", "config", "f4"}}, "four"),
+				update(&gnmipb.Path{Element: []string{"f3", "f5"}}, "five"),
+			},
+		}, {
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Origin: "f2mod"},
+			Update:    []*gnmipb.Update{update(&gnmipb.Path{Element: []string{"config", "f2"}}, "two")},
+		}},
+	}}
+
+	for _, tt := range tests {
+		var got []*gnmipb.Notification
+		err := StreamgNMINotifications(inStruct, 42, tt.inConfig, func(n *gnmipb.Notification) error {
+			got = append(got, n)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): got unexpected error: %v", tt.name, inStruct, tt.inConfig, err)
+			continue
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): did not get expected number of Notifications, got: %v, want: %v", tt.name, inStruct, tt.inConfig, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !proto.Equal(got[i], tt.want[i]) {
+				t.Errorf("%s: StreamgNMINotifications(%v, 42, %v): did not get expected Notification %d, got: %s, want: %s", tt.name, inStruct, tt.inConfig, i, proto.MarshalTextString(got[i]), proto.MarshalTextString(tt.want[i]))
+			}
+		}
+	}
+}
+
+func TestGNMINotificationsJSONIETFSubtrees(t *testing.T) {
+	inStruct := &pathElemExample{
+		StringField: String("foo"),
+		List: map[string]*pathElemExampleChild{
+			"p1": {Val: String("p1"), OtherField: Uint8(42)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		inConfig GNMINotificationsConfig
+		want     []*gnmipb.Notification
+	}{{
+		name:     "path elem paths",
+		inConfig: GNMINotificationsConfig{UsePathElem: true, JSONIETFSubtrees: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "string-field"}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"foo"}},
+			}, {
+				Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "list", Key: map[string]string{"val": "p1"}}}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{[]byte(`{"config":{"val":"p1"},"other-field":42,"val":"p1"}`)}},
+			}},
+		}},
+	}, {
+		name:     "string slice paths grouped by prefix",
+		inConfig: GNMINotificationsConfig{StringSlicePrefix: []string{"root"}, JSONIETFSubtrees: true, GroupByPrefix: true},
+		want: []*gnmipb.Notification{{
+			Timestamp: 42,
+			Prefix:    &gnmipb.Path{Element: []string{"root"}},
+			Update: []*gnmipb.Update{{
+				Path: &gnmipb.Path{Element: []string{"string-field"}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"foo"}},
+			}, {
+				Path: &gnmipb.Path{Element: []string{"list", "p1"}},
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{[]byte(`{"config":{"val":"p1"},"other-field":42,"val":"p1"}`)}},
+			}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		got, err := TogNMINotifications(inStruct, 42, tt.inConfig)
+		if err != nil {
+			t.Errorf("%s: TogNMINotifications(%v, 42, %v): got unexpected error: %v", tt.name, inStruct, tt.inConfig, err)
+			continue
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: TogNMINotifications(%v, 42, %v): did not get expected number of Notifications, got: %d, want: %d", tt.name, inStruct, tt.inConfig, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if !proto.Equal(got[i], tt.want[i]) {
+				t.Errorf("%s: TogNMINotifications(%v, 42, %v): did not get expected Notification %d, got: %s, want: %s", tt.name, inStruct, tt.inConfig, i, proto.MarshalTextString(got[i]), proto.MarshalTextString(tt.want[i]))
+			}
+		}
+	}
+}
+
 // notificationSetEqual checks whether two slices of gNMI Notification messages are
 // equal, ignoring the order of the Notifications.
 func notificationSetEqual(a, b []*gnmipb.Notification) bool {
@@ -1555,6 +2191,32 @@ type diffModAtRootElemTwo struct {
 
 func (*diffModAtRootElemTwo) IsYANGGoStruct() {}
 
+// decimal64JSONExample is used to test the JSON encoding of Decimal64 leaves.
+type decimal64JSONExample struct {
+	Dec     *Decimal64  `path:"dec"`
+	DecList []Decimal64 `path:"dec-list"`
+}
+
+func (*decimal64JSONExample) IsYANGGoStruct() {}
+
+// instanceIDExample is used to test the encoding of InstanceIdentifier leaves.
+type instanceIDExample struct {
+	Ref     *InstanceIdentifier  `path:"ref"`
+	RefList []InstanceIdentifier `path:"ref-list"`
+}
+
+func (*instanceIDExample) IsYANGGoStruct() {}
+
+// mustInstanceIdentifier returns the InstanceIdentifier parsed from s, and
+// panics if s is not valid.
+func mustInstanceIdentifier(s string) InstanceIdentifier {
+	iid, err := ParseInstanceIdentifier(s)
+	if err != nil {
+		panic(err)
+	}
+	return iid
+}
+
 func TestConstructJSON(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -1596,6 +2258,34 @@ func TestConstructJSON(t *testing.T) {
 			InvalidEnum: int64(42),
 		},
 		wantErr: true,
+	}, {
+		name: "decimal64 leaves",
+		in: &decimal64JSONExample{
+			Dec:     &Decimal64{Digits: 4200, Precision: 2},
+			DecList: []Decimal64{{Digits: -15, Precision: 1}, {Digits: 1, Precision: 3}},
+		},
+		wantIETF: map[string]interface{}{
+			"dec":      "42.0",
+			"dec-list": []interface{}{"-1.5", "0.001"},
+		},
+		wantInternal: map[string]interface{}{
+			"dec":      Decimal64{Digits: 4200, Precision: 2},
+			"dec-list": []interface{}{Decimal64{Digits: -15, Precision: 1}, Decimal64{Digits: 1, Precision: 3}},
+		},
+	}, {
+		name: "instance-identifier leaves",
+		in: &instanceIDExample{
+			Ref:     &InstanceIdentifier{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "m:a"}, {Name: "b", Key: map[string]string{"k": "v"}}}}},
+			RefList: []InstanceIdentifier{mustInstanceIdentifier("/m:a/c")},
+		},
+		wantIETF: map[string]interface{}{
+			"ref":      "/m:a/b[k='v']",
+			"ref-list": []interface{}{"/m:a/c"},
+		},
+		wantInternal: map[string]interface{}{
+			"ref":      mustInstanceIdentifier("/m:a/b[k='v']"),
+			"ref-list": []interface{}{mustInstanceIdentifier("/m:a/c")},
+		},
 	}, {
 		name: "different modules at root",
 		in: &diffModAtRoot{
@@ -2371,6 +3061,10 @@ func TestLeaflistToSlice(t *testing.T) {
 		name:      "binary",
 		inVal:     reflect.ValueOf([]Binary{Binary([]byte{1, 2, 3})}),
 		wantSlice: []interface{}{[]byte{1, 2, 3}},
+	}, {
+		name:      "decimal64",
+		inVal:     reflect.ValueOf([]Decimal64{{Digits: 4242, Precision: 2}}),
+		wantSlice: []interface{}{Decimal64{Digits: 4242, Precision: 2}},
 	}, {
 		name:    "invalid type",
 		inVal:   reflect.ValueOf([]complex128{complex(42.42, 84.84)}),
@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
 )
 
 // GzipToSchema takes an input byte slice, and returns it as
@@ -44,8 +45,25 @@ func GzipToSchema(gzj []byte) (map[string]*yang.Entry, error) {
 	}
 
 	schema := map[string]*yang.Entry{}
+	var root *yang.Entry
 	for _, n := range nentries {
 		rebuildSchemaMap(n, nil, schema)
+		if util.IsFakeRoot(n) {
+			root = n
+		}
+	}
+
+	// The input and output of rpcs, and notifications that are defined at the
+	// root of a module are not within the data tree. Their parent is set to
+	// the fake root, if it exists, such that absolute paths within them can be
+	// resolved against the data tree.
+	if root != nil {
+		for _, n := range nentries {
+			switch n.Kind {
+			case yang.InputEntry, yang.OutputEntry, yang.NotificationEntry:
+				n.Parent = root
+			}
+		}
 	}
 	return schema, nil
 }
@@ -65,4 +83,14 @@ func rebuildSchemaMap(e, parent *yang.Entry, schema map[string]*yang.Entry) {
 	for _, ch := range e.Dir {
 		rebuildSchemaMap(ch, e, schema)
 	}
+
+	// The input and output of an action are not within the directory of the
+	// action, and hence are handled explicitly.
+	if e.RPC != nil {
+		for _, io := range []*yang.Entry{e.RPC.Input, e.RPC.Output} {
+			if io != nil {
+				rebuildSchemaMap(io, e, schema)
+			}
+		}
+	}
 }
@@ -15,6 +15,8 @@
 package ygot
 
 import (
+	"bytes"
+	"compress/gzip"
 	"reflect"
 	"testing"
 
@@ -75,3 +77,68 @@ func TestGzipToSchema(t *testing.T) {
 		}
 	}
 }
+
+func TestGzipToSchemaOperations(t *testing.T) {
+	// The schema consists of a fake root, containing a list with an action,
+	// along with a notification that is defined at the root of the module.
+	in := `{
+  "Device": {
+    "Name": "device",
+    "Kind": 1,
+    "Annotation": {"isFakeRoot": true, "structname": "Device"},
+    "Dir": {
+      "list": {
+        "Name": "list",
+        "Kind": 1,
+        "Annotation": {"structname": "List"},
+        "Dir": {
+          "action": {
+            "Name": "action",
+            "Kind": 1,
+            "Dir": {},
+            "RPC": {
+              "Input": {"Name": "input", "Kind": 6, "Annotation": {"structname": "List_Action_Input"}, "Dir": {}}
+            }
+          }
+        }
+      }
+    }
+  },
+  "Notification": {
+    "Name": "notification",
+    "Kind": 7,
+    "Annotation": {"structname": "Notification"},
+    "Dir": {}
+  }
+}`
+
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	if _, err := gzw.Write([]byte(in)); err != nil {
+		t.Fatalf("could not write gzipped schema: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("could not close gzip writer: %v", err)
+	}
+
+	got, err := GzipToSchema(b.Bytes())
+	if err != nil {
+		t.Fatalf("GzipToSchema(...): got unexpected error: %v", err)
+	}
+
+	input, ok := got["List_Action_Input"]
+	if !ok {
+		t.Fatalf("GzipToSchema(...): did not find schema for the input of the action, got: %v", got)
+	}
+	if input.Parent == nil || input.Parent.Name != "action" || input.Parent.Parent != got["List"] {
+		t.Errorf("GzipToSchema(...): input of the action did not have the correct parent, got: %v", input.Parent)
+	}
+
+	notif, ok := got["Notification"]
+	if !ok {
+		t.Fatalf("GzipToSchema(...): did not find schema for the notification, got: %v", got)
+	}
+	if notif.Parent != got["Device"] {
+		t.Errorf("GzipToSchema(...): notification did not have the fake root as its parent, got: %v", notif.Parent)
+	}
+}
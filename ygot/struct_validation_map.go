@@ -30,12 +30,16 @@ import (
 	"strings"
 
 	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/yaml"
 )
 
 const (
 	// indentString represents the default indentation string used for
 	// JSON. Three spaces are used based on the legacy use of EmitJSON.
 	indentString string = "   "
+	// yamlIndentString represents the default indentation string used for
+	// YAML.
+	yamlIndentString string = "  "
 )
 
 // structTagsToLibPaths takes an input struct field as a reflect.Type, and determines
@@ -138,14 +142,13 @@ func initialiseTree(t reflect.Type, v reflect.Value) {
 		fVal := v.Field(i)
 		fType := t.Field(i)
 
-		if fType.Type.Kind() == reflect.Ptr {
-			// Only initialise nested struct pointers, since all struct fields within
-			// a GoStruct are expected to be pointers, and we do not want to initialise
-			// non-struct values.
-			if pVal := reflect.New(fType.Type.Elem()); pVal.Elem().Type().Kind() == reflect.Struct {
-				initialiseTree(pVal.Elem().Type(), pVal.Elem())
-				fVal.Set(pVal)
-			}
+		// Only initialise nested struct pointers, since all struct fields within
+		// a GoStruct are expected to be pointers, and we do not want to initialise
+		// non-struct values, or structs that represent leaf values.
+		if util.IsTypeStructPtr(fType.Type) {
+			pVal := reflect.New(fType.Type.Elem())
+			initialiseTree(pVal.Elem().Type(), pVal.Elem())
+			fVal.Set(pVal)
 		}
 	}
 }
@@ -162,8 +165,8 @@ func InitContainer(s GoStruct, cname string) error {
 	}
 	t := f.Type()
 
-	if n := reflect.New(t.Elem()); n.Elem().Type().Kind() == reflect.Struct {
-		f.Set(n)
+	if util.IsTypeStructPtr(t) {
+		f.Set(reflect.New(t.Elem()))
 		return nil
 	}
 
@@ -191,6 +194,19 @@ const (
 	RFC7951
 )
 
+// Encoding is an enumerated integer value indicating the encoding in which
+// the serialised data tree is output by the EmitJSON function.
+type Encoding int
+
+const (
+	// JSONEncoding outputs the data tree as JSON.
+	JSONEncoding Encoding = iota
+	// YAMLEncoding outputs the data tree as YAML, such that each value has
+	// the same type as it does in the JSON encoding - for example, int64 and
+	// decimal64 values that are encoded as JSON strings are quoted.
+	YAMLEncoding
+)
+
 // EmitJSONConfig specifies the how JSON should be created by the EmitJSON function.
 type EmitJSONConfig struct {
 	// Format specifies the JSON format that should be output by the EmitJSON
@@ -200,8 +216,12 @@ type EmitJSONConfig struct {
 	// RFC7951Config specifies the configuration options for RFC7951 JSON. Only
 	// valid if Format is RFC7951.
 	RFC7951Config *RFC7951JSONConfig
+	// Encoding specifies the encoding of the output - using the enumerated
+	// Encoding type. By default, JSON is output.
+	Encoding Encoding
 	// Indent is the string used for indentation within the JSON output. The
-	// default value is three spaces.
+	// default value is three spaces. For YAML output, the indentation must
+	// consist of spaces, and the default value is two spaces.
 	Indent string
 }
 
@@ -217,6 +237,18 @@ func EmitJSON(s ValidatedGoStruct, opts *EmitJSONConfig) (string, error) {
 		return "", err
 	}
 
+	if opts != nil && opts.Encoding == YAMLEncoding {
+		indent := yamlIndentString
+		if opts.Indent != "" {
+			indent = opts.Indent
+		}
+		y, err := yaml.Marshal(v, indent)
+		if err != nil {
+			return "", fmt.Errorf("YAML marshalling error: %v", err)
+		}
+		return string(y), nil
+	}
+
 	indent := indentString
 	if opts != nil && opts.Indent != "" {
 		indent = opts.Indent
@@ -230,6 +262,21 @@ func EmitJSON(s ValidatedGoStruct, opts *EmitJSONConfig) (string, error) {
 	return string(j), nil
 }
 
+// EmitYAML takes an input ValidatedGoStruct (produced by ygen with validation
+// enabled) and serialises it to a YAML string. The data tree is rendered
+// according to the JSON format specified in opts, such that each value within
+// the YAML has the same type as it does in that format - for example, the
+// RFC7951 encoding of an empty leaf is output as [null]. The order of lists
+// is retained.
+func EmitYAML(s ValidatedGoStruct, opts *EmitJSONConfig) (string, error) {
+	yopts := &EmitJSONConfig{}
+	if opts != nil {
+		*yopts = *opts
+	}
+	yopts.Encoding = YAMLEncoding
+	return EmitJSON(s, yopts)
+}
+
 // makeJSON renders the GoStruct s to map[string]interface{} according to the
 // JSON format specified. By default makeJSON returns internal format JSON.
 func makeJSON(s GoStruct, opts *EmitJSONConfig) (map[string]interface{}, error) {
@@ -375,6 +422,13 @@ func copyStruct(dstVal, srcVal reflect.Value) error {
 		srcField := srcVal.Field(i)
 		dstField := dstVal.Field(i)
 
+		if util.IsYgotAnnotation(srcVal.Type().Field(i)) {
+			if err := copyAnnotationField(dstField, srcField); err != nil {
+				return err
+			}
+			continue
+		}
+
 		switch srcField.Kind() {
 		case reflect.Ptr:
 			if err := copyPtrField(dstField, srcField); err != nil {
@@ -399,6 +453,33 @@ func copyStruct(dstVal, srcVal reflect.Value) error {
 	return nil
 }
 
+// copyAnnotationField copies the metadata stored within srcField to dstField,
+// both of which must be annotation fields of a GoStruct. An error is returned
+// if a node has metadata within both fields.
+func copyAnnotationField(dstField, srcField reflect.Value) error {
+	src, ok := srcField.Interface().(NodeMetadata)
+	if !ok {
+		return fmt.Errorf("invalid annotation field type: %v", srcField.Type())
+	}
+	if len(src) == 0 {
+		return nil
+	}
+
+	dst, _ := dstField.Interface().(NodeMetadata)
+	nm := NodeMetadata{}
+	for k, md := range dst {
+		nm[k] = md
+	}
+	for k, md := range src {
+		if _, ok := nm[k]; ok {
+			return fmt.Errorf("cannot copy metadata of node %s, set in both source and destination", k)
+		}
+		nm[k] = md.copy()
+	}
+	dstField.Set(reflect.ValueOf(nm))
+	return nil
+}
+
 // copyPtrField copies srcField to dstField. srcField and dstField must be
 // reflect.Value structs which represent pointers. If the source and destination
 // are struct pointers, then their contents are merged. If the source and
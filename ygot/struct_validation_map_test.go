@@ -407,6 +407,57 @@ func TestEmitJSON(t *testing.T) {
 			Indent: "  ",
 		},
 		wantJSONPath: filepath.Join(TestRoot, "testdata/emitjson2_ietf.json-txt"),
+	}, {
+		name: "simple schema IETF YAML output",
+		inStruct: &mapStructTestOne{
+			Child: &mapStructTestOneChild{
+				FieldOne:  String("bar"),
+				FieldTwo:  Uint32(84),
+				FieldFive: Uint64(42),
+			},
+		},
+		inConfig: &EmitJSONConfig{
+			Format: RFC7951,
+			RFC7951Config: &RFC7951JSONConfig{
+				AppendModuleName: true,
+			},
+			Encoding: YAMLEncoding,
+		},
+		wantJSONPath: filepath.Join(TestRoot, "testdata/emityaml1_ietf.yaml-txt"),
+	}, {
+		name: "schema with list and enum IETF YAML",
+		inStruct: &mapStructTestFour{
+			C: &mapStructTestFourC{
+				ACLSet: map[string]*mapStructTestFourCACLSet{
+					"n42": {Name: String("n42"), SecondValue: String("foo")},
+				},
+				OtherSet: map[ECTest]*mapStructTestFourCOtherSet{
+					ECTestVALONE: {Name: ECTestVALONE},
+					ECTestVALTWO: {Name: ECTestVALTWO},
+				},
+			},
+		},
+		inConfig: &EmitJSONConfig{
+			Format: RFC7951,
+			RFC7951Config: &RFC7951JSONConfig{
+				AppendModuleName: true,
+			},
+			Encoding: YAMLEncoding,
+			Indent:   "    ",
+		},
+		wantJSONPath: filepath.Join(TestRoot, "testdata/emityaml2_ietf.yaml-txt"),
+	}, {
+		name: "YAML with invalid indentation",
+		inStruct: &mapStructTestOne{
+			Child: &mapStructTestOneChild{
+				FieldOne: String("hello"),
+			},
+		},
+		inConfig: &EmitJSONConfig{
+			Encoding: YAMLEncoding,
+			Indent:   "\t",
+		},
+		wantErr: `YAML marshalling error: invalid YAML indentation "\t", must consist of spaces`,
 	}, {
 		name:     "invalid struct contents",
 		inStruct: &mapStructInvalid{Name: String("aardvark")},
@@ -450,6 +501,57 @@ func TestEmitJSON(t *testing.T) {
 	}
 }
 
+func TestEmitYAML(t *testing.T) {
+	in := &mapStructTestOne{
+		Child: &mapStructTestOneChild{
+			FieldOne:  String("hello"),
+			FieldTwo:  Uint32(42),
+			FieldFive: Uint64(84),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		inConfig *EmitJSONConfig
+		want     string
+	}{{
+		name: "internal format",
+		want: `child:
+  config:
+    field-five: 84
+    field-one: hello
+    field-two: 42
+`,
+	}, {
+		name:     "RFC7951 format",
+		inConfig: &EmitJSONConfig{Format: RFC7951, Indent: "   "},
+		want: `child:
+   config:
+      field-five: "84"
+      field-one: hello
+      field-two: 42
+`,
+	}}
+
+	for _, tt := range tests {
+		var origConfig EmitJSONConfig
+		if tt.inConfig != nil {
+			origConfig = *tt.inConfig
+		}
+		got, err := EmitYAML(in, tt.inConfig)
+		if err != nil {
+			t.Errorf("%s: EmitYAML(%v, %v): got unexpected error: %v", tt.name, in, tt.inConfig, err)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: EmitYAML(%v, %v): did not get expected YAML, diff(-got,+want):\n%s", tt.name, in, tt.inConfig, diff)
+		}
+		if tt.inConfig != nil && *tt.inConfig != origConfig {
+			t.Errorf("%s: EmitYAML(%v, %v): modified input config, got: %v, want: %v", tt.name, in, tt.inConfig, *tt.inConfig, origConfig)
+		}
+	}
+}
+
 // emptyTreeTestOne is a test case for TestBuildEmptyTree.
 type emptyTreeTestOne struct {
 	ValOne   *string
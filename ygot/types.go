@@ -84,4 +84,8 @@ type EnumDefinition struct {
 	// DefiningModule specifies the module within which the enumeration was
 	// defined. Only populated for identity values.
 	DefiningModule string
+	// Bases specifies the identities that the identity value is derived
+	// from, directly or indirectly, in the form module:identity. Only
+	// populated for identity values.
+	Bases []string
 }
@@ -0,0 +1,83 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygotutils
+
+import (
+	"reflect"
+
+	"github.com/openconfig/ygot/util"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// pathMatchesPrefix reports whether prefix is a prefix of path.
+func pathMatchesPrefix(path *gpb.Path, prefix []string) bool {
+	if len(path.GetElem()) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path.GetElem()[i].GetName() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trimGNMIPathPrefix returns path with the prefix trimmed. It returns the
+// original path if the prefix does not fully match.
+func trimGNMIPathPrefix(path *gpb.Path, prefix []string) *gpb.Path {
+	if !pathMatchesPrefix(path, prefix) {
+		return path
+	}
+	out := *path
+	out.Elem = out.GetElem()[len(prefix):]
+	return &out
+}
+
+// popGNMIPath returns the supplied GNMI path with the first path element
+// removed. If the path is empty, it returns an empty path.
+func popGNMIPath(path *gpb.Path) *gpb.Path {
+	if len(path.GetElem()) == 0 {
+		return path
+	}
+	return &gpb.Path{
+		Origin: path.GetOrigin(),
+		Elem:   path.GetElem()[1:],
+	}
+}
+
+// pathStructTagKey returns the string label of the struct field f when it is
+// used in a YANG list. This is the last path element of the struct path tag.
+func pathStructTagKey(f reflect.StructField) (string, error) {
+	p, err := util.RelativeSchemaPath(f)
+	if err != nil {
+		return "", err
+	}
+	return p[len(p)-1], nil
+}
+
+// isNil is a general purpose nil check for the kinds of value types expected in
+// this package.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Slice, reflect.Ptr, reflect.Map:
+		return reflect.ValueOf(value).IsNil()
+	}
+	return false
+}
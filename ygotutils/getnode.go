@@ -0,0 +1,316 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ygotutils implements utility functions for users of
+// github.com/openconfig/ygot, which allow nodes within a data tree of
+// generated GoStructs to be retrieved or created using gNMI paths.
+//
+// Errors returned by the functions in this package are gRPC status errors,
+// such that a caller can retrieve the status code (e.g., NOT_FOUND where a
+// path does not exist in the data tree, INVALID_ARGUMENT where the path or
+// data tree supplied are invalid) using status.FromError, and return it
+// directly to a gRPC client.
+package ygotutils
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// GetNode returns the node in the data tree at the indicated path, relative to
+// the supplied root struct. If the root struct is the tree root, the path may
+// be absolute.
+// It returns an error with the NOT_FOUND status code if the path is not found
+// in the tree, or an error with the INVALID_ARGUMENT status code if an element
+// along the path is nil or the path is otherwise invalid.
+func GetNode(schema *yang.Entry, rootStruct ygot.GoStruct, path *gpb.Path) (interface{}, error) {
+	node, _, err := getNodeInternal(schema, rootStruct, path)
+	return node, err
+}
+
+// NewNode returns a new, empty struct element of the type indicated by the
+// given path in the data tree.
+// Note that the actual data tree is not required
+// since this is a new, empty node. The path is simply used to traverse the
+// schema tree - any key values in the path are ignored.
+// It returns an error with the NOT_FOUND status code if the path does not
+// exist within rootType.
+func NewNode(rootType reflect.Type, path *gpb.Path) (interface{}, error) {
+	if len(path.GetElem()) == 0 {
+		util.ResetIndent()
+		util.DbgPrint("creating new object of type %s", rootType)
+		if rootType.Kind() == reflect.Ptr {
+			return reflect.New(rootType.Elem()).Interface(), nil
+		}
+		return reflect.New(rootType).Elem().Interface(), nil
+	}
+	// Strip off the absolute path prefix since the relative and absolute paths
+	// are assumed to be equal.
+	if path.GetElem()[0].GetName() == "" {
+		path.Elem = path.GetElem()[1:]
+	}
+
+	util.Indent()
+	util.DbgPrint("NewNode type %s, next path %v", rootType, path.GetElem()[0])
+
+	switch {
+	case util.IsTypeStructPtr(rootType):
+		return newNodeContainerType(rootType, path)
+	case util.IsTypeMap(rootType) || util.IsTypeSlicePtr(rootType):
+		return newNodeListType(rootType, path)
+	}
+
+	return nil, status.Errorf(codes.InvalidArgument, "bad data type for %s, must be ptr to struct, slice, or map", rootType)
+}
+
+// getNodeInternal is the internal implementation of GetNode. In
+// addition to GetNode functionality, it can accept non GoStruct types e.g.
+// map for a keyed list, or a leaf.
+func getNodeInternal(schema *yang.Entry, rootStruct interface{}, path *gpb.Path) (interface{}, *yang.Entry, error) {
+	if len(path.GetElem()) == 0 {
+		util.ResetIndent()
+		return rootStruct, schema, nil
+	}
+	if isNil(rootStruct) {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "nil data element type %T, remaining path %v", rootStruct, path)
+	}
+	if schema == nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "nil schema for data element type %T, remaining path %v", rootStruct, path)
+	}
+	// Strip off the absolute path prefix since the relative and absolute paths
+	// are assumed to be equal.
+	if path.GetElem()[0].GetName() == "" {
+		path.Elem = path.GetElem()[1:]
+	}
+
+	util.Indent()
+	util.DbgPrint("GetNode next path %v, value %v", path.GetElem()[0], util.ValueStr(rootStruct))
+
+	switch {
+	case schema.IsContainer() || (schema.IsList() && util.IsTypeStructPtr(reflect.TypeOf(rootStruct))):
+		// Either a container or list schema with struct data node (which could
+		// be an element of a list).
+		return getNodeContainer(schema, rootStruct, path)
+	case schema.IsList():
+		// A list schema with the list data node. Must find the element selected
+		// by the path.
+		return getNodeList(schema, rootStruct, path)
+	}
+
+	return nil, nil, status.Errorf(codes.InvalidArgument, "bad schema type for %s, struct type %T", schema.Name, rootStruct)
+}
+
+// getNodeContainer traverses the container rootStruct, which must be a
+// struct ptr type and matches each field against the first path element in
+// path. If a field matches, it recurses into that field with the remaining
+// path.
+func getNodeContainer(schema *yang.Entry, rootStruct interface{}, path *gpb.Path) (interface{}, *yang.Entry, error) {
+	util.DbgPrint("getNodeContainer: schema %s, next path %v, value %v", schema.Name, path.GetElem()[0], util.ValueStr(rootStruct))
+
+	rv := reflect.ValueOf(rootStruct)
+	if !util.IsValueStructPtr(rv) {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "getNodeContainer: rootStruct has type %T, expect struct ptr", rootStruct)
+	}
+
+	v := rv.Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		ft := v.Type().Field(i)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+		cschema, err := util.ChildSchema(schema, ft)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "error for schema for type %T, field name %s: %s", rootStruct, ft.Name, err)
+		}
+		if cschema == nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "could not find schema for type %T, field name %s", rootStruct, ft.Name)
+		}
+		cschema, err = util.ResolveIfLeafRef(cschema)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "error for schema for type %T, field name %s: %s", rootStruct, ft.Name, err)
+		}
+
+		util.DbgPrint("check field name %s", cschema.Name)
+		ps, err := util.SchemaPaths(ft)
+		if err != nil {
+			return nil, nil, status.Error(codes.Internal, err.Error())
+		}
+		for _, p := range ps {
+			if pathMatchesPrefix(path, p) {
+				// don't trim whole prefix  for keyed list since name and key
+				// are a in the same element.
+				to := len(p)
+				if util.IsTypeMap(ft.Type) {
+					to--
+				}
+				return getNodeInternal(cschema, f.Interface(), trimGNMIPathPrefix(path, p[0:to]))
+			}
+		}
+	}
+
+	return nil, nil, status.Errorf(codes.NotFound, "could not find path in tree beyond schema node %s, (type %T), remaining path %v", schema.Name, rootStruct, path)
+}
+
+// getNodeList traverses the list rootStruct, which must be a map of struct
+// type and matches each map key against the first path element in path. If the
+// key matches completely, it recurses into that field with the remaining path.
+func getNodeList(schema *yang.Entry, rootStruct interface{}, path *gpb.Path) (interface{}, *yang.Entry, error) {
+	util.DbgPrint("getNodeList: schema %s, next path %v, value %v", schema.Name, path.GetElem()[0], util.ValueStr(rootStruct))
+
+	rv := reflect.ValueOf(rootStruct)
+	if schema.Key == "" {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "getNodeList: path %v cannot traverse unkeyed list type %T", path, rootStruct)
+	}
+	if path.GetElem()[0].GetKey() == nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "getNodeList: path %v at %T points to list but does not specify a key element", path, rootStruct)
+	}
+	if !util.IsValueMap(rv) {
+		// Only keyed lists can be traversed with a path.
+		return nil, nil, status.Errorf(codes.InvalidArgument, "getNodeList: rootStruct has type %T, expect map", rootStruct)
+	}
+
+	listElementType := rv.Type().Elem().Elem()
+	listKeyType := rv.Type().Key()
+
+	// Iterate through all the map keys to see if any match the path.
+	for _, k := range rv.MapKeys() {
+		ev := rv.MapIndex(k)
+		util.DbgPrint("checking key %v, value %v", k.Interface(), util.ValueStr(ev.Interface()))
+		match := true
+		if !util.IsValueStruct(k) {
+			// Compare just the single value of the key represented as a string.
+			pathKey, ok := path.GetElem()[0].GetKey()[schema.Key]
+			if !ok {
+				return nil, nil, status.Errorf(codes.InvalidArgument, "gnmi path %v does not contain a map entry for the schema key field name %s, parent type %T",
+					path, schema.Key, rootStruct)
+			}
+			kv, err := util.GetKeyValue(ev.Elem(), schema.Key)
+			if err != nil {
+				return nil, nil, status.Error(codes.Internal, err.Error())
+			}
+			util.DbgPrint("check simple key value %s", pathKey)
+			match = (fmt.Sprint(kv) == pathKey)
+		} else {
+			// Must compare all the key fields.
+			for i := 0; i < k.NumField(); i++ {
+				kfn := listKeyType.Field(i).Name
+				fv := ev.Elem().FieldByName(kfn)
+				if !fv.IsValid() {
+					return nil, nil, status.Errorf(codes.InvalidArgument, "element struct type %s does not contain key field %s", k.Type(), kfn)
+				}
+				nv := fv
+				if fv.Type().Kind() == reflect.Ptr {
+					// Ptr values are deferenced in key struct.
+					nv = nv.Elem()
+				}
+				kf, ok := listElementType.FieldByName(kfn)
+				if !ok {
+					return nil, nil, status.Errorf(codes.InvalidArgument, "element struct type %s does not contain key field %s", k.Type(), kfn)
+				}
+				kn, err := pathStructTagKey(kf)
+				if err != nil {
+					return nil, nil, status.Error(codes.Internal, err.Error())
+				}
+				pathKey, ok := path.GetElem()[0].GetKey()[kn]
+				if !ok {
+					return nil, nil, status.Errorf(codes.InvalidArgument, "gnmi path %v does not contain a map entry for the schema key field name %s, parent type %T",
+						path, schema.Key, rootStruct)
+				}
+				if pathKey != fmt.Sprint(k.Field(i).Interface()) {
+					match = false
+					break
+				}
+				util.DbgPrint("key field value %s matches", pathKey)
+			}
+		}
+
+		if match {
+			// Pass in the list schema, but the actual selected element
+			// rather than the whole list.
+			util.DbgPrint("whole key matches")
+			return getNodeInternal(schema, ev.Interface(), popGNMIPath(path))
+		}
+	}
+
+	return nil, nil, status.Errorf(codes.NotFound, "could not find path in tree beyond schema node %s, (type %T), remaining path %v", schema.Name, rootStruct, path)
+}
+
+// newNodeContainerType traverses the container, which must be a struct ptr
+// and tries to match each field with the path prefix.
+// If a match is found, it removes the matching prefix and recurses the
+// corresponding field type with the remaining path.
+func newNodeContainerType(rootType reflect.Type, path *gpb.Path) (interface{}, error) {
+	util.DbgPrint("newNodeContainerType: type %s, next path %v", rootType, path.GetElem()[0])
+
+	if !util.IsTypeStructPtr(rootType) {
+		return nil, status.Errorf(codes.InvalidArgument, "newNodeContainerType: rootType has type %s, expect struct ptr", rootType)
+	}
+
+	t := rootType.Elem()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if util.IsYgotAnnotation(f) {
+			continue
+		}
+		ps, err := util.SchemaPaths(f)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		for _, p := range ps {
+			if pathMatchesPrefix(path, p) {
+				// don't trim whole prefix  for keyed list since name and key
+				// are a in the same element.
+				to := len(p)
+				if util.IsTypeMap(f.Type) {
+					to--
+				}
+				return NewNode(f.Type, trimGNMIPathPrefix(path, p[0:to]))
+			}
+		}
+	}
+
+	return nil, status.Errorf(codes.NotFound, "could not find path in tree beyond type %s, remaining path %v", rootType, path)
+}
+
+// newNodeListType traverses the list, which must be a map of struct type,
+// to its element struct type. It removes the front element from path and
+// recurses the struct with the remaining path.
+func newNodeListType(rootType reflect.Type, path *gpb.Path) (interface{}, error) {
+	util.DbgPrint("newNodeListType: type %s, next path %v", rootType, path.GetElem()[0])
+
+	var listElementType reflect.Type
+	switch {
+	case util.IsTypeMap(rootType):
+		listElementType = rootType.Elem()
+	case util.IsTypeSlicePtr(rootType):
+		listElementType = rootType.Elem().Elem()
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "newNodeListType: rootType has type %s, expect map or slice ptr", rootType)
+	}
+
+	// Nothing to do execept to pop off the key and pass the container type
+	// with the list schema.
+	return NewNode(listElementType, popGNMIPath(path))
+}
@@ -1,7 +1,20 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package ygotutils
 
 import (
-	"fmt"
 	"reflect"
 	"testing"
 
@@ -12,6 +25,7 @@ import (
 	gpb "github.com/openconfig/gnmi/proto/gnmi"
 	scpb "google.golang.org/genproto/googleapis/rpc/code"
 	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -50,10 +64,31 @@ func errToString(err error) string {
 	return err.Error()
 }
 
+// statusOK indicates an OK Status.
+var statusOK = spb.Status{Code: int32(scpb.Code_OK)}
+
 func isOK(status spb.Status) bool {
 	return status.Code == int32(scpb.Code_OK)
 }
 
+// toStatus returns a Status with the given code and message.
+func toStatus(code scpb.Code, message string) spb.Status {
+	return spb.Status{
+		Code:    int32(code),
+		Message: message,
+	}
+}
+
+// errToStatus returns the Status carried by err, which must either be nil or
+// an error created by the gRPC status package.
+func errToStatus(t *testing.T, err error) spb.Status {
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("error %v is not a status error", err)
+	}
+	return *s.Proto()
+}
+
 type InnerContainerType1 struct {
 	LeafName *int32 `path:"leaf-field"`
 }
@@ -290,12 +325,13 @@ func TestGetNodeSimpleKeyedList(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		val, status := GetNode(containerWithLeafListSchema, tt.rootStruct, tt.path)
-		if got, want := status, tt.wantStatus; !reflect.DeepEqual(got, want) {
+		val, err := GetNode(containerWithLeafListSchema, tt.rootStruct, tt.path)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; !reflect.DeepEqual(got, want) {
 			t.Errorf("%s: got error: %v, wanted error? %v", tt.desc, got, want)
 		}
-		testErrLog(t, tt.desc, fmt.Errorf(status.GetMessage()))
-		if isOK(status) {
+		testErrLog(t, tt.desc, err)
+		if isOK(st) {
 			if got, want := val, tt.want; !reflect.DeepEqual(got, want) {
 				t.Errorf("%s: struct got:\n%v\nwant:\n%v\n", tt.desc, pretty.Sprint(got), pretty.Sprint(want))
 			}
@@ -450,12 +486,13 @@ func TestGetNodeStructKeyedList(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		val, status := GetNode(containerWithLeafListSchema, tt.rootStruct, tt.path)
-		if got, want := status, tt.wantStatus; !reflect.DeepEqual(got, want) {
+		val, err := GetNode(containerWithLeafListSchema, tt.rootStruct, tt.path)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; !reflect.DeepEqual(got, want) {
 			t.Errorf("%s: got error: %v, wanted error? %v", tt.desc, got, want)
 		}
-		testErrLog(t, tt.desc, fmt.Errorf(status.GetMessage()))
-		if isOK(status) {
+		testErrLog(t, tt.desc, err)
+		if isOK(st) {
 			if got, want := val, tt.want; !reflect.DeepEqual(got, want) {
 				t.Errorf("%s: struct got:\n%v\nwant:\n%v\n", tt.desc, pretty.Sprint(got), pretty.Sprint(want))
 			}
@@ -594,12 +631,13 @@ func TestNewNodeSimpleKeyedList(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		val, status := NewNode(reflect.TypeOf(tt.rootStruct), tt.path)
-		if got, want := status, tt.wantStatus; !reflect.DeepEqual(got, want) {
+		val, err := NewNode(reflect.TypeOf(tt.rootStruct), tt.path)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; !reflect.DeepEqual(got, want) {
 			t.Errorf("%s: got error: %v, wanted error? %v", tt.desc, got, want)
 		}
-		testErrLog(t, tt.desc, fmt.Errorf(status.GetMessage()))
-		if isOK(status) {
+		testErrLog(t, tt.desc, err)
+		if isOK(st) {
 			if got, want := reflect.TypeOf(val), reflect.TypeOf(tt.want); got != want {
 				t.Errorf("%s: got: %s, want: %s", tt.desc, got, want)
 			}
@@ -728,12 +766,13 @@ func TestNewNodeStructKeyedList(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		val, status := NewNode(reflect.TypeOf(tt.rootStruct), tt.path)
-		if got, want := status, tt.wantStatus; !reflect.DeepEqual(got, want) {
+		val, err := NewNode(reflect.TypeOf(tt.rootStruct), tt.path)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; !reflect.DeepEqual(got, want) {
 			t.Errorf("%s: got error: %v, wanted error? %v", tt.desc, got, want)
 		}
-		testErrLog(t, tt.desc, fmt.Errorf(status.GetMessage()))
-		if isOK(status) {
+		testErrLog(t, tt.desc, err)
+		if isOK(st) {
 			if got, want := val, tt.want; !reflect.DeepEqual(got, want) {
 				t.Errorf("%s: got: %s, want: %s", tt.desc, got, want)
 			}
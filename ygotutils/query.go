@@ -0,0 +1,485 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygotutils
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	// WildcardElem is a path element name that matches exactly one element
+	// of the data tree path.
+	WildcardElem = "*"
+	// MultiLevelWildcardElem is a path element name that matches zero or more
+	// elements of the data tree path.
+	MultiLevelWildcardElem = "..."
+	// WildcardKey is a list key value that matches any value of the key.
+	WildcardKey = "*"
+)
+
+// NodeMatch is a node in the data tree that was matched by a query path
+// supplied to GetNodes.
+type NodeMatch struct {
+	// Data is the matched data tree node. It has the same form as the value
+	// returned by GetNode for the concrete Path.
+	Data interface{}
+	// Schema is the schema for the matched node.
+	Schema *yang.Entry
+	// Path is the concrete path of the node, relative to the root struct
+	// supplied to GetNodes, with all list keys fully specified.
+	Path *gpb.Path
+}
+
+// GetNodes returns all nodes in the data tree that match the query path,
+// relative to the supplied root struct. If the root struct is the tree root,
+// the path may be absolute.
+//
+// The query path may contain:
+//   - path elements named "*", which match any single element in the data tree
+//     path.
+//   - path elements named "...", which match zero or more elements in the data
+//     tree path.
+//   - list elements which specify only a subset of the list's keys, or specify
+//     a key value of "*". Keys that are not specified, or have a "*" value,
+//     match any value.
+//
+// List elements are always matched individually, such that a path which ends
+// at a list without keys returns each element of the list.
+//
+// Unlike GetNode, paths that do not exist in the data tree, or that traverse
+// nil elements, are not errors - they result in no matches. Unkeyed lists may
+// be matched as a whole, but are not descended into, since their elements
+// cannot be addressed by a concrete path. Where a struct field can be reached
+// through more than one schema path, the first path matched by the query is
+// used.
+//
+// The matches are returned in a deterministic order, with list elements
+// ordered by their key values, compared in the order in which the keys are
+// specified in the schema. Numeric (including enumerated) key values are
+// compared numerically, and other key values by their string form.
+func GetNodes(schema *yang.Entry, rootStruct ygot.GoStruct, path *gpb.Path) ([]*NodeMatch, error) {
+	if schema == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "nil schema for data element type %T", rootStruct)
+	}
+	if isNil(rootStruct) {
+		return nil, status.Errorf(codes.InvalidArgument, "nil data element type %T", rootStruct)
+	}
+
+	q := &nodeQuery{elems: path.GetElem(), origin: path.GetOrigin()}
+	// Strip off the absolute path prefix since the relative and absolute paths
+	// are assumed to be equal.
+	if len(q.elems) != 0 && q.elems[0].GetName() == "" {
+		q.elems = q.elems[1:]
+	}
+
+	util.ResetIndent()
+	if err := q.matchContainer(schema, rootStruct, nil, q.closure([]int{0})); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return q.matches, nil
+}
+
+// nodeQuery stores the state of a GetNodes query whilst the data tree is being
+// walked.
+type nodeQuery struct {
+	// elems is the query path.
+	elems []*gpb.PathElem
+	// origin is the origin of the query path, which is copied to the paths
+	// of the matched nodes.
+	origin string
+	// matches is the set of nodes that have been matched by the query.
+	matches []*NodeMatch
+}
+
+// closure returns the set of positions in the query path that can be reached
+// from the positions in states without consuming a data tree path element.
+// This is the case when the element at a position is a multi-level wildcard,
+// since it can match zero elements.
+func (q *nodeQuery) closure(states []int) []int {
+	seen := map[int]bool{}
+	var out []int
+	for _, s := range states {
+		for ; s <= len(q.elems); s++ {
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+			if s == len(q.elems) || q.elems[s].GetName() != MultiLevelWildcardElem {
+				break
+			}
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// step returns the set of positions in the query path that are reached from
+// the positions in states once the data tree path element with the supplied
+// name has been consumed. Each returned position is paired with the query
+// path element that consumed name, which is nil when it was consumed by a
+// multi-level wildcard.
+func (q *nodeQuery) step(states []int, name string) map[int]*gpb.PathElem {
+	next := map[int]*gpb.PathElem{}
+	for _, s := range states {
+		if s == len(q.elems) {
+			continue
+		}
+		switch e := q.elems[s]; e.GetName() {
+		case MultiLevelWildcardElem:
+			if _, ok := next[s]; !ok {
+				next[s] = nil
+			}
+		case WildcardElem, name:
+			next[s+1] = e
+		}
+	}
+	return next
+}
+
+// consume returns the set of positions in the query path that are reached
+// from the positions in states once all of the data tree path elements in
+// names have been consumed.
+func (q *nodeQuery) consume(states []int, names []string) []int {
+	for _, n := range names {
+		next := q.step(states, n)
+		states = states[:0:0]
+		for s := range next {
+			states = append(states, s)
+		}
+		states = q.closure(states)
+	}
+	return states
+}
+
+// isComplete returns true if the query path has been fully consumed in one
+// of the positions in states.
+func (q *nodeQuery) isComplete(states []int) bool {
+	for _, s := range states {
+		if s == len(q.elems) {
+			return true
+		}
+	}
+	return false
+}
+
+// addMatch appends the node with the supplied data, schema and path to the
+// set of matched nodes.
+func (q *nodeQuery) addMatch(data interface{}, schema *yang.Entry, path []*gpb.PathElem) {
+	util.DbgPrint("matched node %v", path)
+	q.matches = append(q.matches, &NodeMatch{
+		Data:   data,
+		Schema: schema,
+		Path:   &gpb.Path{Origin: q.origin, Elem: copyPathElems(path)},
+	})
+}
+
+// matchContainer matches the query against the container rootStruct, which
+// must be a struct ptr, and its descendants. The prefix is the concrete path
+// to rootStruct and states is the set of positions reached in the query path
+// at rootStruct.
+func (q *nodeQuery) matchContainer(schema *yang.Entry, rootStruct interface{}, prefix []*gpb.PathElem, states []int) error {
+	if q.isComplete(states) {
+		q.addMatch(rootStruct, schema, prefix)
+	}
+
+	rv := reflect.ValueOf(rootStruct)
+	if !util.IsValueStructPtr(rv) {
+		return fmt.Errorf("matchContainer: rootStruct has type %T, expect struct ptr", rootStruct)
+	}
+
+	util.Indent()
+	defer util.Dedent()
+	util.DbgPrint("matchContainer: schema %s, prefix %v, value %v", schema.Name, prefix, util.ValueStr(rootStruct))
+
+	v := rv.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		ft := v.Type().Field(i)
+		if isNil(f.Interface()) || util.IsYgotAnnotation(ft) {
+			continue
+		}
+		cschema, err := util.ChildSchema(schema, ft)
+		if err != nil {
+			return fmt.Errorf("error for schema for type %T, field name %s: %s", rootStruct, ft.Name, err)
+		}
+		if cschema == nil {
+			return fmt.Errorf("could not find schema for type %T, field name %s", rootStruct, ft.Name)
+		}
+		cschema, err = util.ResolveIfLeafRef(cschema)
+		if err != nil {
+			return fmt.Errorf("error for schema for type %T, field name %s: %s", rootStruct, ft.Name, err)
+		}
+
+		ps, err := util.SchemaPaths(ft)
+		if err != nil {
+			return err
+		}
+		for _, p := range ps {
+			matched, err := q.matchField(cschema, f.Interface(), prefix, states, p)
+			if err != nil {
+				return err
+			}
+			if matched {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// matchField matches the query against the value of a struct field, which is
+// found at schema path p relative to its parent. It returns true if the query
+// path matched p, such that the field was examined.
+func (q *nodeQuery) matchField(schema *yang.Entry, value interface{}, prefix []*gpb.PathElem, states []int, p []string) (bool, error) {
+	fieldPath := append(prefix[:len(prefix):len(prefix)], namesToPathElems(p)...)
+
+	switch t := reflect.TypeOf(value); {
+	case util.IsTypeMap(t):
+		// The last element of a keyed list's path is the list itself, which is
+		// matched against the key of each element.
+		lstates := q.consume(states, p[:len(p)-1])
+		next := q.step(lstates, p[len(p)-1])
+		if len(next) == 0 {
+			return false, nil
+		}
+		return true, q.matchList(schema, value, fieldPath, next)
+	case schema.IsContainer():
+		next := q.consume(states, p)
+		if len(next) == 0 {
+			return false, nil
+		}
+		return true, q.matchContainer(schema, value, fieldPath, next)
+	default:
+		// Leaves, leaf-lists and unkeyed lists are matched only if the query
+		// path ends at them.
+		next := q.consume(states, p)
+		if len(next) == 0 {
+			return false, nil
+		}
+		if q.isComplete(next) {
+			q.addMatch(value, schema, fieldPath)
+		}
+		return true, nil
+	}
+}
+
+// matchList matches the query against each element of the keyed list
+// rootStruct, which must be a map of struct ptrs. The prefix is the concrete
+// path to the list, and next is the set of positions reached in the query path
+// once the list has been consumed, each paired with the query path element
+// that consumed it, which is used to filter the list elements by key.
+func (q *nodeQuery) matchList(schema *yang.Entry, rootStruct interface{}, prefix []*gpb.PathElem, next map[int]*gpb.PathElem) error {
+	rv := reflect.ValueOf(rootStruct)
+	if schema.Key == "" {
+		return fmt.Errorf("matchList: schema %s for type %T is not a keyed list", schema.Name, rootStruct)
+	}
+
+	util.Indent()
+	defer util.Dedent()
+	util.DbgPrint("matchList: schema %s, prefix %v, value %v", schema.Name, prefix, util.ValueStr(rootStruct))
+
+	type listElem struct {
+		keys map[string]string
+		// keyVals are the typed values of the keys, in schema key order.
+		keyVals []reflect.Value
+		val     reflect.Value
+	}
+	var elems []listElem
+	for _, k := range rv.MapKeys() {
+		ev := rv.MapIndex(k)
+		keys, keyVals, err := listElemKeys(schema, k, ev)
+		if err != nil {
+			return err
+		}
+		elems = append(elems, listElem{keys: keys, keyVals: keyVals, val: ev})
+	}
+	sort.Slice(elems, func(i, j int) bool {
+		a, b := elems[i].keyVals, elems[j].keyVals
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if c := compareKeyValues(a[n], b[n]); c != 0 {
+				return c < 0
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	for _, e := range elems {
+		var states []int
+		for s, qe := range next {
+			ok, err := keysMatch(qe, e.keys)
+			if err != nil {
+				return fmt.Errorf("%v for list %s, type %T", err, schema.Name, rootStruct)
+			}
+			if ok {
+				states = append(states, s)
+			}
+		}
+		if len(states) == 0 {
+			continue
+		}
+
+		elemPath := copyPathElems(prefix)
+		elemPath[len(elemPath)-1].Key = e.keys
+		if err := q.matchContainer(schema, e.val.Interface(), elemPath, q.closure(states)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listElemKeys returns the keys of the list element ev with map key k, as a
+// map of key name to key value, suitable for use in a gNMI path, along with
+// the typed values of the keys, in the order in which the keys are specified
+// in the schema.
+func listElemKeys(schema *yang.Entry, k, ev reflect.Value) (map[string]string, []reflect.Value, error) {
+	if !util.IsValueStruct(k) {
+		kv, err := util.GetKeyValue(ev.Elem(), schema.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string]string{schema.Key: fmt.Sprint(kv)}, []reflect.Value{k}, nil
+	}
+
+	keys := map[string]string{}
+	vals := map[string]reflect.Value{}
+	listElementType := ev.Type().Elem()
+	for i := 0; i < k.NumField(); i++ {
+		kfn := k.Type().Field(i).Name
+		kf, ok := listElementType.FieldByName(kfn)
+		if !ok {
+			return nil, nil, fmt.Errorf("element struct type %s does not contain key field %s", listElementType, kfn)
+		}
+		kn, err := pathStructTagKey(kf)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[kn] = fmt.Sprint(k.Field(i).Interface())
+		vals[kn] = k.Field(i)
+	}
+
+	var keyVals []reflect.Value
+	for _, kn := range strings.Fields(schema.Key) {
+		v, ok := vals[kn]
+		if !ok {
+			return nil, nil, fmt.Errorf("key struct type %s does not contain key %s", k.Type(), kn)
+		}
+		keyVals = append(keyVals, v)
+	}
+	return keys, keyVals, nil
+}
+
+// compareKeyValues compares the values a and b of a list key, returning a
+// negative value if a sorts before b, a positive value if b sorts before a,
+// and zero if they are equal. Numeric (including enumerated) values are
+// compared numerically, and other values by their string form.
+func compareKeyValues(a, b reflect.Value) int {
+	for a.Kind() == reflect.Interface || a.Kind() == reflect.Ptr {
+		if a.IsNil() {
+			break
+		}
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface || b.Kind() == reflect.Ptr {
+		if b.IsNil() {
+			break
+		}
+		b = b.Elem()
+	}
+
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			switch x, y := a.Int(), b.Int(); {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			switch x, y := a.Uint(), b.Uint(); {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		case reflect.Float32, reflect.Float64:
+			switch x, y := a.Float(), b.Float(); {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+// keysMatch returns true if the keys of the query path element qe match the
+// keys of a list element. A nil qe, or keys that are not specified in qe or
+// have a wildcard value, match any value. It returns an error if qe specifies
+// a key that the list element does not have.
+func keysMatch(qe *gpb.PathElem, keys map[string]string) (bool, error) {
+	for k, v := range qe.GetKey() {
+		ev, ok := keys[k]
+		if !ok {
+			return false, fmt.Errorf("gnmi path element %v contains key %s which is not a list key", qe, k)
+		}
+		if v != WildcardKey && v != ev {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// namesToPathElems returns a slice of gNMI path elements with the supplied
+// names.
+func namesToPathElems(names []string) []*gpb.PathElem {
+	var out []*gpb.PathElem
+	for _, n := range names {
+		out = append(out, &gpb.PathElem{Name: n})
+	}
+	return out
+}
+
+// copyPathElems returns a copy of the supplied gNMI path elements, such that
+// the returned elements can be modified without affecting the input.
+func copyPathElems(in []*gpb.PathElem) []*gpb.PathElem {
+	out := make([]*gpb.PathElem, 0, len(in))
+	for _, e := range in {
+		ne := &gpb.PathElem{Name: e.GetName()}
+		if e.GetKey() != nil {
+			ne.Key = map[string]string{}
+			for k, v := range e.GetKey() {
+				ne.Key[k] = v
+			}
+		}
+		out = append(out, ne)
+	}
+	return out
+}
@@ -0,0 +1,351 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygotutils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	scpb "google.golang.org/genproto/googleapis/rpc/code"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+)
+
+// queryMatch is a simplified form of NodeMatch used for comparison in tests.
+type queryMatch struct {
+	data interface{}
+	path *gpb.Path
+}
+
+func TestGetNodes(t *testing.T) {
+	structKeyListSchema := &yang.Entry{
+		Name: "container",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"struct-key-list": &yang.Entry{
+				Name:     "struct-key-list",
+				Kind:     yang.DirectoryEntry,
+				ListAttr: &yang.ListAttr{MinElements: &yang.Value{Name: "0"}},
+				Key:      "key1 key2 key3",
+				Config:   yang.TSTrue,
+				Dir: map[string]*yang.Entry{
+					"key1": {
+						Name: "key1",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Ystring},
+					},
+					"key2": {
+						Name: "key2",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Yint32},
+					},
+					"key3": {
+						Name: "key3",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Yenum},
+					},
+					"outer": {
+						Name: "outer",
+						Kind: yang.DirectoryEntry,
+						Dir: map[string]*yang.Entry{
+							"inner": &yang.Entry{
+								Name: "inner",
+								Kind: yang.DirectoryEntry,
+								Dir: map[string]*yang.Entry{
+									"leaf-field": &yang.Entry{
+										Name: "leaf-field",
+										Kind: yang.LeafEntry,
+										Type: &yang.YangType{Kind: yang.Yint32},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	c1 := &ContainerStruct2{
+		StructKeyList: map[KeyStruct2]*ListElemStruct2{
+			{"forty-two", 42, 43}: &ListElemStruct2{
+				Key1:    ygot.String("forty-two"),
+				Key2:    ygot.Int32(42),
+				EnumKey: 43,
+				Outer:   &OuterContainerType2{Inner: &InnerContainerType2{LeafName: ygot.Int32(1234)}},
+			},
+			{"forty-two", 44, 43}: &ListElemStruct2{
+				Key1:    ygot.String("forty-two"),
+				Key2:    ygot.Int32(44),
+				EnumKey: 43,
+				Outer:   &OuterContainerType2{Inner: &InnerContainerType2{LeafName: ygot.Int32(5678)}},
+			},
+			{"forty-three", 42, 43}: &ListElemStruct2{
+				Key1:    ygot.String("forty-three"),
+				Key2:    ygot.Int32(42),
+				EnumKey: 43,
+				Outer:   &OuterContainerType2{},
+			},
+		},
+	}
+
+	listElem := func(k1, k2 string) *gpb.PathElem {
+		return &gpb.PathElem{
+			Name: "struct-key-list",
+			Key:  map[string]string{"key1": k1, "key2": k2, "key3": "43"},
+		}
+	}
+	leafPath := func(k1, k2 string) *gpb.Path {
+		return &gpb.Path{
+			Elem: []*gpb.PathElem{listElem(k1, k2), {Name: "outer"}, {Name: "inner"}, {Name: "leaf-field"}},
+		}
+	}
+
+	tests := []struct {
+		desc       string
+		path       *gpb.Path
+		want       []queryMatch
+		wantStatus spb.Status
+	}{{
+		desc: "fully specified path",
+		path: leafPath("forty-two", "42"),
+		want: []queryMatch{{
+			data: ygot.Int32(1234),
+			path: leafPath("forty-two", "42"),
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "absolute path with partial keys",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: ""},
+				{Name: "struct-key-list", Key: map[string]string{"key1": "forty-two"}},
+				{Name: "outer"},
+				{Name: "inner"},
+				{Name: "leaf-field"},
+			},
+		},
+		want: []queryMatch{{
+			data: ygot.Int32(1234),
+			path: leafPath("forty-two", "42"),
+		}, {
+			data: ygot.Int32(5678),
+			path: leafPath("forty-two", "44"),
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "wildcard key value",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: "struct-key-list", Key: map[string]string{"key1": "*", "key2": "42"}},
+				{Name: "outer"},
+				{Name: "inner"},
+				{Name: "leaf-field"},
+			},
+		},
+		want: []queryMatch{{
+			data: ygot.Int32(1234),
+			path: leafPath("forty-two", "42"),
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "list without keys",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{{Name: "struct-key-list"}},
+		},
+		want: []queryMatch{{
+			data: c1.StructKeyList[KeyStruct2{"forty-three", 42, 43}],
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-three", "42")}},
+		}, {
+			data: c1.StructKeyList[KeyStruct2{"forty-two", 42, 43}],
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-two", "42")}},
+		}, {
+			data: c1.StructKeyList[KeyStruct2{"forty-two", 44, 43}],
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-two", "44")}},
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "single level wildcards",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: "*", Key: map[string]string{"key2": "44"}},
+				{Name: "*"},
+				{Name: "inner"},
+				{Name: "*"},
+			},
+		},
+		want: []queryMatch{{
+			data: ygot.Int32(5678),
+			path: leafPath("forty-two", "44"),
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "multi-level wildcard",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{{Name: "..."}, {Name: "leaf-field"}},
+		},
+		want: []queryMatch{{
+			data: ygot.Int32(1234),
+			path: leafPath("forty-two", "42"),
+		}, {
+			data: ygot.Int32(5678),
+			path: leafPath("forty-two", "44"),
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "multi-level wildcard matching zero elements",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: "struct-key-list", Key: map[string]string{"key2": "44"}},
+				{Name: "..."},
+				{Name: "outer"},
+				{Name: "..."},
+				{Name: "inner"},
+			},
+		},
+		want: []queryMatch{{
+			data: c1.StructKeyList[KeyStruct2{"forty-two", 44, 43}].Outer.Inner,
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-two", "44"), {Name: "outer"}, {Name: "inner"}}},
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "trailing multi-level wildcard",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: "struct-key-list", Key: map[string]string{"key1": "forty-three"}},
+				{Name: "..."},
+			},
+		},
+		want: []queryMatch{{
+			data: c1.StructKeyList[KeyStruct2{"forty-three", 42, 43}],
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-three", "42")}},
+		}, {
+			data: ygot.String("forty-three"),
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-three", "42"), {Name: "key1"}}},
+		}, {
+			data: ygot.Int32(42),
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-three", "42"), {Name: "key2"}}},
+		}, {
+			data: EnumType(43),
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-three", "42"), {Name: "key3"}}},
+		}, {
+			data: &OuterContainerType2{},
+			path: &gpb.Path{Elem: []*gpb.PathElem{listElem("forty-three", "42"), {Name: "outer"}}},
+		}},
+		wantStatus: statusOK,
+	}, {
+		desc: "no matches",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: "struct-key-list", Key: map[string]string{"key1": "forty-three"}},
+				{Name: "outer"},
+				{Name: "inner"},
+			},
+		},
+		wantStatus: statusOK,
+	}, {
+		desc: "unknown key",
+		path: &gpb.Path{
+			Elem: []*gpb.PathElem{
+				{Name: "struct-key-list", Key: map[string]string{"bad-key": "forty-three"}},
+			},
+		},
+		wantStatus: toStatus(scpb.Code_INVALID_ARGUMENT, `gnmi path element name:"struct-key-list" key:<key:"bad-key" value:"forty-three" >  contains key bad-key which is not a list key for list struct-key-list, type map[ygotutils.KeyStruct2]*ygotutils.ListElemStruct2`),
+	}}
+
+	for _, tt := range tests {
+		matches, err := GetNodes(structKeyListSchema, c1, tt.path)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: GetNodes(%v): got status: %v, want: %v", tt.desc, tt.path, got, want)
+		}
+		if !isOK(st) {
+			continue
+		}
+		var got []queryMatch
+		for _, m := range matches {
+			got = append(got, queryMatch{data: m.Data, path: m.Path})
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: GetNodes(%v): did not get expected matches, (-got, +want):\n%s", tt.desc, tt.path, diff)
+		}
+	}
+}
+
+func TestGetNodesListOrder(t *testing.T) {
+	listSchema := func(key string) *yang.Entry {
+		return &yang.Entry{
+			Name: "container",
+			Kind: yang.DirectoryEntry,
+			Dir: map[string]*yang.Entry{
+				"struct-key-list": {
+					Name:     "struct-key-list",
+					Kind:     yang.DirectoryEntry,
+					ListAttr: &yang.ListAttr{MinElements: &yang.Value{Name: "0"}},
+					Key:      key,
+					Config:   yang.TSTrue,
+					Dir: map[string]*yang.Entry{
+						"key1": {Name: "key1", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}},
+						"key2": {Name: "key2", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yint32}},
+						"key3": {Name: "key3", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yenum}},
+					},
+				},
+			},
+		}
+	}
+
+	c := &ContainerStruct2{StructKeyList: map[KeyStruct2]*ListElemStruct2{}}
+	for _, k := range []KeyStruct2{{"a", 10, 1}, {"a", 9, 1}, {"b", 1, 1}} {
+		c.StructKeyList[k] = &ListElemStruct2{Key1: ygot.String(k.Key1), Key2: ygot.Int32(k.Key2), EnumKey: k.EnumKey}
+	}
+
+	tests := []struct {
+		desc     string
+		inKey    string
+		wantKeys []KeyStruct2
+	}{{
+		desc:     "numeric keys compared numerically",
+		inKey:    "key1 key2 key3",
+		wantKeys: []KeyStruct2{{"a", 9, 1}, {"a", 10, 1}, {"b", 1, 1}},
+	}, {
+		desc:     "keys compared in schema key order",
+		inKey:    "key2 key1 key3",
+		wantKeys: []KeyStruct2{{"b", 1, 1}, {"a", 9, 1}, {"a", 10, 1}},
+	}}
+
+	path := &gpb.Path{Elem: []*gpb.PathElem{{Name: "struct-key-list"}}}
+	for _, tt := range tests {
+		matches, err := GetNodes(listSchema(tt.inKey), c, path)
+		if err != nil {
+			t.Errorf("%s: GetNodes(%v): got unexpected error: %v", tt.desc, path, err)
+			continue
+		}
+		var got []interface{}
+		for _, m := range matches {
+			got = append(got, m.Data)
+		}
+		var want []interface{}
+		for _, k := range tt.wantKeys {
+			want = append(want, c.StructKeyList[k])
+		}
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("%s: GetNodes(%v): did not get matches in expected order, (-got, +want):\n%s", tt.desc, path, diff)
+		}
+	}
+}
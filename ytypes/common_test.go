@@ -46,6 +46,8 @@ func (EnumType) ΛMap() map[string]map[int64]ygot.EnumDefinition {
 	return globalEnumMap
 }
 
+func (EnumType) IsYANGGoEnum() {}
+
 // EnumType2 is used as an enum type in various tests in the ytypes package.
 type EnumType2 int64
 
@@ -53,6 +55,8 @@ func (EnumType2) ΛMap() map[string]map[int64]ygot.EnumDefinition {
 	return globalEnumMap
 }
 
+func (EnumType2) IsYANGGoEnum() {}
+
 // populateParentField recurses through schema and populates each Parent field
 // with the parent schema node ptr.
 func populateParentField(parent, schema *yang.Entry) {
@@ -226,37 +230,6 @@ func TestValidateListAttr(t *testing.T) {
 	}
 }
 
-func TestIsFakeRoot(t *testing.T) {
-	tests := []struct {
-		name string
-		in   *yang.Entry
-		want bool
-	}{
-		{
-			name: "explicitly true",
-			in: &yang.Entry{
-				Name: "entry",
-				Annotation: map[string]interface{}{
-					"isFakeRoot": true,
-				},
-			},
-			want: true,
-		},
-		{
-			name: "unspecified",
-			in: &yang.Entry{
-				Name: "entry",
-			},
-		},
-	}
-
-	for _, tt := range tests {
-		if got := isFakeRoot(tt.in); got != tt.want {
-			t.Errorf("%v: isFakeRoot(%v): did not get expected return value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
-		}
-	}
-}
-
 type StringListElemStruct struct {
 	LeafName *string `path:"leaf-name"`
 }
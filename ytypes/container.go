@@ -51,10 +51,13 @@ func validateContainer(schema *yang.Entry, value ygot.GoStruct) util.Errors {
 		structTypes := structElems.Type()
 
 		for i := 0; i < structElems.NumField(); i++ {
+			if util.IsYgotAnnotation(structTypes.Field(i)) {
+				continue
+			}
 			fieldName := structElems.Type().Field(i).Name
 			fieldValue := structElems.Field(i).Interface()
 
-			cschema, err := childSchema(schema, structTypes.Field(i))
+			cschema, err := util.ChildSchema(schema, structTypes.Field(i))
 			switch {
 			case err != nil:
 				errors = util.AppendErr(errors, fmt.Errorf("%s: %v", fieldName, err))
@@ -102,7 +105,8 @@ func validateContainer(schema *yang.Entry, value ygot.GoStruct) util.Errors {
 //     unmamshaled into.
 //   parent is the parent struct, which must be a struct ptr.
 //   jsonTree is a JSON data tree which must be a map[string]interface{}.
-func unmarshalContainer(schema *yang.Entry, parent interface{}, jsonTree interface{}) error {
+//   opts is the set of options that were supplied to Unmarshal.
+func unmarshalContainer(schema *yang.Entry, parent interface{}, jsonTree interface{}, opts ...UnmarshalOpt) error {
 	if util.IsValueNil(jsonTree) {
 		return nil
 	}
@@ -126,7 +130,7 @@ func unmarshalContainer(schema *yang.Entry, parent interface{}, jsonTree interfa
 		return fmt.Errorf("unmarshalContainer got parent type %T, expect struct ptr", parent)
 	}
 
-	return unmarshalStruct(schema, parent, jt)
+	return unmarshalStruct(schema, parent, jt, opts...)
 }
 
 // unmarshalStruct unmarshals a JSON tree into a struct.
@@ -134,15 +138,34 @@ func unmarshalContainer(schema *yang.Entry, parent interface{}, jsonTree interfa
 //     unmarshalled into.
 //   parent is the parent struct, which must be a struct ptr.
 //   jsonTree is a JSON data tree which must be a map[string]interface{}.
-func unmarshalStruct(schema *yang.Entry, parent interface{}, jsonTree map[string]interface{}) error {
+//   opts is the set of options that were supplied to Unmarshal.
+func unmarshalStruct(schema *yang.Entry, parent interface{}, jsonTree map[string]interface{}, opts ...UnmarshalOpt) error {
 	destv := reflect.ValueOf(parent).Elem()
 	var allSchemaPaths [][]string
+	// unknown stores the fields not described by the schema that are found
+	// within the descendants of parent when all such fields are reported.
+	var unknown UnknownFieldsError
+	// Where the struct stores the RFC7952 annotations of its nodes, those
+	// within the JSON tree are unmarshalled into its annotation field.
+	// Otherwise, they are ignored.
+	af, hasAnnotations := annotationField(destv)
+	if hasAnnotations {
+		if inReplacedSubtree(schema, opts) {
+			af.Set(reflect.Zero(af.Type()))
+		}
+		if err := unmarshalStructAnnotations(schema, af, jsonTree); err != nil {
+			return err
+		}
+	}
 	// Range over the parent struct fields. For each field, check if the data
 	// is present in the JSON tree and if so unmarshal it into the field.
 	for i := 0; i < destv.NumField(); i++ {
 		f := destv.Field(i)
 		ft := destv.Type().Field(i)
-		cschema, err := childSchema(schema, ft)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+		cschema, err := util.ChildSchema(schema, ft)
 		if err != nil {
 			return err
 		}
@@ -162,6 +185,17 @@ func unmarshalStruct(schema *yang.Entry, parent interface{}, jsonTree map[string
 		}
 
 		allSchemaPaths = append(allSchemaPaths, sp...)
+		// Where the field is within the subtree being replaced, its existing
+		// value is removed, such that it only contains the values within the
+		// JSON tree.
+		if inReplacedSubtree(cschema, opts) {
+			f.Set(reflect.Zero(ft.Type))
+		}
+		if hasAnnotations && (cschema.IsLeaf() || cschema.IsLeafList()) {
+			if err := unmarshalFieldAnnotations(schema, cschema, af, ft, jsonTree, opts); err != nil {
+				return err
+			}
+		}
 		if jsonValue == nil {
 			util.DbgPrint("field %s paths %v not present in tree", ft.Name, sp)
 			continue
@@ -187,15 +221,15 @@ func unmarshalStruct(schema *yang.Entry, parent interface{}, jsonTree map[string
 			// current container.
 			p = f.Interface()
 		}
-		if err := Unmarshal(cschema, p, jsonValue); err != nil {
+		if err := Unmarshal(cschema, p, jsonValue, opts...); err != nil && !collectUnknownFields(err, &unknown) {
 			return err
 		}
 	}
 
 	// Go over all JSON fields to make sure that each one is covered
 	// by a data path in the struct.
-	if err := checkDataTreeAgainstPaths(jsonTree, allSchemaPaths); err != nil {
-		return fmt.Errorf("parent container %s (type %T): %s", schema.Name, parent, err)
+	if err := handleUnknownFields(schema, parent, jsonTree, unknownDataTreeFields(jsonTree, allSchemaPaths), unknown, opts); err != nil {
+		return err
 	}
 
 	util.DbgPrint("container after unmarshal:\n%s\n", pretty.Sprint(destv.Interface()))
@@ -210,7 +244,7 @@ func validateContainerSchema(schema *yang.Entry) error {
 	if schema == nil {
 		return fmt.Errorf("container schema is nil")
 	}
-	if !schema.IsContainer() {
+	if !util.IsContainerLike(schema) {
 		return fmt.Errorf("container schema %s is not a container type", schema.Name)
 	}
 
@@ -75,6 +75,14 @@ func TestValidateContainerSchema(t *testing.T) {
 			desc:   "empty container",
 			schema: &yang.Entry{Kind: yang.DirectoryEntry},
 		},
+		{
+			desc:   "rpc input",
+			schema: &yang.Entry{Kind: yang.InputEntry},
+		},
+		{
+			desc:   "notification",
+			schema: &yang.Entry{Kind: yang.NotificationEntry},
+		},
 		{
 			desc:    "nil schema",
 			schema:  nil,
@@ -168,6 +176,17 @@ func TestValidateContainer(t *testing.T) {
 			val:     &BadStruct{UnknownName: ygot.String("Unknown")},
 			wantErr: `fields [UnknownName] are not found in the container schema container-schema`,
 		},
+		{
+			desc: "success with rpc output",
+			schema: &yang.Entry{
+				Name: "output",
+				Kind: yang.OutputEntry,
+				Dir:  containerSchema.Dir,
+			},
+			val: &ContainerStruct{
+				Leaf2Name: ygot.String("Leaf2Value"),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -223,6 +242,12 @@ func TestUnmarshalContainer(t *testing.T) {
 
 	populateParentField(nil, containerSchema)
 
+	inputSchema := &yang.Entry{
+		Name: "input",
+		Kind: yang.InputEntry,
+		Dir:  containerSchema.Dir,
+	}
+
 	type ContainerStruct struct {
 		ConfigLeaf1Field *int32 `path:"config/leaf1-field"`
 		StateLeaf1Field  *int32 `path:"state/leaf1-field"`
@@ -252,6 +277,12 @@ func TestUnmarshalContainer(t *testing.T) {
 			json:   `{ "container-field": { "leaf2-field": 43, "config": { "leaf1-field": 41 } , "state": { "leaf1-field": 42 } } }`,
 			want:   &ParentContainerStruct{ContainerField: &ContainerStruct{ConfigLeaf1Field: ygot.Int32(41), StateLeaf1Field: ygot.Int32(42), Leaf2Field: ygot.Int32(43)}},
 		},
+		{
+			desc:   "success with rpc input",
+			schema: inputSchema,
+			json:   `{ "container-field": { "leaf2-field": 43 } }`,
+			want:   &ParentContainerStruct{ContainerField: &ContainerStruct{Leaf2Field: ygot.Int32(43)}},
+		},
 		{
 			desc:    "nil schema",
 			schema:  nil,
@@ -18,12 +18,13 @@ import (
 	"fmt"
 
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // Refer to: https://tools.ietf.org/html/rfc6020#section-9.3.
 
-// validateDecimal validates value, which must be a Go float64 type, against the
-// given schema.
+// validateDecimal validates value, which must be a ygot.Decimal64 type,
+// against the given schema.
 func validateDecimal(schema *yang.Entry, value interface{}) error {
 	// Check that the schema itself is valid.
 	if err := validateDecimalSchema(schema); err != nil {
@@ -31,20 +32,29 @@ func validateDecimal(schema *yang.Entry, value interface{}) error {
 	}
 
 	// Check that type of value is the type expected from the schema.
-	f, ok := value.(float64)
+	d, ok := value.(ygot.Decimal64)
 	if !ok {
-		return fmt.Errorf("non float64 type %T with value %v for schema %s", value, value, schema.Name)
+		return fmt.Errorf("non ygot.Decimal64 type %T with value %v for schema %s", value, value, schema.Name)
+	}
+
+	// The value must be representable using the fraction-digits of the
+	// schema. Digits beyond the schema's precision are only allowed if they
+	// are zero.
+	if fd := schema.Type.FractionDigits; fd != 0 && int(d.Precision) > fd {
+		if _, err := ygot.ParseDecimal64(d.String(), uint8(fd)); err != nil {
+			return fmt.Errorf("decimal value %v has more than %d fraction digits for schema %s", d, fd, schema.Name)
+		}
 	}
 
-	if !isInRanges(schema.Type.Range, yang.FromFloat(f)) {
-		return fmt.Errorf("decimal value %v is outside specified ranges for schema %s", value, schema.Name)
+	if !isInRanges(schema.Type.Range, decimalToNumber(d)) {
+		return fmt.Errorf("decimal value %v is outside specified ranges for schema %s", d, schema.Name)
 	}
 
 	return nil
 }
 
-// validateDecimalSlice validates value, which must be a Go float64 slice type,
-// against the given schema.
+// validateDecimalSlice validates value, which must be a ygot.Decimal64 slice
+// type, against the given schema.
 func validateDecimalSlice(schema *yang.Entry, value interface{}) error {
 	// Check that the schema itself is valid.
 	if err := validateDecimalSchema(schema); err != nil {
@@ -52,25 +62,36 @@ func validateDecimalSlice(schema *yang.Entry, value interface{}) error {
 	}
 
 	// Check that type of value is the type expected from the schema.
-	slice, ok := value.([]float64)
+	slice, ok := value.([]ygot.Decimal64)
 	if !ok {
-		return fmt.Errorf("non []float64 type %T with value: %v for schema %s", value, value, schema.Name)
+		return fmt.Errorf("non []ygot.Decimal64 type %T with value: %v for schema %s", value, value, schema.Name)
 	}
 
-	// Each slice element must be valid and unique.
-	tbl := make(map[float64]bool, len(slice))
+	// Each slice element must be valid and unique. Values are compared using
+	// their canonical representation, such that values with different
+	// precisions are considered equal if they represent the same number.
+	tbl := make(map[string]bool, len(slice))
 	for i, val := range slice {
 		if err := validateDecimal(schema, val); err != nil {
 			return fmt.Errorf("invalid element at index %d: %v for schema %s", i, err, schema.Name)
 		}
-		if tbl[val] {
+		if tbl[val.String()] {
 			return fmt.Errorf("duplicate decimal: %v for schema %s", val, schema.Name)
 		}
-		tbl[val] = true
+		tbl[val.String()] = true
 	}
 	return nil
 }
 
+// decimalToNumber returns the yang.Number that corresponds to d, such that it
+// can be compared against the ranges specified in a schema.
+func decimalToNumber(d ygot.Decimal64) yang.Number {
+	if d.Digits < 0 {
+		return yang.Number{Kind: yang.Negative, Value: uint64(-(d.Digits + 1)) + 1, FractionDigits: d.Precision}
+	}
+	return yang.Number{Kind: yang.Positive, Value: uint64(d.Digits), FractionDigits: d.Precision}
+}
+
 // validateDecimalSchema validates the given decimal type schema. This is a
 // sanity check validation rather than a comprehensive validation against the
 // RFC. It is assumed that such a validation is done when the schema is parsed
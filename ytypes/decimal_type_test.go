@@ -19,19 +19,36 @@ import (
 
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
 )
 
 const (
-	largestValidFloat         = float64(yang.MaxInt64)
-	largestNegativeValidFloat = float64(yang.MinInt64)
-	// Overflows to max
-	tooLargeFloat = 123e123
-	// Overflows to min
-	tooLargeNegativeFloat = -123e123
-	// Section 9.3.4 places a limit on decimal representation. Rounded to 0.
-	tooSmallFloat = 1e-20
+	largestValidDecimal         = "922337203685477580.7"
+	largestNegativeValidDecimal = "-922337203685477580.8"
+	// The smallest non-zero value that can be represented, as per Section
+	// 9.3.4 of RFC6020.
+	smallestDecimal = "0.000000000000000001"
 )
 
+// mustDecimal returns s as a ygot.Decimal64, using the number of fraction
+// digits within s as its precision. It panics if s is not a valid decimal.
+func mustDecimal(s string) ygot.Decimal64 {
+	d, err := parseDecimal(&yang.Entry{Type: &yang.YangType{}}, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// mustDecimals returns ss as a slice of ygot.Decimal64 values.
+func mustDecimals(ss ...string) []ygot.Decimal64 {
+	var ds []ygot.Decimal64
+	for _, s := range ss {
+		ds = append(ds, mustDecimal(s))
+	}
+	return ds
+}
+
 var validDecimalSchema = &yang.Entry{Name: "valid-decimal-schema", Type: &yang.YangType{Kind: yang.Ydecimal64}}
 
 func rangeToDecimalSchema(schemaName string, r yang.YangRange) *yang.Entry {
@@ -90,47 +107,50 @@ func TestValidateDecimalType(t *testing.T) {
 		{
 			desc:   "success",
 			schema: validDecimalSchema,
-			val:    float64(4.4),
+			val:    mustDecimal("4.4"),
 		},
 		{
 			desc:    "bad schema",
 			schema:  nil,
-			val:     float64(4.4),
+			val:     mustDecimal("4.4"),
 			wantErr: true,
 		},
 		{
-			desc:    "non float64 type",
+			desc:    "non ygot.Decimal64 type",
 			schema:  validDecimalSchema,
-			val:     "",
+			val:     float64(4.4),
 			wantErr: true,
 		},
 		{
-			desc:   "largest float",
+			desc:   "largest decimal",
 			schema: validDecimalSchema,
-			val:    tooLargeFloat,
+			val:    mustDecimal(largestValidDecimal),
 		},
 		{
-			desc:   "largest -ve float",
+			desc:   "largest -ve decimal",
 			schema: validDecimalSchema,
-			val:    tooLargeNegativeFloat,
+			val:    mustDecimal(largestNegativeValidDecimal),
 		},
 		{
-			// This is ok, rounds to maxNumber (see yang.FromFloat)
-			desc:   "too large",
+			desc:   "smallest decimal",
 			schema: validDecimalSchema,
-			val:    tooLargeFloat,
+			val:    mustDecimal(smallestDecimal),
 		},
 		{
-			// This is ok, rounds to minNumber.
-			desc:   "too large -ve",
-			schema: validDecimalSchema,
-			val:    tooLargeNegativeFloat,
+			desc:   "value within fraction-digits",
+			schema: &yang.Entry{Name: "fraction-digits", Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2}},
+			val:    mustDecimal("4.4"),
 		},
 		{
-			// This is ok, rounds to 0.
-			desc:   "too small float",
-			schema: validDecimalSchema,
-			val:    tooSmallFloat,
+			desc:   "trailing zeros beyond fraction-digits",
+			schema: &yang.Entry{Name: "fraction-digits", Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2}},
+			val:    mustDecimal("4.400"),
+		},
+		{
+			desc:    "too many fraction digits",
+			schema:  &yang.Entry{Name: "fraction-digits", Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2}},
+			val:     mustDecimal("4.401"),
+			wantErr: true,
 		},
 	}
 
@@ -147,32 +167,32 @@ func TestValidateDecimalValue(t *testing.T) {
 	tests := []struct {
 		desc      string
 		ranges    yang.YangRange
-		inValues  []float64
-		outValues []float64
+		inValues  []string
+		outValues []string
 	}{
 		{
 			desc: "single val range -ve",
 			ranges: yang.YangRange{
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(-10.1)},
 			},
-			inValues:  []float64{-10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, -9, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10.1"},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", "-9", largestValidDecimal},
 		},
 		{
 			desc: "single val range 0",
 			ranges: yang.YangRange{
 				yang.YRange{Min: yang.FromFloat(0), Max: yang.FromFloat(0)},
 			},
-			inValues:  []float64{0, tooSmallFloat},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -1e15, 1e15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"0"},
+			outValues: []string{largestNegativeValidDecimal, "-1000000000000000", smallestDecimal, "1000000000000000", largestValidDecimal},
 		},
 		{
 			desc: "single val range +ve",
 			ranges: yang.YangRange{
 				yang.YRange{Min: yang.FromFloat(10.1), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, 10.05, 10.15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"10.1"},
+			outValues: []string{largestNegativeValidDecimal, "10.05", "10.15", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,-], [-,-]",
@@ -180,8 +200,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(-5.1)},
 				yang.YRange{Min: yang.FromFloat(-3.1), Max: yang.FromFloat(-1.1)},
 			},
-			inValues:  []float64{-10.1, -10.05, -7, -5.1, -3.1, -3.05, -1.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, -5.05, 0, -1.05, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10.1", "-10.05", "-7", "-5.1", "-3.1", "-3.05", "-1.1"},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", "-5.05", "0", "-1.05", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,-], [-,+]",
@@ -189,8 +209,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(-5.1)},
 				yang.YRange{Min: yang.FromFloat(-3.1), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{-10.1, -10.05, -5.1, -3.1, -3.05, 0, tooSmallFloat, 5, 10.05, 10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, -5.05, 10.15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10.1", "-10.05", "-5.1", "-3.1", "-3.05", "0", smallestDecimal, "5", "10.05", "10.1"},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", "-5.05", "10.15", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,-], [+,+]",
@@ -198,8 +218,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(-5.1)},
 				yang.YRange{Min: yang.FromFloat(5.1), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{-10.1, -10.05, -5.15, -5.1, 5.1, 5.15, 10.05, 10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, -5.05, 0, 5.05, 10.15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10.1", "-10.05", "-5.15", "-5.1", "5.1", "5.15", "10.05", "10.1"},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", "-5.05", "0", "5.05", "10.15", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,+], [+,+]",
@@ -207,8 +227,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(1.1)},
 				yang.YRange{Min: yang.FromFloat(5.1), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{-10.1, -10.05, 0, tooSmallFloat, 1.05, 1.1, 5.1, 5.15, 7, 10.05, 10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, 1.15, 5.05, 10.15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10.1", "-10.05", "0", "1.05", "1.1", "5.1", "5.15", "7", "10.05", "10.1"},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", "1.15", "5.05", "10.15", largestValidDecimal},
 		},
 		{
 			desc: "ranges [+,+], [+,+]",
@@ -216,8 +236,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(1.1), Max: yang.FromFloat(3.1)},
 				yang.YRange{Min: yang.FromFloat(5.1), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{1.1, 1.15, 3.05, 3.1, 5.1, 5.15, 7, 10.05, 10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -1, 0, 1.05, 3.15, 5.05, 10.15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"1.1", "1.15", "3.05", "3.1", "5.1", "5.15", "7", "10.05", "10.1"},
+			outValues: []string{largestNegativeValidDecimal, "-1", "0", "1.05", "3.15", "5.05", "10.15", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,0], [+,+]",
@@ -225,8 +245,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(0)},
 				yang.YRange{Min: yang.FromFloat(5.1), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{-10, -7, 0, tooSmallFloat, 5.1, 7, 10},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, 0.01, 5.05, 10.105, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10", "-7", "0", "5.1", "7", "10"},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", smallestDecimal, "0.01", "5.05", "10.105", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,-], [0,+]",
@@ -234,8 +254,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(-5.1)},
 				yang.YRange{Min: yang.FromFloat(0), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{-10.1, -10.0005, -7, -5.1, 0, tooSmallFloat, 5, 10.005, 10.1},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.1005, -5.005, -0.001, 10.15, largestValidFloat, tooLargeFloat},
+			inValues:  []string{"-10.1", "-10.0005", "-7", "-5.1", "0", "5", "10.005", "10.1"},
+			outValues: []string{largestNegativeValidDecimal, "-10.1005", "-5.005", "-0.001", "10.15", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-inf,-], [0,+]",
@@ -243,8 +263,8 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: util.YangMinNumber, Max: yang.FromFloat(-5.1)},
 				yang.YRange{Min: yang.FromFloat(0), Max: yang.FromFloat(10.1)},
 			},
-			inValues:  []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -100, -7, -5.1, 0, tooSmallFloat, 5, 10},
-			outValues: []float64{-5.05, -0.001, 10.10001, largestValidFloat, tooLargeFloat},
+			inValues:  []string{largestNegativeValidDecimal, "-100", "-7", "-5.1", "0", "5", "10"},
+			outValues: []string{"-5.05", "-0.001", "10.10001", largestValidDecimal},
 		},
 		{
 			desc: "ranges [-,-], [0,+inf]",
@@ -252,19 +272,19 @@ func TestValidateDecimalValue(t *testing.T) {
 				yang.YRange{Min: yang.FromFloat(-10.1), Max: yang.FromFloat(-5.1)},
 				yang.YRange{Min: yang.FromFloat(0), Max: util.YangMaxNumber},
 			},
-			inValues:  []float64{-10.1, -10.05, -7, -5.100001, -5.1, 0, tooSmallFloat, 5, 100, largestValidFloat, tooLargeFloat},
-			outValues: []float64{tooLargeNegativeFloat, largestNegativeValidFloat, -10.15, -5.0009, -0.0001},
+			inValues:  []string{"-10.1", "-10.05", "-7", "-5.100001", "-5.1", "0", "5", "100", largestValidDecimal},
+			outValues: []string{largestNegativeValidDecimal, "-10.15", "-5.0009", "-0.0001"},
 		},
 	}
 
 	for _, test := range tests {
 		for _, val := range test.inValues {
-			if err := validateDecimal(rangeToDecimalSchema(test.desc+"-schema", test.ranges), val); err != nil {
+			if err := validateDecimal(rangeToDecimalSchema(test.desc+"-schema", test.ranges), mustDecimal(val)); err != nil {
 				t.Errorf("%s: %v should be inside ranges %v", test.desc, val, test.ranges)
 			}
 		}
 		for _, val := range test.outValues {
-			if err := validateDecimal(rangeToDecimalSchema(test.desc+"-schema", test.ranges), val); err == nil {
+			if err := validateDecimal(rangeToDecimalSchema(test.desc+"-schema", test.ranges), mustDecimal(val)); err == nil {
 				t.Errorf("%s: %v should be outside ranges %v", test.desc, val, test.ranges)
 			}
 		}
@@ -282,24 +302,30 @@ func TestValidateDecimalSlice(t *testing.T) {
 		{
 			desc:   "success",
 			schema: validDecimalSchema,
-			val:    []float64{4.4, 5.0},
+			val:    mustDecimals("4.4", "5.0"),
 		},
 		{
 			desc:    "bad schema",
 			schema:  nil,
-			val:     []float64{4.4, 5.0},
+			val:     mustDecimals("4.4", "5.0"),
 			wantErr: true,
 		},
 		{
-			desc:    "non []float64",
+			desc:    "non []ygot.Decimal64",
 			schema:  validDecimalSchema,
-			val:     []int{1, 2, 3},
+			val:     []float64{4.4, 5.0},
 			wantErr: true,
 		},
 		{
 			desc:    "duplicate element",
 			schema:  validDecimalSchema,
-			val:     []float64{4.4, 5.0, 5.0},
+			val:     mustDecimals("4.4", "5.0", "5.0"),
+			wantErr: true,
+		},
+		{
+			desc:    "duplicate element with different precision",
+			schema:  validDecimalSchema,
+			val:     mustDecimals("4.4", "5.0", "5.00"),
 			wantErr: true,
 		},
 	}
@@ -0,0 +1,447 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// validateDynamicNode validates the data tree rooted at the DynamicNode n
+// against the schema that describes it.
+func validateDynamicNode(n *ygot.DynamicNode) util.Errors {
+	var errors []error
+	schema := n.Schema()
+
+	// selected stores the names of the cases that are selected for each
+	// choice within the schema of n, keyed by the choice.
+	selected := map[*yang.Entry][]string{}
+
+	for _, name := range n.Names() {
+		cs, err := n.ChildSchema(name)
+		if err != nil {
+			errors = util.AppendErr(errors, err)
+			continue
+		}
+
+		for prev, a := cs, cs.Parent; a != nil && a != schema; prev, a = a, a.Parent {
+			if a.IsChoice() && !containsString(selected[a], prev.Name) {
+				selected[a] = append(selected[a], prev.Name)
+			}
+		}
+
+		switch v := n.Get(name); {
+		case cs.IsLeaf():
+			errors = util.AppendErr(errors, validateDynamicLeaf(cs, v))
+		case cs.IsLeafList():
+			l, ok := v.([]interface{})
+			if !ok {
+				errors = util.AppendErr(errors, fmt.Errorf("leaf-list %s has value %v (%T), must be []interface{}", cs.Name, v, v))
+				continue
+			}
+			if cs.ListAttr != nil {
+				errors = util.AppendErrs(errors, validateListAttr(cs, l))
+			}
+			for _, e := range l {
+				errors = util.AppendErr(errors, validateDynamicLeaf(cs, e))
+			}
+		case cs.IsList():
+			l, ok := v.([]*ygot.DynamicNode)
+			if !ok {
+				errors = util.AppendErr(errors, fmt.Errorf("list %s has value %v (%T), must be []*ygot.DynamicNode", cs.Name, v, v))
+				continue
+			}
+			errors = util.AppendErrs(errors, validateDynamicList(cs, l))
+		default:
+			c, ok := v.(*ygot.DynamicNode)
+			if !ok {
+				errors = util.AppendErr(errors, fmt.Errorf("container %s has value %v (%T), must be *ygot.DynamicNode", cs.Name, v, v))
+				continue
+			}
+			errors = util.AppendErrs(errors, validateDynamicNode(c))
+		}
+	}
+
+	for choice, cases := range selected {
+		if len(cases) > 1 {
+			errors = util.AppendErr(errors, fmt.Errorf("multiple cases %v selected for choice %s", cases, choice.Name))
+		}
+	}
+
+	return errors
+}
+
+// containsString reports whether the slice l contains s.
+func containsString(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDynamicList validates the members l of the list described by
+// schema, checking that each member has a value for each of the list's keys,
+// and that the keys of each member are unique.
+func validateDynamicList(schema *yang.Entry, l []*ygot.DynamicNode) util.Errors {
+	var errors []error
+	if schema.ListAttr != nil {
+		errors = util.AppendErrs(errors, validateListAttr(schema, l))
+	}
+
+	kn := strings.Fields(schema.Key)
+	seen := keyTuples{}
+	for _, e := range l {
+		if len(kn) != 0 {
+			var kv []interface{}
+			var ks []string
+			for _, k := range kn {
+				v := e.Get(k)
+				if v == nil {
+					errors = util.AppendErr(errors, fmt.Errorf("member of list %s has no value for key %s", schema.Name, k))
+				}
+				kv = append(kv, v)
+				ks = append(ks, fmt.Sprintf("%v", v))
+			}
+			if _, dup := seen.add(kv, nil); dup {
+				errors = util.AppendErr(errors, fmt.Errorf("duplicate key %s for list %s", strings.Join(ks, " "), schema.Name))
+			}
+		}
+		errors = util.AppendErrs(errors, validateDynamicNode(e))
+	}
+	return errors
+}
+
+// validateDynamicLeaf validates the value v of the leaf, or leaf-list member,
+// of a DynamicNode described by schema.
+func validateDynamicLeaf(schema *yang.Entry, v interface{}) error {
+	if util.IsValueNil(v) {
+		return nil
+	}
+	if schema.Type == nil {
+		return fmt.Errorf("nil type for schema %s", schema.Name)
+	}
+	return validateDynamicValue(schema, schema.Type, v)
+}
+
+// validateDynamicValue validates the value v of a leaf of a DynamicNode
+// described by schema against the type t, which is either the type of the
+// leaf, or a member type of its union type.
+func validateDynamicValue(schema *yang.Entry, t *yang.YangType, v interface{}) error {
+	schema, err := entryWithType(schema, t)
+	if err != nil {
+		return err
+	}
+	t = schema.Type
+
+	switch kind := t.Kind; {
+	case kind == yang.Ystring:
+		return validateString(schema, v)
+	case kind == yang.Ybool:
+		return validateBool(schema, v)
+	case kind == yang.Yempty:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("non bool type %T with value %v for empty schema %s", v, v, schema.Name)
+		}
+		return nil
+	case kind == yang.Ydecimal64:
+		return validateDecimal(schema, v)
+	case kind == yang.YinstanceIdentifier:
+		return validateInstanceIdentifier(schema, v)
+	case kind == yang.Ybinary:
+		return validateBinary(schema, v)
+	case kind == yang.Ybits:
+		return validateBitset(schema, v)
+	case kind == yang.Yenum:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("non string type %T with value %v for enumeration schema %s", v, v, schema.Name)
+		}
+		if t.Enum == nil || !t.Enum.IsDefined(s) {
+			return fmt.Errorf("%s is not a valid value of enumeration schema %s", s, schema.Name)
+		}
+		return nil
+	case kind == yang.Yidentityref:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("non string type %T with value %v for identityref schema %s", v, v, schema.Name)
+		}
+		if t.IdentityBase == nil || !t.IdentityBase.IsDefined(s) {
+			return fmt.Errorf("%s is not a valid value of identityref schema %s", s, schema.Name)
+		}
+		return nil
+	case kind == yang.Yunion:
+		for _, ut := range t.Type {
+			if err := validateDynamicValue(schema, ut, v); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %v (%T) does not match any member type of union schema %s", v, v, schema.Name)
+	case isIntegerType(kind):
+		return validateInt(schema, v)
+	}
+	return fmt.Errorf("unknown leaf type %v for schema %s", t.Kind, schema.Name)
+}
+
+// entryWithType returns an entry that is the same as schema, but has the
+// type t. Leafrefs are resolved to the leaf that they refer to.
+func entryWithType(schema *yang.Entry, t *yang.YangType) (*yang.Entry, error) {
+	if t != schema.Type {
+		e := *schema
+		e.Type = t
+		schema = &e
+	}
+	return util.ResolveIfLeafRef(schema)
+}
+
+// unmarshalDynamicNode unmarshals the JSON tree jsonTree into the DynamicNode
+// n. Values already within n that are not present within jsonTree are
+// preserved, and list members with the same keys as those within jsonTree are
+// merged. The fields of jsonTree that are not described by the schema of n
+// are handled according to opts.
+func unmarshalDynamicNode(n *ygot.DynamicNode, jsonTree interface{}, opts ...UnmarshalOpt) error {
+	jt, ok := jsonTree.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unmarshalDynamicNode for schema %s: got type %T, expect map[string]interface{}", n.Schema().Name, jsonTree)
+	}
+
+	// Values within the subtree being replaced are removed, such that they
+	// only contain the values within jsonTree.
+	for _, name := range n.Names() {
+		if cs, err := n.ChildSchema(name); err == nil && inReplacedSubtree(cs, opts) {
+			n.Delete(name)
+		}
+	}
+
+	var unknownFields []string
+	var unknown UnknownFieldsError
+	for k, jv := range jt {
+		if util.IsValueNil(jv) {
+			continue
+		}
+		// RFC7952 annotations are not stored by DynamicNodes, and hence
+		// those of the node, and of its children, are ignored.
+		if isAnnotationMember(k, func(c string) bool { _, err := n.ChildSchema(c); return err == nil }) {
+			continue
+		}
+		cs, err := n.ChildSchema(k)
+		if err != nil {
+			unknownFields = append(unknownFields, k)
+			continue
+		}
+
+		switch {
+		case cs.IsLeaf():
+			v, err := dynamicValueFromJSON(cs, cs.Type, jv)
+			if err != nil {
+				return err
+			}
+			if err := n.Set(cs.Name, v); err != nil {
+				return err
+			}
+		case cs.IsLeafList():
+			jl, ok := jv.([]interface{})
+			if !ok {
+				return fmt.Errorf("unmarshalDynamicNode for schema %s: got type %T for leaf-list, expect []interface{}", cs.Name, jv)
+			}
+			l := []interface{}{}
+			for _, je := range jl {
+				v, err := dynamicValueFromJSON(cs, cs.Type, je)
+				if err != nil {
+					return err
+				}
+				l = append(l, v)
+			}
+			if err := n.Set(cs.Name, l); err != nil {
+				return err
+			}
+		case cs.IsList():
+			if err := unmarshalDynamicList(n, cs, jv, opts...); err != nil && !collectUnknownFields(err, &unknown) {
+				return err
+			}
+		default:
+			c, err := n.Container(cs.Name)
+			if err != nil {
+				return err
+			}
+			if err := unmarshalDynamicNode(c, jv, opts...); err != nil && !collectUnknownFields(err, &unknown) {
+				return err
+			}
+		}
+	}
+	return handleUnknownFields(n.Schema(), n, jt, unknownFields, unknown, opts)
+}
+
+// unmarshalDynamicList unmarshals the JSON array jsonList into the members of
+// the list described by schema within the DynamicNode parent.
+func unmarshalDynamicList(parent *ygot.DynamicNode, schema *yang.Entry, jsonList interface{}, opts ...UnmarshalOpt) error {
+	jl, ok := jsonList.([]interface{})
+	if !ok {
+		return fmt.Errorf("unmarshalDynamicList for schema %s: got type %T, expect []interface{}", schema.Name, jsonList)
+	}
+
+	var unknown UnknownFieldsError
+	for _, je := range jl {
+		jm, ok := je.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unmarshalDynamicList for schema %s: got member type %T, expect map[string]interface{}", schema.Name, je)
+		}
+
+		keys := map[string]interface{}{}
+		for _, k := range strings.Fields(schema.Key) {
+			ks, ok := schema.Dir[k]
+			if !ok {
+				return fmt.Errorf("key %s not found in schema %s", k, schema.Name)
+			}
+			jk, ok := jm[k]
+			if !ok {
+				return fmt.Errorf("member of list %s has no value for key %s", schema.Name, k)
+			}
+			kv, err := dynamicValueFromJSON(ks, ks.Type, jk)
+			if err != nil {
+				return err
+			}
+			keys[k] = kv
+		}
+
+		e := parent.ListEntry(schema.Name, keys)
+		if e == nil || len(keys) == 0 {
+			var err error
+			if e, err = parent.NewListEntry(schema.Name, keys); err != nil {
+				return err
+			}
+		}
+		if err := unmarshalDynamicNode(e, jm, opts...); err != nil && !collectUnknownFields(err, &unknown) {
+			return err
+		}
+	}
+	if len(unknown) != 0 {
+		return unknown
+	}
+	return nil
+}
+
+// dynamicValueFromJSON returns the value of a leaf of a DynamicNode described
+// by schema, which has the type t, from its JSON representation v. Both the
+// RFC7951 and internal JSON representations of values are accepted.
+func dynamicValueFromJSON(schema *yang.Entry, t *yang.YangType, v interface{}) (interface{}, error) {
+	schema, err := entryWithType(schema, t)
+	if err != nil {
+		return nil, err
+	}
+	t = schema.Type
+
+	switch kind := t.Kind; kind {
+	case yang.Ystring, yang.Yenum, yang.Ybits:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case yang.Yidentityref:
+		if s, ok := v.(string); ok {
+			return s[strings.LastIndex(s, ":")+1:], nil
+		}
+	case yang.Ybool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case yang.Yempty:
+		switch ev := v.(type) {
+		case bool:
+			return ev, nil
+		case []interface{}:
+			if len(ev) == 1 && ev[0] == nil {
+				return true, nil
+			}
+		}
+	case yang.Ybinary:
+		if s, ok := v.(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("error in DecodeString for \n%v\n for schema %s: %v", v, schema.Name, err)
+			}
+			return b, nil
+		}
+	case yang.Ydecimal64:
+		s, ok := v.(string)
+		if f, isFloat := v.(float64); isFloat {
+			s, ok = strconv.FormatFloat(f, 'f', -1, 64), true
+		}
+		if ok {
+			d, err := parseDecimal(schema, s)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %v for schema %s: %v", v, schema.Name, err)
+			}
+			return d, nil
+		}
+	case yang.YinstanceIdentifier:
+		if s, ok := v.(string); ok {
+			iid, err := ygot.ParseInstanceIdentifier(s)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %v for schema %s: %v", v, schema.Name, err)
+			}
+			return iid, nil
+		}
+	case yang.Yint64, yang.Yuint64:
+		s, ok := v.(string)
+		if f, isFloat := v.(float64); isFloat {
+			s, ok = strconv.FormatFloat(f, 'f', -1, 64), true
+		}
+		if ok {
+			if kind == yang.Yint64 {
+				i, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing %v for schema %s: %v", v, schema.Name, err)
+				}
+				return i, nil
+			}
+			u, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %v for schema %s: %v", v, schema.Name, err)
+			}
+			return u, nil
+		}
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		if f, ok := v.(float64); ok {
+			pv, err := yangFloatIntToGoType(kind, f)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %v for schema %s: %v", v, schema.Name, err)
+			}
+			return pv, nil
+		}
+	case yang.Yunion:
+		// The first member type that the value can be converted to, and
+		// that the converted value is valid for, is used.
+		for _, ut := range t.Type {
+			uv, err := dynamicValueFromJSON(schema, ut, v)
+			if err != nil {
+				continue
+			}
+			if err := validateDynamicValue(schema, ut, uv); err == nil {
+				return uv, nil
+			}
+		}
+		return nil, fmt.Errorf("could not find suitable union type to unmarshal value %v type %T into schema %s", v, v, schema.Name)
+	default:
+		return nil, fmt.Errorf("unsupported type %v in schema node %s", kind, schema.Name)
+	}
+	return nil, fmt.Errorf("got %T type for field %s, not valid for type %v", v, schema.Name, t.Kind)
+}
@@ -0,0 +1,406 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+)
+
+const dynamicTestModule = `
+module dyn {
+  prefix "d";
+  namespace "urn:dyn";
+
+  identity base-id;
+  identity id-one { base base-id; }
+
+  container top {
+    leaf name { type string { length "1..8"; } }
+    leaf count { type uint64; }
+    leaf ratio { type decimal64 { fraction-digits 2; } }
+    leaf enabled { type empty; }
+    leaf data { type binary; }
+    leaf kind { type enumeration { enum RED; enum BLUE; } }
+    leaf id { type identityref { base base-id; } }
+    leaf mixed { type union { type int8; type string; } }
+    leaf ref { type leafref { path "../count"; } }
+    leaf-list tags { type string; max-elements 2; }
+    choice mode {
+      case fast { leaf speed { type uint32; } }
+      case slow { leaf delay { type uint32; } }
+    }
+    list item {
+      key "id";
+      leaf id { type uint32; }
+      leaf value { type int16 { range "-10..10"; } }
+    }
+    list pair {
+      key "a b";
+      leaf a { type string; }
+      leaf b { type string; }
+    }
+  }
+}
+`
+
+// dynamicTestRoot returns an empty DynamicNode for the dyn module.
+func dynamicTestRoot(t *testing.T) *ygot.DynamicNode {
+	ms := yang.NewModules()
+	if err := ms.Parse(dynamicTestModule, "dyn.yang"); err != nil {
+		t.Fatalf("cannot parse test module: %v", err)
+	}
+	if errs := ms.Process(); len(errs) != 0 {
+		t.Fatalf("cannot process test module: %v", errs)
+	}
+	root, err := ygot.NewDynamicNode(yang.ToEntry(ms.Modules["dyn"]))
+	if err != nil {
+		t.Fatalf("NewDynamicNode: got unexpected error: %v", err)
+	}
+	return root
+}
+
+func TestValidateDynamicNode(t *testing.T) {
+	tests := []struct {
+		name          string
+		inLeaves      map[string]interface{}
+		inItems       []map[string]interface{}
+		wantErrSubstr string
+	}{{
+		name: "valid tree",
+		inLeaves: map[string]interface{}{
+			"name":    "eth0",
+			"count":   uint64(42),
+			"ratio":   ygot.Decimal64{Digits: 150, Precision: 2},
+			"enabled": true,
+			"data":    []byte("hi"),
+			"kind":    "BLUE",
+			"id":      "id-one",
+			"mixed":   "str",
+			"ref":     uint64(42),
+			"tags":    []string{"a", "b"},
+			"speed":   uint32(10),
+		},
+		inItems: []map[string]interface{}{{"id": uint32(1), "value": int16(-3)}, {"id": uint32(2)}},
+	}, {
+		name:          "string too long",
+		inLeaves:      map[string]interface{}{"name": "interface0"},
+		wantErrSubstr: "length 10 is outside range",
+	}, {
+		name:          "wrong integer type",
+		inLeaves:      map[string]interface{}{"count": uint32(42)},
+		wantErrSubstr: "non uint64 type uint32",
+	}, {
+		name:          "invalid enumerated value",
+		inLeaves:      map[string]interface{}{"kind": "GREEN"},
+		wantErrSubstr: "GREEN is not a valid value of enumeration schema kind",
+	}, {
+		name:          "invalid identity",
+		inLeaves:      map[string]interface{}{"id": "id-two"},
+		wantErrSubstr: "id-two is not a valid value of identityref schema id",
+	}, {
+		name:          "value matching no union member",
+		inLeaves:      map[string]interface{}{"mixed": int16(1)},
+		wantErrSubstr: "does not match any member type of union schema mixed",
+	}, {
+		name:          "leafref of wrong type",
+		inLeaves:      map[string]interface{}{"ref": "42"},
+		wantErrSubstr: "non uint64 type string",
+	}, {
+		name:          "too many leaf-list elements",
+		inLeaves:      map[string]interface{}{"tags": []string{"a", "b", "c"}},
+		wantErrSubstr: "contains more than max allowed elements",
+	}, {
+		name:          "multiple cases selected",
+		inLeaves:      map[string]interface{}{"speed": uint32(1), "delay": uint32(2)},
+		wantErrSubstr: "multiple cases [slow fast] selected for choice mode",
+	}, {
+		name:          "list member out of range",
+		inItems:       []map[string]interface{}{{"id": uint32(1), "value": int16(11)}},
+		wantErrSubstr: "integer value 11 is outside specified ranges",
+	}}
+
+	for _, tt := range tests {
+		root := dynamicTestRoot(t)
+		top, err := root.Container("top")
+		if err != nil {
+			t.Fatalf("%s: Container(top): got unexpected error: %v", tt.name, err)
+		}
+		for k, v := range tt.inLeaves {
+			if err := top.Set(k, v); err != nil {
+				t.Fatalf("%s: Set(%s, %v): got unexpected error: %v", tt.name, k, v, err)
+			}
+		}
+		for _, item := range tt.inItems {
+			e, err := top.NewListEntry("item", map[string]interface{}{"id": item["id"]})
+			if err != nil {
+				t.Fatalf("%s: NewListEntry(%v): got unexpected error: %v", tt.name, item, err)
+			}
+			if v, ok := item["value"]; ok {
+				e.Set("value", v)
+			}
+		}
+
+		errs := Validate(root.Schema(), root)
+		switch {
+		case tt.wantErrSubstr == "" && errs != nil:
+			t.Errorf("%s: Validate: got unexpected errors: %v", tt.name, errs)
+		case tt.wantErrSubstr != "" && (errs == nil || !strings.Contains(errs.Error(), tt.wantErrSubstr)):
+			t.Errorf("%s: Validate: did not get expected error, got: %v, want error containing: %q", tt.name, errs, tt.wantErrSubstr)
+		}
+	}
+}
+
+func TestValidateDynamicNodeDuplicateKeys(t *testing.T) {
+	root := dynamicTestRoot(t)
+	top, err := root.Container("top")
+	if err != nil {
+		t.Fatalf("Container(top): got unexpected error: %v", err)
+	}
+	for _, id := range []uint32{1, 2} {
+		if _, err := top.NewListEntry("item", map[string]interface{}{"id": id}); err != nil {
+			t.Fatalf("NewListEntry(%d): got unexpected error: %v", id, err)
+		}
+	}
+	// Changing the key of a member can result in duplicate keys.
+	top.ListEntries("item")[1].Set("id", uint32(1))
+
+	if errs := Validate(root.Schema(), root); errs == nil || !strings.Contains(errs.Error(), "duplicate key 1 for list item") {
+		t.Errorf("Validate: did not get expected error, got: %v", errs)
+	}
+}
+
+func TestValidateDynamicNodeMultipleKeys(t *testing.T) {
+	root := dynamicTestRoot(t)
+	top, err := root.Container("top")
+	if err != nil {
+		t.Fatalf("Container(top): got unexpected error: %v", err)
+	}
+	// The keys are distinct, although their values have the same string
+	// representation when they are joined.
+	for _, k := range []map[string]interface{}{{"a": "x y", "b": "z"}, {"a": "x", "b": "y z"}} {
+		if _, err := top.NewListEntry("pair", k); err != nil {
+			t.Fatalf("NewListEntry(%v): got unexpected error: %v", k, err)
+		}
+	}
+	if errs := Validate(root.Schema(), root); errs != nil {
+		t.Errorf("Validate: got unexpected errors: %v", errs)
+	}
+
+	top.ListEntries("pair")[1].Set("a", "x y")
+	top.ListEntries("pair")[1].Set("b", "z")
+	if errs := Validate(root.Schema(), root); errs == nil || !strings.Contains(errs.Error(), "duplicate key x y z for list pair") {
+		t.Errorf("Validate: did not get expected error, got: %v", errs)
+	}
+}
+
+func TestDynamicNodeSchemaMismatch(t *testing.T) {
+	root := dynamicTestRoot(t)
+	other := dynamicTestRoot(t)
+
+	if errs := Validate(nil, root); errs != nil {
+		t.Errorf("Validate with nil schema: got unexpected errors: %v", errs)
+	}
+	if errs := Validate(other.Schema(), root); errs == nil {
+		t.Errorf("Validate with mismatched schema: got no error, want error")
+	}
+	j := map[string]interface{}{"top": map[string]interface{}{"name": "n"}}
+	if err := Unmarshal(other.Schema(), root, j); err == nil {
+		t.Errorf("Unmarshal with mismatched schema: got no error, want error")
+	}
+	if err := Unmarshal(nil, root, j); err != nil {
+		t.Errorf("Unmarshal with nil schema: got unexpected error: %v", err)
+	}
+}
+
+func TestUnmarshalDynamicNode(t *testing.T) {
+	tests := []struct {
+		name          string
+		inJSON        []string
+		want          string
+		wantErrSubstr string
+	}{{
+		name: "RFC7951 JSON",
+		inJSON: []string{`{
+		  "dyn:top": {
+		    "name": "eth0",
+		    "count": "42",
+		    "ratio": "1.5",
+		    "enabled": [null],
+		    "data": "aGk=",
+		    "kind": "RED",
+		    "id": "dyn:id-one",
+		    "mixed": 5,
+		    "ref": "42",
+		    "tags": ["a", "b"],
+		    "speed": 10,
+		    "item": [{"id": 1, "value": -3}]
+		  }
+		}`},
+		want: `{
+		  "dyn:top": {
+		    "name": "eth0",
+		    "count": "42",
+		    "ratio": "1.5",
+		    "enabled": [null],
+		    "data": "aGk=",
+		    "kind": "RED",
+		    "id": "dyn:id-one",
+		    "mixed": 5,
+		    "ref": "42",
+		    "tags": ["a", "b"],
+		    "speed": 10,
+		    "item": [{"id": 1, "value": -3}]
+		  }
+		}`,
+	}, {
+		name: "merge into existing list members",
+		inJSON: []string{
+			`{"top": {"name": "eth0", "item": [{"id": 1, "value": -3}, {"id": 2}]}}`,
+			`{"top": {"mixed": "str", "item": [{"id": 2, "value": 4}]}}`,
+		},
+		want: `{
+		  "dyn:top": {
+		    "name": "eth0",
+		    "mixed": "str",
+		    "item": [{"id": 1, "value": -3}, {"id": 2, "value": 4}]
+		  }
+		}`,
+	}, {
+		name:   "annotations are ignored",
+		inJSON: []string{`{"top": {"@": {"m:a": "top"}, "name": "eth0", "@name": {"m:a": "name"}, "item": [{"@": {"m:a": "item"}, "id": 1}]}}`},
+		want: `{
+		  "dyn:top": {
+		    "name": "eth0",
+		    "item": [{"id": 1}]
+		  }
+		}`,
+	}, {
+		name:          "unknown field",
+		inJSON:        []string{`{"top": {"missing": 1}}`},
+		wantErrSubstr: "JSON contains unexpected field missing",
+	}, {
+		name:          "annotation of unknown field",
+		inJSON:        []string{`{"top": {"@missing": {"m:a": "missing"}}}`},
+		wantErrSubstr: "JSON contains unexpected field @missing",
+	}, {
+		name:          "value of wrong JSON type",
+		inJSON:        []string{`{"top": {"speed": "10"}}`},
+		wantErrSubstr: "got string type for field speed",
+	}, {
+		name:          "value matching no union member",
+		inJSON:        []string{`{"top": {"mixed": 500}}`},
+		wantErrSubstr: "could not find suitable union type",
+	}}
+
+	for _, tt := range tests {
+		root := dynamicTestRoot(t)
+		var err error
+		for _, in := range tt.inJSON {
+			var j interface{}
+			if err := json.Unmarshal([]byte(in), &j); err != nil {
+				t.Fatalf("%s: cannot unmarshal input JSON: %v", tt.name, err)
+			}
+			if err = Unmarshal(root.Schema(), root, j); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: Unmarshal: got unexpected error: %v, want error containing: %q", tt.name, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: Unmarshal: did not get expected error, want error containing: %q", tt.name, tt.wantErrSubstr)
+			continue
+		}
+
+		if errs := Validate(root.Schema(), root); errs != nil {
+			t.Errorf("%s: Validate: got unexpected errors: %v", tt.name, errs)
+		}
+
+		out, err := ygot.ConstructIETFJSON(root, &ygot.RFC7951JSONConfig{AppendModuleName: true})
+		if err != nil {
+			t.Errorf("%s: ConstructIETFJSON: got unexpected error: %v", tt.name, err)
+			continue
+		}
+		js, err := json.Marshal(out)
+		if err != nil {
+			t.Errorf("%s: cannot marshal JSON: %v", tt.name, err)
+			continue
+		}
+		var got, want interface{}
+		if err := json.Unmarshal(js, &got); err != nil {
+			t.Errorf("%s: cannot unmarshal output JSON: %v", tt.name, err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Errorf("%s: cannot unmarshal expected JSON: %v", tt.name, err)
+			continue
+		}
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("%s: did not get expected JSON, diff(-got,+want):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestUnmarshalDynamicNodeOptions(t *testing.T) {
+	root := dynamicTestRoot(t)
+	for _, tt := range []struct {
+		inJSON      string
+		wantSkipped []string
+	}{{
+		inJSON: `{"top": {"name": "eth0", "count": "1", "item": [{"id": 1}, {"id": 2}]}}`,
+	}, {
+		inJSON:      `{"top": {"name": "eth1", "item": [{"id": 3, "vendor:extra": true}]}}`,
+		wantSkipped: []string{"/top/item/vendor:extra"},
+	}} {
+		var j interface{}
+		if err := json.Unmarshal([]byte(tt.inJSON), &j); err != nil {
+			t.Fatalf("cannot unmarshal input JSON: %v", err)
+		}
+		ignore := &IgnoreExtraFields{}
+		if err := Unmarshal(root.Schema(), root, j, Replace{Path: "/top/item"}, ignore); err != nil {
+			t.Fatalf("Unmarshal: got unexpected error: %v", err)
+		}
+		if diff := pretty.Compare(unknownFieldPaths(ignore.Skipped), tt.wantSkipped); diff != "" {
+			t.Errorf("Unmarshal(%s): did not get expected skipped fields, diff(-got,+want):\n%s", tt.inJSON, diff)
+		}
+	}
+
+	top, err := root.Container("top")
+	if err != nil {
+		t.Fatalf("Container(top): got unexpected error: %v", err)
+	}
+	if got, want := top.Get("name"), "eth1"; got != want {
+		t.Errorf("did not get expected name, got: %v, want: %v", got, want)
+	}
+	if got, want := top.Get("count"), uint64(1); got != want {
+		t.Errorf("did not get expected count, got: %v, want: %v", got, want)
+	}
+	var ids []interface{}
+	for _, e := range top.ListEntries("item") {
+		ids = append(ids, e.Get("id"))
+	}
+	if diff := pretty.Compare(ids, []interface{}{uint32(3)}); diff != "" {
+		t.Errorf("did not get expected list members, diff(-got,+want):\n%s", diff)
+	}
+}
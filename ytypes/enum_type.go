@@ -0,0 +1,67 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc6020#section-9.6 and
+// https://tools.ietf.org/html/rfc6020#section-9.10.
+
+// validateEnum validates value, which is a generated enumerated type, against
+// the given enumeration or identityref schema. isSet specifies whether the
+// value was explicitly set - the zero (UNSET) value of a generated enumerated
+// type indicates that a leaf is not set, but is not valid within a leaf-list
+// or union.
+func validateEnum(schema *yang.Entry, value ygot.GoEnum, isSet bool) error {
+	v := reflect.ValueOf(value).Int()
+	if v == 0 {
+		if isSet {
+			return fmt.Errorf("UNSET value of %T is not a valid value for schema %s", value, schema.Name)
+		}
+		return nil
+	}
+
+	def, ok := value.ΛMap()[reflect.TypeOf(value).Name()][v]
+	if !ok {
+		return fmt.Errorf("%d is not a valid value of %T for schema %s", v, value, schema.Name)
+	}
+
+	// The set of values defined by the schema is checked where it is known,
+	// which is not the case for schemas that are serialised within generated
+	// code.
+	switch t := schema.Type; t.Kind {
+	case yang.Yenum:
+		if t.Enum != nil && len(t.Enum.Names()) != 0 && !t.Enum.IsDefined(def.Name) {
+			return fmt.Errorf("%s is not a valid value of enumeration schema %s", def.Name, schema.Name)
+		}
+	case yang.Yidentityref:
+		if t.IdentityBase == nil || len(t.IdentityBase.Values) == 0 {
+			return nil
+		}
+		for _, id := range t.IdentityBase.Values {
+			if id.Name == def.Name {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not derived from identity %s for identityref schema %s", def.Name, t.IdentityBase.Name, schema.Name)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+func TestValidateEnum(t *testing.T) {
+	enum := yang.NewEnumType()
+	if err := enum.Set("E_VALUE_FORTY_TWO", 42); err != nil {
+		t.Fatalf("cannot set enumeration value: %v", err)
+	}
+	otherEnum := yang.NewEnumType()
+	if err := otherEnum.Set("E_VALUE_FORTY_THREE", 43); err != nil {
+		t.Fatalf("cannot set enumeration value: %v", err)
+	}
+
+	enumSchema := func(e *yang.EnumType) *yang.Entry {
+		return &yang.Entry{Name: "enum-leaf", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yenum, Enum: e}}
+	}
+	identitySchema := func(values ...string) *yang.Entry {
+		base := &yang.Identity{Name: "BASE"}
+		for _, v := range values {
+			base.Values = append(base.Values, &yang.Identity{Name: v})
+		}
+		return &yang.Entry{Name: "identity-leaf", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yidentityref, IdentityBase: base}}
+	}
+	set := func(v interface{}) interface{} { return &v }
+
+	tests := []struct {
+		desc          string
+		schema        *yang.Entry
+		val           interface{}
+		wantErrSubstr string
+	}{{
+		desc:   "enumeration value",
+		schema: enumSchema(enum),
+		val:    EnumType(42),
+	}, {
+		desc:   "enumeration without defined values",
+		schema: enumSchema(nil),
+		val:    EnumType(42),
+	}, {
+		desc:   "unset enumeration leaf",
+		schema: enumSchema(enum),
+		val:    EnumType(0),
+	}, {
+		desc:          "set UNSET enumeration value",
+		schema:        enumSchema(enum),
+		val:           set(EnumType(0)),
+		wantErrSubstr: "UNSET value of ytypes.EnumType is not a valid value for schema enum-leaf",
+	}, {
+		desc:          "value not defined by Go type",
+		schema:        enumSchema(enum),
+		val:           EnumType(41),
+		wantErrSubstr: "41 is not a valid value of ytypes.EnumType for schema enum-leaf",
+	}, {
+		desc:          "value not defined by schema",
+		schema:        enumSchema(otherEnum),
+		val:           set(EnumType(42)),
+		wantErrSubstr: "E_VALUE_FORTY_TWO is not a valid value of enumeration schema enum-leaf",
+	}, {
+		desc:   "plain int64 value",
+		schema: enumSchema(enum),
+		val:    int64(0),
+	}, {
+		desc:   "identity derived from base",
+		schema: identitySchema("E_VALUE_FORTY_THREE", "E_VALUE_FORTY_TWO"),
+		val:    EnumType(42),
+	}, {
+		desc:   "identityref without derived identities",
+		schema: identitySchema(),
+		val:    EnumType(42),
+	}, {
+		desc:          "identity not derived from base",
+		schema:        identitySchema("E_VALUE_FORTY_THREE"),
+		val:           EnumType(42),
+		wantErrSubstr: "E_VALUE_FORTY_TWO is not derived from identity BASE for identityref schema identity-leaf",
+	}, {
+		desc:          "set UNSET identity value",
+		schema:        identitySchema("E_VALUE_FORTY_TWO"),
+		val:           set(EnumType(0)),
+		wantErrSubstr: "UNSET value",
+	}}
+
+	for _, tt := range tests {
+		err := validateLeaf(tt.schema, tt.val)
+		switch {
+		case err != nil && (tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr)):
+			t.Errorf("%s: validateLeaf(%v): got unexpected error: %v, want error containing: %q", tt.desc, tt.val, err, tt.wantErrSubstr)
+		case err == nil && tt.wantErrSubstr != "":
+			t.Errorf("%s: validateLeaf(%v): did not get expected error, want error containing: %q", tt.desc, tt.val, tt.wantErrSubstr)
+		}
+	}
+
+	// Each member of a leaf-list is set, such that UNSET is not valid.
+	llSchema := &yang.Entry{Name: "enum-leaf-list", Kind: yang.LeafEntry, ListAttr: &yang.ListAttr{}, Type: &yang.YangType{Kind: yang.Yenum, Enum: enum}}
+	if err := validateLeafList(llSchema, []EnumType{42}); err != nil {
+		t.Errorf("validateLeafList([42]): got unexpected error: %v", err)
+	}
+	if err := validateLeafList(llSchema, []EnumType{42, 0}); err == nil || !strings.Contains(err.Error(), "UNSET value") {
+		t.Errorf("validateLeafList([42, 0]): got error: %v, want UNSET value error", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ygotutils"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc6020#section-9.13.
+
+// validateInstanceIdentifier validates value, which must be a
+// ygot.InstanceIdentifier, against the given schema. The path of the
+// identifier must be representable in its string form, and, where the root of
+// the schema tree is known, must identify a node within the schema tree. The
+// root is known where it is the fake root of generated code, or a module,
+// such as for a schema tree that is loaded at runtime. Each list along the
+// path must be identified by all of its keys.
+func validateInstanceIdentifier(schema *yang.Entry, value interface{}) error {
+	iid, ok := value.(ygot.InstanceIdentifier)
+	if !ok {
+		return fmt.Errorf("non ygot.InstanceIdentifier type %T with value %v for schema %s", value, value, schema.Name)
+	}
+	if len(iid.Path.GetElem()) == 0 {
+		return fmt.Errorf("instance-identifier for schema %s does not identify a node", schema.Name)
+	}
+	if _, err := iid.MarshalJSON(); err != nil {
+		return fmt.Errorf("%v for schema %s", err, schema.Name)
+	}
+	if _, err := ygot.ParseInstanceIdentifier(iid.String()); err != nil {
+		return fmt.Errorf("%v for schema %s", err, schema.Name)
+	}
+
+	root := util.SchemaTreeRoot(schema)
+	if !util.IsFakeRoot(root) && !util.IsModuleEntry(root) {
+		return nil
+	}
+	if _, err := instanceIdentifierSchema(root, iid.Path.GetElem()); err != nil {
+		return fmt.Errorf("instance-identifier %s for schema %s: %v", iid, schema.Name, err)
+	}
+	return nil
+}
+
+// instanceIdentifierSchema returns the schema of the node identified by the
+// path elements elems, relative to the root of the schema tree, root. It
+// returns an error if the node does not exist within the schema, or if the
+// keys of the path elements do not match those of the nodes that they
+// describe.
+func instanceIdentifierSchema(root *yang.Entry, elems []*gpb.PathElem) (*yang.Entry, error) {
+	schema := root
+	for len(elems) != 0 {
+		if !schema.IsDir() {
+			return nil, fmt.Errorf("path traverses non-container node %s", schema.Name)
+		}
+		var names []string
+		for _, e := range elems {
+			names = append(names, e.GetName())
+		}
+		ch, n := util.DataPathChild(schema, names)
+		if ch == nil || n > len(elems) {
+			return nil, fmt.Errorf("path element %s not found in schema %s", elems[0].GetName(), schema.Name)
+		}
+		for _, e := range elems[:n-1] {
+			if len(e.GetKey()) != 0 {
+				return nil, fmt.Errorf("path element %s specifies keys, but is not a list", e.GetName())
+			}
+		}
+		if err := checkInstanceIdentifierKeys(ch, elems[n-1]); err != nil {
+			return nil, err
+		}
+		schema, elems = ch, elems[n:]
+	}
+	return schema, nil
+}
+
+// checkInstanceIdentifierKeys checks that the keys of the path element e are
+// valid for the node described by schema. Keyed list entries must specify
+// each of the keys of the list, and leaf-list entries may only be identified
+// by their value.
+func checkInstanceIdentifierKeys(schema *yang.Entry, e *gpb.PathElem) error {
+	var keys []string
+	for k := range e.GetKey() {
+		keys = append(keys, util.StripModulePrefix(k))
+	}
+	sort.Strings(keys)
+
+	switch {
+	case schema.IsList():
+		want := strings.Fields(schema.Key)
+		if len(want) == 0 {
+			return fmt.Errorf("entries of keyless list %s cannot be identified", schema.Name)
+		}
+		sort.Strings(want)
+		if !reflect.DeepEqual(keys, want) {
+			return fmt.Errorf("path element %s specifies keys %v, but list %s has keys %v", e.GetName(), keys, schema.Name, want)
+		}
+	case schema.IsLeafList():
+		if len(keys) != 0 && (len(keys) != 1 || keys[0] != ygot.LeafListValueKey) {
+			return fmt.Errorf("path element %s specifies keys %v, but leaf-list %s can only be identified by its value", e.GetName(), keys, schema.Name)
+		}
+	case len(keys) != 0:
+		return fmt.Errorf("path element %s specifies keys %v, but is not a list", e.GetName(), keys)
+	}
+	return nil
+}
+
+// instanceIdentifierExists determines whether the node identified by iid
+// exists within the data tree rooted at root, which is described by schema.
+// An entry of a leaf-list exists if the leaf-list contains its value.
+func instanceIdentifierExists(schema *yang.Entry, root ygot.GoStruct, iid ygot.InstanceIdentifier) (bool, error) {
+	var (
+		elems   []*gpb.PathElem
+		llValue *string
+	)
+	for _, e := range iid.Path.GetElem() {
+		ne := &gpb.PathElem{Name: util.StripModulePrefix(e.GetName())}
+		for k, v := range e.GetKey() {
+			if k == ygot.LeafListValueKey {
+				v := v
+				llValue = &v
+				continue
+			}
+			if ne.Key == nil {
+				ne.Key = map[string]string{}
+			}
+			ne.Key[util.StripModulePrefix(k)] = v
+		}
+		elems = append(elems, ne)
+	}
+
+	matches, err := ygotutils.GetNodes(schema, root, &gpb.Path{Elem: elems})
+	if err != nil {
+		return false, err
+	}
+	for _, m := range matches {
+		if util.IsValueNil(m.Data) {
+			continue
+		}
+		if llValue == nil {
+			return true, nil
+		}
+		for _, v := range leafValues(m.Data) {
+			if fmt.Sprint(v) == *llValue {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
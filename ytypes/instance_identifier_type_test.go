@@ -0,0 +1,255 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+type instanceIDRoot struct {
+	Item     map[string]*instanceIDItem `path:"item"`
+	Ref      *ygot.InstanceIdentifier   `path:"ref"`
+	Optional *ygot.InstanceIdentifier   `path:"optional"`
+}
+
+func (*instanceIDRoot) IsYANGGoStruct() {}
+
+type instanceIDItem struct {
+	Name *string  `path:"name"`
+	Tag  []string `path:"tag"`
+}
+
+func (*instanceIDItem) IsYANGGoStruct() {}
+
+// instanceIDSchema returns the schema for instanceIDRoot, which is a fake
+// root, with the parent of each entry populated.
+func instanceIDSchema() *yang.Entry {
+	root := &yang.Entry{
+		Name:       "device",
+		Kind:       yang.DirectoryEntry,
+		Annotation: map[string]interface{}{"isFakeRoot": true},
+		Dir: map[string]*yang.Entry{
+			"item": {
+				Name:     "item",
+				Kind:     yang.DirectoryEntry,
+				ListAttr: &yang.ListAttr{},
+				Key:      "name",
+				Dir: map[string]*yang.Entry{
+					"name": {Name: "name", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}},
+					"tag":  {Name: "tag", Kind: yang.LeafEntry, ListAttr: &yang.ListAttr{}, Type: &yang.YangType{Kind: yang.Ystring}},
+				},
+			},
+			"ref":      {Name: "ref", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.YinstanceIdentifier}},
+			"optional": {Name: "optional", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.YinstanceIdentifier, OptionalInstance: true}},
+		},
+	}
+	populateParentField(nil, root)
+	return root
+}
+
+// mustInstanceIdentifier returns a pointer to the InstanceIdentifier parsed
+// from s, and fails the test if s is not valid.
+func mustInstanceIdentifier(t *testing.T, s string) *ygot.InstanceIdentifier {
+	iid, err := ygot.ParseInstanceIdentifier(s)
+	if err != nil {
+		t.Fatalf("cannot parse instance-identifier %q: %v", s, err)
+	}
+	return &iid
+}
+
+func TestValidateInstanceIdentifier(t *testing.T) {
+	schema := instanceIDSchema().Dir["ref"]
+
+	tests := []struct {
+		desc          string
+		in            string
+		wantErrSubstr string
+	}{{
+		desc: "list entry",
+		in:   "/item[name='a']",
+	}, {
+		desc: "leaf within list entry using module names",
+		in:   "/mod:item[mod:name='a']/name",
+	}, {
+		desc: "leaf-list",
+		in:   "/item[name='a']/tag",
+	}, {
+		desc: "leaf-list entry",
+		in:   "/item[name='a']/tag[.='x']",
+	}, {
+		desc: "leaf at root",
+		in:   "/ref",
+	}, {
+		desc:          "node not in schema",
+		in:            "/other",
+		wantErrSubstr: "path element other not found in schema device",
+	}, {
+		desc:          "list without keys",
+		in:            "/item/name",
+		wantErrSubstr: "specifies keys [], but list item has keys [name]",
+	}, {
+		desc:          "list with incorrect key",
+		in:            "/item[id='a']",
+		wantErrSubstr: "specifies keys [id], but list item has keys [name]",
+	}, {
+		desc:          "keys for a leaf",
+		in:            "/item[name='a']/name[name='a']",
+		wantErrSubstr: "specifies keys [name], but is not a list",
+	}, {
+		desc:          "key for a leaf-list",
+		in:            "/item[name='a']/tag[name='a']",
+		wantErrSubstr: "can only be identified by its value",
+	}, {
+		desc:          "path traversing a leaf",
+		in:            "/item[name='a']/name/other",
+		wantErrSubstr: "path traverses non-container node name",
+	}}
+
+	for _, tt := range tests {
+		errs := validateLeaf(schema, mustInstanceIdentifier(t, tt.in))
+		err := errs.Error()
+		switch {
+		case errs != nil && (tt.wantErrSubstr == "" || !strings.Contains(err, tt.wantErrSubstr)):
+			t.Errorf("%s: validateLeaf(%s): got unexpected error: %v, want error containing: %q", tt.desc, tt.in, err, tt.wantErrSubstr)
+		case errs == nil && tt.wantErrSubstr != "":
+			t.Errorf("%s: validateLeaf(%s): did not get expected error, want error containing: %q", tt.desc, tt.in, tt.wantErrSubstr)
+		}
+	}
+
+	// A value that cannot be represented in its string form is invalid.
+	bad := &ygot.InstanceIdentifier{Path: &gpb.Path{Elem: []*gpb.PathElem{{Name: "item", Key: map[string]string{"name": `a'"`}}}}}
+	if errs := validateLeaf(schema, bad); errs == nil || !strings.Contains(errs.Error(), "both single and double quotes") {
+		t.Errorf("validateLeaf(%v): got error: %v, want error for quotes", bad, errs)
+	}
+	if errs := validateLeaf(schema, &ygot.InstanceIdentifier{}); errs == nil || !strings.Contains(errs.Error(), "does not identify a node") {
+		t.Errorf("validateLeaf(empty path): got error: %v, want error for empty path", errs)
+	}
+
+	// Where the root of the schema tree is not known, only the form of the
+	// value is checked.
+	detached := &yang.Entry{Name: "ref", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.YinstanceIdentifier}}
+	if errs := validateLeaf(detached, mustInstanceIdentifier(t, "/other")); errs != nil {
+		t.Errorf("validateLeaf(/other) with detached schema: got unexpected error: %v", errs)
+	}
+}
+
+func TestUnmarshalInstanceIdentifier(t *testing.T) {
+	schema := instanceIDSchema()
+
+	tests := []struct {
+		desc    string
+		json    string
+		want    *instanceIDRoot
+		wantErr string
+	}{{
+		desc: "valid instance-identifier",
+		json: `{"ref": "/item[name='a']/tag[.='x']"}`,
+		want: &instanceIDRoot{Ref: mustInstanceIdentifier(t, "/item[name='a']/tag[.='x']")},
+	}, {
+		desc:    "invalid instance-identifier",
+		json:    `{"ref": "item"}`,
+		wantErr: `error parsing item for schema ref: invalid instance-identifier "item": expected / at position 0`,
+	}}
+
+	for _, tt := range tests {
+		var jsonTree interface{}
+		if err := json.Unmarshal([]byte(tt.json), &jsonTree); err != nil {
+			t.Fatalf("%s: cannot unmarshal input JSON: %v", tt.desc, err)
+		}
+		got := &instanceIDRoot{}
+		err := Unmarshal(schema, got, jsonTree)
+		if errStr := errToString(err); errStr != tt.wantErr {
+			t.Errorf("%s: Unmarshal: got error: %v, want error: %v", tt.desc, errStr, tt.wantErr)
+		}
+		if err == nil && !areEqual(got, tt.want) {
+			t.Errorf("%s: Unmarshal: got:\n%v\nwant:\n%v", tt.desc, pretty.Sprint(got), pretty.Sprint(tt.want))
+		}
+	}
+}
+
+func TestValidatePathInstanceIdentifier(t *testing.T) {
+	schema := instanceIDSchema()
+	refPath := &gpb.Path{Elem: []*gpb.PathElem{{Name: "ref"}}}
+	itemPath := &gpb.Path{Elem: []*gpb.PathElem{{Name: "item", Key: map[string]string{"name": "a"}}}}
+
+	tests := []struct {
+		desc          string
+		inRef         string
+		inOptional    string
+		inPath        *gpb.Path
+		wantErrSubstr string
+	}{{
+		desc:   "list entry exists",
+		inRef:  "/item[name='a']",
+		inPath: refPath,
+	}, {
+		desc:   "leaf-list entry exists",
+		inRef:  "/item[name='a']/tag[.='x']",
+		inPath: refPath,
+	}, {
+		desc:          "list entry does not exist",
+		inRef:         "/item[name='b']",
+		inPath:        refPath,
+		wantErrSubstr: "instance-identifier /ref value /item[name='b'] does not identify a node in the data tree",
+	}, {
+		desc:          "leaf does not exist",
+		inRef:         "/optional",
+		inPath:        refPath,
+		wantErrSubstr: "value /optional does not identify a node in the data tree",
+	}, {
+		desc:          "leaf-list entry does not exist",
+		inRef:         "/item[name='a']/tag[.='y']",
+		inPath:        refPath,
+		wantErrSubstr: "does not identify a node in the data tree",
+	}, {
+		desc:       "instance not required",
+		inOptional: "/item[name='b']",
+		inPath:     &gpb.Path{Elem: []*gpb.PathElem{{Name: "optional"}}},
+	}, {
+		desc:   "instance-identifier outside of the validated subtree",
+		inRef:  "/item[name='b']",
+		inPath: itemPath,
+	}}
+
+	for _, tt := range tests {
+		root := &instanceIDRoot{
+			Item: map[string]*instanceIDItem{
+				"a": {Name: ygot.String("a"), Tag: []string{"x"}},
+			},
+		}
+		if tt.inRef != "" {
+			root.Ref = mustInstanceIdentifier(t, tt.inRef)
+		}
+		if tt.inOptional != "" {
+			root.Optional = mustInstanceIdentifier(t, tt.inOptional)
+		}
+
+		errs := ValidatePath(schema, root, tt.inPath)
+		switch {
+		case errs != nil && (tt.wantErrSubstr == "" || !strings.Contains(errs.Error(), tt.wantErrSubstr)):
+			t.Errorf("%s: ValidatePath: got unexpected error: %v, want error containing: %q", tt.desc, errs, tt.wantErrSubstr)
+		case errs == nil && tt.wantErrSubstr != "":
+			t.Errorf("%s: ValidatePath: did not get expected error, want error containing: %q", tt.desc, tt.wantErrSubstr)
+		}
+	}
+}
@@ -15,7 +15,6 @@
 package ytypes
 
 import (
-	"bytes"
 	"encoding/base64"
 	"fmt"
 	"reflect"
@@ -41,7 +40,7 @@ func validateLeaf(inSchema *yang.Entry, value interface{}) util.Errors {
 
 	util.DbgPrint("validateLeaf with value %s (%T), schema name %s (%s)", util.ValueStr(value), value, inSchema.Name, inSchema.Type.Kind)
 
-	schema, err := resolveLeafRef(inSchema)
+	schema, err := util.ResolveIfLeafRef(inSchema)
 	if err != nil {
 		return util.NewErrs(err)
 	}
@@ -84,10 +83,22 @@ func validateLeaf(inSchema *yang.Entry, value interface{}) util.Errors {
 		return util.NewErrs(validateString(schema, rv))
 	case yang.Ydecimal64:
 		return util.NewErrs(validateDecimal(schema, rv))
+	case yang.YinstanceIdentifier:
+		return util.NewErrs(validateInstanceIdentifier(schema, rv))
 	case yang.Yenum, yang.Yidentityref:
 		if rkind != reflect.Int64 && !isValueInterfacePtrToEnum(reflect.ValueOf(value)) {
 			return util.NewErrs(fmt.Errorf("bad leaf value type %v, expect Int64 for schema %s, type %v", rkind, schema.Name, ykind))
 		}
+		// Enumerated leaves within generated structs are not pointers, and
+		// have their UNSET value when they are not set. Values within
+		// leaf-lists and unions are supplied as a pointer, and are set.
+		ev := value
+		if rkind == reflect.Ptr {
+			ev = rv
+		}
+		if e, ok := ev.(ygot.GoEnum); ok {
+			return util.NewErrs(validateEnum(schema, e, rkind == reflect.Ptr))
+		}
 		return nil
 	case yang.Yunion:
 		return validateUnion(schema, value)
@@ -228,7 +239,7 @@ func validateUnion(schema *yang.Entry, value interface{}) util.Errors {
 		}
 	}
 
-	if v.Type().Kind() == reflect.Struct {
+	if util.IsValueStruct(v) {
 		if v.NumField() != 1 {
 			return util.NewErrs(fmt.Errorf("union %s should only have one field, but has %d", schema.Name, v.NumField()))
 		}
@@ -254,6 +265,9 @@ func validateMatchingSchemas(schema *yang.Entry, value interface{}) util.Errors
 		return util.NewErrs(fmt.Errorf("no types in schema %s match the type of value %v, which is %T", schema.Name, util.ValueStr(value), value))
 	}
 	for _, s := range ss {
+		// The member schemas are created from the union's types, so carry
+		// the annotations of the union, such as the inverted patterns.
+		s.Annotation = schema.Annotation
 		var errs []error
 		if reflect.ValueOf(value).Kind() == reflect.Ptr {
 			errs = validateLeaf(s, value)
@@ -302,116 +316,6 @@ func findMatchingSchemasInUnion(ytype *yang.YangType, value interface{}) []*yang
 	return matches
 }
 
-// stripPrefix removes the prefix from a YANG path element. For example, removing
-// foo from "foo:bar". Such qualified paths are used in YANG modules where remote
-// paths are referenced.
-func stripPrefix(name string) (string, error) {
-	ps := strings.Split(name, ":")
-	switch len(ps) {
-	case 1:
-		return name, nil
-	case 2:
-		return ps[1], nil
-	}
-	return "", fmt.Errorf("path element did not form a valid name (name, prefix:name): %v", name)
-}
-
-// removeXPATHPredicates removes predicates from an XPath string. e.g.,
-// removeXPATHPredicates(/foo/bar[name="foo"]/config/baz -> /foo/bar/config/baz.
-func removeXPATHPredicates(s string) (string, error) {
-	var b bytes.Buffer
-	for i := 0; i < len(s); {
-		ss := s[i:]
-		si, ei := strings.Index(ss, "["), strings.Index(ss, "]")
-		switch {
-		case si == -1 && ei == -1:
-			// This substring didn't contain a [] pair, therefore write it
-			// to the buffer.
-			b.WriteString(ss)
-			// Move to the last character of the substring.
-			i += len(ss)
-		case si == -1 || ei == -1:
-			// This substring contained a mismatched pair of []s.
-			return "", fmt.Errorf("Mismatched brackets within substring %s of %s, [ pos: %d, ] pos: %d", ss, s, si, ei)
-		case si > ei:
-			// This substring contained a ] before a [.
-			return "", fmt.Errorf("Incorrect ordering of [] within substring %s of %s, [ pos: %d, ] pos: %d", ss, s, si, ei)
-		default:
-			// This substring contained a matched set of []s.
-			b.WriteString(ss[0:si])
-			i += ei + 1
-		}
-	}
-
-	return b.String(), nil
-}
-
-// findLeafRefSchema returns a schema Entry at the path pathStr relative to
-// schema if it exists, or an error otherwise.
-// pathStr has either:
-//  - the relative form "../a/b/../b/c", where ".." indicates the parent of the
-//    node, or
-//  - the absolute form "/a/b/c", which indicates the absolute path from the
-//    root of the schema tree.
-func findLeafRefSchema(schema *yang.Entry, pathStr string) (*yang.Entry, error) {
-	if pathStr == "" {
-		return nil, fmt.Errorf("leafref schema %s has empty path", schema.Name)
-	}
-
-	refSchema := schema
-	pathStr, err := removeXPATHPredicates(pathStr)
-	if err != nil {
-		return nil, err
-	}
-	path := strings.Split(pathStr, "/")
-
-	// For absolute path, reset to root of the schema tree.
-	if pathStr[0] == '/' {
-		refSchema = schemaTreeRoot(schema)
-		path = path[1:]
-	}
-
-	for i := 0; i < len(path); i++ {
-		pe, err := stripPrefix(path[i])
-		if err != nil {
-			return nil, fmt.Errorf("leafref schema %s path %s: %v", schema.Name, pathStr, err)
-		}
-
-		if pe == ".." {
-			if refSchema.Parent == nil {
-				return nil, fmt.Errorf("parent of %s is nil for leafref schema %s with path %s", refSchema.Name, schema.Name, pathStr)
-			}
-			refSchema = refSchema.Parent
-			continue
-		}
-		if refSchema.Dir[pe] == nil {
-			if isFakeRoot(refSchema) {
-				// Special handling is required for the fake root, since it
-				// contains only entries for which code is generated. These
-				// would not normally be removed in the schema, but the
-				// schema fakeroot is a special case which is constructed to
-				// contain the generated root elements.
-				// Therefore, need to check the path element also against the
-				// child of the entry.
-				pech, err := stripPrefix(path[i+1])
-				if err != nil {
-					return nil, err
-				}
-				if refSchema.Dir[pech] != nil {
-					refSchema = refSchema.Dir[pech]
-					// Skip this element.
-					i++
-					continue
-				}
-			}
-			return nil, fmt.Errorf("schema node %s is nil for leafref schema %s with path %s", pe, schema.Name, pathStr)
-		}
-		refSchema = refSchema.Dir[pe]
-	}
-
-	return refSchema, nil
-}
-
 // validateLeafSchema validates the given leaf type schema. This is a sanity
 // check validation rather than a comprehensive validation against the RFC.
 // It is assumed that such a validation is done when the schema is parsed from
@@ -449,7 +353,7 @@ func unmarshalLeaf(inSchema *yang.Entry, parent interface{}, value interface{})
 		return err
 	}
 
-	schema, err := resolveLeafRef(inSchema)
+	schema, err := util.ResolveIfLeafRef(inSchema)
 	if err != nil {
 		return err
 	}
@@ -651,7 +555,7 @@ func getUnionTypesNotEnums(schema *yang.Entry, yt *yang.YangType) ([]*yang.YangT
 		// Enum types handled separately.
 		return nil, nil
 	case yang.Yleafref:
-		ns, err := findLeafRefSchema(schema, yt.Path)
+		ns, err := util.FindLeafRefSchema(schema, yt.Path)
 		if err != nil {
 			return nil, err
 		}
@@ -740,12 +644,19 @@ func unmarshalScalar(parent interface{}, schema *yang.Entry, fieldName string, v
 		return value.(string), nil
 
 	case yang.Ydecimal64:
-		floatV, err := strconv.ParseFloat(value.(string), 64)
+		decV, err := parseDecimal(schema, value.(string))
 		if err != nil {
 			return nil, fmt.Errorf("error parsing %v for schema %s: %v", value, schema.Name, err)
 		}
 
-		return floatV, nil
+		return decV, nil
+
+	case yang.YinstanceIdentifier:
+		iid, err := ygot.ParseInstanceIdentifier(value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %v for schema %s: %v", value, schema.Name, err)
+		}
+		return iid, nil
 
 	case yang.Yenum, yang.Yidentityref:
 		return enumStringToValue(parent, fieldName, value.(string))
@@ -787,6 +698,23 @@ func unmarshalScalar(parent interface{}, schema *yang.Entry, fieldName string, v
 	return nil, fmt.Errorf("unmarshalScalar: unsupported type %v in schema node %s", ykind, schema.Name)
 }
 
+// parseDecimal parses the decimal64 value in s, using the fraction-digits of
+// the schema as its precision. Where the schema does not specify the
+// fraction-digits, such as when the type is a member of a union, the number of
+// fraction digits within s is used.
+func parseDecimal(schema *yang.Entry, s string) (ygot.Decimal64, error) {
+	fd := schema.Type.FractionDigits
+	if fd == 0 {
+		if i := strings.Index(s, "."); i >= 0 {
+			fd = len(s) - i - 1
+		}
+	}
+	if fd < 0 || fd > 18 {
+		return ygot.Decimal64{}, fmt.Errorf("invalid number of fraction digits %d", fd)
+	}
+	return ygot.ParseDecimal64(s, uint8(fd))
+}
+
 // isValueInterfacePtrToEnum reports whether v is an interface ptr to enum type.
 func isValueInterfacePtrToEnum(v reflect.Value) bool {
 	if v.Kind() != reflect.Ptr {
@@ -20,7 +20,6 @@ import (
 
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
-	"github.com/openconfig/ygot/ygot"
 )
 
 // Refer to: https://tools.ietf.org/html/rfc6020#section-7.7.
@@ -46,14 +45,10 @@ func validateLeafList(schema *yang.Entry, value interface{}) util.Errors {
 		for i := 0; i < v.Len(); i++ {
 			cv := v.Index(i).Interface()
 
-			// Handle the case that this is a leaf-list of enumerated values, where we expect that the
-			// input to validateLeaf is a scalar value, rather than a pointer.
-			if _, ok := cv.(ygot.GoEnum); ok {
-				errors = util.AppendErrs(errors, validateLeaf(schema, cv))
-			} else {
-				errors = util.AppendErrs(errors, validateLeaf(schema, &cv))
-			}
-
+			// Enumerated values are also supplied to validateLeaf as a
+			// pointer, such that their UNSET value is validated, since each
+			// element of a leaf-list is set.
+			errors = util.AppendErrs(errors, validateLeaf(schema, &cv))
 		}
 	default:
 		errors = util.AppendErr(errors, fmt.Errorf("expected slice type for %s, got %T", schema.Name, value))
@@ -202,7 +202,7 @@ func TestValidateLeaf(t *testing.T) {
 		{
 			desc:   "decimal64 success",
 			schema: typeToLeafSchema("decimal", yang.Ydecimal64),
-			val:    ygot.Float64(42.42),
+			val:    &ygot.Decimal64{Digits: 4242, Precision: 2},
 		},
 		{
 			desc:    "decimal64 bad type",
@@ -810,82 +810,23 @@ func TestValidateLeafRef(t *testing.T) {
 	}
 }
 
-func TestRemoveXPATHPredicates(t *testing.T) {
-	tests := []struct {
-		name    string
-		in      string
-		want    string
-		wantErr bool
-	}{{
-		name: "simple predicate",
-		in:   `/foo/bar[name="eth0"]`,
-		want: "/foo/bar",
-	}, {
-		name: "predicate with path",
-		in:   `/foo/bar[name="/foo/bar/baz"]/config/hat`,
-		want: "/foo/bar/config/hat",
-	}, {
-		name: "predicate with function",
-		in:   `/foo/bar[name="current()/../interface"]/config/baz`,
-		want: "/foo/bar/config/baz",
-	}, {
-		name: "multiple predicates",
-		in:   `/foo/bar[name="current()/../interface"]/container/list[key="42"]/config/foo`,
-		want: "/foo/bar/container/list/config/foo",
-	}, {
-		name:    "] without [",
-		in:      `/foo/bar]`,
-		wantErr: true,
-	}, {
-		name:    "[ without closure",
-		in:      `/foo/bar[`,
-		wantErr: true,
-	}, {
-		name: "multiple predicates, end of string",
-		in:   `/foo/bar/name[e="1"]/bar[j="2"]`,
-		want: "/foo/bar/name/bar",
-	}, {
-		name:    "][ in incorrect order",
-		in:      `/foo/bar][`,
-		wantErr: true,
-	}, {
-		name: "empty string",
-		in:   ``,
-		want: ``,
-	}, {
-		name: "predicate directly",
-		in:   `foo[bar="test"]`,
-		want: `foo`,
-	}}
-
-	for _, tt := range tests {
-		got, err := removeXPATHPredicates(tt.in)
-		if (err != nil) != tt.wantErr {
-			t.Errorf("%s: removeXPATHPredicates(%s): got unexpected error, got: %v", tt.name, tt.in, err)
-		}
-
-		if got != tt.want {
-			t.Errorf("%s: removePredicate(%v): did not get expected value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
-		}
-	}
-}
-
 type LeafContainerStruct struct {
-	Int8Leaf    *int8         `path:"int8-leaf"`
-	Uint8Leaf   *uint8        `path:"uint8-leaf"`
-	Int16Leaf   *int16        `path:"int16-leaf"`
-	Uint16Leaf  *uint16       `path:"uint16-leaf"`
-	Int32Leaf   *int32        `path:"int32-leaf"`
-	Uint32Leaf  *uint32       `path:"uint32-leaf"`
-	Int64Leaf   *int64        `path:"int64-leaf"`
-	Uint64Leaf  *uint64       `path:"uint64-leaf"`
-	StringLeaf  *string       `path:"string-leaf"`
-	BinaryLeaf  []byte        `path:"binary-leaf"`
-	BoolLeaf    *bool         `path:"bool-leaf"`
-	DecimalLeaf *float64      `path:"decimal-leaf"`
-	EnumLeaf    EnumType      `path:"enum-leaf"`
-	UnionLeaf   UnionLeafType `path:"union-leaf"`
-	UnionLeaf2  *string       `path:"union-leaf2"`
+	Int8Leaf     *int8           `path:"int8-leaf"`
+	Uint8Leaf    *uint8          `path:"uint8-leaf"`
+	Int16Leaf    *int16          `path:"int16-leaf"`
+	Uint16Leaf   *uint16         `path:"uint16-leaf"`
+	Int32Leaf    *int32          `path:"int32-leaf"`
+	Uint32Leaf   *uint32         `path:"uint32-leaf"`
+	Int64Leaf    *int64          `path:"int64-leaf"`
+	Uint64Leaf   *uint64         `path:"uint64-leaf"`
+	StringLeaf   *string         `path:"string-leaf"`
+	BinaryLeaf   []byte          `path:"binary-leaf"`
+	BoolLeaf     *bool           `path:"bool-leaf"`
+	DecimalLeaf  *ygot.Decimal64 `path:"decimal-leaf"`
+	DecimalLeaf2 *ygot.Decimal64 `path:"decimal-leaf2"`
+	EnumLeaf     EnumType        `path:"enum-leaf"`
+	UnionLeaf    UnionLeafType   `path:"union-leaf"`
+	UnionLeaf2   *string         `path:"union-leaf2"`
 }
 
 type UnionLeafType interface {
@@ -1015,7 +956,17 @@ func TestUnmarshalLeaf(t *testing.T) {
 		{
 			desc: "decimal success",
 			json: `{"decimal-leaf" : "42.42"}`,
-			want: LeafContainerStruct{DecimalLeaf: ygot.Float64(42.42)},
+			want: LeafContainerStruct{DecimalLeaf: &ygot.Decimal64{Digits: 4242, Precision: 2}},
+		},
+		{
+			desc: "decimal with fraction-digits success",
+			json: `{"decimal-leaf2" : "-42.42"}`,
+			want: LeafContainerStruct{DecimalLeaf2: &ygot.Decimal64{Digits: -424200, Precision: 4}},
+		},
+		{
+			desc: "decimal with fewer digits than fraction-digits",
+			json: `{"decimal-leaf2" : "0.1234"}`,
+			want: LeafContainerStruct{DecimalLeaf2: &ygot.Decimal64{Digits: 1234, Precision: 4}},
 		},
 		{
 			desc: "union string success",
@@ -1127,6 +1078,11 @@ func TestUnmarshalLeaf(t *testing.T) {
 			json:    `{"decimal-leaf" : 42.42}`,
 			wantErr: `got float64 type for field decimal-leaf, expect string`,
 		},
+		{
+			desc:    "decimal too many fraction digits",
+			json:    `{"decimal-leaf2" : "42.42424"}`,
+			wantErr: `error parsing 42.42424 for schema decimal-leaf2: "42.42424" has more than 4 fraction digits`,
+		},
 	}
 
 	containerSchema := &yang.Entry{
@@ -1204,6 +1160,7 @@ func TestUnmarshalLeaf(t *testing.T) {
 		typeToLeafSchema("binary-leaf", yang.Ybinary),
 		typeToLeafSchema("bool-leaf", yang.Ybool),
 		typeToLeafSchema("decimal-leaf", yang.Ydecimal64),
+		{Name: "decimal-leaf2", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 4}},
 		enumLeafSchema,
 		unionSchema,
 		unionNoStructSchema,
@@ -176,10 +176,13 @@ func validateStructElems(schema *yang.Entry, value interface{}) util.Errors {
 	}
 	// Verify each elements's fields.
 	for i := 0; i < structElems.NumField(); i++ {
+		if util.IsYgotAnnotation(structTypes.Field(i)) {
+			continue
+		}
 		fieldName := structElems.Type().Field(i).Name
 		fieldValue := structElems.Field(i).Interface()
 
-		cschema, err := childSchema(schema, structTypes.Field(i))
+		cschema, err := util.ChildSchema(schema, structTypes.Field(i))
 		if err != nil {
 			errors = util.AppendErr(errors, err)
 			continue
@@ -233,7 +236,10 @@ func validateListSchema(schema *yang.Entry) error {
 // key field name.
 func schemaNameToFieldName(structElems reflect.Value, schemaKeyFieldName string) (string, error) {
 	for i := 0; i < structElems.NumField(); i++ {
-		ps, err := pathToSchema(structElems.Type().Field(i))
+		if util.IsYgotAnnotation(structElems.Type().Field(i)) {
+			continue
+		}
+		ps, err := util.RelativeSchemaPath(structElems.Type().Field(i))
 		if err != nil {
 			return "", err
 		}
@@ -268,7 +274,8 @@ func nameMatchesPath(fieldName string, path []string) (bool, error) {
 //   schema is the schema of the schema node corresponding to the struct being
 //     unmamshaled into
 //   jsonList is a JSON list
-func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{}) error {
+//   opts is the set of options that were supplied to Unmarshal.
+func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{}, opts ...UnmarshalOpt) error {
 	if util.IsValueNil(jsonList) {
 		return nil
 	}
@@ -285,7 +292,7 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{})
 	if util.IsTypeStructPtr(t) {
 		// May be trying to unmarshal a single list element rather than the
 		// whole list.
-		return unmarshalContainerWithListSchema(schema, parent, jsonList)
+		return unmarshalContainerWithListSchema(schema, parent, jsonList, opts...)
 	}
 
 	// jsonList represents a JSON array, which is a Go slice.
@@ -315,12 +322,13 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{})
 	// types respectively.
 	// For a keyed list, the value(s) of the key are derived from the key fields
 	// in the new list element.
+	var unknown UnknownFieldsError
 	for _, le := range jl {
 		var err error
 		jt := le.(map[string]interface{})
 		newVal := reflect.New(listElementType.Elem())
 		util.DbgPrint("creating a new list element val of type %v", newVal.Type())
-		if err := unmarshalStruct(schema, newVal.Interface(), jt); err != nil {
+		if err := unmarshalStruct(schema, newVal.Interface(), jt, opts...); err != nil && !collectUnknownFields(err, &unknown) {
 			return err
 		}
 
@@ -342,6 +350,9 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{})
 	}
 	util.DbgPrint("list after unmarshal:\n%s\n", pretty.Sprint(parent))
 
+	if len(unknown) != 0 {
+		return unknown
+	}
 	return nil
 }
 
@@ -372,7 +383,7 @@ func makeKeyForInsert(schema *yang.Entry, parentMap interface{}, newVal reflect.
 	} else {
 		// Simple key type. Get the value from the new value struct,
 		// given the key string.
-		kv, err := getKeyValue(newVal.Elem(), schema.Key)
+		kv, err := util.GetKeyValue(newVal.Elem(), schema.Key)
 		if err != nil {
 			return reflect.ValueOf(nil), err
 		}
@@ -388,7 +399,7 @@ func makeKeyForInsert(schema *yang.Entry, parentMap interface{}, newVal reflect.
 // share the list schema so if a user attempts to unmarshal a list element vs.
 // the whole list, the supplied schema is the same - the only difference is
 // that in the latter case the target is a struct ptr.
-func unmarshalContainerWithListSchema(schema *yang.Entry, parent interface{}, value interface{}) error {
+func unmarshalContainerWithListSchema(schema *yang.Entry, parent interface{}, value interface{}, opts ...UnmarshalOpt) error {
 
 	if !util.IsTypeStructPtr(reflect.TypeOf(parent)) {
 		return fmt.Errorf("unmarshalContainerWithListSchema value %v, type %T, into parent type %T, schema name %s: parent must be a struct ptr",
@@ -398,34 +409,5 @@ func unmarshalContainerWithListSchema(schema *yang.Entry, parent interface{}, va
 	// with ListAttrs unset.
 	newSchema := *schema
 	newSchema.ListAttr = nil
-	return Unmarshal(&newSchema, parent, value)
-}
-
-// getKeyValue returns the value from the structVal field whose last path
-// element is key. The value is dereferenced if it is a ptr type. This function
-// is used to create a key value for a keyed list.
-// getKeyValue returns an error if no path in any of the fields of structVal has
-// key as the last path element.
-func getKeyValue(structVal reflect.Value, key string) (interface{}, error) {
-	for i := 0; i < structVal.NumField(); i++ {
-		f := structVal.Type().Field(i)
-		p, err := pathToSchema(f)
-		if err != nil {
-			return nil, err
-		}
-		if p[len(p)-1] == key {
-			fv := structVal.Field(i)
-			if fv.Type().Kind() == reflect.Ptr {
-				// The type for the key is the dereferenced type, if the type
-				// is a ptr.
-				if !fv.Elem().IsValid() {
-					return nil, fmt.Errorf("key field %s (%s) has nil value %v", key, fv.Type(), fv)
-				}
-				return fv.Elem().Interface(), nil
-			}
-			return fv.Interface(), nil
-		}
-	}
-
-	return nil, fmt.Errorf("could not find key field %s in struct type %s", key, structVal.Type())
+	return Unmarshal(&newSchema, parent, value, opts...)
 }
@@ -0,0 +1,163 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc7952#section-5.2.
+
+// annotationField returns the field of the struct v that stores the metadata
+// annotations of its nodes, and true, or false if v has no such field.
+func annotationField(v reflect.Value) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if util.IsYgotAnnotation(v.Type().Field(i)) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// isAnnotationMember determines whether the JSON member name is the member
+// that stores the RFC7952 annotations of its parent object, or of a member of
+// the parent object for which known returns true. known is called with the
+// name of the annotated member, without any module prefix.
+func isAnnotationMember(name string, known func(string) bool) bool {
+	switch {
+	case name == "@":
+		return true
+	case strings.HasPrefix(name, "@"):
+		return known(util.StripModulePrefix(name[1:]))
+	}
+	return false
+}
+
+// unmarshalStructAnnotations unmarshals the RFC7952 annotations of the
+// container or list entry described by schema, which are found within the
+// "@" member of jsonTree, into the annotation field af of its struct.
+func unmarshalStructAnnotations(schema *yang.Entry, af reflect.Value, jsonTree map[string]interface{}) error {
+	j, ok := jsonTree["@"]
+	if !ok {
+		return nil
+	}
+	if err := setAnnotations(af, nil, j); err != nil {
+		return fmt.Errorf("invalid annotations for %s: %v", schema.Name, err)
+	}
+	return nil
+}
+
+// unmarshalFieldAnnotations unmarshals the RFC7952 annotations of the leaf or
+// leaf-list described by schema, stored in the struct field f, into the
+// annotation field af of the struct. The annotations of a leaf or leaf-list
+// with the member name "name" are found within the "@name" member of the JSON
+// object that contains it. The annotations of a field that is within a
+// subtree being replaced are removed, such that only those within jsonTree
+// are retained.
+func unmarshalFieldAnnotations(parentSchema, schema *yang.Entry, af reflect.Value, f reflect.StructField, jsonTree map[string]interface{}, opts []UnmarshalOpt) error {
+	ps, err := dataTreePaths(parentSchema, schema, f)
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if len(p) == 0 {
+			continue
+		}
+		if md, _ := af.Interface().(ygot.NodeMetadata); md != nil && inReplacedSubtree(schema, opts) {
+			if err := md.Delete(annotationPath(p)); err != nil {
+				return err
+			}
+		}
+		parent, ok := getJSONTreeValForPath(jsonTree, p[:len(p)-1])
+		if !ok {
+			continue
+		}
+		pm, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, j := range pm {
+			if !strings.HasPrefix(k, "@") || util.StripModulePrefix(k[1:]) != p[len(p)-1] {
+				continue
+			}
+			if err := setAnnotations(af, p, j); err != nil {
+				return fmt.Errorf("invalid annotations for %s: %v", schema.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setAnnotations sets the annotations of the node with the path p, relative
+// to the struct containing the annotation field af, to those within the
+// RFC7952 JSON encoding j. The annotations of each entry of a leaf-list are
+// encoded as an array, in which case the annotations of all of its entries
+// are stored. The timestamp of any existing metadata of the node is retained.
+func setAnnotations(af reflect.Value, p []string, j interface{}) error {
+	ann := map[string]interface{}{}
+	objs, ok := j.([]interface{})
+	if !ok {
+		objs = []interface{}{j}
+	}
+	for _, o := range objs {
+		if o == nil {
+			continue
+		}
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("got type %T, expect map[string]interface{}", o)
+		}
+		for k, v := range m {
+			if i := strings.Index(k, ":"); i <= 0 || i == len(k)-1 {
+				return fmt.Errorf("annotation %s is not of the form module:name", k)
+			}
+			ann[k] = v
+		}
+	}
+
+	md, _ := af.Interface().(ygot.NodeMetadata)
+	if md == nil {
+		md = ygot.NodeMetadata{}
+		af.Set(reflect.ValueOf(md))
+	}
+	ap := annotationPath(p)
+	old, err := md.Get(ap)
+	if err != nil {
+		return err
+	}
+	n := &ygot.Metadata{Annotations: ann}
+	if old != nil {
+		n.Timestamp = old.Timestamp
+	}
+	return md.Set(ap, n)
+}
+
+// annotationPath returns the gNMI path corresponding to the data tree path p,
+// which is used as the key of the node within an annotation field.
+func annotationPath(p []string) *gpb.Path {
+	ap := &gpb.Path{}
+	for _, e := range p {
+		ap.Elem = append(ap.Elem, &gpb.PathElem{Name: e})
+	}
+	return ap
+}
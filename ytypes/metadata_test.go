@@ -0,0 +1,258 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// annotatedSchema returns the schema used to test the unmarshalling of
+// RFC7952 annotations.
+func annotatedSchema() *yang.Entry {
+	schema := &yang.Entry{
+		Name: "parent",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"name": {
+				Name: "name",
+				Kind: yang.LeafEntry,
+				Type: &yang.YangType{Kind: yang.Ystring},
+			},
+			"tags": {
+				Name:     "tags",
+				Kind:     yang.LeafEntry,
+				ListAttr: &yang.ListAttr{},
+				Type:     &yang.YangType{Kind: yang.Ystring},
+			},
+			"child": {
+				Name: "child",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"config": {
+						Name: "config",
+						Kind: yang.DirectoryEntry,
+						Dir: map[string]*yang.Entry{
+							"value": {
+								Name: "value",
+								Kind: yang.LeafEntry,
+								Type: &yang.YangType{Kind: yang.Yint32},
+							},
+						},
+					},
+				},
+			},
+			"entry": {
+				Name:     "entry",
+				Kind:     yang.DirectoryEntry,
+				ListAttr: &yang.ListAttr{},
+				Key:      "key",
+				Dir: map[string]*yang.Entry{
+					"key": {
+						Name: "key",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Ystring},
+					},
+				},
+			},
+		},
+	}
+	populateParentField(nil, schema)
+	return schema
+}
+
+type annotatedEntry struct {
+	Key       *string           `path:"key"`
+	ΛMetadata ygot.NodeMetadata `ygotAnnotation:"true"`
+}
+
+func (*annotatedEntry) IsYANGGoStruct() {}
+
+type annotatedChild struct {
+	Value     *int32            `path:"config/value"`
+	ΛMetadata ygot.NodeMetadata `ygotAnnotation:"true"`
+}
+
+func (*annotatedChild) IsYANGGoStruct() {}
+
+type annotatedParent struct {
+	Name      *string                    `path:"name"`
+	Tags      []string                   `path:"tags"`
+	Child     *annotatedChild            `path:"child"`
+	Entry     map[string]*annotatedEntry `path:"entry"`
+	ΛMetadata ygot.NodeMetadata          `ygotAnnotation:"true"`
+}
+
+func (*annotatedParent) IsYANGGoStruct() {}
+
+// unannotatedParent is a struct that does not store annotations.
+type unannotatedParent struct {
+	Name *string `path:"name"`
+}
+
+func (*unannotatedParent) IsYANGGoStruct() {}
+
+func TestUnmarshalAnnotations(t *testing.T) {
+	schema := annotatedSchema()
+	ann := func(kv ...string) *ygot.Metadata {
+		m := &ygot.Metadata{Annotations: map[string]interface{}{}}
+		for i := 0; i < len(kv); i += 2 {
+			m.Annotations[kv[i]] = kv[i+1]
+		}
+		return m
+	}
+
+	tests := []struct {
+		desc    string
+		inJSON  string
+		inOpts  []UnmarshalOpt
+		inValue interface{}
+		want    interface{}
+		wantErr bool
+	}{{
+		desc: "annotations of all nodes",
+		inJSON: `{
+			"@": {"m:a": "parent"},
+			"name": "n",
+			"@m:name": {"m:a": "name"},
+			"tags": ["x", "y"],
+			"@tags": [{"m:a": "x"}, null],
+			"child": {
+				"@": {"m:a": "child"},
+				"config": {"value": 1, "@value": {"m:a": "value"}}
+			},
+			"entry": [{"key": "k", "@": {"m:a": "entry"}}]
+		}`,
+		inValue: &annotatedParent{},
+		want: &annotatedParent{
+			Name: ygot.String("n"),
+			Tags: []string{"x", "y"},
+			Child: &annotatedChild{
+				Value: ygot.Int32(1),
+				ΛMetadata: ygot.NodeMetadata{
+					"/":             ann("m:a", "child"),
+					"/config/value": ann("m:a", "value"),
+				},
+			},
+			Entry: map[string]*annotatedEntry{
+				"k": {Key: ygot.String("k"), ΛMetadata: ygot.NodeMetadata{"/": ann("m:a", "entry")}},
+			},
+			ΛMetadata: ygot.NodeMetadata{
+				"/":     ann("m:a", "parent"),
+				"/name": ann("m:a", "name"),
+				"/tags": ann("m:a", "x"),
+			},
+		},
+	}, {
+		desc:   "existing timestamps are retained",
+		inJSON: `{"name": "n", "@name": {"m:a": "new"}}`,
+		inValue: &annotatedParent{ΛMetadata: ygot.NodeMetadata{
+			"/name": {Timestamp: 42, Annotations: map[string]interface{}{"m:a": "old"}},
+		}},
+		want: &annotatedParent{Name: ygot.String("n"), ΛMetadata: ygot.NodeMetadata{
+			"/name": {Timestamp: 42, Annotations: map[string]interface{}{"m:a": "new"}},
+		}},
+	}, {
+		desc:   "annotations removed within replaced subtree",
+		inJSON: `{"child": {"config": {"value": 2}}}`,
+		inOpts: []UnmarshalOpt{Replace{Path: "/parent/child"}},
+		inValue: &annotatedParent{Child: &annotatedChild{ΛMetadata: ygot.NodeMetadata{
+			"/config/value": ann("m:a", "old"),
+		}}},
+		want: &annotatedParent{Child: &annotatedChild{Value: ygot.Int32(2)}},
+	}, {
+		desc:    "annotations ignored for struct without annotation field",
+		inJSON:  `{"@": {"m:a": "parent"}, "name": "n", "@name": {"m:a": "name"}}`,
+		inValue: &unannotatedParent{},
+		want:    &unannotatedParent{Name: ygot.String("n")},
+	}, {
+		desc:    "annotation of unknown member",
+		inJSON:  `{"@unknown": {"m:a": "name"}}`,
+		inValue: &annotatedParent{},
+		wantErr: true,
+	}, {
+		desc:    "annotation without module name",
+		inJSON:  `{"name": "n", "@name": {"a": "name"}}`,
+		inValue: &annotatedParent{},
+		wantErr: true,
+	}, {
+		desc:    "annotations that are not an object",
+		inJSON:  `{"@": "parent"}`,
+		inValue: &annotatedParent{},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		var jsonTree interface{}
+		if err := json.Unmarshal([]byte(tt.inJSON), &jsonTree); err != nil {
+			t.Fatalf("%s: cannot unmarshal input JSON: %v", tt.desc, err)
+		}
+		err := Unmarshal(schema, tt.inValue, jsonTree, tt.inOpts...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Unmarshal: got error: %v, want error: %v", tt.desc, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(tt.inValue, tt.want); diff != "" {
+			t.Errorf("%s: Unmarshal: did not get expected value, diff(-got,+want):\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestAnnotationsRoundTrip(t *testing.T) {
+	schema := annotatedSchema()
+	in := &annotatedParent{
+		Name: ygot.String("n"),
+		Tags: []string{"x"},
+		Child: &annotatedChild{
+			Value:     ygot.Int32(1),
+			ΛMetadata: ygot.NodeMetadata{"/config/value": {Annotations: map[string]interface{}{"ietf-origin:origin": "ietf-origin:intended"}}},
+		},
+		ΛMetadata: ygot.NodeMetadata{
+			"/":     {Annotations: map[string]interface{}{"m:a": "parent"}},
+			"/tags": {Annotations: map[string]interface{}{"m:a": "tags"}},
+		},
+	}
+
+	j, err := ygot.ConstructIETFJSON(in, nil)
+	if err != nil {
+		t.Fatalf("ConstructIETFJSON(%v): got unexpected error: %v", in, err)
+	}
+	js, err := json.Marshal(j)
+	if err != nil {
+		t.Fatalf("json.Marshal(%v): got unexpected error: %v", j, err)
+	}
+	var jsonTree interface{}
+	if err := json.Unmarshal(js, &jsonTree); err != nil {
+		t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+
+	got := &annotatedParent{}
+	if err := Unmarshal(schema, got, jsonTree); err != nil {
+		t.Fatalf("Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+	if diff := pretty.Compare(got, in); diff != "" {
+		t.Errorf("Unmarshal(%s): did not get expected value, diff(-got,+want):\n%s", js, diff)
+	}
+	if errs := Validate(schema, got); errs != nil {
+		t.Errorf("Validate(%v): got unexpected errors: %v", got, errs)
+	}
+}
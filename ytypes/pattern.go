@@ -0,0 +1,692 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc7950#section-9.4.5 and
+// https://www.w3.org/TR/2004/REC-xmlschema-2-20041028/#regexs.
+
+// InvertMatchAnnotation is the key of the annotation of a yang.Entry that
+// lists the patterns of the entry's type that have the YANG 1.1 "modifier
+// invert-match" statement, such that a value is only valid if it does not
+// match them. The value of the annotation is a []string (or []interface{} of
+// strings, as is unmarshalled from JSON) of the patterns. The annotation is
+// set by ygen, since the modifier statement is not represented within a
+// goyang YangType.
+const InvertMatchAnnotation = "invert-match"
+
+// patternCache stores the compiled regular expression for each YANG pattern
+// that has been validated, such that each pattern is only compiled once. The
+// cache is keyed by the pattern string, and hence is bounded by the number of
+// distinct patterns in the schemas that are used.
+var patternCache = struct {
+	sync.RWMutex
+	m map[string]*regexp.Regexp
+}{m: map[string]*regexp.Regexp{}}
+
+// compiledPatterns returns the compiled regular expressions for the patterns
+// of the type t, in the same order as t.Pattern.
+func compiledPatterns(t *yang.YangType) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range t.Pattern {
+		r, err := compiledPattern(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// compiledPattern returns the compiled regular expression for the YANG
+// pattern p, compiling it and storing it in the cache if it has not been
+// compiled before. Patterns that fail to compile are not cached.
+func compiledPattern(p string) (*regexp.Regexp, error) {
+	patternCache.RLock()
+	r, ok := patternCache.m[p]
+	patternCache.RUnlock()
+	if ok {
+		return r, nil
+	}
+
+	// The pattern is compiled without holding the lock, such that the
+	// validation of other patterns is not blocked. Where the pattern is
+	// compiled concurrently, the first result to be stored is used.
+	r, err := compilePattern(p)
+	if err != nil {
+		return nil, err
+	}
+
+	patternCache.Lock()
+	defer patternCache.Unlock()
+	if cached, ok := patternCache.m[p]; ok {
+		return cached, nil
+	}
+	patternCache.m[p] = r
+	return r, nil
+}
+
+// compilePattern compiles the YANG pattern p, which is an XSD regular
+// expression, to an equivalent Go regular expression that matches the whole
+// of its input.
+func compilePattern(p string) (*regexp.Regexp, error) {
+	s, err := translateXSDRegexp(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+	}
+	r, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+	}
+	return r, nil
+}
+
+// invertMatchPatterns returns the set of patterns of the type of schema that
+// have the invert-match modifier, as specified by the InvertMatchAnnotation
+// of schema.
+func invertMatchPatterns(schema *yang.Entry) map[string]bool {
+	inv := map[string]bool{}
+	switch ps := schema.Annotation[InvertMatchAnnotation].(type) {
+	case []string:
+		for _, p := range ps {
+			inv[p] = true
+		}
+	case []interface{}:
+		for _, p := range ps {
+			if s, ok := p.(string); ok {
+				inv[s] = true
+			}
+		}
+	}
+	return inv
+}
+
+// translateXSDRegexp translates the XSD regular expression p into an
+// equivalent regular expression using the RE2 syntax of the Go regexp
+// package. XSD regular expressions are implicitly anchored at both ends, so
+// the expression returned is anchored. Since many YANG modules use a leading
+// ^ and a trailing $ as though they were anchors (where XSD treats them as
+// normal characters), they are treated as anchors when they appear at the
+// start and end of p respectively.
+//
+// Character class escapes (e.g., \d, \i and \c), Unicode categories and
+// blocks (e.g., \p{Lu} and \p{IsBasicLatin}) and character class subtraction
+// are translated to explicit sets of characters. An error is returned for
+// expressions that are not valid XSD regular expressions, or that use
+// constructs that cannot be translated.
+func translateXSDRegexp(p string) (string, error) {
+	if strings.HasPrefix(p, "^") {
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "$") && !escaped(p, len(p)-1) {
+		p = p[:len(p)-1]
+	}
+
+	x := &xsdTranslator{in: []rune(p)}
+	x.out.WriteString("^(?:")
+	if err := x.regExp(); err != nil {
+		return "", err
+	}
+	if !x.done() {
+		return "", x.errorf("unexpected %q", x.peek())
+	}
+	x.out.WriteString(")$")
+	return x.out.String(), nil
+}
+
+// escaped reports whether the character at index i of p is escaped by an odd
+// number of preceding backslashes.
+func escaped(p string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && p[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// xsdTranslator stores the state of the translation of an XSD regular
+// expression.
+type xsdTranslator struct {
+	// in is the expression being translated, and pos is the position of the
+	// next rune within in to be consumed.
+	in  []rune
+	pos int
+	// out is the translated expression.
+	out bytes.Buffer
+}
+
+func (x *xsdTranslator) done() bool { return x.pos >= len(x.in) }
+func (x *xsdTranslator) peek() rune { return x.in[x.pos] }
+
+func (x *xsdTranslator) next() rune {
+	r := x.in[x.pos]
+	x.pos++
+	return r
+}
+
+// errorf returns an error describing a problem at the current position.
+func (x *xsdTranslator) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at offset %d: %s", x.pos, fmt.Sprintf(format, args...))
+}
+
+// regExp translates the production regExp ::= branch ( '|' branch )*.
+func (x *xsdTranslator) regExp() error {
+	for {
+		if err := x.branch(); err != nil {
+			return err
+		}
+		if x.done() || x.peek() != '|' {
+			return nil
+		}
+		x.next()
+		x.out.WriteRune('|')
+	}
+}
+
+// branch translates the production branch ::= piece*.
+func (x *xsdTranslator) branch() error {
+	for !x.done() && x.peek() != '|' && x.peek() != ')' {
+		if err := x.piece(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// piece translates the production piece ::= atom quantifier?.
+func (x *xsdTranslator) piece() error {
+	if err := x.atom(); err != nil {
+		return err
+	}
+	if x.done() {
+		return nil
+	}
+	switch r := x.peek(); r {
+	case '?', '*', '+':
+		x.next()
+		x.out.WriteRune(r)
+	case '{':
+		return x.quantity()
+	}
+	return nil
+}
+
+// quantity translates a quantifier of the form {n}, {n,} or {n,m}.
+func (x *xsdTranslator) quantity() error {
+	start := x.pos
+	x.next()
+	end := start + 1
+	for end < len(x.in) && x.in[end] != '}' {
+		end++
+	}
+	if end == len(x.in) {
+		return x.errorf("unterminated quantifier")
+	}
+	q := string(x.in[start+1 : end])
+	parts := strings.SplitN(q, ",", 2)
+	min, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return x.errorf("invalid quantifier {%s}", q)
+	}
+	if len(parts) == 2 && parts[1] != "" {
+		max, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil || max < min {
+			return x.errorf("invalid quantifier {%s}", q)
+		}
+	}
+	x.pos = end + 1
+	fmt.Fprintf(&x.out, "{%s}", q)
+	return nil
+}
+
+// atom translates the production atom ::= Char | charClass | '(' regExp ')'.
+func (x *xsdTranslator) atom() error {
+	switch r := x.next(); r {
+	case '(':
+		x.out.WriteString("(?:")
+		if err := x.regExp(); err != nil {
+			return err
+		}
+		if x.done() || x.next() != ')' {
+			return x.errorf("missing closing )")
+		}
+		x.out.WriteRune(')')
+	case ')', '?', '*', '+', ']':
+		return x.errorf("unexpected %q", r)
+	case '{':
+		return x.errorf("quantifier without preceding atom")
+	case '.':
+		// The wildcard matches any character other than newline and
+		// carriage return.
+		x.out.WriteString(`[^\n\r]`)
+	case '[':
+		s, err := x.charClassExpr()
+		if err != nil {
+			return err
+		}
+		x.out.WriteString(s.String())
+	case '\\':
+		s, single, err := x.escape()
+		if err != nil {
+			return err
+		}
+		if single {
+			x.out.WriteString(regexp.QuoteMeta(string(s[0].lo)))
+			return nil
+		}
+		x.out.WriteString(s.String())
+	default:
+		x.out.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	return nil
+}
+
+// charClassExpr translates a character class expression, the opening [ of
+// which has been consumed, returning the set of characters it matches. It
+// implements the productions:
+//
+//	charClassExpr ::= '[' charGroup ']'
+//	charGroup     ::= posCharGroup | negCharGroup | charClassSub
+//	charClassSub  ::= ( posCharGroup | negCharGroup ) '-' charClassExpr
+func (x *xsdTranslator) charClassExpr() (runeSet, error) {
+	negate := false
+	if !x.done() && x.peek() == '^' {
+		x.next()
+		negate = true
+	}
+
+	var set runeSet
+	for first := true; ; first = false {
+		if x.done() {
+			return nil, x.errorf("missing closing ]")
+		}
+		r := x.next()
+		switch {
+		case r == ']':
+			if first {
+				return nil, x.errorf("empty character class")
+			}
+			if negate {
+				set = set.negate()
+			}
+			return set, nil
+		case r == '-' && !first && !x.done() && x.peek() == '[':
+			// Character class subtraction, which must be the last part of
+			// the character group.
+			x.next()
+			sub, err := x.charClassExpr()
+			if err != nil {
+				return nil, err
+			}
+			if x.done() || x.next() != ']' {
+				return nil, x.errorf("character class subtraction must be the last part of a character class")
+			}
+			if negate {
+				set = set.negate()
+			}
+			return set.subtract(sub), nil
+		case r == '-' && !first && !x.done() && x.peek() != ']':
+			// A - is only a normal character at the start or end of a
+			// character group.
+			return nil, x.errorf("unescaped - within character class")
+		case r == '[':
+			return nil, x.errorf("unescaped [ within character class")
+		}
+
+		lo := r
+		if r == '\\' {
+			s, single, err := x.escape()
+			if err != nil {
+				return nil, err
+			}
+			if !single {
+				set = set.union(s)
+				continue
+			}
+			lo = s[0].lo
+		}
+
+		// Determine whether this character is the start of a range.
+		hi := lo
+		if x.pos+1 < len(x.in) && x.peek() == '-' && x.in[x.pos+1] != ']' && x.in[x.pos+1] != '[' {
+			x.next()
+			hi = x.next()
+			if hi == '\\' {
+				s, single, err := x.escape()
+				if err != nil {
+					return nil, err
+				}
+				if !single {
+					return nil, x.errorf("invalid end of character range")
+				}
+				hi = s[0].lo
+			}
+			if hi < lo {
+				return nil, x.errorf("invalid character range %q-%q", lo, hi)
+			}
+		}
+		set = set.union(runeSet{{lo, hi}})
+	}
+}
+
+// escape translates an escape sequence, the \ of which has been consumed. It
+// returns the set of characters matched by the escape, and whether the escape
+// is a single character escape.
+func (x *xsdTranslator) escape() (runeSet, bool, error) {
+	if x.done() {
+		return nil, false, x.errorf("trailing \\")
+	}
+	switch r := x.next(); r {
+	case 'n':
+		return runeSet{{'\n', '\n'}}, true, nil
+	case 'r':
+		return runeSet{{'\r', '\r'}}, true, nil
+	case 't':
+		return runeSet{{'\t', '\t'}}, true, nil
+	case '\\', '|', '.', '?', '*', '+', '(', ')', '{', '}', '-', '[', ']', '^', '$':
+		return runeSet{{r, r}}, true, nil
+	case 's', 'S', 'i', 'I', 'c', 'C', 'd', 'D', 'w', 'W':
+		s := multiCharEscape(unicode.ToLower(r))
+		if unicode.IsUpper(r) {
+			s = s.negate()
+		}
+		return s, false, nil
+	case 'p', 'P':
+		s, err := x.charProp()
+		if err != nil {
+			return nil, false, err
+		}
+		if r == 'P' {
+			s = s.negate()
+		}
+		return s, false, nil
+	default:
+		return nil, false, x.errorf("unsupported escape \\%c", r)
+	}
+}
+
+// charProp translates a character property of the form {name}, returning
+// the set of characters that it matches.
+func (x *xsdTranslator) charProp() (runeSet, error) {
+	if x.done() || x.next() != '{' {
+		return nil, x.errorf("missing { after character property escape")
+	}
+	start := x.pos
+	for !x.done() && x.peek() != '}' {
+		x.next()
+	}
+	if x.done() {
+		return nil, x.errorf("unterminated character property")
+	}
+	name := string(x.in[start:x.pos])
+	x.next()
+
+	if strings.HasPrefix(name, "Is") {
+		ranges, ok := xsdBlocks[name[2:]]
+		if !ok {
+			return nil, x.errorf("unsupported Unicode block %s", name)
+		}
+		return ranges.normalise(), nil
+	}
+	t, ok := unicode.Categories[name]
+	if !ok {
+		return nil, x.errorf("unsupported Unicode category %s", name)
+	}
+	return tableToRuneSet(t), nil
+}
+
+// multiCharEscape returns the set of characters matched by the lower case
+// multi-character escape \r.
+func multiCharEscape(r rune) runeSet {
+	switch r {
+	case 's':
+		return runeSet{{' ', ' '}, {'\t', '\t'}, {'\n', '\n'}, {'\r', '\r'}}.normalise()
+	case 'i':
+		return xmlNameStartChars
+	case 'c':
+		return xmlNameChars
+	case 'd':
+		return tableToRuneSet(unicode.Nd)
+	default:
+		// \w matches all characters other than punctuation, separators and
+		// other characters.
+		return tableToRuneSet(unicode.P).union(tableToRuneSet(unicode.Z)).union(tableToRuneSet(unicode.C)).negate()
+	}
+}
+
+// runeRange is an inclusive range of characters.
+type runeRange struct {
+	lo, hi rune
+}
+
+// runeSet is a set of characters, stored as a sorted slice of non-overlapping
+// ranges.
+type runeSet []runeRange
+
+// normalise returns s sorted, with overlapping and adjacent ranges merged.
+func (s runeSet) normalise() runeSet {
+	c := append(runeSet{}, s...)
+	sort.Slice(c, func(i, j int) bool { return c[i].lo < c[j].lo })
+	var out runeSet
+	for _, r := range c {
+		if n := len(out); n > 0 && r.lo <= out[n-1].hi+1 {
+			if r.hi > out[n-1].hi {
+				out[n-1].hi = r.hi
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// union returns the set of characters that are in either s or o.
+func (s runeSet) union(o runeSet) runeSet {
+	return append(append(runeSet{}, s...), o...).normalise()
+}
+
+// negate returns the set of characters that are not in s.
+func (s runeSet) negate() runeSet {
+	var out runeSet
+	next := rune(0)
+	for _, r := range s.normalise() {
+		if r.lo > next {
+			out = append(out, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, runeRange{next, unicode.MaxRune})
+	}
+	return out
+}
+
+// subtract returns the set of characters that are in s, but not in o.
+func (s runeSet) subtract(o runeSet) runeSet {
+	return s.negate().union(o).negate()
+}
+
+// String returns the set as a Go regular expression character class.
+func (s runeSet) String() string {
+	if len(s) == 0 {
+		// An empty set matches no characters.
+		return `[^\x{0}-\x{10ffff}]`
+	}
+	var b bytes.Buffer
+	b.WriteRune('[')
+	for _, r := range s {
+		fmt.Fprintf(&b, `\x{%x}`, r.lo)
+		if r.hi != r.lo {
+			fmt.Fprintf(&b, `-\x{%x}`, r.hi)
+		}
+	}
+	b.WriteRune(']')
+	return b.String()
+}
+
+// tableToRuneSet returns the set of characters within the Unicode table t.
+func tableToRuneSet(t *unicode.RangeTable) runeSet {
+	var s runeSet
+	for _, r := range t.R16 {
+		for c := rune(r.Lo); c <= rune(r.Hi); c += rune(r.Stride) {
+			if r.Stride == 1 {
+				s = append(s, runeRange{c, rune(r.Hi)})
+				break
+			}
+			s = append(s, runeRange{c, c})
+		}
+	}
+	for _, r := range t.R32 {
+		for c := rune(r.Lo); c <= rune(r.Hi); c += rune(r.Stride) {
+			if r.Stride == 1 {
+				s = append(s, runeRange{c, rune(r.Hi)})
+				break
+			}
+			s = append(s, runeRange{c, c})
+		}
+	}
+	return s.normalise()
+}
+
+var (
+	// xmlNameStartChars is the set of characters matched by \i, which is
+	// NameStartChar as defined by XML 1.0 (Fifth Edition).
+	xmlNameStartChars = runeSet{
+		{':', ':'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}, {0xC0, 0xD6},
+		{0xD8, 0xF6}, {0xF8, 0x2FF}, {0x370, 0x37D}, {0x37F, 0x1FFF},
+		{0x200C, 0x200D}, {0x2070, 0x218F}, {0x2C00, 0x2FEF},
+		{0x3001, 0xD7FF}, {0xF900, 0xFDCF}, {0xFDF0, 0xFFFD},
+		{0x10000, 0xEFFFF},
+	}.normalise()
+
+	// xmlNameChars is the set of characters matched by \c, which is
+	// NameChar as defined by XML 1.0 (Fifth Edition).
+	xmlNameChars = xmlNameStartChars.union(runeSet{
+		{'-', '-'}, {'.', '.'}, {'0', '9'}, {0xB7, 0xB7}, {0x300, 0x36F},
+		{0x203F, 0x2040},
+	})
+)
+
+// xsdBlocks is the set of Unicode blocks that may be referred to using the
+// \p{IsBlock} escape, as defined by XML Schema Part 2, keyed by the name of
+// the block with spaces removed. The surrogate blocks are omitted, since they
+// cannot be matched within UTF-8 strings.
+var xsdBlocks = map[string]runeSet{
+	"BasicLatin":                           {{0x0000, 0x007F}},
+	"Latin-1Supplement":                    {{0x0080, 0x00FF}},
+	"LatinExtended-A":                      {{0x0100, 0x017F}},
+	"LatinExtended-B":                      {{0x0180, 0x024F}},
+	"IPAExtensions":                        {{0x0250, 0x02AF}},
+	"SpacingModifierLetters":               {{0x02B0, 0x02FF}},
+	"CombiningDiacriticalMarks":            {{0x0300, 0x036F}},
+	"Greek":                                {{0x0370, 0x03FF}},
+	"Cyrillic":                             {{0x0400, 0x04FF}},
+	"Armenian":                             {{0x0530, 0x058F}},
+	"Hebrew":                               {{0x0590, 0x05FF}},
+	"Arabic":                               {{0x0600, 0x06FF}},
+	"Syriac":                               {{0x0700, 0x074F}},
+	"Thaana":                               {{0x0780, 0x07BF}},
+	"Devanagari":                           {{0x0900, 0x097F}},
+	"Bengali":                              {{0x0980, 0x09FF}},
+	"Gurmukhi":                             {{0x0A00, 0x0A7F}},
+	"Gujarati":                             {{0x0A80, 0x0AFF}},
+	"Oriya":                                {{0x0B00, 0x0B7F}},
+	"Tamil":                                {{0x0B80, 0x0BFF}},
+	"Telugu":                               {{0x0C00, 0x0C7F}},
+	"Kannada":                              {{0x0C80, 0x0CFF}},
+	"Malayalam":                            {{0x0D00, 0x0D7F}},
+	"Sinhala":                              {{0x0D80, 0x0DFF}},
+	"Thai":                                 {{0x0E00, 0x0E7F}},
+	"Lao":                                  {{0x0E80, 0x0EFF}},
+	"Tibetan":                              {{0x0F00, 0x0FFF}},
+	"Myanmar":                              {{0x1000, 0x109F}},
+	"Georgian":                             {{0x10A0, 0x10FF}},
+	"HangulJamo":                           {{0x1100, 0x11FF}},
+	"Ethiopic":                             {{0x1200, 0x137F}},
+	"Cherokee":                             {{0x13A0, 0x13FF}},
+	"UnifiedCanadianAboriginalSyllabics":   {{0x1400, 0x167F}},
+	"Ogham":                                {{0x1680, 0x169F}},
+	"Runic":                                {{0x16A0, 0x16FF}},
+	"Khmer":                                {{0x1780, 0x17FF}},
+	"Mongolian":                            {{0x1800, 0x18AF}},
+	"LatinExtendedAdditional":              {{0x1E00, 0x1EFF}},
+	"GreekExtended":                        {{0x1F00, 0x1FFF}},
+	"GeneralPunctuation":                   {{0x2000, 0x206F}},
+	"SuperscriptsandSubscripts":            {{0x2070, 0x209F}},
+	"CurrencySymbols":                      {{0x20A0, 0x20CF}},
+	"CombiningMarksforSymbols":             {{0x20D0, 0x20FF}},
+	"LetterlikeSymbols":                    {{0x2100, 0x214F}},
+	"NumberForms":                          {{0x2150, 0x218F}},
+	"Arrows":                               {{0x2190, 0x21FF}},
+	"MathematicalOperators":                {{0x2200, 0x22FF}},
+	"MiscellaneousTechnical":               {{0x2300, 0x23FF}},
+	"ControlPictures":                      {{0x2400, 0x243F}},
+	"OpticalCharacterRecognition":          {{0x2440, 0x245F}},
+	"EnclosedAlphanumerics":                {{0x2460, 0x24FF}},
+	"BoxDrawing":                           {{0x2500, 0x257F}},
+	"BlockElements":                        {{0x2580, 0x259F}},
+	"GeometricShapes":                      {{0x25A0, 0x25FF}},
+	"MiscellaneousSymbols":                 {{0x2600, 0x26FF}},
+	"Dingbats":                             {{0x2700, 0x27BF}},
+	"BraillePatterns":                      {{0x2800, 0x28FF}},
+	"CJKRadicalsSupplement":                {{0x2E80, 0x2EFF}},
+	"KangxiRadicals":                       {{0x2F00, 0x2FDF}},
+	"IdeographicDescriptionCharacters":     {{0x2FF0, 0x2FFF}},
+	"CJKSymbolsandPunctuation":             {{0x3000, 0x303F}},
+	"Hiragana":                             {{0x3040, 0x309F}},
+	"Katakana":                             {{0x30A0, 0x30FF}},
+	"Bopomofo":                             {{0x3100, 0x312F}},
+	"HangulCompatibilityJamo":              {{0x3130, 0x318F}},
+	"Kanbun":                               {{0x3190, 0x319F}},
+	"BopomofoExtended":                     {{0x31A0, 0x31BF}},
+	"EnclosedCJKLettersandMonths":          {{0x3200, 0x32FF}},
+	"CJKCompatibility":                     {{0x3300, 0x33FF}},
+	"CJKUnifiedIdeographsExtensionA":       {{0x3400, 0x4DB5}},
+	"CJKUnifiedIdeographs":                 {{0x4E00, 0x9FFF}},
+	"YiSyllables":                          {{0xA000, 0xA48F}},
+	"YiRadicals":                           {{0xA490, 0xA4CF}},
+	"HangulSyllables":                      {{0xAC00, 0xD7A3}},
+	"PrivateUse":                           {{0xE000, 0xF8FF}, {0xF0000, 0xFFFFD}, {0x100000, 0x10FFFD}},
+	"CJKCompatibilityIdeographs":           {{0xF900, 0xFAFF}},
+	"AlphabeticPresentationForms":          {{0xFB00, 0xFB4F}},
+	"ArabicPresentationForms-A":            {{0xFB50, 0xFDFF}},
+	"CombiningHalfMarks":                   {{0xFE20, 0xFE2F}},
+	"CJKCompatibilityForms":                {{0xFE30, 0xFE4F}},
+	"SmallFormVariants":                    {{0xFE50, 0xFE6F}},
+	"ArabicPresentationForms-B":            {{0xFE70, 0xFEFE}},
+	"Specials":                             {{0xFEFF, 0xFEFF}, {0xFFF0, 0xFFFD}},
+	"HalfwidthandFullwidthForms":           {{0xFF00, 0xFFEF}},
+	"OldItalic":                            {{0x10300, 0x1032F}},
+	"Gothic":                               {{0x10330, 0x1034F}},
+	"Deseret":                              {{0x10400, 0x1044F}},
+	"ByzantineMusicalSymbols":              {{0x1D000, 0x1D0FF}},
+	"MusicalSymbols":                       {{0x1D100, 0x1D1FF}},
+	"MathematicalAlphanumericSymbols":      {{0x1D400, 0x1D7FF}},
+	"CJKUnifiedIdeographsExtensionB":       {{0x20000, 0x2A6D6}},
+	"CJKCompatibilityIdeographsSupplement": {{0x2F800, 0x2FA1F}},
+	"Tags":                                 {{0xE0000, 0xE007F}},
+}
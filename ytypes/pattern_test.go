@@ -0,0 +1,314 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		desc          string
+		pattern       string
+		wantMatch     []string
+		wantNoMatch   []string
+		wantErrSubstr string
+	}{{
+		desc:        "implicitly anchored",
+		pattern:     `[0-9]+`,
+		wantMatch:   []string{"0", "123"},
+		wantNoMatch: []string{"", "a1", "1a"},
+	}, {
+		desc:        "alternation is within the anchors",
+		pattern:     `a|b`,
+		wantMatch:   []string{"a", "b"},
+		wantNoMatch: []string{"ab", "ba"},
+	}, {
+		desc:        "wildcard does not match newlines",
+		pattern:     `a.c`,
+		wantMatch:   []string{"abc", "a.c"},
+		wantNoMatch: []string{"a\nc", "a\rc"},
+	}, {
+		desc:        "Unicode block",
+		pattern:     `\p{IsBasicLatin}+`,
+		wantMatch:   []string{"abc~"},
+		wantNoMatch: []string{"café"},
+	}, {
+		desc:        "negated Unicode block",
+		pattern:     `\P{IsGreek}+`,
+		wantMatch:   []string{"abc"},
+		wantNoMatch: []string{"αβγ"},
+	}, {
+		desc:        "Unicode category",
+		pattern:     `\p{Lu}\p{Ll}*`,
+		wantMatch:   []string{"Hello", "Éa"},
+		wantNoMatch: []string{"hello"},
+	}, {
+		desc:        "XML name characters",
+		pattern:     `\i\c*`,
+		wantMatch:   []string{"_a-1.b", "ns:name", "é"},
+		wantNoMatch: []string{"1a", "-a", "a b"},
+	}, {
+		desc:        "negated XML name start characters",
+		pattern:     `\I+`,
+		wantMatch:   []string{"1-"},
+		wantNoMatch: []string{"a"},
+	}, {
+		desc:        "digits and whitespace",
+		pattern:     `\d+\s\S`,
+		wantMatch:   []string{"12 a", "٣\tb"},
+		wantNoMatch: []string{"12  a", "1a a"},
+	}, {
+		desc:        "word characters",
+		pattern:     `\w+`,
+		wantMatch:   []string{"ab1", "ü"},
+		wantNoMatch: []string{"a-b", "a b"},
+	}, {
+		desc:        "character class subtraction",
+		pattern:     `[a-z-[aeiou]]+`,
+		wantMatch:   []string{"bcd"},
+		wantNoMatch: []string{"bad"},
+	}, {
+		desc:        "negated character class subtraction",
+		pattern:     `[^0-9-[a-c]]`,
+		wantMatch:   []string{"d"},
+		wantNoMatch: []string{"a", "1"},
+	}, {
+		desc:        "nested character class subtraction",
+		pattern:     `[a-z-[a-f-[c]]]+`,
+		wantMatch:   []string{"cxyz"},
+		wantNoMatch: []string{"abc"},
+	}, {
+		desc:        "multi-character escape within character class",
+		pattern:     `[\d\-_]+`,
+		wantMatch:   []string{"1-2_3"},
+		wantNoMatch: []string{"a"},
+	}, {
+		desc:        "hyphen at the start and end of character class",
+		pattern:     `[-a][b-]`,
+		wantMatch:   []string{"-b", "a-"},
+		wantNoMatch: []string{"bb"},
+	}, {
+		desc:        "caret and dollar are normal characters",
+		pattern:     `a^b$c`,
+		wantMatch:   []string{"a^b$c"},
+		wantNoMatch: []string{"abc"},
+	}, {
+		desc:        "leading caret and trailing dollar are anchors",
+		pattern:     `^ab$`,
+		wantMatch:   []string{"ab"},
+		wantNoMatch: []string{"^ab$"},
+	}, {
+		desc:        "escaped trailing dollar",
+		pattern:     `a\$`,
+		wantMatch:   []string{"a$"},
+		wantNoMatch: []string{"a"},
+	}, {
+		desc:        "quantifiers",
+		pattern:     `a{2}b{1,}c{0,2}(de)?`,
+		wantMatch:   []string{"aab", "aabbbcc", "aabde"},
+		wantNoMatch: []string{"ab", "aabccc"},
+	}, {
+		desc:          "unsupported Unicode block",
+		pattern:       `\p{IsHighSurrogates}`,
+		wantErrSubstr: "unsupported Unicode block IsHighSurrogates",
+	}, {
+		desc:          "unsupported Unicode category",
+		pattern:       `\p{Xx}`,
+		wantErrSubstr: "unsupported Unicode category Xx",
+	}, {
+		desc:          "unsupported escape",
+		pattern:       `\b`,
+		wantErrSubstr: `unsupported escape \b`,
+	}, {
+		desc:          "unbalanced parentheses",
+		pattern:       `(^(.*)`,
+		wantErrSubstr: "missing closing )",
+	}, {
+		desc:          "unterminated character class",
+		pattern:       `[abc`,
+		wantErrSubstr: "missing closing ]",
+	}, {
+		desc:          "empty character class",
+		pattern:       `[]`,
+		wantErrSubstr: "empty character class",
+	}, {
+		desc:          "invalid character range",
+		pattern:       `[z-a]`,
+		wantErrSubstr: "invalid character range",
+	}, {
+		desc:          "invalid quantifier",
+		pattern:       `a{2,1}`,
+		wantErrSubstr: "invalid quantifier {2,1}",
+	}, {
+		desc:          "quantifier without atom",
+		pattern:       `*a`,
+		wantErrSubstr: `unexpected '*'`,
+	}, {
+		desc:          "subtraction that is not last",
+		pattern:       `[a-z-[aeiou]b]`,
+		wantErrSubstr: "character class subtraction must be the last part",
+	}}
+
+	for _, tt := range tests {
+		r, err := compilePattern(tt.pattern)
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: compilePattern(%q): got unexpected error: %v, want error containing: %q", tt.desc, tt.pattern, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: compilePattern(%q): did not get expected error, got: %s, want error containing: %q", tt.desc, tt.pattern, r, tt.wantErrSubstr)
+			continue
+		}
+		for _, s := range tt.wantMatch {
+			if !r.MatchString(s) {
+				t.Errorf("%s: compilePattern(%q): %q did not match, want match", tt.desc, tt.pattern, s)
+			}
+		}
+		for _, s := range tt.wantNoMatch {
+			if r.MatchString(s) {
+				t.Errorf("%s: compilePattern(%q): %q matched, want no match", tt.desc, tt.pattern, s)
+			}
+		}
+	}
+}
+
+func TestValidateStringInvertMatch(t *testing.T) {
+	tests := []struct {
+		desc          string
+		inAnnotation  interface{}
+		inVal         string
+		wantErrSubstr string
+	}{{
+		desc:         "value matching pattern without inverted patterns",
+		inAnnotation: nil,
+		inVal:        "xml-doc",
+	}, {
+		desc:          "value not matching pattern without inverted patterns",
+		inAnnotation:  nil,
+		inVal:         "1doc",
+		wantErrSubstr: `"1doc" does not match regular expression pattern "[a-zA-Z_][a-zA-Z0-9\\-_.]*"`,
+	}, {
+		desc:         "value not matching inverted pattern",
+		inAnnotation: []string{`[xX][mM][lL].*`},
+		inVal:        "doc",
+	}, {
+		desc:          "value matching inverted pattern",
+		inAnnotation:  []string{`[xX][mM][lL].*`},
+		inVal:         "xml-doc",
+		wantErrSubstr: `"xml-doc" matches inverted regular expression pattern "[xX][mM][lL].*"`,
+	}, {
+		desc:          "inverted patterns unmarshalled from JSON",
+		inAnnotation:  []interface{}{`[xX][mM][lL].*`},
+		inVal:         "XMLdoc",
+		wantErrSubstr: "matches inverted regular expression pattern",
+	}}
+
+	typ := &yang.YangType{
+		Kind:    yang.Ystring,
+		Pattern: []string{`[a-zA-Z_][a-zA-Z0-9\-_.]*`, `[xX][mM][lL].*`},
+	}
+	for _, tt := range tests {
+		schema := &yang.Entry{Name: "name", Type: typ}
+		if tt.inAnnotation != nil {
+			schema.Annotation = map[string]interface{}{InvertMatchAnnotation: tt.inAnnotation}
+		} else {
+			// Without the annotation, both patterns must be matched, so
+			// only the first is used.
+			schema.Type = &yang.YangType{Kind: yang.Ystring, Pattern: typ.Pattern[:1]}
+		}
+		err := validateString(schema, tt.inVal)
+		switch {
+		case err != nil && (tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr)):
+			t.Errorf("%s: validateString(%q): got unexpected error: %v, want error containing: %q", tt.desc, tt.inVal, err, tt.wantErrSubstr)
+		case err == nil && tt.wantErrSubstr != "":
+			t.Errorf("%s: validateString(%q): did not get expected error, want error containing: %q", tt.desc, tt.inVal, tt.wantErrSubstr)
+		}
+	}
+}
+
+func TestValidateUnionInvertMatch(t *testing.T) {
+	schema := &yang.Entry{
+		Name: "name",
+		Kind: yang.LeafEntry,
+		Type: &yang.YangType{
+			Kind: yang.Yunion,
+			Type: []*yang.YangType{
+				{Kind: yang.Ystring, Pattern: []string{`[xX][mM][lL].*`}},
+				{Kind: yang.Yint32},
+			},
+		},
+		Annotation: map[string]interface{}{InvertMatchAnnotation: []string{`[xX][mM][lL].*`}},
+	}
+
+	ok := "doc"
+	if errs := validateUnion(schema, &ok); errs != nil {
+		t.Errorf("validateUnion(%q): got unexpected error: %v", ok, errs)
+	}
+	bad := "xml-doc"
+	if errs := validateUnion(schema, &bad); errs == nil || !strings.Contains(errs.Error(), "matches inverted regular expression pattern") {
+		t.Errorf("validateUnion(%q): got error: %v, want error for inverted pattern", bad, errs)
+	}
+}
+
+func TestCompiledPatternsCache(t *testing.T) {
+	typ := &yang.YangType{Kind: yang.Ystring, Pattern: []string{`a+`, `[ab]+`}}
+	first, err := compiledPatterns(typ)
+	if err != nil {
+		t.Fatalf("compiledPatterns: got unexpected error: %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("compiledPatterns: got %d patterns, want 2", len(first))
+	}
+	second, err := compiledPatterns(typ)
+	if err != nil {
+		t.Fatalf("compiledPatterns: got unexpected error on second call: %v", err)
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("compiledPatterns: pattern %d was recompiled, want cached value", i)
+		}
+	}
+
+	// Patterns that cannot be compiled are not cached.
+	bad := &yang.YangType{Kind: yang.Ystring, Pattern: []string{`(a`}}
+	for i := 0; i < 2; i++ {
+		if _, err := compiledPatterns(bad); err == nil {
+			t.Errorf("compiledPatterns: call %d did not get expected error for invalid pattern", i)
+		}
+	}
+}
+
+func TestCompiledPatternsSharedAcrossTypes(t *testing.T) {
+	// Types that share a pattern use the same compiled regular expression.
+	a := &yang.YangType{Kind: yang.Ystring, Pattern: []string{`c+`}}
+	b := &yang.YangType{Kind: yang.Ystring, Pattern: []string{`d+`, `c+`}}
+	ra, err := compiledPatterns(a)
+	if err != nil {
+		t.Fatalf("compiledPatterns: got unexpected error: %v", err)
+	}
+	rb, err := compiledPatterns(b)
+	if err != nil {
+		t.Fatalf("compiledPatterns: got unexpected error: %v", err)
+	}
+	if ra[0] != rb[1] {
+		t.Errorf("compiledPatterns: pattern %q was compiled for each type, want shared value", a.Pattern[0])
+	}
+}
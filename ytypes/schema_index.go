@@ -0,0 +1,250 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// SchemaIndex indexes the schema tree of a set of generated GoStructs, such
+// that the yang.Entry of any node within the tree can be found by its
+// absolute schema path, or its data tree path, and the yang.Entry of the
+// fields of a GoStruct can be found without walking the tree. The index is
+// built when it is first used.
+type SchemaIndex struct {
+	// tree is the schema tree of the generated code, keyed by the name of
+	// the GoStruct that each yang.Entry describes.
+	tree map[string]*yang.Entry
+
+	once sync.Once
+	// schemaPaths and dataPaths store the nodes of the tree keyed by their
+	// absolute schema path and data tree path respectively.
+	schemaPaths map[string]*yang.Entry
+	dataPaths   map[string]*yang.Entry
+
+	// leafRefs stores the leafrefs found within the schema trees validated
+	// using ValidatePaths, keyed by the root of each tree.
+	leafRefsMu sync.Mutex
+	leafRefs   map[*yang.Entry]*leafRefs
+}
+
+// NewSchemaIndex returns a SchemaIndex for the schema tree of generated code,
+// which is a map of yang.Entry keyed by the name of the GoStruct that each
+// entry describes (i.e., the SchemaTree variable of the generated code).
+func NewSchemaIndex(tree map[string]*yang.Entry) *SchemaIndex {
+	return &SchemaIndex{tree: tree}
+}
+
+// build populates the path indexes of x from its schema tree.
+func (x *SchemaIndex) build() {
+	x.schemaPaths = map[string]*yang.Entry{}
+	x.dataPaths = map[string]*yang.Entry{}
+	seen := map[*yang.Entry]bool{}
+	var add func(e *yang.Entry)
+	add = func(e *yang.Entry) {
+		if e == nil || seen[e] {
+			return
+		}
+		seen[e] = true
+		if !util.IsFakeRoot(e) {
+			x.schemaPaths[SchemaTreePath(e)] = e
+			if !util.IsChoiceOrCase(e) {
+				x.dataPaths[DataTreePath(e)] = e
+			}
+		}
+		for _, ch := range e.Dir {
+			add(ch)
+		}
+		if e.RPC != nil {
+			add(e.RPC.Input)
+			add(e.RPC.Output)
+		}
+	}
+	for _, e := range x.tree {
+		add(e)
+	}
+}
+
+// BySchemaPath returns the yang.Entry at the absolute schema path p, e.g.,
+// /interfaces/interface/config/mtu. The path includes the names of any choice
+// and case statements that the node is within. Module prefixes within p are
+// ignored.
+func (x *SchemaIndex) BySchemaPath(p string) (*yang.Entry, error) {
+	x.once.Do(x.build)
+	e, ok := x.schemaPaths[normalisePath(p)]
+	if !ok {
+		return nil, fmt.Errorf("schema path %s not found", p)
+	}
+	return e, nil
+}
+
+// ByDataPath returns the yang.Entry of the node at the data tree path p,
+// which excludes the names of choice and case statements. Module prefixes and
+// list key predicates within p are ignored, such that the path
+// /interfaces/interface[name=eth0]/config/mtu is found.
+func (x *SchemaIndex) ByDataPath(p string) (*yang.Entry, error) {
+	x.once.Do(x.build)
+	np, err := util.RemoveXPATHPredicates(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data path %s: %v", p, err)
+	}
+	e, ok := x.dataPaths[normalisePath(np)]
+	if !ok {
+		return nil, fmt.Errorf("data path %s not found", p)
+	}
+	return e, nil
+}
+
+// StructSchema returns the yang.Entry that describes the GoStruct s.
+func (x *SchemaIndex) StructSchema(s ygot.GoStruct) (*yang.Entry, error) {
+	t := reflect.TypeOf(s)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	e, ok := x.tree[t.Name()]
+	if !ok {
+		return nil, fmt.Errorf("could not find schema for type %s", t.Name())
+	}
+	return e, nil
+}
+
+// FieldSchema returns the yang.Entry of the field named fieldName within the
+// GoStruct parent.
+func (x *SchemaIndex) FieldSchema(parent ygot.GoStruct, fieldName string) (*yang.Entry, error) {
+	t := reflect.TypeOf(parent)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	f, ok := t.FieldByName(fieldName)
+	if !ok {
+		return nil, fmt.Errorf("field %s not found in type %s", fieldName, t.Name())
+	}
+	return x.StructFieldSchema(parent, f)
+}
+
+// StructFieldSchema returns the yang.Entry of the struct field f of the
+// GoStruct parent.
+func (x *SchemaIndex) StructFieldSchema(parent ygot.GoStruct, f reflect.StructField) (*yang.Entry, error) {
+	ps, err := x.StructSchema(parent)
+	if err != nil {
+		return nil, err
+	}
+	e, err := util.ChildSchema(ps, f)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("could not find schema for field %s of type %T", f.Name, parent)
+	}
+	return e, nil
+}
+
+// FieldDataPaths returns the absolute data tree paths of the struct field f
+// of the GoStruct parent. A field has more than one path where it maps to
+// more than one node of the schema, e.g., a list key that is mapped to both
+// the key leaf of the list and the leaf within the config container that it
+// refers to.
+func (x *SchemaIndex) FieldDataPaths(parent ygot.GoStruct, f reflect.StructField) ([]string, error) {
+	ps, err := x.StructSchema(parent)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := util.SchemaPaths(f)
+	if err != nil {
+		return nil, err
+	}
+	// The rootname tag of the fields of the fake root is the name of the
+	// child of the fake root within the schema tree, which omits the
+	// containers that surround lists, so it is not used where the field
+	// also has a path.
+	if _, ok := f.Tag.Lookup("rootname"); ok && len(paths) > 1 {
+		paths = paths[1:]
+	}
+	base := DataTreePath(ps)
+	if util.IsFakeRoot(ps) {
+		base = ""
+	}
+	var out []string
+	for _, p := range paths {
+		out = append(out, fmt.Sprintf("%s/%s", base, strings.Join(p, "/")))
+	}
+	return out, nil
+}
+
+// ValidatePaths validates the data tree rooted at root, which is described by
+// schema, at each of the supplied paths, as per the ValidatePaths function.
+// The leafrefs within the schema tree are found when it is first validated,
+// and are stored in the index for subsequent calls, hence it should be used
+// rather than the ValidatePaths function where a data tree is validated
+// repeatedly. schema must be one of the entries of the schema tree of the
+// index.
+func (x *SchemaIndex) ValidatePaths(schema *yang.Entry, root ygot.GoStruct, paths []*gpb.Path) util.Errors {
+	if schema == nil {
+		return util.NewErrs(fmt.Errorf("nil schema for type %T", root))
+	}
+	inTree := false
+	for _, e := range x.tree {
+		if e == schema {
+			inTree = true
+			break
+		}
+	}
+	if !inTree {
+		return util.NewErrs(fmt.Errorf("schema %s is not an entry of the indexed schema tree", schema.Name))
+	}
+
+	x.leafRefsMu.Lock()
+	lr, ok := x.leafRefs[schema]
+	if !ok {
+		if x.leafRefs == nil {
+			x.leafRefs = map[*yang.Entry]*leafRefs{}
+		}
+		lr = schemaLeafRefs(schema)
+		x.leafRefs[schema] = lr
+	}
+	x.leafRefsMu.Unlock()
+	return validatePaths(schema, root, paths, lr)
+}
+
+// SchemaTreePath returns the absolute schema path of the node described by
+// e, including the names of any choice and case statements that the node is
+// within. The path does not include the name of the module, or the fake root
+// of the generated code.
+func SchemaTreePath(e *yang.Entry) string {
+	return "/" + strings.Join(util.SchemaNodePath(e), "/")
+}
+
+// DataTreePath returns the absolute path of the node described by e within
+// the data tree, which excludes the names of any choice and case statements
+// that the node is within.
+func DataTreePath(e *yang.Entry) string {
+	return "/" + strings.Join(util.SchemaDataPath(e), "/")
+}
+
+// normalisePath returns the path p with module prefixes removed from each of
+// its elements, and a leading "/".
+func normalisePath(p string) string {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	return "/" + strings.Join(util.StripModulePrefixes(parts), "/")
+}
@@ -0,0 +1,259 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+)
+
+type indexDevice struct {
+	Interface map[string]*indexInterface `path:"interfaces/interface" rootname:"interface"`
+}
+
+func (*indexDevice) IsYANGGoStruct() {}
+
+type indexInterface struct {
+	Name    *string       `path:"config/name|name"`
+	Mtu     *uint16       `path:"config/mtu"`
+	Counter *indexCounter `path:"counter"`
+	Options *indexOptions `path:"options"`
+	Unknown *string       `path:"unknown"`
+}
+
+func (*indexInterface) IsYANGGoStruct() {}
+
+type indexCounter struct {
+	Value *uint64 `path:"value"`
+}
+
+func (*indexCounter) IsYANGGoStruct() {}
+
+type indexOptions struct{}
+
+func (*indexOptions) IsYANGGoStruct() {}
+
+// indexSchemaTree returns a schema tree of the form that is unmarshalled from
+// the schema stored in generated code, keyed by the name of the type that each
+// entry describes. The fake root, indexDevice, has the interface list as its
+// direct child, such that the interfaces container is omitted from the tree.
+func indexSchemaTree() map[string]*yang.Entry {
+	leaf := func(name string) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}}
+	}
+	dir := func(name string, kind yang.EntryKind, children ...*yang.Entry) *yang.Entry {
+		e := &yang.Entry{Name: name, Kind: kind, Dir: map[string]*yang.Entry{}}
+		for _, ch := range children {
+			e.Dir[ch.Name] = ch
+			ch.Parent = e
+		}
+		return e
+	}
+
+	counter := dir("counter", yang.DirectoryEntry, leaf("value"))
+	counter.Annotation = map[string]interface{}{"schemapath": "/mod/interfaces/interface/kind/physical/counter", "structname": "indexCounter"}
+
+	intf := dir("interface", yang.DirectoryEntry,
+		leaf("name"),
+		dir("config", yang.DirectoryEntry, leaf("name"), leaf("mtu")),
+		dir("state", yang.DirectoryEntry, leaf("name"), leaf("mtu")),
+		dir("kind", yang.ChoiceEntry,
+			dir("physical", yang.CaseEntry, counter),
+		),
+		dir("options", yang.DirectoryEntry),
+	)
+	intf.Key = "name"
+	intf.ListAttr = &yang.ListAttr{}
+	intf.Annotation = map[string]interface{}{"schemapath": "/mod/interfaces/interface", "structname": "indexInterface"}
+	intf.Dir["options"].Annotation = map[string]interface{}{"schemapath": "/mod/interfaces/interface/options", "structname": "indexOptions"}
+
+	root := dir("device", yang.DirectoryEntry, intf)
+	root.Annotation = map[string]interface{}{"isFakeRoot": true, "structname": "indexDevice"}
+
+	return map[string]*yang.Entry{
+		"indexDevice":    root,
+		"indexInterface": intf,
+		"indexCounter":   counter,
+		"indexOptions":   intf.Dir["options"],
+	}
+}
+
+func TestSchemaIndexPaths(t *testing.T) {
+	tree := indexSchemaTree()
+	intf := tree["indexInterface"]
+	x := NewSchemaIndex(tree)
+
+	tests := []struct {
+		name          string
+		inPath        string
+		inData        bool
+		want          *yang.Entry
+		wantErrSubstr string
+	}{{
+		name:   "leaf by schema path",
+		inPath: "/interfaces/interface/config/mtu",
+		want:   intf.Dir["config"].Dir["mtu"],
+	}, {
+		name:   "leaf by data path",
+		inPath: "/interfaces/interface/state/mtu",
+		inData: true,
+		want:   intf.Dir["state"].Dir["mtu"],
+	}, {
+		name:   "list by schema path with module prefixes",
+		inPath: "/mod:interfaces/mod:interface",
+		want:   intf,
+	}, {
+		name:   "data path with key predicates",
+		inPath: "/interfaces/interface[name=eth0]/config/name",
+		inData: true,
+		want:   intf.Dir["config"].Dir["name"],
+	}, {
+		name:   "leaf within choice by schema path",
+		inPath: "/interfaces/interface/kind/physical/counter/value",
+		want:   tree["indexCounter"].Dir["value"],
+	}, {
+		name:   "leaf within choice by data path",
+		inPath: "/interfaces/interface/counter/value",
+		inData: true,
+		want:   tree["indexCounter"].Dir["value"],
+	}, {
+		name:   "choice by schema path",
+		inPath: "/interfaces/interface/kind",
+		want:   intf.Dir["kind"],
+	}, {
+		name:          "choice is not within the data tree",
+		inPath:        "/interfaces/interface/kind",
+		inData:        true,
+		wantErrSubstr: "data path /interfaces/interface/kind not found",
+	}, {
+		name:          "data path is not a schema path",
+		inPath:        "/interfaces/interface/counter/value",
+		wantErrSubstr: "schema path /interfaces/interface/counter/value not found",
+	}}
+
+	for _, tt := range tests {
+		var got *yang.Entry
+		var err error
+		if tt.inData {
+			got, err = x.ByDataPath(tt.inPath)
+		} else {
+			got, err = x.BySchemaPath(tt.inPath)
+		}
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: got unexpected error looking up %s: %v, want error containing: %q", tt.name, tt.inPath, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: did not get expected error looking up %s, want error containing: %q", tt.name, tt.inPath, tt.wantErrSubstr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: looking up %s did not return expected entry, got: %s, want: %s", tt.name, tt.inPath, SchemaTreePath(got), SchemaTreePath(tt.want))
+		}
+	}
+}
+
+func TestSchemaIndexFields(t *testing.T) {
+	tree := indexSchemaTree()
+	intf := tree["indexInterface"]
+	x := NewSchemaIndex(tree)
+
+	tests := []struct {
+		name          string
+		inParent      ygot.GoStruct
+		inField       string
+		want          *yang.Entry
+		wantPaths     []string
+		wantErrSubstr string
+	}{{
+		name:      "field of fake root",
+		inParent:  &indexDevice{},
+		inField:   "Interface",
+		want:      intf,
+		wantPaths: []string{"/interfaces/interface"},
+	}, {
+		name:      "list key field",
+		inParent:  &indexInterface{},
+		inField:   "Name",
+		want:      intf.Dir["config"].Dir["name"],
+		wantPaths: []string{"/interfaces/interface/config/name", "/interfaces/interface/name"},
+	}, {
+		name:      "container within choice",
+		inParent:  &indexInterface{},
+		inField:   "Counter",
+		want:      tree["indexCounter"],
+		wantPaths: []string{"/interfaces/interface/counter"},
+	}, {
+		name:          "field without schema",
+		inParent:      &indexInterface{},
+		inField:       "Unknown",
+		wantErrSubstr: "could not find schema for field Unknown",
+	}, {
+		name:          "missing field",
+		inParent:      &indexInterface{},
+		inField:       "Missing",
+		wantErrSubstr: "field Missing not found in type indexInterface",
+	}, {
+		name:          "type not within tree",
+		inParent:      &validatePathRoot{},
+		inField:       "Ref",
+		wantErrSubstr: "could not find schema for type validatePathRoot",
+	}}
+
+	for _, tt := range tests {
+		got, err := x.FieldSchema(tt.inParent, tt.inField)
+		if err != nil {
+			if tt.wantErrSubstr == "" || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+				t.Errorf("%s: FieldSchema(%T, %s): got unexpected error: %v, want error containing: %q", tt.name, tt.inParent, tt.inField, err, tt.wantErrSubstr)
+			}
+			continue
+		}
+		if tt.wantErrSubstr != "" {
+			t.Errorf("%s: FieldSchema(%T, %s): did not get expected error, want error containing: %q", tt.name, tt.inParent, tt.inField, tt.wantErrSubstr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: FieldSchema(%T, %s): did not return expected entry, got: %s, want: %s", tt.name, tt.inParent, tt.inField, SchemaTreePath(got), SchemaTreePath(tt.want))
+		}
+
+		f, _ := reflect.TypeOf(tt.inParent).Elem().FieldByName(tt.inField)
+		gotPaths, err := x.FieldDataPaths(tt.inParent, f)
+		if err != nil {
+			t.Errorf("%s: FieldDataPaths(%T, %s): got unexpected error: %v", tt.name, tt.inParent, tt.inField, err)
+			continue
+		}
+		if diff := pretty.Compare(gotPaths, tt.wantPaths); diff != "" {
+			t.Errorf("%s: FieldDataPaths(%T, %s): did not get expected paths, diff(-got,+want):\n%s", tt.name, tt.inParent, tt.inField, diff)
+		}
+	}
+}
+
+func TestEntryPaths(t *testing.T) {
+	tree := indexSchemaTree()
+	v := tree["indexCounter"].Dir["value"]
+	if got, want := SchemaTreePath(v), "/interfaces/interface/kind/physical/counter/value"; got != want {
+		t.Errorf("SchemaTreePath(%s): did not get expected path, got: %s, want: %s", v.Name, got, want)
+	}
+	if got, want := DataTreePath(v), "/interfaces/interface/counter/value"; got != want {
+		t.Errorf("DataTreePath(%s): did not get expected path, got: %s, want: %s", v.Name, got, want)
+	}
+}
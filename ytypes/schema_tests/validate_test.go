@@ -25,15 +25,16 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/openconfig/ygot/experimental/ygotutils"
 	"github.com/openconfig/ygot/util"
 	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ygotutils"
 	"github.com/pmezard/go-difflib/difflib"
 
 	gpb "github.com/openconfig/gnmi/proto/gnmi"
 	oc "github.com/openconfig/ygot/exampleoc"
 	scpb "google.golang.org/genproto/googleapis/rpc/code"
 	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -589,12 +590,13 @@ func TestNewNode(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		n, status := ygotutils.NewNode(reflect.TypeOf(tt.rootType), tt.gnmiPath)
-		if got, want := status, tt.wantStatus; got.GetMessage() != want.GetMessage() {
+		n, err := ygotutils.NewNode(reflect.TypeOf(tt.rootType), tt.gnmiPath)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; got.GetMessage() != want.GetMessage() {
 			t.Errorf("%s: got status: %v, want status: %v ", tt.desc, got, want)
 		}
-		testErrLog(t, tt.desc, fmt.Errorf(status.GetMessage()))
-		if isOK(status) {
+		testErrLog(t, tt.desc, err)
+		if isOK(st) {
 			if got, want := n, tt.want; !reflect.DeepEqual(got, want) {
 				t.Errorf("%s: got: %v, want: %v ", tt.desc, util.ValueStr(got), util.ValueStr(want))
 			}
@@ -722,12 +724,13 @@ func TestGetNode(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		n, status := ygotutils.GetNode(oc.SchemaTree["Device"], testDevice, tt.gnmiPath)
-		if got, want := status, tt.wantStatus; !reflect.DeepEqual(got, want) {
+		n, err := ygotutils.GetNode(oc.SchemaTree["Device"], testDevice, tt.gnmiPath)
+		st := errToStatus(t, err)
+		if got, want := st, tt.wantStatus; !reflect.DeepEqual(got, want) {
 			t.Errorf("%s: got status: %v, want status: %v ", tt.desc, got, want)
 		}
-		testErrLog(t, tt.desc, fmt.Errorf(status.GetMessage()))
-		if isOK(status) {
+		testErrLog(t, tt.desc, err)
+		if isOK(st) {
 			if got, want := n, tt.want; !reflect.DeepEqual(got, want) {
 				t.Errorf("%s: got: %v, want: %v ", tt.desc, util.ValueStr(got), util.ValueStr(want))
 			}
@@ -750,6 +753,16 @@ func isOK(status spb.Status) bool {
 	return status.GetCode() == int32(scpb.Code_OK)
 }
 
+// errToStatus returns the Status carried by err, which must either be nil or
+// an error created by the gRPC status package.
+func errToStatus(t *testing.T, err error) spb.Status {
+	s, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("error %v is not a status error", err)
+	}
+	return *s.Proto()
+}
+
 // generateUnifiedDiff takes two strings and generates a diff that can be
 // shown to the user in a test error message.
 func generateUnifiedDiff(want, got string) (string, error) {
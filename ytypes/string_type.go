@@ -15,9 +15,7 @@
 package ytypes
 
 import (
-	"bytes"
 	"fmt"
-	"regexp"
 	"unicode/utf8"
 
 	"github.com/openconfig/goyang/pkg/yang"
@@ -46,16 +44,21 @@ func validateString(schema *yang.Entry, value interface{}) error {
 		return fmt.Errorf("length %d is outside range %v for schema %s", strLen, allowedRanges, schema.Name)
 	}
 
-	// Check that the value satisfies any regex patterns.
-	for _, p := range schema.Type.Pattern {
-		r, err := regexp.Compile(fixYangRegexp(p))
-		if err != nil {
-			return err
-		}
-		// fixYangRegexp adds ^(...)$ around the pattern - the result is
-		// equivalent to a full match of whole string.
-		if !r.MatchString(stringVal) {
-			return fmt.Errorf("%q does not match regular expression pattern %q for schema %s", stringVal, r, schema.Name)
+	// Check that the value satisfies any regex patterns. The compiled
+	// patterns are anchored, such that they must match the whole string,
+	// or must not match it where the pattern has the invert-match modifier.
+	patterns, err := compiledPatterns(schema.Type)
+	if err != nil {
+		return err
+	}
+	inverted := invertMatchPatterns(schema)
+	for i, r := range patterns {
+		p := schema.Type.Pattern[i]
+		switch {
+		case inverted[p] && r.MatchString(stringVal):
+			return fmt.Errorf("%q matches inverted regular expression pattern %q for schema %s", stringVal, p, schema.Name)
+		case !inverted[p] && !r.MatchString(stringVal):
+			return fmt.Errorf("%q does not match regular expression pattern %q for schema %s", stringVal, p, schema.Name)
 		}
 	}
 
@@ -105,69 +108,9 @@ func validateStringSchema(schema *yang.Entry) error {
 		return fmt.Errorf("string schema %s has wrong type %v", schema.Name, schema.Type.Kind)
 	}
 
-	for _, p := range schema.Type.Pattern {
-		if _, err := regexp.Compile(fixYangRegexp(p)); err != nil {
-			return fmt.Errorf("error generating regexp %s %v for schema %s", p, err, schema.Name)
-		}
+	if _, err := compiledPatterns(schema.Type); err != nil {
+		return fmt.Errorf("error generating regexp %v for schema %s", err, schema.Name)
 	}
 
 	return validateLengthSchema(schema)
 }
-
-// fixYangRegexp takes a pattern regular expression from a YANG module and
-// returns it into a format which can be used by the Go regular expression
-// library. YANG uses a W3C standard that is defined to be implicitly anchored
-// at the head or tail of the expression. See
-// https://www.w3.org/TR/2004/REC-xmlschema-2-20041028/#regexs for details.
-func fixYangRegexp(pattern string) string {
-	var buf bytes.Buffer
-	var inEscape bool
-	var prevChar rune
-	addParens := false
-
-	for i, ch := range pattern {
-		if i == 0 && ch != '^' {
-			buf.WriteRune('^')
-			// Add parens around entire expression to prevent logical
-			// subexpressions associating with leading/trailing ^ / $.
-			buf.WriteRune('(')
-			addParens = true
-		}
-
-		switch ch {
-		case '$':
-			// Dollar signs need to be escaped unless they are at
-			// the end of the pattern, or are already escaped.
-			if !inEscape && i != len(pattern)-1 {
-				buf.WriteRune('\\')
-			}
-		case '^':
-			// Carets need to be escaped unless they are already
-			// escaped, indicating set negation ([^.*]) or at the
-			// start of the string.
-			if !inEscape && prevChar != '[' && i != 0 {
-				buf.WriteRune('\\')
-			}
-		}
-
-		// If the previous character was an escape character, then we
-		// leave the escape, otherwise check whether this is an escape
-		// char and if so, then enter escape.
-		inEscape = !inEscape && ch == '\\'
-
-		buf.WriteRune(ch)
-
-		if i == len(pattern)-1 {
-			if addParens {
-				buf.WriteRune(')')
-			}
-			if ch != '$' {
-				buf.WriteRune('$')
-			}
-		}
-
-		prevChar = ch
-	}
-
-	return buf.String()
-}
@@ -16,15 +16,27 @@ package ytypes
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/yaml"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // Unmarshal recursively unmarshals JSON data tree in value into the given
 // parent, using the given schema. Any values already in the parent that are
-// not present in value are preserved.
-func Unmarshal(schema *yang.Entry, parent interface{}, value interface{}) error {
+// not present in value are preserved, unless the Replace option is supplied
+// in opts. Where the parent is a ygot.DynamicNode, value is unmarshalled
+// using the schema that the node was created with, and schema must be either
+// nil or that schema.
+//
+// By default, Unmarshal returns an error for the first field of value that
+// is not described by the schema. The handling of such fields can be changed
+// by supplying the IgnoreExtraFields, ReportAllUnknownFields or
+// UnknownFieldHandler options in opts.
+func Unmarshal(schema *yang.Entry, parent interface{}, value interface{}, opts ...UnmarshalOpt) error {
 	util.Indent()
 	defer util.Dedent()
 
@@ -32,6 +44,12 @@ func Unmarshal(schema *yang.Entry, parent interface{}, value interface{}) error
 	if util.IsValueNil(value) {
 		return nil
 	}
+	if n, ok := parent.(*ygot.DynamicNode); ok {
+		if schema != nil && schema != n.Schema() {
+			return fmt.Errorf("schema %s does not match the schema %s of the DynamicNode", schema.Name, n.Schema().Name)
+		}
+		return unmarshalDynamicNode(n, value, opts...)
+	}
 	if schema == nil {
 		return fmt.Errorf("nil schema for parent type %T, value %v (%T)", parent, value, value)
 	}
@@ -43,11 +61,205 @@ func Unmarshal(schema *yang.Entry, parent interface{}, value interface{}) error
 	case schema.IsLeafList():
 		return unmarshalLeafList(schema, parent, value)
 	case schema.IsList():
-		return unmarshalList(schema, parent, value)
+		return unmarshalList(schema, parent, value, opts...)
 	case schema.IsChoice():
 		return fmt.Errorf("cannot pass choice schema %s to Unmarshal", schema.Name)
-	case schema.IsContainer():
-		return unmarshalContainer(schema, parent, value)
+	case util.IsContainerLike(schema):
+		return unmarshalContainer(schema, parent, value, opts...)
 	}
 	return fmt.Errorf("unknown schema type for type %T, value %v", value, value)
 }
+
+// UnmarshalYAML unmarshals the YAML document data into the given parent,
+// using the given schema. The document is parsed into a JSON data tree, which
+// is unmarshalled according to the rules of Unmarshal. Values must therefore
+// have the type that they would have in JSON - for example, int64 values must
+// be quoted. Errors in the syntax of the document specify the line at which
+// they occurred.
+func UnmarshalYAML(schema *yang.Entry, parent interface{}, data []byte, opts ...UnmarshalOpt) error {
+	value, err := yaml.Parse(data)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(schema, parent, value, opts...)
+}
+
+// UnmarshalOpt is an interface implemented by the options that can be passed
+// to Unmarshal to change its behaviour.
+type UnmarshalOpt interface {
+	// IsUnmarshalOpt is a marker method for each UnmarshalOpt.
+	IsUnmarshalOpt()
+}
+
+// UnknownField describes a field of a JSON data tree that is not described by
+// the schema of the node that it is unmarshalled into.
+type UnknownField struct {
+	// Path is the data tree path of the field, e.g.,
+	// /interfaces/interface/vendor:counters.
+	Path string
+	// Parent is the value that the field was to be unmarshalled into, which
+	// is a GoStruct or a *ygot.DynamicNode.
+	Parent interface{}
+	// Value is the JSON subtree of the field.
+	Value interface{}
+}
+
+// IgnoreExtraFields is an UnmarshalOpt that causes fields of the JSON data
+// tree that are not described by the schema to be skipped, rather than
+// causing Unmarshal to return an error. Each field that is skipped is
+// appended to Skipped.
+type IgnoreExtraFields struct {
+	Skipped []*UnknownField
+}
+
+// IsUnmarshalOpt marks IgnoreExtraFields as a valid UnmarshalOpt.
+func (*IgnoreExtraFields) IsUnmarshalOpt() {}
+
+// ReportAllUnknownFields is an UnmarshalOpt that causes Unmarshal to continue
+// to unmarshal the JSON data tree when a field that is not described by the
+// schema is found, such that the UnknownFieldsError that it returns lists
+// every such field within the tree, rather than only the first.
+type ReportAllUnknownFields struct{}
+
+// IsUnmarshalOpt marks ReportAllUnknownFields as a valid UnmarshalOpt.
+func (ReportAllUnknownFields) IsUnmarshalOpt() {}
+
+// UnknownFieldHandler is an UnmarshalOpt that is called for each field of the
+// JSON data tree that is not described by the schema, such that the subtree
+// can be processed elsewhere. The field is skipped, unless the handler
+// returns an error, in which case Unmarshal returns it. The handler takes
+// precedence over the IgnoreExtraFields and ReportAllUnknownFields options.
+type UnknownFieldHandler func(*UnknownField) error
+
+// IsUnmarshalOpt marks UnknownFieldHandler as a valid UnmarshalOpt.
+func (UnknownFieldHandler) IsUnmarshalOpt() {}
+
+// Replace is an UnmarshalOpt that causes the subtree of the data tree at
+// Path to be replaced by the contents of the JSON data tree, consistent with
+// the semantics of a gNMI replace operation. The existing values of all
+// fields at or below Path are removed before they are unmarshalled, such that
+// fields that are not present in the JSON data tree are deleted. Where Path
+// describes a list, all of its existing members are removed, and each member
+// within the JSON data tree is created from only its contents.
+//
+// Path is an absolute schema path that does not include the names of choice
+// and case statements, or list keys, e.g., /interfaces/interface/config. Any
+// module prefixes within Path are ignored. The path "/" causes the whole of
+// the parent that is unmarshalled into to be replaced.
+type Replace struct {
+	Path string
+}
+
+// IsUnmarshalOpt marks Replace as a valid UnmarshalOpt.
+func (Replace) IsUnmarshalOpt() {}
+
+// UnknownFieldsError is the error returned by Unmarshal when the
+// ReportAllUnknownFields option is supplied and the JSON data tree contains
+// fields that are not described by the schema.
+type UnknownFieldsError []*UnknownField
+
+// Error implements the error interface.
+func (e UnknownFieldsError) Error() string {
+	var paths []string
+	for _, f := range e {
+		paths = append(paths, f.Path)
+	}
+	return fmt.Sprintf("JSON contains unexpected fields: %s", strings.Join(paths, ", "))
+}
+
+// hasIgnoreExtraFields returns the IgnoreExtraFields option within opts, or
+// nil if it is not present.
+func hasIgnoreExtraFields(opts []UnmarshalOpt) *IgnoreExtraFields {
+	for _, o := range opts {
+		if i, ok := o.(*IgnoreExtraFields); ok {
+			return i
+		}
+	}
+	return nil
+}
+
+// hasReportAllUnknownFields determines whether the ReportAllUnknownFields
+// option is present within opts.
+func hasReportAllUnknownFields(opts []UnmarshalOpt) bool {
+	for _, o := range opts {
+		if _, ok := o.(ReportAllUnknownFields); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownFieldHandler returns the UnknownFieldHandler within opts, or nil if
+// it is not present.
+func unknownFieldHandler(opts []UnmarshalOpt) UnknownFieldHandler {
+	for _, o := range opts {
+		if h, ok := o.(UnknownFieldHandler); ok {
+			return h
+		}
+	}
+	return nil
+}
+
+// inReplacedSubtree determines whether the node described by schema is at or
+// below the path of the Replace option within opts.
+func inReplacedSubtree(schema *yang.Entry, opts []UnmarshalOpt) bool {
+	for _, o := range opts {
+		r, ok := o.(Replace)
+		if !ok {
+			continue
+		}
+		rp := normalisePath(r.Path)
+		if rp == "/" {
+			return true
+		}
+		p := normalisePath(DataTreePath(schema))
+		return p == rp || strings.HasPrefix(p, rp+"/")
+	}
+	return false
+}
+
+// handleUnknownFields processes the fields of jsonTree that are named by
+// unknown, which are not described by the schema of the node being
+// unmarshalled into parent, according to opts. The fields are processed in
+// sorted order. An UnknownFieldsError is returned listing the fields if the
+// ReportAllUnknownFields option is present, along with any fields within
+// collected, which are those found within the descendants of parent.
+func handleUnknownFields(schema *yang.Entry, parent interface{}, jsonTree map[string]interface{}, unknown []string, collected UnknownFieldsError, opts []UnmarshalOpt) error {
+	sort.Strings(unknown)
+	h, ignore, reportAll := unknownFieldHandler(opts), hasIgnoreExtraFields(opts), hasReportAllUnknownFields(opts)
+	for _, f := range unknown {
+		uf := &UnknownField{
+			Path:   strings.TrimSuffix(DataTreePath(schema), "/") + "/" + f,
+			Parent: parent,
+			Value:  jsonTree[f],
+		}
+		switch {
+		case h != nil:
+			if err := h(uf); err != nil {
+				return err
+			}
+		case ignore != nil:
+			ignore.Skipped = append(ignore.Skipped, uf)
+		case reportAll:
+			collected = append(collected, uf)
+		default:
+			return fmt.Errorf("parent container %s (type %T): JSON contains unexpected field %s", schema.Name, parent, f)
+		}
+	}
+	if len(collected) != 0 {
+		return collected
+	}
+	return nil
+}
+
+// collectUnknownFields appends the fields of err to collected if err is an
+// UnknownFieldsError, such that unmarshalling can continue when the
+// ReportAllUnknownFields option is set. It returns false if err is any
+// other error, which must be returned by the caller.
+func collectUnknownFields(err error, collected *UnknownFieldsError) bool {
+	uerr, ok := err.(UnknownFieldsError)
+	if ok {
+		*collected = append(*collected, uerr...)
+	}
+	return ok
+}
@@ -15,9 +15,13 @@
 package ytypes
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 
+	"github.com/kylelemons/godebug/pretty"
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
 )
 
 func TestUnmarshal(t *testing.T) {
@@ -69,3 +73,332 @@ func TestUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalUnknownFields(t *testing.T) {
+	schema := &yang.Entry{
+		Name: "parent",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"container": {
+				Name: "container",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"leaf": {
+						Name: "leaf",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Ystring},
+					},
+					"item": {
+						Name:     "item",
+						Kind:     yang.DirectoryEntry,
+						ListAttr: &yang.ListAttr{},
+						Key:      "id",
+						Dir: map[string]*yang.Entry{
+							"id": {
+								Name: "id",
+								Kind: yang.LeafEntry,
+								Type: &yang.YangType{Kind: yang.Ystring},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	populateParentField(nil, schema)
+
+	type ItemStruct struct {
+		ID *string `path:"id"`
+	}
+	type InnerStruct struct {
+		Leaf *string                `path:"leaf"`
+		Item map[string]*ItemStruct `path:"item"`
+	}
+	type OuterStruct struct {
+		Container *InnerStruct `path:"container"`
+	}
+
+	inJSON := `{
+		"container": {
+			"leaf": "a",
+			"vendor:extra": 1,
+			"item": [{"id": "x", "bad": true}, {"id": "y"}]
+		},
+		"top-extra": {"a": 1}
+	}`
+	wantStruct := &OuterStruct{
+		Container: &InnerStruct{
+			Leaf: ygot.String("a"),
+			Item: map[string]*ItemStruct{
+				"x": {ID: ygot.String("x")},
+				"y": {ID: ygot.String("y")},
+			},
+		},
+	}
+	allPaths := []string{"/parent/container/item/bad", "/parent/container/vendor:extra", "/parent/top-extra"}
+
+	var handled []string
+	ignore := &IgnoreExtraFields{}
+	tests := []struct {
+		desc          string
+		inOpts        []UnmarshalOpt
+		wantErr       string
+		wantPaths     func() []string
+		wantPopulated bool
+	}{{
+		desc:    "no options",
+		wantErr: `parent container item (type *ytypes.ItemStruct): JSON contains unexpected field bad`,
+	}, {
+		desc:          "ignore extra fields",
+		inOpts:        []UnmarshalOpt{ignore},
+		wantPaths:     func() []string { return unknownFieldPaths(ignore.Skipped) },
+		wantPopulated: true,
+	}, {
+		desc:    "report all unknown fields",
+		inOpts:  []UnmarshalOpt{ReportAllUnknownFields{}},
+		wantErr: "JSON contains unexpected fields: /parent/container/item/bad, /parent/container/vendor:extra, /parent/top-extra",
+	}, {
+		desc: "handler",
+		inOpts: []UnmarshalOpt{UnknownFieldHandler(func(f *UnknownField) error {
+			handled = append(handled, f.Path)
+			return nil
+		}), ReportAllUnknownFields{}},
+		wantPaths:     func() []string { return handled },
+		wantPopulated: true,
+	}, {
+		desc: "handler returning error",
+		inOpts: []UnmarshalOpt{UnknownFieldHandler(func(f *UnknownField) error {
+			return fmt.Errorf("cannot route %s with value %v", f.Path, f.Value)
+		})},
+		wantErr: "cannot route /parent/container/item/bad with value true",
+	}}
+
+	for _, tt := range tests {
+		var jsonTree interface{}
+		if err := json.Unmarshal([]byte(inJSON), &jsonTree); err != nil {
+			t.Fatalf("%s: cannot unmarshal input JSON: %v", tt.desc, err)
+		}
+		var parent OuterStruct
+		err := Unmarshal(schema, &parent, jsonTree, tt.inOpts...)
+		if got, want := errToString(err), tt.wantErr; got != want {
+			t.Errorf("%s: Unmarshal: got error: %v, want error: %v", tt.desc, got, want)
+		}
+		if tt.wantPaths != nil {
+			if diff := pretty.Compare(tt.wantPaths(), allPaths); diff != "" {
+				t.Errorf("%s: did not get expected unknown fields, diff(-got,+want):\n%s", tt.desc, diff)
+			}
+		}
+		if tt.wantPopulated && !areEqual(&parent, wantStruct) {
+			t.Errorf("%s: Unmarshal: got:\n%v\nwant:\n%v", tt.desc, pretty.Sprint(&parent), pretty.Sprint(wantStruct))
+		}
+	}
+
+	// The error returned when all unknown fields are reported describes each
+	// of the fields.
+	var jsonTree interface{}
+	if err := json.Unmarshal([]byte(inJSON), &jsonTree); err != nil {
+		t.Fatalf("cannot unmarshal input JSON: %v", err)
+	}
+	err := Unmarshal(schema, &OuterStruct{}, jsonTree, ReportAllUnknownFields{})
+	uerr, ok := err.(UnknownFieldsError)
+	if !ok {
+		t.Fatalf("Unmarshal with ReportAllUnknownFields: got error %v (%T), want UnknownFieldsError", err, err)
+	}
+	if diff := pretty.Compare(unknownFieldPaths(uerr), allPaths); diff != "" {
+		t.Errorf("Unmarshal with ReportAllUnknownFields: did not get expected paths, diff(-got,+want):\n%s", diff)
+	}
+	if _, ok := uerr[2].Parent.(*OuterStruct); !ok {
+		t.Errorf("Unmarshal with ReportAllUnknownFields: got parent type %T for %s, want *OuterStruct", uerr[2].Parent, uerr[2].Path)
+	}
+}
+
+// unknownFieldPaths returns the paths of the fields in fs.
+func unknownFieldPaths(fs []*UnknownField) []string {
+	var paths []string
+	for _, f := range fs {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+func TestUnmarshalReplace(t *testing.T) {
+	schema := &yang.Entry{
+		Name: "parent",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"container": {
+				Name: "container",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"leaf": {
+						Name: "leaf",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Ystring},
+					},
+					"other": {
+						Name: "other",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Ystring},
+					},
+					"item": {
+						Name:     "item",
+						Kind:     yang.DirectoryEntry,
+						ListAttr: &yang.ListAttr{},
+						Key:      "id",
+						Dir: map[string]*yang.Entry{
+							"id": {
+								Name: "id",
+								Kind: yang.LeafEntry,
+								Type: &yang.YangType{Kind: yang.Ystring},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	populateParentField(nil, schema)
+
+	type ItemStruct struct {
+		ID *string `path:"id"`
+	}
+	type InnerStruct struct {
+		Leaf  *string                `path:"leaf"`
+		Other *string                `path:"other"`
+		Item  map[string]*ItemStruct `path:"item"`
+	}
+	type OuterStruct struct {
+		Container *InnerStruct `path:"container"`
+	}
+
+	items := func(ids ...string) map[string]*ItemStruct {
+		m := map[string]*ItemStruct{}
+		for _, id := range ids {
+			m[id] = &ItemStruct{ID: ygot.String(id)}
+		}
+		return m
+	}
+
+	inJSON := `{"container": {"leaf": "new", "item": [{"id": "c"}]}}`
+	tests := []struct {
+		desc   string
+		inOpts []UnmarshalOpt
+		want   *OuterStruct
+	}{{
+		desc: "merge",
+		want: &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Other: ygot.String("old"), Item: items("a", "b", "c")}},
+	}, {
+		desc:   "replace root",
+		inOpts: []UnmarshalOpt{Replace{Path: "/"}},
+		want:   &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Item: items("c")}},
+	}, {
+		desc:   "replace container",
+		inOpts: []UnmarshalOpt{Replace{Path: "/parent/container"}},
+		want:   &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Item: items("c")}},
+	}, {
+		desc:   "replace list",
+		inOpts: []UnmarshalOpt{Replace{Path: "/parent/container/item"}},
+		want:   &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Other: ygot.String("old"), Item: items("c")}},
+	}, {
+		desc:   "replace list using prefixed path",
+		inOpts: []UnmarshalOpt{Replace{Path: "/m:parent/m:container/m:item/"}},
+		want:   &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Other: ygot.String("old"), Item: items("c")}},
+	}, {
+		desc:   "replace leaf not present in JSON",
+		inOpts: []UnmarshalOpt{Replace{Path: "/parent/container/other"}},
+		want:   &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Item: items("a", "b", "c")}},
+	}, {
+		desc:   "replace path not within the tree",
+		inOpts: []UnmarshalOpt{Replace{Path: "/parent/container/oth"}},
+		want:   &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("new"), Other: ygot.String("old"), Item: items("a", "b", "c")}},
+	}}
+
+	for _, tt := range tests {
+		var jsonTree interface{}
+		if err := json.Unmarshal([]byte(inJSON), &jsonTree); err != nil {
+			t.Fatalf("%s: cannot unmarshal input JSON: %v", tt.desc, err)
+		}
+		parent := &OuterStruct{Container: &InnerStruct{Leaf: ygot.String("old"), Other: ygot.String("old"), Item: items("a", "b")}}
+		if err := Unmarshal(schema, parent, jsonTree, tt.inOpts...); err != nil {
+			t.Errorf("%s: Unmarshal: got unexpected error: %v", tt.desc, err)
+			continue
+		}
+		if !areEqual(parent, tt.want) {
+			t.Errorf("%s: Unmarshal: got:\n%v\nwant:\n%v", tt.desc, pretty.Sprint(parent), pretty.Sprint(tt.want))
+		}
+	}
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	type ParentStruct struct {
+		Int64   *int64          `path:"int64"`
+		Decimal *ygot.Decimal64 `path:"decimal"`
+		Tags    []string        `path:"tags"`
+	}
+	schema := &yang.Entry{
+		Name: "parent",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"int64": {
+				Name: "int64",
+				Kind: yang.LeafEntry,
+				Type: &yang.YangType{Kind: yang.Yint64},
+			},
+			"decimal": {
+				Name: "decimal",
+				Kind: yang.LeafEntry,
+				Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2},
+			},
+			"tags": {
+				Name:     "tags",
+				Kind:     yang.LeafEntry,
+				ListAttr: &yang.ListAttr{},
+				Type:     &yang.YangType{Kind: yang.Ystring},
+			},
+		},
+	}
+	populateParentField(nil, schema)
+
+	tests := []struct {
+		desc    string
+		in      string
+		want    *ParentStruct
+		wantErr string
+	}{{
+		desc: "success",
+		in: `# A YAML document.
+int64: "9223372036854775807"
+decimal: "42.42"
+tags:
+  - z
+  - a
+`,
+		want: &ParentStruct{
+			Int64:   ygot.Int64(9223372036854775807),
+			Decimal: &ygot.Decimal64{Digits: 4242, Precision: 2},
+			Tags:    []string{"z", "a"},
+		},
+	}, {
+		desc:    "unquoted int64",
+		in:      "int64: 42\n",
+		wantErr: "got float64 type for field int64, expect string",
+	}, {
+		desc:    "invalid YAML",
+		in:      "int64: \"42\"\ntags:\n  - a\n - b\n",
+		wantErr: "yaml: line 4: unexpected indentation",
+	}}
+
+	for _, tt := range tests {
+		got := &ParentStruct{}
+		err := UnmarshalYAML(schema, got, []byte(tt.in))
+		if gotErr := errToString(err); gotErr != tt.wantErr {
+			t.Errorf("%s: UnmarshalYAML(%s): got error: %v, want error: %v", tt.desc, tt.in, gotErr, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("%s: UnmarshalYAML(%s): did not get expected value, diff(-got,+want):\n%s", tt.desc, tt.in, diff)
+		}
+	}
+}
@@ -128,131 +128,23 @@ func validateListAttr(schema *yang.Entry, value interface{}) util.Errors {
 	return errors
 }
 
-// isChoiceOrCase returns true if the entry is either a 'case' or a 'choice'
-// node within the schema. These are schema nodes only, and the code generation
-// operates on data tree paths.
-func isChoiceOrCase(e *yang.Entry) bool {
-	return e.IsChoice() || e.IsCase()
-}
-
 // isValueScalar reports whether v is a scalar (non-composite) type.
 func isValueScalar(v reflect.Value) bool {
 	return !util.IsValueStruct(v) && !util.IsValueStructPtr(v) && !util.IsValueMap(v) && !util.IsValueSlice(v)
 }
 
-// isFakeRoot reports whether the supplied yang.Entry represents the synthesised
-// root entity in the generated code.
-func isFakeRoot(e *yang.Entry) bool {
-	if _, ok := e.Annotation["isFakeRoot"]; ok {
-		return true
-	}
-	return false
-}
-
 // isUnkeyedList reports whether e is an unkeyed list.
 func isUnkeyedList(e *yang.Entry) bool {
 	return e.IsList() && e.Key == ""
 }
 
-// childSchema returns the schema for the struct field f, if f contains a valid
-// path tag and the schema path is found in the schema tree. It returns an error
-// if the struct tag is invalid, or nil if tag is valid but the schema is not
-// found in the tree at the specified path.
-func childSchema(schema *yang.Entry, f reflect.StructField) (*yang.Entry, error) {
-	pathTag, _ := f.Tag.Lookup("path")
-	util.DbgSchema("childSchema for schema %s, field %s, tag %s\n", schema.Name, f.Name, pathTag)
-	if rootName, ok := f.Tag.Lookup("rootname"); ok {
-		return schema.Dir[rootName], nil
-	}
-	p, err := pathToSchema(f)
-	if err != nil {
-		return nil, err
-	}
-
-	// Containers have the container schema name as the first element in the
-	// path tag for each field e.g. System { Dns ... path: "system/dns"
-	// Strip this off since the supplied schema already refers to the struct
-	// schema element.
-	if schema.IsContainer() && len(p) > 1 && p[0] == schema.Name {
-		p = p[1:]
-	}
-	util.DbgSchema("pathToSchema yields %v\n", p)
-	// For empty path, return the parent schema.
-	childSchema := schema
-	foundSchema := true
-	// Traverse the returned schema path to get the child schema.
-	util.DbgSchema("traversing schema Dirs...")
-	for ; len(p) > 0; p = p[1:] {
-		util.DbgSchema("/%s", p[0])
-		ns, ok := childSchema.Dir[stripModulePrefix(p[0])]
-		if !ok {
-			foundSchema = false
-			break
-		}
-		childSchema = ns
-	}
-	if foundSchema {
-		util.DbgSchema(" - found\n")
-		return childSchema, nil
-	}
-	util.DbgSchema(" - not found\n")
-
-	// Path is not null and was not found in the schema. It could be inside a
-	// choice/case schema element which is not represented in the path tags.
-	// e.g. choice1/case1/leaf1 could have abbreviated tag `path: "leaf1"`.
-	// In this case, try to match against any named elements within any choice/
-	// case subtrees. These are guaranteed to be unique within the current
-	// level namespace so a path tag name match will be unique if one is found.
-	if len(p) != 1 {
-		// Nodes within choice/case have a path tag with only the last schema
-		// path element i.e. choice1/case1/leaf1 path in the schema will have
-		// struct tag `path:"leaf1"`. This implies that only paths with length
-		// 1 are eligible for this matching.
-		return nil, nil
-	}
-	entries := make(map[string]*yang.Entry)
-	for _, ch := range childSchema.Dir {
-		if isChoiceOrCase(ch) {
-			findFirstNonChoiceOrCase(ch, entries)
-		}
-	}
-
-	util.DbgSchema("checking for %s against non choice/case entries: %v\n", p[0], stringMapKeys(entries))
-	for name, entry := range entries {
-		util.DbgSchema("%s ? ", name)
-
-		if stripModulePrefix(name) == p[0] {
-			util.DbgSchema(" - match\n")
-			return entry, nil
-		}
-	}
-
-	util.DbgSchema(" - no matches\n")
-	return nil, nil
-}
-
-// schemaTreeRoot returns the root of the schema tree, given any node in that
-// tree. It returns nil if schema is nil.
-func schemaTreeRoot(schema *yang.Entry) *yang.Entry {
-	if schema == nil {
-		return nil
-	}
-
-	root := schema
-	for root.Parent != nil {
-		root = root.Parent
-	}
-
-	return root
-}
-
 // absoluteSchemaDataPath returns the absolute path of the schema, excluding
 // any choice or case entries.
 // TODO(mostrowski): why are these excluded?
 func absoluteSchemaDataPath(schema *yang.Entry) string {
 	out := []string{schema.Name}
 	for s := schema.Parent; s != nil; s = s.Parent {
-		if !isChoiceOrCase(s) && !isFakeRoot(s) {
+		if !util.IsChoiceOrCase(s) && !util.IsFakeRoot(s) {
 			out = append([]string{s.Name}, out...)
 		}
 	}
@@ -260,80 +152,11 @@ func absoluteSchemaDataPath(schema *yang.Entry) string {
 	return "/" + strings.Join(out, "/")
 }
 
-// findFirstNonChoiceOrCase recursively traverses the schema tree and populates
-// m with the set of the first nodes in every path that neither case nor choice
-// nodes. The keys in the map are the schema element names of the matching
-// elements.
-func findFirstNonChoiceOrCase(e *yang.Entry, m map[string]*yang.Entry) {
-	switch {
-	case !isChoiceOrCase(e):
-		m[e.Name] = e
-	case e.IsDir():
-		for _, ch := range e.Dir {
-			findFirstNonChoiceOrCase(ch, m)
-		}
-	}
-}
-
-// pathToSchema returns a path to the schema for the struct field f.
-// Paths are embedded in the "path" struct tag and can be either simple:
-//   e.g. "path:a"
-// or composite e.g.
-//   e.g. "path:config/a|a"
-// which is found in OpenConfig leaf-ref cases where the key of a list is a
-// leafref. In the latter case, this function returns {"config", "a"}, and the
-// schema *yang.Entry for the field is given by schema.Dir["config"].Dir["a"].
-func pathToSchema(f reflect.StructField) ([]string, error) {
-	pathAnnotation, ok := f.Tag.Lookup("path")
-	if !ok {
-		return nil, fmt.Errorf("field %s did not specify a path", f.Name)
-	}
-
-	paths := strings.Split(pathAnnotation, "|")
-	if len(paths) == 1 {
-		pathAnnotation = strings.TrimPrefix(pathAnnotation, "/")
-		return strings.Split(pathAnnotation, "/"), nil
-	}
-	for _, pv := range paths {
-		pv = strings.TrimPrefix(pv, "/")
-		pe := strings.Split(pv, "/")
-		if len(pe) > 1 {
-			return pe, nil
-		}
-	}
-
-	return nil, fmt.Errorf("field %s had path tag %s with |, but no elements of form a/b", f.Name, pathAnnotation)
-}
-
-// schemaPaths returns all the paths in the path tag, plus the path value of
-// the rootname tag, if one is present.
-func schemaPaths(schema *yang.Entry, f reflect.StructField) ([][]string, error) {
-	var out [][]string
-	rootTag, ok := f.Tag.Lookup("rootname")
-	if ok {
-		out = append(out, strings.Split(rootTag, "/"))
-	}
-	pathTag, ok := f.Tag.Lookup("path")
-	if (!ok || pathTag == "") && rootTag == "" {
-		return nil, fmt.Errorf("field %s did not specify a path", f.Name)
-	}
-	if pathTag == "" {
-		return out, nil
-	}
-
-	ps := strings.Split(pathTag, "|")
-	for _, p := range ps {
-		sp := removeRootPrefix(strings.Split(p, "/"))
-		out = append(out, stripModulePrefixes(sp))
-	}
-	return out, nil
-}
-
-// dataTreePaths returns all the data tree paths corresponding to schemaPaths.
+// dataTreePaths returns all the data tree paths corresponding to util.SchemaPaths.
 // Any intermediate nodes not found in the data tree (i.e. choice/case) are
 // removed from the paths.
 func dataTreePaths(parentSchema, schema *yang.Entry, f reflect.StructField) ([][]string, error) {
-	out, err := schemaPaths(schema, f)
+	out, err := util.SchemaPaths(f)
 	if err != nil {
 		return nil, err
 	}
@@ -363,7 +186,7 @@ func removeNonDataPathElements(parentSchema, schema *yang.Entry, paths [][]strin
 				po = path
 				break
 			}
-			if !isChoiceOrCase(s) {
+			if !util.IsChoiceOrCase(s) {
 				po = append(po, pe)
 			}
 		}
@@ -373,66 +196,32 @@ func removeNonDataPathElements(parentSchema, schema *yang.Entry, paths [][]strin
 	return out, nil
 }
 
-// checkDataTreeAgainstPaths checks each of dataPaths against the first level
-// of the data tree. It returns an error with the first element in the data tree first
-// level that is not found in dataPaths.
-// This function is used to verify that the jsonTree does not contain any elements
-// in the first level that do not have data paths found in the schema.
-func checkDataTreeAgainstPaths(jsonTree map[string]interface{}, dataPaths [][]string) error {
+// unknownDataTreeFields checks each of dataPaths against the first level
+// of the data tree. It returns the names of the elements in the first level
+// of the data tree that are not found in dataPaths.
+// This function is used to find the elements in the first level of jsonTree
+// that do not have data paths found in the schema.
+func unknownDataTreeFields(jsonTree map[string]interface{}, dataPaths [][]string) []string {
 	// Go over all first level JSON tree map keys to make sure they all point
 	// to valid schema paths.
 	pm := map[string]bool{}
 	for _, sp := range dataPaths {
-		pm[stripModulePrefix(sp[0])] = true
+		pm[util.StripModulePrefix(sp[0])] = true
 	}
 	util.DbgSchema("check dataPaths %v against dataTree %v\n", pm, jsonTree)
+	var unknown []string
 	for jf := range jsonTree {
-		if !pm[stripModulePrefix(jf)] {
-			return fmt.Errorf("JSON contains unexpected field %s", jf)
+		// RFC7952 annotations of the node, and of its known children, are
+		// not fields of the data tree.
+		if isAnnotationMember(jf, func(n string) bool { return pm[n] }) {
+			continue
 		}
-	}
-
-	return nil
-}
-
-// removeRootPrefix removes the root prefix from root schema entities e.g.
-// Bgp_Global has path "/bgp/global" == {"", "bgp", "global"}
-//   -> {"global"}
-func removeRootPrefix(path []string) []string {
-	if len(path) < 2 || path[0] != "" {
-		// not a root path
-		return path
-	}
-	return path[2:]
-}
-
-// resolveLeafRef returns a ptr to the schema pointed to by the provided leaf-ref
-// schema. It returns schema itself if schema is not a leaf-ref.
-func resolveLeafRef(schema *yang.Entry) (*yang.Entry, error) {
-	if schema == nil {
-		return nil, nil
-	}
-	// TODO(mostrowski): this should only be possible in fakeroot. Add an
-	// explicit check for that once data is available in the schema.
-	if schema.Type == nil {
-		return schema, nil
-	}
-
-	orig := schema
-	s := schema
-	for ykind := s.Type.Kind; ykind == yang.Yleafref; {
-		ns, err := findLeafRefSchema(s, s.Type.Path)
-		if err != nil {
-			return schema, err
+		if !pm[util.StripModulePrefix(jf)] {
+			unknown = append(unknown, jf)
 		}
-		s = ns
-		ykind = s.Type.Kind
 	}
 
-	if s != orig {
-		util.DbgPrint("follow schema leaf-ref from %s to %s, type %v", orig.Name, s.Name, s.Type.Kind)
-	}
-	return s, nil
+	return unknown
 }
 
 // schemaToStructFieldName returns the string name of the field, which must be
@@ -457,8 +246,11 @@ func schemaToStructFieldName(schema *yang.Entry, parent interface{}) (string, *y
 
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
+		if util.IsYgotAnnotation(f) {
+			continue
+		}
 		fieldName := f.Name
-		p, err := pathToSchema(f)
+		p, err := util.RelativeSchemaPath(f)
 		if err != nil {
 			return "", nil, err
 		}
@@ -478,7 +270,7 @@ func schemaToStructFieldName(schema *yang.Entry, parent interface{}) (string, *y
 func findSchemaAtPath(schema *yang.Entry, path []string) *yang.Entry {
 	s := schema
 	for i := 0; i < len(path); i++ {
-		pe := stripModulePrefix(path[i])
+		pe := util.StripModulePrefix(path[i])
 		if s.Dir[pe] == nil {
 			return nil
 		}
@@ -497,7 +289,7 @@ func hasRelativePath(schema *yang.Entry, path []string) bool {
 		if s == nil || len(p) == 0 {
 			break
 		}
-		n := stripModulePrefix(p[len(p)-1])
+		n := util.StripModulePrefix(p[len(p)-1])
 		if s.Name != n {
 			return false
 		}
@@ -508,23 +300,6 @@ func hasRelativePath(schema *yang.Entry, path []string) bool {
 	return len(p) == 0
 }
 
-// stripModulePrefixes returns "in" with each element with the format "A:B" changed
-// to "B".
-func stripModulePrefixes(in []string) []string {
-	var out []string
-	for _, v := range in {
-		out = append(out, stripModulePrefix(v))
-	}
-	return out
-}
-
-// stripModulePrefix returns s with any prefix up to and including the last ':'
-// character removed.
-func stripModulePrefix(s string) string {
-	sv := strings.Split(s, ":")
-	return sv[len(sv)-1]
-}
-
 // derefIfStructPtr returns the dereferenced reflect.Value of value if it is a
 // ptr, or value if it is not.
 func derefIfStructPtr(value reflect.Value) reflect.Value {
@@ -579,7 +354,10 @@ func forEachSchemaNodeInternal(ni *SchemaNodeInfo, in, out interface{}, iterFunc
 	case util.IsValueStruct(ni.FieldValue) || util.IsValueStructPtr(ni.FieldValue):
 		structElems := derefIfStructPtr(ni.FieldValue)
 		for i := 0; i < structElems.NumField(); i++ {
-			cschema, err := childSchema(ni.Schema, structElems.Type().Field(i))
+			if util.IsYgotAnnotation(structElems.Type().Field(i)) {
+				continue
+			}
+			cschema, err := util.ChildSchema(ni.Schema, structElems.Type().Field(i))
 			if err != nil {
 				errs = util.AppendErr(errs, fmt.Errorf("%s: %v", structElems.Type().Field(i).Name, err))
 				continue
@@ -148,7 +148,7 @@ func TestYangBuiltinTypeToGoType(t *testing.T) {
 		{
 			desc:  "decimal",
 			ykind: yang.Ydecimal64,
-			want:  reflect.Float64,
+			want:  reflect.Struct,
 		},
 		{
 			desc:  "binary",
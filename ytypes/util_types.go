@@ -78,7 +78,7 @@ func castToEnumValue(ft reflect.Type, value string) (interface{}, error) {
 	}
 
 	for k, v := range m {
-		if stripModulePrefix(v.Name) == stripModulePrefix(value) {
+		if util.StripModulePrefix(v.Name) == util.StripModulePrefix(value) {
 			// Convert to destination enum type.
 			return reflect.ValueOf(k).Convert(ft).Interface(), nil
 		}
@@ -113,7 +113,9 @@ func yangBuiltinTypeToGoType(t yang.TypeKind) interface{} {
 	case yang.Ystring:
 		return string("")
 	case yang.Ydecimal64:
-		return float64(0)
+		return ygot.Decimal64{}
+	case yang.YinstanceIdentifier:
+		return ygot.InstanceIdentifier{}
 	case yang.Ybinary:
 		return []byte(nil)
 	case yang.Yenum, yang.Yidentityref:
@@ -132,7 +134,7 @@ func yangToJSONType(t yang.TypeKind) reflect.Type {
 	case yang.Yint8, yang.Yint16, yang.Yint32,
 		yang.Yuint8, yang.Yuint16, yang.Yuint32:
 		return reflect.TypeOf(float64(0))
-	case yang.Ybinary, yang.Ydecimal64, yang.Yenum, yang.Yidentityref, yang.Yint64, yang.Yuint64, yang.Ystring:
+	case yang.Ybinary, yang.Ydecimal64, yang.Yenum, yang.Yidentityref, yang.YinstanceIdentifier, yang.Yint64, yang.Yuint64, yang.Ystring:
 		return reflect.TypeOf(string(""))
 	case yang.Ybool, yang.Yempty:
 		return reflect.TypeOf(bool(false))
@@ -23,12 +23,20 @@ import (
 )
 
 // Validate recursively validates the value of the given data tree struct
-// against the given schema.
+// against the given schema. A ygot.DynamicNode is validated against the schema
+// that it was created with, in which case schema must be either nil or that
+// schema.
 func Validate(schema *yang.Entry, value interface{}) util.Errors {
 	// Nil value means the field is unset.
 	if util.IsValueNil(value) {
 		return nil
 	}
+	if n, ok := value.(*ygot.DynamicNode); ok {
+		if schema != nil && schema != n.Schema() {
+			return util.NewErrs(fmt.Errorf("schema %s does not match the schema %s of the DynamicNode", schema.Name, n.Schema().Name))
+		}
+		return validateDynamicNode(n)
+	}
 	if schema == nil {
 		return util.NewErrs(fmt.Errorf("nil schema for type %T, value %v", value, value))
 	}
@@ -37,7 +45,7 @@ func Validate(schema *yang.Entry, value interface{}) util.Errors {
 	switch {
 	case schema.IsLeaf():
 		return validateLeaf(schema, value)
-	case schema.IsContainer():
+	case util.IsContainerLike(schema):
 		gsv, ok := value.(ygot.GoStruct)
 		if !ok {
 			return util.NewErrs(fmt.Errorf("type %T is not a GoStruct for schema %s", value, schema.Name))
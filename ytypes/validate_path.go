@@ -0,0 +1,660 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ygotutils"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ValidatePath validates the node at path within the data tree rooted at
+// root, rather than the whole data tree. The schema supplied must be the
+// schema of root, which is typically the fake root of the generated code.
+// The checks performed are:
+//   - the subtree at path is validated as per Validate.
+//   - the map key of each list entry along the path is checked against the
+//     key fields of the entry.
+//   - the unique statements of each list along the path are checked across
+//     all the entries of the list.
+//   - leafrefs that are within the subtree at path, or that refer to a node
+//     within the subtree, are checked to refer to a value that exists in the
+//     data tree. Predicates within leafref paths are not evaluated, such that
+//     a leafref is satisfied by any instance of the node that it refers to
+//     with the same value. Since leafrefs are selected using the schema, all
+//     instances of a leafref are checked when any instance of the node that
+//     it refers to is within the subtree.
+//   - instance-identifiers that are within the subtree at path, and require
+//     the instance that they identify to exist, are checked to identify a
+//     node that exists in the data tree.
+//
+// A path that does not exist in the data tree, for example because it has
+// been deleted, is valid provided that no leafrefs refer to the removed
+// subtree.
+func ValidatePath(schema *yang.Entry, root ygot.GoStruct, path *gpb.Path) util.Errors {
+	return ValidatePaths(schema, root, []*gpb.Path{path})
+}
+
+// ValidatePaths validates the data tree rooted at root at each of the
+// supplied paths, as per ValidatePath. It is intended to be used to validate
+// the set of paths that were changed in a data tree, e.g., the paths of the
+// updates and deletes produced by diffing the tree against its previous
+// contents. Paths that are within the subtree of another supplied path are
+// not validated separately, and each leafref is checked once, regardless of
+// the number of supplied paths that it relates to.
+//
+// The leafrefs within schema are found by walking the whole schema tree on
+// each call. Where a data tree is validated repeatedly, such as after each
+// change that is made to it, SchemaIndex.ValidatePaths should be used, which
+// stores the leafrefs within the index such that the schema tree is walked
+// only once.
+func ValidatePaths(schema *yang.Entry, root ygot.GoStruct, paths []*gpb.Path) util.Errors {
+	if schema == nil {
+		return util.NewErrs(fmt.Errorf("nil schema for type %T", root))
+	}
+	return NewSchemaIndex(map[string]*yang.Entry{schema.Name: schema}).ValidatePaths(schema, root, paths)
+}
+
+// validatePaths validates the data tree rooted at root at each of the
+// supplied paths, as per ValidatePaths, using the leafrefs lr that have been
+// found within schema.
+func validatePaths(schema *yang.Entry, root ygot.GoStruct, paths []*gpb.Path, lr *leafRefs) util.Errors {
+	if util.IsValueNil(root) {
+		return util.NewErrs(fmt.Errorf("nil data tree for schema %s", schema.Name))
+	}
+
+	// The errors are copied, since lr may be reused by the caller.
+	errors := append(util.Errors(nil), lr.errs...)
+	touched, touchedIDs := make([]bool, len(lr.refs)), make([]bool, len(lr.instanceIDs))
+	for _, p := range prunePaths(paths) {
+		target, errs := validatePathNode(schema, root, p)
+		errors = util.AppendErrs(errors, errs)
+		if target == nil {
+			continue
+		}
+		for i, r := range lr.refs {
+			if isSchemaDescendant(r.schema, target) || isSchemaDescendant(r.target, target) {
+				touched[i] = true
+			}
+		}
+		for i, r := range lr.instanceIDs {
+			if isSchemaDescendant(r.schema, target) {
+				touchedIDs[i] = true
+			}
+		}
+	}
+
+	for i, r := range lr.refs {
+		if touched[i] {
+			errors = util.AppendErrs(errors, validateLeafRefData(schema, root, r))
+		}
+	}
+	for i, r := range lr.instanceIDs {
+		if touchedIDs[i] {
+			errors = util.AppendErrs(errors, validateInstanceIdentifierData(schema, root, r))
+		}
+	}
+	return errors
+}
+
+// listEntry is an entry of a keyed list in the data tree.
+type listEntry struct {
+	// schema is the schema of the list.
+	schema *yang.Entry
+	// list is the map that stores the list, and key is the map key of the
+	// entry within it.
+	list, key reflect.Value
+}
+
+// validatePathNode validates the node at path within the data tree rooted at
+// root, which is described by schema, along with the keyed lists that are
+// traversed to reach it. It returns the schema of the node, which is
+// returned even if the node does not exist in the data tree, or nil if the
+// path does not exist within the schema.
+func validatePathNode(schema *yang.Entry, root ygot.GoStruct, path *gpb.Path) (*yang.Entry, util.Errors) {
+	elems := path.GetElem()
+	// Strip off the absolute path prefix since the relative and absolute
+	// paths are assumed to be equal.
+	if len(elems) != 0 && elems[0].GetName() == "" {
+		elems = elems[1:]
+	}
+
+	util.DbgPrint("validatePathNode for path %v, schema %s", elems, schema.Name)
+
+	var (
+		lists []listEntry
+		// cur is the data tree node at the path consumed so far, which is
+		// invalid once the path leaves the populated data tree.
+		cur = reflect.ValueOf(root)
+	)
+	for len(elems) != 0 {
+		if !cur.IsValid() || (cur.Kind() == reflect.Ptr && cur.IsNil()) {
+			// The remaining path is not populated in the data tree, so
+			// only the schema is traversed.
+			var err error
+			if schema, err = schemaChild(schema, elems[0].GetName()); err != nil {
+				return nil, util.NewErrs(fmt.Errorf("%s: %v", pathString(path), err))
+			}
+			cur, elems = reflect.Value{}, elems[1:]
+			continue
+		}
+
+		if !util.IsValueStructPtr(cur) {
+			return nil, util.NewErrs(fmt.Errorf("%s: path traverses non-container node of type %s, schema %s", pathString(path), cur.Type(), schema.Name))
+		}
+
+		fv, fschema, n, err := matchPathField(schema, cur.Elem(), elems)
+		switch {
+		case err != nil:
+			return nil, util.NewErrs(fmt.Errorf("%s: %v", pathString(path), err))
+		case fschema == nil:
+			return nil, util.NewErrs(fmt.Errorf("%s: path element %s not found in schema %s", pathString(path), elems[0].GetName(), schema.Name))
+		}
+		schema, cur = fschema, fv
+		last := elems[n-1]
+		elems = elems[n:]
+
+		if fv.Kind() != reflect.Map {
+			continue
+		}
+		if len(last.GetKey()) == 0 {
+			if len(elems) != 0 {
+				return nil, util.NewErrs(fmt.Errorf("%s: path traverses list %s without specifying keys", pathString(path), schema.Name))
+			}
+			// The whole list is validated below, including its keys.
+			lists = append(lists, listEntry{schema: schema, list: fv})
+			break
+		}
+
+		k, err := findListKey(schema, fv, last.GetKey())
+		if err != nil {
+			return nil, util.NewErrs(fmt.Errorf("%s: %v", pathString(path), err))
+		}
+		if k.IsValid() {
+			lists = append(lists, listEntry{schema: schema, list: fv, key: k})
+			cur = fv.MapIndex(k)
+		} else {
+			cur = reflect.Value{}
+		}
+	}
+
+	var errors []error
+	for _, l := range lists {
+		if l.key.IsValid() {
+			errors = util.AppendErrs(errors, checkKeys(l.schema, l.list.MapIndex(l.key).Elem(), l.key))
+		}
+		errors = util.AppendErrs(errors, validateUnique(l.schema, l.list))
+	}
+	if cur.IsValid() {
+		errors = util.AppendErrs(errors, Validate(schema, cur.Interface()))
+	}
+	return schema, errors
+}
+
+// matchPathField returns the field of the struct structElems, which is
+// described by schema, whose path matches the start of the path elems. It
+// returns the value and schema of the field, and the number of path elements
+// that were matched. A nil schema is returned if no field matches.
+func matchPathField(schema *yang.Entry, structElems reflect.Value, elems []*gpb.PathElem) (reflect.Value, *yang.Entry, int, error) {
+	for i := 0; i < structElems.NumField(); i++ {
+		ft := structElems.Type().Field(i)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+		ps, err := util.SchemaPaths(ft)
+		if err != nil {
+			return reflect.Value{}, nil, 0, err
+		}
+		for _, p := range ps {
+			if !pathElemsHaveNames(elems, p) {
+				continue
+			}
+			cschema, err := util.ChildSchema(schema, ft)
+			if err != nil {
+				return reflect.Value{}, nil, 0, err
+			}
+			if cschema == nil {
+				return reflect.Value{}, nil, 0, fmt.Errorf("could not find schema for type %s, field name %s", structElems.Type(), ft.Name)
+			}
+			return structElems.Field(i), cschema, len(p), nil
+		}
+	}
+	return reflect.Value{}, nil, 0, nil
+}
+
+// pathElemsHaveNames returns true if the names of the path elements elems
+// start with the supplied names.
+func pathElemsHaveNames(elems []*gpb.PathElem, names []string) bool {
+	if len(names) > len(elems) {
+		return false
+	}
+	for i, n := range names {
+		if util.StripModulePrefix(elems[i].GetName()) != n {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaChild returns the child of schema with the supplied name, which may
+// be within a choice or case statement.
+func schemaChild(schema *yang.Entry, name string) (*yang.Entry, error) {
+	name = util.StripModulePrefix(name)
+	if ch := schema.Dir[name]; ch != nil {
+		return ch, nil
+	}
+	entries := map[string]*yang.Entry{}
+	for _, ch := range schema.Dir {
+		if util.IsChoiceOrCase(ch) {
+			util.FindFirstNonChoiceOrCase(ch, entries)
+		}
+	}
+	if ch := entries[name]; ch != nil {
+		return ch, nil
+	}
+	return nil, fmt.Errorf("path element %s not found in schema %s", name, schema.Name)
+}
+
+// findListKey returns the map key of the entry of the keyed list stored in
+// the map list, described by schema, whose key fields have the supplied
+// values. It returns an invalid value if no entry matches.
+func findListKey(schema *yang.Entry, list reflect.Value, keys map[string]string) (reflect.Value, error) {
+	keyNames := strings.Split(schema.Key, " ")
+	for _, kn := range keyNames {
+		if _, ok := keys[kn]; !ok {
+			return reflect.Value{}, fmt.Errorf("path does not specify key %s for list %s", kn, schema.Name)
+		}
+	}
+	if len(keys) != len(keyNames) {
+		return reflect.Value{}, fmt.Errorf("path specifies keys %v, but list %s has keys %v", keys, schema.Name, keyNames)
+	}
+
+	for _, k := range list.MapKeys() {
+		ev := list.MapIndex(k)
+		if !util.IsValueStructPtr(ev) || ev.IsNil() {
+			return reflect.Value{}, fmt.Errorf("list %s has invalid element %v", schema.Name, util.ValueStr(ev.Interface()))
+		}
+		match := true
+		for _, kn := range keyNames {
+			kv, err := util.GetKeyValue(ev.Elem(), kn)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if fmt.Sprint(kv) != keys[kn] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return k, nil
+		}
+	}
+	return reflect.Value{}, nil
+}
+
+// validateUnique checks the unique statements of the list described by schema
+// against the entries of the list stored in the map list. As per RFC7950
+// Section 7.8.3, entries in which any of the leaves referenced by a unique
+// statement are not set are not checked against the statement.
+func validateUnique(schema *yang.Entry, list reflect.Value) util.Errors {
+	ls, ok := schema.Node.(*yang.List)
+	if !ok || len(ls.Unique) == 0 {
+		return nil
+	}
+
+	// Sort the entries such that errors are reported deterministically.
+	keys := list.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	var errors []error
+	for _, u := range ls.Unique {
+		leaves := strings.Fields(u.Name)
+		seen := keyTuples{}
+		for _, k := range keys {
+			gs, ok := list.MapIndex(k).Interface().(ygot.GoStruct)
+			if !ok {
+				errors = util.AppendErr(errors, fmt.Errorf("list %s entry %v is not a GoStruct", schema.Name, k.Interface()))
+				continue
+			}
+
+			var vals []interface{}
+			for _, l := range leaves {
+				matches, err := ygotutils.GetNodes(schema, gs, &gpb.Path{Elem: schemaPathElems(strings.Split(l, "/"))})
+				if err != nil {
+					errors = util.AppendErr(errors, fmt.Errorf("list %s unique %s: %v", schema.Name, u.Name, err))
+					break
+				}
+				var lv []interface{}
+				if len(matches) != 0 {
+					lv = leafValues(matches[0].Data)
+				}
+				if len(lv) == 0 {
+					break
+				}
+				vals = append(vals, lv[0])
+			}
+			if len(vals) != len(leaves) {
+				continue
+			}
+
+			if prev, dup := seen.add(vals, k.Interface()); dup {
+				errors = util.AppendErr(errors, fmt.Errorf("list %s entries %v and %v have the same values %v for unique leaves %s", schema.Name, prev, k.Interface(), vals, u.Name))
+			}
+		}
+	}
+	return errors
+}
+
+// keyTuples is a set of tuples of the values of the key, or unique, leaves
+// of list entries. Tuples are compared by their typed values, such that
+// tuples whose values have the same string representation, e.g., ("a b", "c")
+// and ("a", "b c"), are distinct.
+type keyTuples map[string][]keyTuple
+
+// keyTuple is a tuple of leaf values within a keyTuples set, along with the
+// identifier of the list entry that it was added for.
+type keyTuple struct {
+	vals []interface{}
+	id   interface{}
+}
+
+// add adds the tuple vals, for the list entry identified by id, to the set.
+// If an equal tuple is already within the set, the identifier of the entry
+// that it was added for is returned, along with true, and the set is not
+// modified.
+func (k keyTuples) add(vals []interface{}, id interface{}) (interface{}, bool) {
+	// The tuples are grouped by their string representation, such that only
+	// tuples with the same representation are compared.
+	s := fmt.Sprint(vals)
+	for _, t := range k[s] {
+		if reflect.DeepEqual(t.vals, vals) {
+			return t.id, true
+		}
+	}
+	k[s] = append(k[s], keyTuple{vals: vals, id: id})
+	return nil, false
+}
+
+// leafRef describes a leafref leaf or leaf-list within a schema tree.
+type leafRef struct {
+	// schema is the schema of the leafref.
+	schema *yang.Entry
+	// target is the schema of the node that the leafref refers to.
+	target *yang.Entry
+	// path and targetPath are the paths of the leafref and the node that it
+	// refers to in the data tree, relative to the root of the schema tree.
+	path, targetPath *gpb.Path
+}
+
+// instanceIDRef describes an instance-identifier leaf or leaf-list within a
+// schema tree, which requires the instance that it identifies to exist.
+type instanceIDRef struct {
+	// schema is the schema of the instance-identifier.
+	schema *yang.Entry
+	// path is the path of the instance-identifier in the data tree, relative
+	// to the root of the schema tree.
+	path *gpb.Path
+}
+
+// leafRefs stores the leafrefs and instance-identifiers found within a schema
+// tree, along with the errors encountered whilst finding them.
+type leafRefs struct {
+	refs        []*leafRef
+	instanceIDs []*instanceIDRef
+	errs        util.Errors
+}
+
+// schemaLeafRefs returns the leafrefs and instance-identifiers within the
+// schema tree rooted at root that require the instance that they refer to
+// exist, along with any errors encountered resolving them.
+func schemaLeafRefs(root *yang.Entry) *leafRefs {
+	lr := &leafRefs{}
+	findLeafRefs(root, lr)
+	return lr
+}
+
+// findLeafRefs appends the leafrefs and instance-identifiers within the schema
+// tree rooted at schema to lr.
+func findLeafRefs(schema *yang.Entry, lr *leafRefs) {
+	if schema.IsLeaf() || schema.IsLeafList() {
+		if schema.Type == nil || schema.Type.OptionalInstance {
+			return
+		}
+		if schema.Type.Kind == yang.YinstanceIdentifier {
+			lr.instanceIDs = append(lr.instanceIDs, &instanceIDRef{
+				schema: schema,
+				path:   &gpb.Path{Elem: schemaPathElems(treeDataPath(schema))},
+			})
+			return
+		}
+		if schema.Type.Kind != yang.Yleafref {
+			return
+		}
+		target, err := util.FindLeafRefSchema(schema, schema.Type.Path)
+		if err != nil {
+			lr.errs = util.AppendErr(lr.errs, err)
+			return
+		}
+		lr.refs = append(lr.refs, &leafRef{
+			schema:     schema,
+			target:     target,
+			path:       &gpb.Path{Elem: schemaPathElems(treeDataPath(schema))},
+			targetPath: &gpb.Path{Elem: schemaPathElems(treeDataPath(target))},
+		})
+		return
+	}
+
+	for _, k := range stringMapKeys(schema.Dir) {
+		findLeafRefs(schema.Dir[k], lr)
+	}
+}
+
+// treeDataPath returns the data tree path of e relative to the root of its
+// schema tree. The path of the fake root of generated code, or of a module, is
+// empty, such that the path of e is unchanged where the tree has such a root.
+func treeDataPath(e *yang.Entry) []string {
+	p := util.SchemaDataPath(e)
+	rp := util.SchemaDataPath(util.SchemaTreeRoot(e))
+	if len(rp) <= len(p) && reflect.DeepEqual(rp, p[:len(rp)]) {
+		return p[len(rp):]
+	}
+	return p
+}
+
+// validateLeafRefData checks that each value of the leafref r within the data
+// tree rooted at root, which is described by schema, is a value of the node
+// that r refers to.
+func validateLeafRefData(schema *yang.Entry, root ygot.GoStruct, r *leafRef) util.Errors {
+	refs, err := ygotutils.GetNodes(schema, root, r.path)
+	if err != nil {
+		return util.NewErrs(fmt.Errorf("leafref %s: %v", pathString(r.path), err))
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	targets, err := ygotutils.GetNodes(schema, root, r.targetPath)
+	if err != nil {
+		return util.NewErrs(fmt.Errorf("leafref %s: %v", pathString(r.path), err))
+	}
+
+	tvals := map[string]bool{}
+	for _, t := range targets {
+		for _, v := range leafValues(t.Data) {
+			tvals[fmt.Sprint(v)] = true
+		}
+	}
+
+	var errors []error
+	for _, m := range refs {
+		for _, v := range leafValues(m.Data) {
+			if !tvals[fmt.Sprint(v)] {
+				errors = util.AppendErr(errors, fmt.Errorf("leafref %s value %v does not match any value of %s", pathString(m.Path), v, pathString(r.targetPath)))
+			}
+		}
+	}
+	return errors
+}
+
+// validateInstanceIdentifierData checks that each value of the
+// instance-identifier r within the data tree rooted at root, which is
+// described by schema, identifies a node that exists in the data tree.
+func validateInstanceIdentifierData(schema *yang.Entry, root ygot.GoStruct, r *instanceIDRef) util.Errors {
+	refs, err := ygotutils.GetNodes(schema, root, r.path)
+	if err != nil {
+		return util.NewErrs(fmt.Errorf("instance-identifier %s: %v", pathString(r.path), err))
+	}
+
+	var errors []error
+	for _, m := range refs {
+		for _, v := range leafValues(m.Data) {
+			iid, ok := v.(ygot.InstanceIdentifier)
+			if !ok {
+				errors = util.AppendErr(errors, fmt.Errorf("instance-identifier %s has value %v of type %T, want ygot.InstanceIdentifier", pathString(m.Path), v, v))
+				continue
+			}
+			exists, err := instanceIdentifierExists(schema, root, iid)
+			switch {
+			case err != nil:
+				errors = util.AppendErr(errors, fmt.Errorf("instance-identifier %s value %s: %v", pathString(m.Path), iid, err))
+			case !exists:
+				errors = util.AppendErr(errors, fmt.Errorf("instance-identifier %s value %s does not identify a node in the data tree", pathString(m.Path), iid))
+			}
+		}
+	}
+	return errors
+}
+
+// leafValues returns the scalar values of the leaf or leaf-list value v, with
+// pointers dereferenced, and union values resolved to the value that they
+// contain.
+func leafValues(v interface{}) []interface{} {
+	if util.IsValueNil(v) {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface:
+		return leafValues(rv.Elem().Interface())
+	case rv.Kind() == reflect.Slice && rv.Type().Name() == ygot.BinaryTypeName:
+		return []interface{}{string(rv.Bytes())}
+	case rv.Kind() == reflect.Slice:
+		var out []interface{}
+		for i := 0; i < rv.Len(); i++ {
+			out = append(out, leafValues(rv.Index(i).Interface())...)
+		}
+		return out
+	case util.IsStructValueWithNFields(rv, 1):
+		// Union values are structs with a single field.
+		return leafValues(rv.Field(0).Interface())
+	}
+	return []interface{}{v}
+}
+
+// schemaPathElems returns the gNMI path elements with the supplied names, with
+// any module prefixes removed.
+func schemaPathElems(names []string) []*gpb.PathElem {
+	var elems []*gpb.PathElem
+	for _, n := range names {
+		elems = append(elems, &gpb.PathElem{Name: util.StripModulePrefix(n)})
+	}
+	return elems
+}
+
+// isSchemaDescendant returns true if schema is ancestor, or is a descendant of
+// ancestor.
+func isSchemaDescendant(schema, ancestor *yang.Entry) bool {
+	for e := schema; e != nil; e = e.Parent {
+		if e == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// prunePaths returns the paths within paths that are not within the subtree
+// of another path in paths, with duplicate paths removed. The paths returned
+// are ordered by their length.
+func prunePaths(paths []*gpb.Path) []*gpb.Path {
+	sorted := append([]*gpb.Path{}, paths...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(trimRootElem(sorted[i])) < len(trimRootElem(sorted[j]))
+	})
+
+	var out []*gpb.Path
+	for i, p := range sorted {
+		covered := false
+		for j, o := range sorted {
+			// Where two paths are equal, only the first is retained.
+			if i != j && isPathPrefix(trimRootElem(o), trimRootElem(p)) && (j < i || !isPathPrefix(trimRootElem(p), trimRootElem(o))) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// trimRootElem returns the elements of path p with the empty element that
+// indicates an absolute path removed.
+func trimRootElem(p *gpb.Path) []*gpb.PathElem {
+	elems := p.GetElem()
+	if len(elems) != 0 && elems[0].GetName() == "" {
+		return elems[1:]
+	}
+	return elems
+}
+
+// isPathPrefix returns true if the path elements prefix are a prefix of the
+// path elements elems. A prefix element which does not specify keys matches
+// any keys in elems.
+func isPathPrefix(prefix, elems []*gpb.PathElem) bool {
+	if len(prefix) > len(elems) {
+		return false
+	}
+	for i, pe := range prefix {
+		if pe.GetName() != elems[i].GetName() {
+			return false
+		}
+		if len(pe.GetKey()) != 0 && !proto.Equal(pe, elems[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathString returns the string form of the gNMI path p, as per
+// ygot.PathToString, for use in error messages. The text format of p is
+// returned where it cannot be converted to a string.
+func pathString(p *gpb.Path) string {
+	s, err := ygot.PathToString(&gpb.Path{Elem: trimRootElem(p)})
+	if err != nil {
+		return proto.CompactTextString(p)
+	}
+	return s
+}
@@ -0,0 +1,324 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+type validatePathRoot struct {
+	Item map[string]*validatePathItem `path:"item"`
+	Ref  *string                      `path:"ref"`
+}
+
+func (*validatePathRoot) IsYANGGoStruct() {}
+
+type validatePathItem struct {
+	Name *string `path:"name"`
+	Addr *string `path:"addr"`
+	Port *string `path:"port"`
+	Peer *string `path:"peer"`
+}
+
+func (*validatePathItem) IsYANGGoStruct() {}
+
+// validatePathSchema returns the schema for validatePathRoot, with the
+// parent of each entry populated.
+func validatePathSchema() *yang.Entry {
+	stringLeaf := func(name string) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}}
+	}
+	leafRefLeaf := func(name, path string) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yleafref, Path: path}}
+	}
+
+	addr := stringLeaf("addr")
+	addr.Type.Pattern = []string{"^[0-9.]+$"}
+
+	item := &yang.Entry{
+		Name:     "item",
+		Kind:     yang.DirectoryEntry,
+		ListAttr: &yang.ListAttr{MinElements: &yang.Value{Name: "0"}},
+		Key:      "name",
+		Config:   yang.TSTrue,
+		Node:     &yang.List{Unique: []*yang.Value{{Name: "addr port"}}},
+		Dir: map[string]*yang.Entry{
+			"name": stringLeaf("name"),
+			"addr": addr,
+			"port": stringLeaf("port"),
+			"peer": leafRefLeaf("peer", "../../item/name"),
+		},
+	}
+	root := &yang.Entry{
+		Name: "root",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"item": item,
+			"ref":  leafRefLeaf("ref", "/item/name"),
+		},
+	}
+
+	var setParents func(e *yang.Entry)
+	setParents = func(e *yang.Entry) {
+		for _, ch := range e.Dir {
+			ch.Parent = e
+			setParents(ch)
+		}
+	}
+	setParents(root)
+	return root
+}
+
+// validatePathData returns a valid data tree for validatePathSchema.
+func validatePathData() *validatePathRoot {
+	return &validatePathRoot{
+		Item: map[string]*validatePathItem{
+			"a": {Name: ygot.String("a"), Addr: ygot.String("10.0.0.1"), Port: ygot.String("80"), Peer: ygot.String("b")},
+			"b": {Name: ygot.String("b"), Addr: ygot.String("10.0.0.2"), Port: ygot.String("80")},
+		},
+		Ref: ygot.String("a"),
+	}
+}
+
+// itemPath returns the path of the entry of the item list with the supplied
+// name, with the additional path elements appended.
+func itemPath(name string, elems ...string) *gpb.Path {
+	p := &gpb.Path{Elem: []*gpb.PathElem{{Name: "item", Key: map[string]string{"name": name}}}}
+	for _, e := range elems {
+		p.Elem = append(p.Elem, &gpb.PathElem{Name: e})
+	}
+	return p
+}
+
+func TestValidatePaths(t *testing.T) {
+	schema := validatePathSchema()
+
+	tests := []struct {
+		desc string
+		// inModify modifies the valid data tree prior to validation.
+		inModify func(*validatePathRoot)
+		inPaths  []*gpb.Path
+		// wantErrs are substrings of the errors that are expected, in
+		// order.
+		wantErrs []string
+	}{{
+		desc:    "valid list entry",
+		inPaths: []*gpb.Path{itemPath("a")},
+	}, {
+		desc:    "valid absolute path to leaf",
+		inPaths: []*gpb.Path{{Elem: []*gpb.PathElem{{}, {Name: "ref"}}}},
+	}, {
+		desc:     "valid whole list",
+		inPaths:  []*gpb.Path{{Elem: []*gpb.PathElem{{Name: "item"}}}},
+		inModify: func(d *validatePathRoot) { d.Item["b"].Port = ygot.String("81") },
+	}, {
+		desc:     "invalid leaf value",
+		inPaths:  []*gpb.Path{itemPath("b", "addr")},
+		inModify: func(d *validatePathRoot) { d.Item["b"].Addr = ygot.String("bad!") },
+		wantErrs: []string{`"bad!" does not match regular expression pattern`},
+	}, {
+		desc:     "invalid leaf outside of the validated subtree",
+		inPaths:  []*gpb.Path{itemPath("a")},
+		inModify: func(d *validatePathRoot) { d.Item["b"].Addr = ygot.String("bad!") },
+	}, {
+		desc:     "leafref within subtree does not exist",
+		inPaths:  []*gpb.Path{itemPath("a")},
+		inModify: func(d *validatePathRoot) { d.Item["a"].Peer = ygot.String("z") },
+		wantErrs: []string{`leafref /item[name=a]/peer value z does not match any value of /item/name`},
+	}, {
+		desc:     "leafref not touched by path is not checked",
+		inPaths:  []*gpb.Path{{Elem: []*gpb.PathElem{{Name: "ref"}}}},
+		inModify: func(d *validatePathRoot) { d.Item["a"].Peer = ygot.String("z") },
+	}, {
+		desc:     "leafref to deleted list entry",
+		inPaths:  []*gpb.Path{itemPath("b")},
+		inModify: func(d *validatePathRoot) { delete(d.Item, "b") },
+		wantErrs: []string{`leafref /item[name=a]/peer value b does not match any value of /item/name`},
+	}, {
+		desc:     "leafref outside subtree refers to deleted list entry",
+		inPaths:  []*gpb.Path{itemPath("a")},
+		inModify: func(d *validatePathRoot) { delete(d.Item, "a") },
+		wantErrs: []string{`leafref /ref value a does not match any value of /item/name`},
+	}, {
+		desc:     "map key does not match key field",
+		inPaths:  []*gpb.Path{itemPath("c", "port")},
+		inModify: func(d *validatePathRoot) { d.Item["x"] = &validatePathItem{Name: ygot.String("c")} },
+		wantErrs: []string{`key field Name: element key c != map key x`},
+	}, {
+		desc:     "unique statement violated",
+		inPaths:  []*gpb.Path{itemPath("b", "addr")},
+		inModify: func(d *validatePathRoot) { d.Item["b"].Addr = ygot.String("10.0.0.1") },
+		wantErrs: []string{`list item entries a and b have the same values [10.0.0.1 80] for unique leaves addr port`},
+	}, {
+		desc:    "unique statement with unset leaf",
+		inPaths: []*gpb.Path{itemPath("b", "addr")},
+		inModify: func(d *validatePathRoot) {
+			d.Item["b"].Addr = ygot.String("10.0.0.1")
+			d.Item["b"].Port = nil
+		},
+	}, {
+		desc: "batch of paths",
+		inPaths: []*gpb.Path{
+			itemPath("a", "peer"),
+			itemPath("a"),
+			{Elem: []*gpb.PathElem{{Name: "ref"}}},
+		},
+		inModify: func(d *validatePathRoot) { d.Item["a"].Peer = ygot.String("z") },
+		wantErrs: []string{`leafref /item[name=a]/peer value z does not match any value of /item/name`},
+	}, {
+		desc:     "path not in schema",
+		inPaths:  []*gpb.Path{itemPath("a", "missing")},
+		wantErrs: []string{`/item[name=a]/missing: path element missing not found in schema item`},
+	}, {
+		desc:     "path traverses list without keys",
+		inPaths:  []*gpb.Path{{Elem: []*gpb.PathElem{{Name: "item"}, {Name: "addr"}}}},
+		wantErrs: []string{`path traverses list item without specifying keys`},
+	}}
+
+	for _, tt := range tests {
+		data := validatePathData()
+		if tt.inModify != nil {
+			tt.inModify(data)
+		}
+
+		errs := ValidatePaths(schema, data, tt.inPaths)
+		if len(errs) != len(tt.wantErrs) {
+			t.Errorf("%s: ValidatePaths(%v): got %d errors (%v), want %d errors", tt.desc, tt.inPaths, len(errs), errs, len(tt.wantErrs))
+			continue
+		}
+		for i, err := range errs {
+			if !strings.Contains(err.Error(), tt.wantErrs[i]) {
+				t.Errorf("%s: ValidatePaths(%v): got error %d: %v, want error containing: %s", tt.desc, tt.inPaths, i, err, tt.wantErrs[i])
+			}
+		}
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	schema := validatePathSchema()
+	data := validatePathData()
+	data.Item["a"].Peer = ygot.String("z")
+
+	ref := &gpb.Path{Elem: []*gpb.PathElem{{Name: "ref"}}}
+	if errs := ValidatePath(schema, data, ref); errs != nil {
+		t.Errorf("ValidatePath(%v): got unexpected errors: %v", ref, errs)
+	}
+	if errs := ValidatePath(schema, data, itemPath("a", "peer")); len(errs) != 1 {
+		t.Errorf("ValidatePath(%v): got errors: %v, want 1 error", itemPath("a", "peer"), errs)
+	}
+
+	if errs := ValidatePath(nil, data, itemPath("a")); errs == nil {
+		t.Errorf("ValidatePath with nil schema: got no error, want error")
+	}
+	if errs := ValidatePath(schema, nil, itemPath("a")); errs == nil {
+		t.Errorf("ValidatePath with nil data tree: got no error, want error")
+	}
+}
+
+func TestPrunePaths(t *testing.T) {
+	a := itemPath("a")
+	aAddr := itemPath("a", "addr")
+	bAddr := itemPath("b", "addr")
+	list := &gpb.Path{Elem: []*gpb.PathElem{{}, {Name: "item"}}}
+	ref := &gpb.Path{Elem: []*gpb.PathElem{{Name: "ref"}}}
+
+	tests := []struct {
+		desc string
+		in   []*gpb.Path
+		want []*gpb.Path
+	}{{
+		desc: "no overlapping paths",
+		in:   []*gpb.Path{aAddr, bAddr, ref},
+		want: []*gpb.Path{ref, aAddr, bAddr},
+	}, {
+		desc: "path within list entry",
+		in:   []*gpb.Path{aAddr, bAddr, a},
+		want: []*gpb.Path{a, bAddr},
+	}, {
+		desc: "paths within list",
+		in:   []*gpb.Path{aAddr, a, bAddr, list, ref},
+		want: []*gpb.Path{list, ref},
+	}, {
+		desc: "duplicate paths",
+		in:   []*gpb.Path{aAddr, itemPath("a", "addr")},
+		want: []*gpb.Path{aAddr},
+	}}
+
+	for _, tt := range tests {
+		got := prunePaths(tt.in)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: prunePaths(%v): got %v, want %v", tt.desc, tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !proto.Equal(got[i], tt.want[i]) {
+				t.Errorf("%s: prunePaths(%v): got %v, want %v", tt.desc, tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSchemaIndexValidatePaths(t *testing.T) {
+	schema := validatePathSchema()
+	x := NewSchemaIndex(map[string]*yang.Entry{"validatePathRoot": schema})
+
+	data := validatePathData()
+	if errs := x.ValidatePaths(schema, data, []*gpb.Path{itemPath("a")}); errs != nil {
+		t.Errorf("ValidatePaths(%v): got unexpected errors: %v", itemPath("a"), errs)
+	}
+	// The leafrefs stored in the index are used for subsequent calls.
+	data.Item["a"].Peer = ygot.String("z")
+	if errs := x.ValidatePaths(schema, data, []*gpb.Path{itemPath("a")}); len(errs) != 1 {
+		t.Errorf("ValidatePaths(%v): got errors: %v, want 1 error", itemPath("a"), errs)
+	}
+	if got := len(x.leafRefs); got != 1 {
+		t.Errorf("ValidatePaths: got %d stored schema trees, want 1", got)
+	}
+
+	if errs := x.ValidatePaths(validatePathSchema(), data, []*gpb.Path{itemPath("a")}); errs == nil {
+		t.Errorf("ValidatePaths with schema outside the index: got no error, want error")
+	}
+	if errs := x.ValidatePaths(nil, data, []*gpb.Path{itemPath("a")}); errs == nil {
+		t.Errorf("ValidatePaths with nil schema: got no error, want error")
+	}
+}
+
+func TestKeyTuples(t *testing.T) {
+	k := keyTuples{}
+	if _, dup := k.add([]interface{}{"a b", "c"}, 1); dup {
+		t.Errorf("add(a b, c): got duplicate, want not duplicate")
+	}
+	if _, dup := k.add([]interface{}{"a", "b c"}, 2); dup {
+		t.Errorf("add(a, b c): got duplicate, want not duplicate")
+	}
+	// Values of different types are distinct.
+	if _, dup := k.add([]interface{}{int64(1)}, 3); dup {
+		t.Errorf("add(int64(1)): got duplicate, want not duplicate")
+	}
+	if _, dup := k.add([]interface{}{"1"}, 4); dup {
+		t.Errorf("add(1): got duplicate, want not duplicate")
+	}
+	if id, dup := k.add([]interface{}{"a", "b c"}, 5); !dup || id != 2 {
+		t.Errorf("add(a, b c): got (%v, %v), want (2, true)", id, dup)
+	}
+}